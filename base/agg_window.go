@@ -13,6 +13,10 @@ package base
 
 import (
 	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/buger/jsonparser"
 
 	"github.com/couchbase/rhmap/store"
 )
@@ -66,10 +70,20 @@ type WindowFrame struct {
 	// ValComparer is used when the type is "range".
 	ValComparer *ValComparer
 
+	// Desc is used when the type is "range" and is true when the
+	// partition is sorted by the ValIdx val in descending order.
+	Desc bool
+
+	// RangeUnit is used when the type is "range" and is "" when the
+	// ValIdx val is a number. Otherwise, the ValIdx val is an
+	// ISO-8601 date string and the RangeUnit is the unit of the
+	// BegF64 and EndF64 offsets, such as "second", "day", "month".
+	RangeUnit string
+
 	// --------------------------------------------------------
 
 	// Partition is the current window partition.
-	Partition *store.Heap
+	Partition WindowPartition
 
 	// WindowFrameCurr tracks the current window frame, which is
 	// updated as the caller steps through the window partition.
@@ -81,6 +95,87 @@ type WindowFrame struct {
 
 // -------------------------------------------------------------------
 
+// WindowPartition represents the entries of a window partition that
+// are accessible to a window frame, where each entry is an encoded
+// vals. A store.Heap implements a WindowPartition that holds a whole,
+// materialized window partition, while a WindowRing implements a
+// WindowPartition that holds only the recent entries when streaming.
+type WindowPartition interface {
+	// Len returns the number of entries in the window partition,
+	// which might be only the number of entries seen so far.
+	Len() int
+
+	// Get returns the entry at the given 0-based position.
+	Get(i int64) ([]byte, error)
+}
+
+var _ WindowPartition = (*store.Heap)(nil)
+
+// -------------------------------------------------------------------
+
+// WindowRing is a WindowPartition for streaming window evaluation,
+// which retains only the most recently pushed entries of the current
+// window partition in a fixed capacity ring buffer.
+type WindowRing struct {
+	// Items is the ring buffer, where the entry at position i is
+	// held in Items[i % len(Items)].
+	Items [][]byte
+
+	// Total is the number of entries that have been pushed into the
+	// current window partition.
+	Total int64
+
+	// Extra is application specific data, similar to store.Heap.
+	Extra interface{}
+}
+
+// NewWindowRing returns a WindowRing that retains at most capacity
+// number of the most recent entries.
+func NewWindowRing(capacity int) *WindowRing {
+	if capacity < 1 {
+		capacity = 1
+	}
+
+	return &WindowRing{Items: make([][]byte, capacity)}
+}
+
+// Len returns the number of entries pushed into the current window
+// partition so far, including entries that are no longer retained.
+func (r *WindowRing) Len() int { return int(r.Total) }
+
+// Get returns the entry at the given 0-based position, or an error if
+// the entry has been evicted from the ring buffer.
+func (r *WindowRing) Get(i int64) ([]byte, error) {
+	if i < 0 || i >= r.Total || i < r.Total-int64(len(r.Items)) {
+		return nil, fmt.Errorf("WindowRing.Get, pos: %d not retained,"+
+			" total: %d, capacity: %d", i, r.Total, len(r.Items))
+	}
+
+	return r.Items[i%int64(len(r.Items))], nil
+}
+
+// PushBytes appends a copy of the entry, evicting the oldest retained
+// entry when the ring buffer is full.
+func (r *WindowRing) PushBytes(b []byte) error {
+	slot := r.Total % int64(len(r.Items))
+
+	r.Items[slot] = append(r.Items[slot][:0], b...)
+
+	r.Total++
+
+	return nil
+}
+
+// Reset clears the ring buffer for the next window partition, while
+// keeping its memory for reuse.
+func (r *WindowRing) Reset() error {
+	r.Total = 0
+
+	return nil
+}
+
+// -------------------------------------------------------------------
+
 // WindowFrameCurr represents the current positions of entries of a
 // window frame in a window partition.
 type WindowFrameCurr struct {
@@ -108,7 +203,7 @@ type WindowSpan struct {
 
 // -------------------------------------------------------------------
 
-func (wf *WindowFrame) Init(cfg interface{}, partition *store.Heap) {
+func (wf *WindowFrame) Init(cfg interface{}, partition WindowPartition) {
 	// Default window frame cfg according to standard is...
 	// RANGE BETWEEN UNBOUNDED PRECEDING AND CURRENT ROW EXCLUDE NO OTHERS
 	parts := cfg.([]interface{})
@@ -145,6 +240,42 @@ func (wf *WindowFrame) Init(cfg interface{}, partition *store.Heap) {
 	wf.Partition = partition
 
 	wf.ValIdx = parts[6].(int)
+
+	// Optional cfg of the ORDER-BY direction and the range unit,
+	// which are used when the type is "range".
+	if len(parts) > 7 {
+		wf.Desc = parts[7].(string) == "desc"
+	}
+
+	if len(parts) > 8 {
+		wf.RangeUnit = parts[8].(string)
+	}
+}
+
+// -------------------------------------------------------------------
+
+// Streamable returns true if the window frame accesses only the vals
+// that a streaming WindowRing retains, given the max number of vals
+// before and after the current vals that the ring retains. Only ROWS
+// frames are streamable, as RANGE and GROUPS frames scan for peers.
+// The frame's end must be a bounded offset, while its beginning is
+// either a bounded offset or UNBOUNDED PRECEDING without excluded
+// rows, where the latter is for the incremental aggregations like
+// the running totals of window-frame-agg, which revisit no earlier
+// vals, and for window-frame-count.
+func (wf *WindowFrame) Streamable(numPreceding, numFollowing int) bool {
+	if wf.Type != WTokRows || wf.EndBoundary != WTokNum ||
+		wf.EndNum < -int64(numPreceding) || wf.EndNum > int64(numFollowing) {
+		return false
+	}
+
+	if wf.BegBoundary == WTokUnbounded {
+		return wf.Exclude == WTokNoOthers
+	}
+
+	return wf.BegBoundary == WTokNum &&
+		wf.BegNum >= -int64(numPreceding) && wf.BegNum <= int64(numFollowing) &&
+		(wf.Exclude == WTokNoOthers || wf.Exclude == WTokCurrentRow)
 }
 
 // -------------------------------------------------------------------
@@ -177,7 +308,6 @@ func (wf *WindowFrame) CurrentUpdate(currentPos uint64) (err error) {
 				return err
 			}
 		} else { // wf.Type == WTokRange.
-			// TODO: Assumes ASC order-by.
 			wf.Include.Beg, err = wf.FindGroupEdge(wf.Pos, -1, true)
 			if err != nil {
 				return err
@@ -204,7 +334,6 @@ func (wf *WindowFrame) CurrentUpdate(currentPos uint64) (err error) {
 				return err
 			}
 		} else { // wf.Type == WTokRange.
-			// TODO: Assumes ASC order-by.
 			wf.Include.End, err = wf.FindGroupEdge(wf.Pos, 1, true)
 			if err != nil {
 				return err
@@ -292,6 +421,15 @@ func (wf *WindowFrame) StepGroups(n int64) (int64, error) {
 // of a group, depending on the direction dir parameter which should
 // be a 1 or -1. When 1, the ending member of the group is
 // returned. When -1, the starting member of the group is returned.
+// For the "rows" and "groups" types, a group is the rows with equal
+// ValIdx vals, such as a rank or denseRank.
+//
+// For the "range" type and when isRange is true, the group is the
+// rows whose val is within the BegF64 (when dir is -1) or EndF64 (when
+// dir is 1) offset of the current row's val, following the Desc
+// ordering. A NULL or MISSING val is never within range of a valued
+// val, so all the NULL and MISSING rows form a single group, wherever
+// the ORDER-BY's NULLS FIRST or NULLS LAST positioned them.
 func (wf *WindowFrame) FindGroupEdge(i, dir int64, isRange bool) (int64, error) {
 	end := int64(wf.Partition.Len())
 
@@ -300,17 +438,29 @@ func (wf *WindowFrame) FindGroupEdge(i, dir int64, isRange bool) (int64, error)
 		return i, err
 	}
 
-	var f64Edge float64
+	var f64Edge, sign float64
+	var okCurr bool
+
 	if wf.Type == WTokRange {
-		f64Curr, err := ParseFloat64(valCurr)
+		var f64Curr float64
+
+		f64Curr, okCurr, err = wf.RangeKey(valCurr)
 		if err != nil {
 			return i, err
 		}
 
-		if dir < 0 {
-			f64Edge = f64Curr + wf.BegF64
-		} else {
-			f64Edge = f64Curr + wf.EndF64
+		f64Edge = f64Curr
+		if okCurr && isRange {
+			f64Edge, err = wf.RangeEdge(f64Curr, dir)
+			if err != nil {
+				return i, err
+			}
+		}
+
+		// The sign flips the comparisons for DESC ordering.
+		sign = 1.0
+		if wf.Desc {
+			sign = -1.0
 		}
 	}
 
@@ -325,15 +475,15 @@ func (wf *WindowFrame) FindGroupEdge(i, dir int64, isRange bool) (int64, error)
 			return i, err
 		}
 
-		if wf.Type == WTokGroups {
+		if wf.Type != WTokRange {
 			if !bytes.Equal(valCurr, valNext) {
 				return i, nil
 			}
 		} else { // wf.Type == WTokRange.
-			f64Next, err := ParseFloat64(valNext)
-			if err != nil ||
-				((dir < 0) && (f64Next < f64Edge)) ||
-				((dir > 0) && (f64Next > f64Edge)) {
+			f64Next, okNext, err := wf.RangeKey(valNext)
+			if err != nil || okCurr != okNext ||
+				(okCurr && (dir < 0) && (sign*(f64Next-f64Edge) < 0)) ||
+				(okCurr && (dir > 0) && (sign*(f64Next-f64Edge) > 0)) {
 				return i, err
 			}
 		}
@@ -344,6 +494,110 @@ func (wf *WindowFrame) FindGroupEdge(i, dir int64, isRange bool) (int64, error)
 
 // -------------------------------------------------------------------
 
+// RangeKey parses a val into a float64 that's usable for "range"
+// comparisons, where a date string is parsed into milliseconds since
+// the unix epoch. The returned ok is false for a NULL or MISSING val.
+func (wf *WindowFrame) RangeKey(val Val) (f64 float64, ok bool, err error) {
+	if !ValHasValue(val) {
+		return 0, false, nil
+	}
+
+	if wf.RangeUnit == "" {
+		f64, err = ParseFloat64(val)
+
+		return f64, err == nil, err
+	}
+
+	t, err := ParseDate(val)
+	if err != nil {
+		return 0, false, err
+	}
+
+	return float64(t.UnixNano()) / float64(time.Millisecond), true, nil
+}
+
+// RangeEdge returns the range key of the edge that's the BegF64 (when
+// dir is -1) or EndF64 (when dir is 1) offset away from the given
+// range key, following the Desc ordering.
+func (wf *WindowFrame) RangeEdge(f64 float64, dir int64) (float64, error) {
+	offsetF64, offsetNum := wf.EndF64, wf.EndNum
+	if dir < 0 {
+		offsetF64, offsetNum = wf.BegF64, wf.BegNum
+	}
+
+	if wf.Desc {
+		offsetF64, offsetNum = -offsetF64, -offsetNum
+	}
+
+	if wf.RangeUnit == "" {
+		return f64 + offsetF64, nil
+	}
+
+	if wf.RangeUnit == "month" || wf.RangeUnit == "year" {
+		// Calendar units have variable lengths.
+		t := time.Unix(0, int64(f64*float64(time.Millisecond))).UTC()
+		if wf.RangeUnit == "month" {
+			t = t.AddDate(0, int(offsetNum), 0)
+		} else {
+			t = t.AddDate(int(offsetNum), 0, 0)
+		}
+
+		return float64(t.UnixNano()) / float64(time.Millisecond), nil
+	}
+
+	d, exists := RangeUnitDurations[wf.RangeUnit]
+	if !exists {
+		return f64, fmt.Errorf("RangeEdge, unknown RangeUnit: %s", wf.RangeUnit)
+	}
+
+	return f64 + offsetF64*float64(d/time.Millisecond), nil
+}
+
+// RangeUnitDurations are the fixed length units of a date "range".
+var RangeUnitDurations = map[string]time.Duration{
+	"millisecond": time.Millisecond,
+	"second":      time.Second,
+	"minute":      time.Minute,
+	"hour":        time.Hour,
+	"day":         24 * time.Hour,
+	"week":        7 * 24 * time.Hour,
+}
+
+// -------------------------------------------------------------------
+
+// DateFormats are the ISO-8601 date string layouts that are parsed by
+// ParseDate, where a date without a timezone is treated as UTC.
+var DateFormats = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04",
+	"2006-01-02",
+}
+
+// ParseDate parses a JSON string val that holds an ISO-8601 date.
+func ParseDate(val Val) (time.Time, error) {
+	parseVal, parseType := Parse(val)
+	if parseType != int(jsonparser.String) {
+		return time.Time{}, fmt.Errorf("ParseDate, not a string: %s", val)
+	}
+
+	s := string(parseVal)
+
+	for _, layout := range DateFormats {
+		t, err := time.Parse(layout, s)
+		if err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("ParseDate, unknown format: %s", val)
+}
+
+// -------------------------------------------------------------------
+
 // GetValsVal returns the valIdx'th val that's in the vals entry at
 // the given i position in the partition, both 0-based.
 func (wf *WindowFrame) GetValsVal(i int64, valIdx int) (Val, error) {
@@ -450,6 +704,21 @@ func (wf *WindowFrameCurr) Count() int64 {
 
 // -------------------------------------------------------------------
 
+// NTile returns the 1-based bucket number for the 0-based pos, when n
+// entries are divided as evenly as possible into a number of buckets,
+// where any extra entries go into the earlier buckets.
+func NTile(pos, n, buckets uint64) uint64 {
+	small, large := n/buckets, n%buckets
+
+	if pos < large*(small+1) {
+		return pos/(small+1) + 1
+	}
+
+	return (pos-large*(small+1))/small + large + 1
+}
+
+// -------------------------------------------------------------------
+
 // Int64RangesOverlap returns true if the range [xBeg, xEnd) overlaps
 // with the range [yBeg, yEnd).
 func Int64RangesOverlap(xBeg, xEnd, yBeg, yEnd int64) bool {
@@ -0,0 +1,42 @@
+package base
+
+import (
+	"testing"
+)
+
+func TestWindowFrameStreamable(t *testing.T) {
+	tests := []struct {
+		cfg []interface{}
+
+		numPreceding, numFollowing int
+
+		expect bool
+	}{
+		{[]interface{}{"rows", "num", -1, "num", 1, "no-others", 0}, 1, 1, true},
+		{[]interface{}{"rows", "num", 0, "num", 0, "no-others", 0}, 0, 0, true},
+		{[]interface{}{"rows", "num", -2, "num", -1, "no-others", 0}, 2, 0, true},
+		{[]interface{}{"rows", "num", -2, "num", 0, "no-others", 0}, 1, 0, false},
+		{[]interface{}{"rows", "num", 0, "num", 2, "no-others", 0}, 0, 1, false},
+		{[]interface{}{"rows", "unbounded", 0, "num", 0, "no-others", 0}, 1, 1, true},
+		{[]interface{}{"rows", "unbounded", 0, "num", 0, "no-others", 0}, 0, 0, true},
+		{[]interface{}{"rows", "unbounded", 0, "num", 1, "no-others", 0}, 0, 0, false},
+		{[]interface{}{"rows", "unbounded", 0, "num", 0, "current-row", 0}, 1, 1, false},
+		{[]interface{}{"rows", "unbounded", 0, "unbounded", 0, "no-others", 0}, 1, 1, false},
+		{[]interface{}{"rows", "num", 0, "unbounded", 0, "no-others", 0}, 1, 1, false},
+		{[]interface{}{"rows", "num", -1, "num", 1, "group", 0}, 1, 1, false},
+		{[]interface{}{"range", "num", -1, "num", 1, "no-others", 0}, 1, 1, false},
+		{[]interface{}{"groups", "num", 0, "num", 0, "no-others", 0}, 1, 1, false},
+	}
+
+	for testi, test := range tests {
+		var wf WindowFrame
+
+		wf.Init(test.cfg, nil)
+
+		got := wf.Streamable(test.numPreceding, test.numFollowing)
+		if got != test.expect {
+			t.Errorf("testi: %d, cfg: %v, expect: %t, got: %t",
+				testi, test.cfg, test.expect, got)
+		}
+	}
+}
@@ -15,6 +15,7 @@ package base
 
 import (
 	"encoding/binary"
+	"errors"
 	"fmt"
 
 	"github.com/buger/jsonparser"
@@ -36,6 +37,10 @@ var ValFalse = Val([]byte("false"))
 
 var ValArrayEmpty = Val([]byte("[]"))
 
+// ErrMaxIterations is yielded by an iterating operator, such as a
+// recursive union, when it exceeds its configured max iterations.
+var ErrMaxIterations = errors.New("max iterations exceeded")
+
 // -----------------------------------------------------
 
 func ValEqualMissing(val Val) bool {
@@ -50,6 +55,10 @@ func ValEqualTrue(val Val) bool {
 	return len(val) != 0 && val[0] == 't' // Ex: true.
 }
 
+func ValEqualFalse(val Val) bool {
+	return len(val) != 0 && val[0] == 'f' // Ex: false.
+}
+
 // ValEqual is based on N1QL's rules for missing & null's.
 func ValEqual(valA, valB Val, valComparer *ValComparer) Val {
 	if ValEqualMissing(valA) {
@@ -78,11 +87,16 @@ func ValHasValue(val Val) bool {
 // returns the JSON found there, or missing. Example path: [] or
 // ["addresses", "work", "city"].
 func ValPathGet(val Val, path []string) Val {
-	valOut, _, _, err := jsonparser.Get(val, path...)
+	valOut, valType, endOffset, err := jsonparser.Get(val, path...)
 	if err != nil {
 		return ValMissing
 	}
 
+	if valType == jsonparser.String {
+		// The jsonparser strips the double-quotes of a string.
+		return val[endOffset-len(valOut)-2 : endOffset]
+	}
+
 	return valOut
 }
 
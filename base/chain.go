@@ -45,3 +45,80 @@ func YieldChainedVals(yieldVals YieldVals, valsSuffix Vals, chunks *store.Chunks
 
 	return valsOut, nil // Return extended valsOut for caller reusability.
 }
+
+// VisitChainedVals is like YieldChainedVals, but also provides the
+// offset of each chain item to the visitor callback, which can return
+// false to stop the visit early.
+func VisitChainedVals(visitor func(offset uint64, vals Vals) bool,
+	valsSuffix Vals, chunks *store.Chunks,
+	ref []byte, valsOut Vals) (valsOutRV Vals, err error) {
+	for {
+		offset := binary.LittleEndian.Uint64(ref[:8])
+		if offset <= 0 {
+			break
+		}
+
+		ref, err = chunks.BytesRead(offset, binary.LittleEndian.Uint64(ref[8:16]))
+		if err != nil {
+			return valsOut, fmt.Errorf("VisitChainedVals: err: %v", err)
+		}
+
+		valsOut = ValsDecode(ref[16:], valsOut[:0])
+
+		valsOut = append(valsOut, valsSuffix...)
+
+		if !visitor(offset, valsOut) {
+			break
+		}
+	}
+
+	return valsOut, nil // Return extended valsOut for caller reusability.
+}
+
+// ChainedValsArray appends to out a JSON array of the first val of
+// each of the chained vals, given a starting chain item ref. As a
+// chain references its items from newest to oldest, the array is in
+// the reverse of the chain's order, which is the order that the
+// items were added to the chain. The optional scratch allows for the
+// caller to provide reusable, pre-allocated memory.
+func ChainedValsArray(out []byte, chunks *store.Chunks, ref []byte,
+	scratch []byte) (outRV []byte, scratchRV []byte, err error) {
+	scratch = scratch[:0]
+
+	var valsOut Vals
+
+	// The scratch is filled with entries of [val, len(val)], so
+	// that it can be walked backwards.
+	for {
+		offset := binary.LittleEndian.Uint64(ref[:8])
+		if offset <= 0 {
+			break
+		}
+
+		ref, err = chunks.BytesRead(offset, binary.LittleEndian.Uint64(ref[8:16]))
+		if err != nil {
+			return out, scratch, fmt.Errorf("ChainedValsArray: err: %v", err)
+		}
+
+		valsOut = ValsDecode(ref[16:], valsOut[:0])
+		if len(valsOut) > 0 {
+			scratch = append(scratch, valsOut[0]...)
+			scratch = BinaryAppendUint64(scratch, uint64(len(valsOut[0])))
+		}
+	}
+
+	out = append(out, '[')
+
+	for pos := len(scratch); pos > 0; {
+		n := int(binary.LittleEndian.Uint64(scratch[pos-8 : pos]))
+
+		out = append(out, scratch[pos-8-n:pos-8]...)
+
+		pos = pos - 8 - n
+		if pos > 0 {
+			out = append(out, ',')
+		}
+	}
+
+	return append(out, ']'), scratch, nil
+}
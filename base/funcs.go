@@ -0,0 +1,438 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the
+//  License. You may obtain a copy of the License at
+//  http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing,
+//  software distributed under the License is distributed on an "AS
+//  IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+//  express or implied. See the License for the specific language
+//  governing permissions and limitations under the License.
+
+package base
+
+import (
+	"bytes"
+	"math"
+	"strconv"
+
+	"github.com/buger/jsonparser"
+)
+
+// ValArith applies an arithmetic op ("add", "sub", "mult", "div",
+// "mod") to two vals, following N1QL's rules where a MISSING operand
+// leads to MISSING, and where any other non-number operand or a
+// division by zero leads to NULL. The result is appended to out.
+func ValArith(op string, valA, valB Val, out []byte) (Val, []byte) {
+	if ValEqualMissing(valA) || ValEqualMissing(valB) {
+		return ValMissing, out
+	}
+
+	a, aOk := ValFloat64(valA)
+	b, bOk := ValFloat64(valB)
+	if !aOk || !bOk {
+		return ValNull, out
+	}
+
+	var f float64
+
+	switch op {
+	case "add":
+		f = a + b
+	case "sub":
+		f = a - b
+	case "mult":
+		f = a * b
+	case "div":
+		if b == 0 {
+			return ValNull, out
+		}
+		f = a / b
+	case "mod":
+		if b == 0 {
+			return ValNull, out
+		}
+		f = math.Mod(a, b)
+	default:
+		return ValNull, out
+	}
+
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return ValNull, out
+	}
+
+	out = strconv.AppendFloat(out[:0], f, 'f', -1, 64)
+
+	return Val(out), out
+}
+
+// ValFloat64 returns the float64 of a val that's a JSON number.
+func ValFloat64(val Val) (float64, bool) {
+	v, vt := Parse(val)
+	if ParseTypeToValType[vt] != ValTypeNumber {
+		return 0, false
+	}
+
+	f, err := ParseFloat64(v)
+
+	return f, err == nil
+}
+
+// ValNot implements N1QL's logical NOT, where MISSING and NULL are
+// propagated, see ValTruth().
+func ValNot(val Val) Val {
+	val = ValTruth(val)
+	if ValEqualTrue(val) {
+		return ValFalse
+	}
+
+	if ValEqualFalse(val) {
+		return ValTrue
+	}
+
+	return val
+}
+
+// ValTruth returns ValTrue or ValFalse following N1QL's rules, where
+// MISSING and NULL are propagated and where false, 0, "", [] and {}
+// are falsy.
+func ValTruth(val Val) Val {
+	v, vt := Parse(val)
+
+	switch ParseTypeToValType[vt] {
+	case ValTypeMissing:
+		return ValMissing
+	case ValTypeNull:
+		return ValNull
+	case ValTypeBoolean:
+		if v[0] == 't' {
+			return ValTrue
+		}
+	case ValTypeNumber:
+		if f, err := ParseFloat64(v); err == nil && f != 0 {
+			return ValTrue
+		}
+	case ValTypeString:
+		if len(v) > 0 {
+			return ValTrue
+		}
+	case ValTypeArray:
+		var n int
+		jsonparser.ArrayEach(v, func(x []byte, xt jsonparser.ValueType,
+			offset int, err error) {
+			n++
+		})
+		if n > 0 {
+			return ValTrue
+		}
+	case ValTypeObject:
+		var n int
+		jsonparser.ObjectEach(v, func(k []byte, x []byte,
+			xt jsonparser.ValueType, offset int) error {
+			n++
+			return nil
+		})
+		if n > 0 {
+			return ValTrue
+		}
+	}
+
+	return ValFalse
+}
+
+// ValAnd implements N1QL's logical AND of two vals from ValTruth(),
+// where FALSE wins over MISSING, which wins over NULL.
+func ValAnd(valA, valB Val) Val {
+	if ValEqualFalse(valA) || ValEqualFalse(valB) {
+		return ValFalse
+	}
+
+	if ValEqualMissing(valA) || ValEqualMissing(valB) {
+		return ValMissing
+	}
+
+	if ValEqualNull(valA) || ValEqualNull(valB) {
+		return ValNull
+	}
+
+	return ValTrue
+}
+
+// ValOr implements N1QL's logical OR of two vals from ValTruth(),
+// where TRUE wins over NULL, which wins over MISSING.
+func ValOr(valA, valB Val) Val {
+	if ValEqualTrue(valA) || ValEqualTrue(valB) {
+		return ValTrue
+	}
+
+	if ValEqualNull(valA) || ValEqualNull(valB) {
+		return ValNull
+	}
+
+	if ValEqualMissing(valA) || ValEqualMissing(valB) {
+		return ValMissing
+	}
+
+	return ValFalse
+}
+
+// ValIs implements N1QL's IS [NOT] MISSING, IS [NOT] NULL and IS
+// [NOT] VALUED, where the kind is one of "isMissing",
+// "isNotMissing", "isNull", "isNotNull", "isValued" or "isNotValued".
+// Like N1QL, IS [NOT] NULL of MISSING is MISSING.
+func ValIs(kind string, val Val) Val {
+	missing := ValEqualMissing(val)
+	null := !missing && ValEqualNull(val)
+
+	var rv bool
+
+	switch kind {
+	case "isMissing":
+		rv = missing
+	case "isNotMissing":
+		rv = !missing
+	case "isNull":
+		if missing {
+			return ValMissing
+		}
+		rv = null
+	case "isNotNull":
+		if missing {
+			return ValMissing
+		}
+		rv = !null
+	case "isValued":
+		rv = !missing && !null
+	case "isNotValued":
+		rv = missing || null
+	}
+
+	if rv {
+		return ValTrue
+	}
+
+	return ValFalse
+}
+
+// ValConcat implements N1QL's string concatenation, where MISSING is
+// propagated and any other non-string leads to NULL. The result is
+// appended to out.
+func ValConcat(valA, valB Val, out []byte) (Val, []byte) {
+	a, at := Parse(valA)
+	b, bt := Parse(valB)
+
+	if ParseTypeToValType[at] == ValTypeMissing ||
+		ParseTypeToValType[bt] == ValTypeMissing {
+		return ValMissing, out
+	}
+
+	if ParseTypeToValType[at] != ValTypeString ||
+		ParseTypeToValType[bt] != ValTypeString {
+		return ValNull, out
+	}
+
+	// The parsed strings are still JSON escaped, so they're
+	// concatenated as-is.
+	out = append(out[:0], '"')
+	out = append(out, a...)
+	out = append(out, b...)
+	out = append(out, '"')
+
+	return Val(out), out
+}
+
+// ValElement implements N1QL's element navigation, like `a[i]` or
+// `o[s]`, where a negative index counts from the end of an array. An
+// out of range index or a missing field leads to MISSING. Any other
+// kind of index leads to NULL, or to MISSING when the val is MISSING.
+func ValElement(val, idx Val) Val {
+	v, vt := Parse(val)
+	x, xt := Parse(idx)
+
+	switch ParseTypeToValType[xt] {
+	case ValTypeMissing:
+		return ValMissing
+
+	case ValTypeNumber:
+		f, err := ParseFloat64(x)
+		if err == nil && f == math.Trunc(f) {
+			if ParseTypeToValType[vt] != ValTypeArray {
+				return ValMissing
+			}
+
+			i := int(f)
+			if i < 0 {
+				var n int
+				jsonparser.ArrayEach(v, func(x []byte,
+					xt jsonparser.ValueType, offset int, err error) {
+					n++
+				})
+
+				i += n
+				if i < 0 {
+					return ValMissing
+				}
+			}
+
+			return ValPathGet(v, []string{"[" + strconv.Itoa(i) + "]"})
+		}
+
+	case ValTypeString:
+		if ParseTypeToValType[vt] != ValTypeObject {
+			return ValMissing
+		}
+
+		s, err := jsonparser.ParseString(x)
+		if err == nil {
+			return ValPathGet(v, []string{s})
+		}
+	}
+
+	if ParseTypeToValType[vt] == ValTypeMissing {
+		return ValMissing
+	}
+
+	return ValNull
+}
+
+// ValStringMap applies a string mapping function, like
+// strings.ToLower, to a val that's a JSON string, where MISSING is
+// propagated and any other non-string leads to NULL. The result is
+// appended to out.
+func ValStringMap(c *ValComparer, val Val, f func(string) string,
+	out []byte) (Val, []byte) {
+	v, vt := Parse(val)
+
+	switch ParseTypeToValType[vt] {
+	case ValTypeMissing:
+		return ValMissing, out
+	case ValTypeString:
+		s, err := jsonparser.ParseString(v)
+		if err == nil {
+			out, err = c.EncodeAsString([]byte(f(s)), out[:0])
+			if err == nil {
+				return Val(out), out
+			}
+		}
+	}
+
+	return ValNull, out
+}
+
+// ValPathSet returns a copy of an object val where the field at the
+// path is set to newVal, or is removed when newVal is MISSING, as in
+// the SET and UNSET clauses of a N1QL UPDATE. Like N1QL, intermediate
+// objects along the path are not created, so the val is returned
+// as-is when the path's parent isn't an object. The result is
+// appended to out.
+func ValPathSet(c *ValComparer, val Val, path []string, newVal Val,
+	out []byte) (Val, []byte) {
+	if len(path) <= 0 {
+		return val, out
+	}
+
+	v, vt := Parse(val)
+	if ParseTypeToValType[vt] != ValTypeObject {
+		return val, out
+	}
+
+	rv, ok, err := valPathSetAppend(c, v, path, newVal, out[:0])
+	if err != nil || !ok {
+		return val, out
+	}
+
+	return Val(rv), rv
+}
+
+// valPathSetAppend appends a copy of the object v with the path set
+// or removed, where ok is false if the path wasn't found, such as
+// when the path's parent isn't an object.
+func valPathSetAppend(c *ValComparer, v []byte, path []string, newVal Val,
+	out []byte) (rv []byte, ok bool, err error) {
+	unset := ValEqualMissing(newVal)
+
+	out = append(out, '{')
+
+	start := len(out)
+
+	appendKey := func(key []byte) {
+		if len(out) > start {
+			out = append(out, ',')
+		}
+
+		out = append(out, '"')
+		out = append(out, key...)
+		out = append(out, '"', ':')
+	}
+
+	err = jsonparser.ObjectEach(v, func(key []byte, value []byte,
+		dataType jsonparser.ValueType, offset int) error {
+		name := string(key)
+		if bytes.IndexByte(key, '\\') >= 0 {
+			name, _ = jsonparser.ParseString(key)
+		}
+
+		if name != path[0] {
+			appendKey(key)
+
+			if dataType == jsonparser.String {
+				out = append(out, '"')
+				out = append(out, value...)
+				out = append(out, '"')
+			} else {
+				out = append(out, value...)
+			}
+
+			return nil
+		}
+
+		if len(path) > 1 {
+			if dataType != jsonparser.Object {
+				return nil
+			}
+
+			appendKey(key)
+
+			var errChild error
+
+			out, ok, errChild = valPathSetAppend(c, value, path[1:], newVal, out)
+
+			return errChild
+		}
+
+		ok = true
+
+		if !unset {
+			appendKey(key)
+
+			out = append(out, newVal...)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return out, false, err
+	}
+
+	if !ok {
+		if len(path) > 1 || unset {
+			return out, false, nil
+		}
+
+		if len(out) > start {
+			out = append(out, ',')
+		}
+
+		out, err = c.EncodeAsString([]byte(path[0]), out)
+		if err != nil {
+			return out, false, err
+		}
+
+		out = append(out, ':')
+		out = append(out, newVal...)
+	}
+
+	out = append(out, '}')
+
+	return out, true, nil
+}
@@ -0,0 +1,77 @@
+package base
+
+import (
+	"testing"
+)
+
+func TestValTruth(t *testing.T) {
+	tests := []struct {
+		val       string
+		expect    string
+		expectNot string
+	}{
+		{``, ``, ``},
+		{`null`, `null`, `null`},
+		{`true`, `true`, `false`},
+		{`false`, `false`, `true`},
+		{`0`, `false`, `true`},
+		{`-1.5`, `true`, `false`},
+		{`""`, `false`, `true`},
+		{`"a"`, `true`, `false`},
+		{`"[]"`, `true`, `false`},
+		{`"{}"`, `true`, `false`},
+		{`[]`, `false`, `true`},
+		{`[ ]`, `false`, `true`},
+		{`[0]`, `true`, `false`},
+		{`[ null ]`, `true`, `false`},
+		{`{}`, `false`, `true`},
+		{`{ }`, `false`, `true`},
+		{`{"a":false}`, `true`, `false`},
+	}
+
+	for i, test := range tests {
+		v := ValTruth(Val(test.val))
+		if string(v) != test.expect {
+			t.Errorf("i: %d, test: %+v, got: %s", i, test, v)
+		}
+
+		v = ValNot(Val(test.val))
+		if string(v) != test.expectNot {
+			t.Errorf("i: %d, test: %+v, got not: %s", i, test, v)
+		}
+	}
+}
+
+func TestValAndOr(t *testing.T) {
+	vals := []string{`true`, `false`, `null`, ``}
+
+	expectAnd := [][]string{
+		{`true`, `false`, `null`, ``},
+		{`false`, `false`, `false`, `false`},
+		{`null`, `false`, `null`, ``},
+		{``, `false`, ``, ``},
+	}
+
+	expectOr := [][]string{
+		{`true`, `true`, `true`, `true`},
+		{`true`, `false`, `null`, ``},
+		{`true`, `null`, `null`, `null`},
+		{`true`, ``, `null`, ``},
+	}
+
+	for a, valA := range vals {
+		for b, valB := range vals {
+			v := ValAnd(Val(valA), Val(valB))
+			if string(v) != expectAnd[a][b] {
+				t.Errorf("%q AND %q, expected: %q, got: %q",
+					valA, valB, expectAnd[a][b], v)
+			}
+
+			v = ValOr(Val(valA), Val(valB))
+			if string(v) != expectOr[a][b] {
+				t.Errorf("%q OR %q, expected: %q, got: %q",
+					valA, valB, expectOr[a][b], v)
+			}
+		}
+	}
+}
@@ -0,0 +1,225 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the
+//  License. You may obtain a copy of the License at
+//  http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing,
+//  software distributed under the License is distributed on an "AS
+//  IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+//  express or implied. See the License for the specific language
+//  governing permissions and limitations under the License.
+
+package base
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+)
+
+// Partitions is a set of append-only partitions of vals, where vals
+// are assigned to a partition by the hash of their key, such as for
+// a partitioned (Grace) hash join, so that vals with the same key are
+// always in the same partition.
+type Partitions struct {
+	Parts []*Partition
+
+	KeyBuf []byte // Reusable buffer for canonical keys.
+}
+
+// NewPartitions returns a ready-to-use Partitions, whose partitions
+// spill to temp files in the dir once they're larger than the budget.
+func NewPartitions(dir, prefix string, numPartitions int,
+	budgetBytes int) *Partitions {
+	ps := &Partitions{}
+
+	for i := 0; i < numPartitions; i++ {
+		ps.Parts = append(ps.Parts, &Partition{
+			Dir:         dir,
+			Prefix:      fmt.Sprintf("%s%d_", prefix, i),
+			BudgetBytes: budgetBytes,
+		})
+	}
+
+	return ps
+}
+
+// Add appends the vals to the partition chosen by the hash of the
+// key, where a key that's not canonical is first canonicalized. Vals
+// with a MISSING or NULL key, which never match, go to partition 0.
+func (ps *Partitions) Add(key Val, canonical bool, vals Vals,
+	valComparer *ValComparer) (err error) {
+	var idx int
+
+	if ValHasValue(key) {
+		if !canonical {
+			ps.KeyBuf, err = valComparer.CanonicalJSON(key, ps.KeyBuf[:0])
+			if err != nil {
+				return err
+			}
+
+			key = ps.KeyBuf
+		}
+
+		idx = int(HashFNV1a(key) % uint64(len(ps.Parts)))
+	}
+
+	return ps.Parts[idx].Add(vals)
+}
+
+// Close closes all the partitions, removing any temp files.
+func (ps *Partitions) Close() (err error) {
+	for _, p := range ps.Parts {
+		if errClose := p.Close(); errClose != nil && err == nil {
+			err = errClose
+		}
+	}
+
+	return err
+}
+
+// HashFNV1a returns the 64-bit FNV-1a hash of the bytes.
+func HashFNV1a(b []byte) uint64 {
+	h := uint64(14695981039346656037)
+
+	for _, c := range b {
+		h ^= uint64(c)
+		h *= 1099511628211
+	}
+
+	return h
+}
+
+// -----------------------------------------------------
+
+// Partition is an append-only sequence of vals, whose entries are
+// kept in memory until they're more than BudgetBytes, after which
+// they're appended to a temp file in the Dir, leaving the memory
+// available for subsequent entries.
+type Partition struct {
+	Dir         string
+	Prefix      string
+	BudgetBytes int
+
+	Buf []byte // The entries that have not been spilled yet.
+
+	File *os.File // The spill file, or nil if nothing was spilled.
+}
+
+// Add appends the vals as an entry of [len(encoded), encoded], where
+// the encoded vals are from ValsEncode().
+func (p *Partition) Add(vals Vals) error {
+	beg := len(p.Buf)
+
+	p.Buf = append(p.Buf, Zero8[:]...) // Prepare space for entry len.
+	p.Buf = ValsEncode(vals, p.Buf)
+
+	binary.LittleEndian.PutUint64(p.Buf[beg:beg+8], uint64(len(p.Buf)-8-beg))
+
+	if len(p.Buf) > p.BudgetBytes {
+		return p.Spill()
+	}
+
+	return nil
+}
+
+// Spill appends the in-memory entries to the spill file.
+func (p *Partition) Spill() (err error) {
+	if p.File == nil {
+		p.File, err = ioutil.TempFile(p.Dir, p.Prefix)
+		if err != nil {
+			return fmt.Errorf("Partition.Spill, TempFile, err: %v", err)
+		}
+	}
+
+	_, err = p.File.Write(p.Buf)
+	if err != nil {
+		return fmt.Errorf("Partition.Spill, Write, err: %v", err)
+	}
+
+	p.Buf = p.Buf[:0]
+
+	return nil
+}
+
+// Visit invokes the yieldVals callback on each of the partition's
+// vals, in the order that they were added.
+func (p *Partition) Visit(yieldVals YieldVals) error {
+	var vals Vals
+
+	if p.File != nil {
+		_, err := p.File.Seek(0, io.SeekStart)
+		if err != nil {
+			return fmt.Errorf("Partition.Visit, Seek, err: %v", err)
+		}
+
+		r := bufio.NewReader(p.File)
+
+		var buf8 [8]byte
+		var entry []byte
+
+		for {
+			_, err = io.ReadFull(r, buf8[:])
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return fmt.Errorf("Partition.Visit, ReadFull, err: %v", err)
+			}
+
+			n := int(binary.LittleEndian.Uint64(buf8[:]))
+			if cap(entry) < n {
+				entry = make([]byte, n)
+			}
+			entry = entry[:n]
+
+			_, err = io.ReadFull(r, entry)
+			if err != nil {
+				return fmt.Errorf("Partition.Visit, ReadFull entry, err: %v", err)
+			}
+
+			vals = ValsDecode(entry, vals[:0])
+
+			yieldVals(vals)
+		}
+
+		// Leave the file position at the end for any later Spill().
+		_, err = p.File.Seek(0, io.SeekEnd)
+		if err != nil {
+			return fmt.Errorf("Partition.Visit, Seek end, err: %v", err)
+		}
+	}
+
+	for b := p.Buf; len(b) > 0; {
+		n := int(binary.LittleEndian.Uint64(b[:8]))
+
+		vals = ValsDecode(b[8:8+n], vals[:0])
+
+		yieldVals(vals)
+
+		b = b[8+n:]
+	}
+
+	return nil
+}
+
+// Close releases the partition's memory and removes any spill file.
+// Close may be called more than once.
+func (p *Partition) Close() error {
+	p.Buf = nil
+
+	if p.File != nil {
+		p.File.Close()
+
+		err := os.Remove(p.File.Name())
+
+		p.File = nil
+
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,81 @@
+package base
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestPartitionsSpill(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "n1k1PartitionsTest")
+	defer os.RemoveAll(dir)
+
+	// A tiny budget that forces every partition to spill.
+	ps := NewPartitions(dir, "test", 3, 1)
+
+	valComparer := NewValComparer()
+
+	var expect [][]Vals
+
+	var idxs []int
+
+	for i := 0; i < len(ps.Parts); i++ {
+		expect = append(expect, nil)
+	}
+
+	keys := []string{`"a"`, `"b"`, `"c"`, `"a"`, `{"x":1,"y":2}`, `{ "y": 2, "x": 1 }`, `null`, ``}
+
+	for i, key := range keys {
+		vals := Vals{Val(key), Val([]byte{byte('0' + i)})}
+
+		err := ps.Add(Val(key), false, vals, valComparer)
+		if err != nil {
+			t.Fatalf("expected no err, got: %v", err)
+		}
+
+		idx := 0
+		if ValHasValue(Val(key)) {
+			canonical, _ := valComparer.CanonicalJSON(Val(key), nil)
+			idx = int(HashFNV1a(canonical) % uint64(len(ps.Parts)))
+		}
+
+		expect[idx] = append(expect[idx], vals)
+
+		idxs = append(idxs, idx)
+	}
+
+	files, _ := ioutil.ReadDir(dir)
+	if len(files) == 0 {
+		t.Fatalf("expected spill files")
+	}
+
+	for i, p := range ps.Parts {
+		var got []Vals
+
+		err := p.Visit(func(vals Vals) {
+			valsCopy, _, _ := ValsDeepCopy(vals, nil, nil)
+
+			got = append(got, valsCopy)
+		})
+		if err != nil {
+			t.Fatalf("expected no visit err, got: %v", err)
+		}
+
+		if !reflect.DeepEqual(got, expect[i]) {
+			t.Fatalf("partition: %d, expected: %v, got: %v", i, expect[i], got)
+		}
+	}
+
+	// Equivalent keys must be in the same partition.
+	if idxs[4] != idxs[5] {
+		t.Fatalf("expected equivalent keys in same partition, got: %v", idxs)
+	}
+
+	ps.Close()
+
+	files, _ = ioutil.ReadDir(dir)
+	if len(files) != 0 {
+		t.Fatalf("expected no spill files after close, got: %d", len(files))
+	}
+}
@@ -130,19 +130,7 @@ func (stage *Stage) StartActor(aFunc ActorFunc, aData interface{}, batchSize int
 		if errIn != nil {
 			err = errIn
 
-			stage.M.Lock()
-
-			if stage.Err == nil {
-				stage.Err = errIn // First error by any actor.
-
-				// Closed & nil'ed under lock to have single close().
-				if stage.StopCh != nil {
-					close(stage.StopCh)
-					stage.StopCh = nil
-				}
-			}
-
-			stage.M.Unlock()
+			stage.SetErr(errIn)
 		}
 
 		if err == nil {
@@ -161,6 +149,27 @@ func (stage *Stage) StartActor(aFunc ActorFunc, aData interface{}, batchSize int
 
 // --------------------------------------------------------
 
+// SetErr records the first error of the stage, such as from an actor
+// or from the processing of the actors' batches, and stops the actors
+// from sending any more batches.
+func (stage *Stage) SetErr(err error) {
+	stage.M.Lock()
+
+	if stage.Err == nil {
+		stage.Err = err
+
+		// Closed & nil'ed under lock to have single close().
+		if stage.StopCh != nil {
+			close(stage.StopCh)
+			stage.StopCh = nil
+		}
+	}
+
+	stage.M.Unlock()
+}
+
+// --------------------------------------------------------
+
 // YieldResultsFromActors receives batches from the actors and yields
 // them onwards, until all the actors are done.
 func (stage *Stage) YieldResultsFromActors() {
@@ -222,3 +231,69 @@ func (stage *Stage) AcquireBatch() (rv []Vals) {
 
 	return rv
 }
+
+// --------------------------------------------------------
+
+// StageCursor allows the vals from the actors of a stage to be pulled
+// one at a time, instead of being pushed, so that a caller can step
+// through a child's vals in lockstep with another child's vals.
+type StageCursor struct {
+	Stage *Stage
+
+	Batch []Vals // The current batch from the actors.
+	Pos   int    // The position of the next vals in the Batch.
+
+	NumActorsDone int
+}
+
+// NewStageCursor returns a StageCursor after waiting for the stage's
+// actors to be ready.
+func NewStageCursor(stage *Stage) *StageCursor {
+	for i := 0; i < stage.NumActors; i++ {
+		<-stage.ActorReadyCh
+	}
+
+	return &StageCursor{Stage: stage}
+}
+
+// Next returns the next vals from the actors, or nil when all the
+// actors are done. The returned vals are valid only until the
+// current batch is exhausted by later calls to Next().
+func (c *StageCursor) Next() Vals {
+	for c.Pos >= len(c.Batch) {
+		if c.Batch != nil {
+			c.Stage.RecycleBatch(c.Batch)
+			c.Batch = nil
+		}
+
+		if c.NumActorsDone >= c.Stage.NumActors {
+			return nil
+		}
+
+		c.Batch = <-c.Stage.BatchCh
+		c.Pos = 0
+
+		if c.Batch == nil {
+			c.NumActorsDone++
+		}
+	}
+
+	vals := c.Batch[c.Pos]
+
+	c.Pos++
+
+	return vals
+}
+
+// Drain receives and discards any remaining vals, so that the actors
+// can finish, and then returns the first error from any actor.
+func (c *StageCursor) Drain() error {
+	for c.Next() != nil {
+	}
+
+	c.Stage.M.Lock()
+	err := c.Stage.Err
+	c.Stage.M.Unlock()
+
+	return err
+}
@@ -25,6 +25,11 @@ type Vars struct {
 	Temps []interface{}
 	Next  *Vars // The root Vars has nil Next.
 	Ctx   *Ctx
+
+	// Outer holds the outer vals of an enclosing, correlated
+	// subquery, whose labels are OuterLabels.
+	Outer       Vals
+	OuterLabels Labels
 }
 
 // -----------------------------------------------------
@@ -36,6 +41,9 @@ func (v *Vars) ChainExtend() *Vars {
 		Temps: make([]interface{}, len(v.Temps)),
 		Next:  v,
 		Ctx:   v.Ctx.Clone(),
+
+		Outer:       v.Outer,
+		OuterLabels: v.OuterLabels,
 	}
 }
 
@@ -57,6 +65,21 @@ func (v *Vars) TempSet(idx int, resource interface{}) {
 
 // -----------------------------------------------------
 
+// TempGet returns the resource of a temp slot, where an empty slot,
+// such as in a Vars from ChainExtend(), falls back to the resource in
+// that slot of the Next Vars in the chain.
+func (v *Vars) TempGet(idx int) interface{} {
+	for ; v != nil; v = v.Next {
+		if idx < len(v.Temps) && v.Temps[idx] != nil {
+			return v.Temps[idx]
+		}
+	}
+
+	return nil
+}
+
+// -----------------------------------------------------
+
 // TempGetHeap casts the retrieved temp resource into a heap.
 func (v *Vars) TempGetHeap(idx int) (rv *store.Heap) {
 	r := v.Temps[idx]
@@ -67,6 +90,30 @@ func (v *Vars) TempGetHeap(idx int) (rv *store.Heap) {
 	return rv
 }
 
+// TempGetPartition casts the retrieved temp resource into a partition.
+func (v *Vars) TempGetPartition(idx int) (rv *Partition) {
+	r := v.Temps[idx]
+	if r != nil {
+		rv, _ = r.(*Partition)
+	}
+
+	return rv
+}
+
+// TempGetWindowPartition casts the retrieved temp resource into a
+// window partition, which is either a heap or a window ring, along
+// with a pointer to its Extra field.
+func (v *Vars) TempGetWindowPartition(idx int) (WindowPartition, *interface{}) {
+	switch r := v.Temps[idx].(type) {
+	case *store.Heap:
+		return r, &r.Extra
+	case *WindowRing:
+		return r, &r.Extra
+	}
+
+	return nil, nil
+}
+
 // -----------------------------------------------------
 
 // Ctx represents the runtime context for a request, where a Ctx is
@@ -96,6 +143,19 @@ type Ctx struct {
 	AllocChunks   func() (*store.Chunks, error)
 	RecycleChunks func(*store.Chunks)
 
+	// NamedParams and PositionalParams are the bound query parameters
+	// of the request, where positional parameters are 1-based.
+	NamedParams      map[string]Val
+	PositionalParams Vals
+
+	// ParamsBinder, when non-nil, is invoked by BindParams() to bind
+	// the NamedParams and PositionalParams on their first use, such
+	// as from the request args of an execution context.
+	ParamsBinder func(ctx *Ctx) error
+
+	// ParamsErr is the error from the ParamsBinder, if any.
+	ParamsErr error
+
 	// TODO: Other things that might appear here might be request ID,
 	// request-specific allocators or resources, etc.
 }
@@ -111,3 +171,38 @@ func (ctx *Ctx) Clone() (ctxCopy *Ctx) {
 
 	return ctxCopy
 }
+
+// -----------------------------------------------------
+
+// BindParams binds the query parameters via the ParamsBinder, when
+// they're not bound yet, and returns the binding's error, if any.
+func (ctx *Ctx) BindParams() error {
+	if ctx.NamedParams == nil && ctx.PositionalParams == nil &&
+		ctx.ParamsErr == nil && ctx.ParamsBinder != nil {
+		ctx.ParamsErr = ctx.ParamsBinder(ctx)
+	}
+
+	return ctx.ParamsErr
+}
+
+// -----------------------------------------------------
+
+// ParamVal returns the bound query parameter for a ref, which is
+// either a name or a 1-based position, or ValMissing if the parameter
+// is unknown.
+func (ctx *Ctx) ParamVal(ref interface{}) Val {
+	switch x := ref.(type) {
+	case string:
+		if v, ok := ctx.NamedParams[x]; ok {
+			return v
+		}
+	case int:
+		if x >= 1 && x <= len(ctx.PositionalParams) {
+			return ctx.PositionalParams[x-1]
+		}
+	case float64: // Ex: from a JSON decoded expression.
+		return ctx.ParamVal(int(x))
+	}
+
+	return ValMissing
+}
@@ -25,6 +25,7 @@ var Keywords = map[string]bool{
 	"switch": true,
 	"case":   true,
 	"make":   true,
+	"map":    true,
 	"append": true,
 	"copy":   true,
 	"cap":    true,
@@ -41,6 +42,7 @@ var Keywords = map[string]bool{
 	"int":       true,
 	"int64":     true,
 	"uint64":    true,
+	"float64":   true,
 }
 
 // ---------------------------------------------------------------
@@ -22,11 +22,15 @@ import (
 // ExprCatalog is the default registry of known expression functions.
 var ExprCatalog = map[string]base.ExprCatalogFunc{
 	"json":        ExprJson,
+	"param":       ExprParam,
 	"labelPath":   ExprLabelPath,
 	"labelUint64": ExprLabelUint64,
 
 	"valsEncode":          ExprValsEncode,
 	"valsEncodeCanonical": ExprValsEncodeCanonical,
+
+	"keysEncodeCanonical":    ExprKeysEncodeCanonical,
+	"keysEncodeCanonicalAll": ExprKeysEncodeCanonicalAll,
 }
 
 // -----------------------------------------------------
@@ -61,6 +65,78 @@ func ExprJson(lzVars *base.Vars, labels base.Labels,
 
 // -----------------------------------------------------
 
+// ExprParam resolves a named or positional query parameter from the
+// Ctx, which is then treated as static JSON.
+func ExprParam(lzVars *base.Vars, labels base.Labels,
+	params []interface{}, path string) (lzExprFunc base.ExprFunc) {
+	if lzVars.Ctx.BindParams() != nil { // !lz
+		return ExprParamBindErr(lzVars, labels, params, path) // !lz
+	} // !lz
+
+	expr := ExprParamResolve(lzVars, []interface{}{"param", params[0]}) // !lz
+
+	return ExprJson(lzVars, labels, expr.([]interface{})[1:], path)
+}
+
+// ExprParamBindErr yields the error from binding the query parameters
+// of the Ctx, see base.Ctx.BindParams(), and evaluates to MISSING.
+func ExprParamBindErr(lzVars *base.Vars, labels base.Labels,
+	params []interface{}, path string) (lzExprFunc base.ExprFunc) {
+	lzExprFunc = func(lzVals base.Vals, lzYieldErr base.YieldErr) (lzVal base.Val) {
+		lzErr := lzVars.Ctx.BindParams()
+		if lzErr != nil {
+			lzYieldErr(lzErr)
+		}
+
+		lzVal = base.ValMissing
+
+		return lzVal
+	}
+
+	return lzExprFunc
+}
+
+// ExprParamResolve returns the static JSON expression for a param
+// expression, such as ["param", "name"] or ["param", 1], or otherwise
+// returns the expression unchanged, such as when the query parameters
+// fail to be bound, so that the ExprParam yields the error.
+func ExprParamResolve(vars *base.Vars, expr interface{}) interface{} {
+	if x, ok := expr.([]interface{}); ok && len(x) > 1 && x[0] == "param" {
+		v, err := ExprParamVal(vars, x[1])
+		if err == nil {
+			return []interface{}{"json", string(v)}
+		}
+	}
+
+	return expr
+}
+
+// ExprParamVal returns the bound query parameter for a ref, see
+// base.Ctx.ParamVal(), where the Ctx's query parameters are first
+// bound via base.Ctx.BindParams(), if needed.
+func ExprParamVal(vars *base.Vars, ref interface{}) (base.Val, error) {
+	err := vars.Ctx.BindParams()
+	if err != nil {
+		return nil, err
+	}
+
+	return vars.Ctx.ParamVal(ref), nil
+}
+
+// ExprParamsResolve returns a copy of the params where any param
+// expressions are resolved to static JSON, so that optimizations for
+// static JSON also apply to bound query parameters.
+func ExprParamsResolve(vars *base.Vars, params []interface{}) []interface{} {
+	rv := make([]interface{}, len(params))
+	for i, param := range params {
+		rv[i] = ExprParamResolve(vars, param)
+	}
+
+	return rv
+}
+
+// -----------------------------------------------------
+
 func ExprLabelPath(lzVars *base.Vars, labels base.Labels,
 	params []interface{}, path string) (lzExprFunc base.ExprFunc) {
 	idx := labels.IndexOf(params[0].(string))
@@ -176,3 +252,73 @@ func ExprValsEncodeCanonical(lzVars *base.Vars, labels base.Labels,
 
 	return lzExprFunc
 }
+
+// -----------------------------------------------------
+
+// ExprKeysEncodeCanonical evaluates the params as a list of key exprs
+// and encodes the resulting key vals using base.ValsEncodeCanonical(),
+// such as for a composite join key. The result is MISSING if any key
+// val is MISSING or NULL, as such a key never matches.
+func ExprKeysEncodeCanonical(lzVars *base.Vars, labels base.Labels,
+	params []interface{}, path string) (lzExprFunc base.ExprFunc) {
+	return ExprKeysEncode(lzVars, labels, params, path, true)
+}
+
+// ExprKeysEncodeCanonicalAll is like ExprKeysEncodeCanonical, but
+// MISSING and NULL key vals are also encoded, such as for a cache key
+// where a NULL key val is still distinct from other key vals.
+func ExprKeysEncodeCanonicalAll(lzVars *base.Vars, labels base.Labels,
+	params []interface{}, path string) (lzExprFunc base.ExprFunc) {
+	return ExprKeysEncode(lzVars, labels, params, path, false)
+}
+
+// ExprKeysEncode encodes the vals of the key exprs in the params,
+// where the result is MISSING if valuedOnly is true and any key val
+// is MISSING or NULL.
+func ExprKeysEncode(lzVars *base.Vars, labels base.Labels,
+	params []interface{}, path string, valuedOnly bool) (lzExprFunc base.ExprFunc) {
+	var lzProjectFunc base.ProjectFunc
+
+	lzProjectFunc =
+		MakeProjectFunc(lzVars, labels, params, path, "PF") // !lz
+
+	_ = lzProjectFunc
+
+	var lzValsKey base.Vals // <== varLift: lzValsKey by path
+	var lzJoined []byte     // <== varLift: lzJoined by path
+
+	lzExprFunc = func(lzVals base.Vals, lzYieldErr base.YieldErr) (lzVal base.Val) {
+		if LzScope {
+			lzValsOut := lzValsKey[:0]
+
+			lzValsOut = lzProjectFunc(lzVals, lzValsOut, lzYieldErr) // <== emitCaptured: path "PF"
+
+			lzValsKey = lzValsOut
+
+			lzValued := true
+
+			if valuedOnly { // !lz
+				for _, lzValKey := range lzValsKey {
+					if !base.ValHasValue(lzValKey) {
+						lzValued = false
+					}
+				}
+			} // !lz
+
+			if lzValued {
+				var lzErr error
+
+				lzBytes := lzJoined[:0]
+
+				lzJoined, lzErr = base.ValsEncodeCanonical(lzValsKey, lzBytes, lzVars.Ctx.ValComparer)
+				if lzErr == nil {
+					lzVal = base.Val(lzJoined)
+				}
+			}
+		}
+
+		return lzVal
+	}
+
+	return lzExprFunc
+}
@@ -57,13 +57,18 @@ func MakeBiExprFunc(lzVars *base.Vars, labels base.Labels,
 
 // -----------------------------------------------------
 
+// ExprOr implements N1QL's logical OR, where the second param is
+// evaluated only when the first param isn't truthy, see base.ValOr().
 func ExprOr(lzVars *base.Vars, labels base.Labels,
 	params []interface{}, path string) (lzExprFunc base.ExprFunc) {
 	biExprFunc := func(lzA, lzB base.ExprFunc, lzVals base.Vals, lzYieldErr base.YieldErr) (lzVal base.Val) { // !lz
-		// TODO: This might not match N1QL logical OR semantics.
 		lzVal = lzA(lzVals, lzYieldErr) // <== emitCaptured: path "A"
+		lzVal = base.ValTruth(lzVal)
 		if !base.ValEqualTrue(lzVal) {
+			lzValA := lzVal
+
 			lzVal = lzB(lzVals, lzYieldErr) // <== emitCaptured: path "B"
+			lzVal = base.ValOr(lzValA, base.ValTruth(lzVal))
 		}
 
 		return lzVal
@@ -77,13 +82,18 @@ func ExprOr(lzVars *base.Vars, labels base.Labels,
 
 // -----------------------------------------------------
 
+// ExprAnd implements N1QL's logical AND, where the second param is
+// evaluated only when the first param isn't falsy, see base.ValAnd().
 func ExprAnd(lzVars *base.Vars, labels base.Labels,
 	params []interface{}, path string) (lzExprFunc base.ExprFunc) {
 	biExprFunc := func(lzA, lzB base.ExprFunc, lzVals base.Vals, lzYieldErr base.YieldErr) (lzVal base.Val) { // !lz
-		// TODO: This might not match N1QL logical AND semantics.
 		lzVal = lzA(lzVals, lzYieldErr) // <== emitCaptured: path "A"
-		if base.ValEqualTrue(lzVal) {
+		lzVal = base.ValTruth(lzVal)
+		if !base.ValEqualFalse(lzVal) {
+			lzValA := lzVal
+
 			lzVal = lzB(lzVals, lzYieldErr) // <== emitCaptured: path "B"
+			lzVal = base.ValAnd(lzValA, base.ValTruth(lzVal))
 		}
 
 		return lzVal
@@ -61,6 +61,8 @@ var LTCmps = []base.Val{base.ValTrue, base.ValFalse}
 func ExprCmp(lzVars *base.Vars, labels base.Labels, params []interface{},
 	path string, cmps []base.Val, cmpEQ base.Val, cmpEQOnly bool) (
 	lzExprFunc base.ExprFunc) {
+	params = ExprParamsResolve(lzVars, params) // !lz
+
 	for parami, param := range params {
 		expr := param.([]interface{})
 		if expr[0].(string) == "json" { // Optimize when param is static JSON.
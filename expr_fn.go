@@ -0,0 +1,355 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the
+//  License. You may obtain a copy of the License at
+//  http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing,
+//  software distributed under the License is distributed on an "AS
+//  IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+//  express or implied. See the License for the specific language
+//  governing permissions and limitations under the License.
+
+package n1k1
+
+import (
+	"strings" // <== genCompiler:hide
+
+	"github.com/couchbase/n1k1/base"
+)
+
+func init() {
+	ExprCatalog["add"] = ExprAdd
+	ExprCatalog["sub"] = ExprSub
+	ExprCatalog["mult"] = ExprMult
+	ExprCatalog["div"] = ExprDiv
+	ExprCatalog["mod"] = ExprMod
+	ExprCatalog["not"] = ExprNot
+	ExprCatalog["isMissing"] = ExprIsMissing
+	ExprCatalog["isNotMissing"] = ExprIsNotMissing
+	ExprCatalog["isNull"] = ExprIsNull
+	ExprCatalog["isNotNull"] = ExprIsNotNull
+	ExprCatalog["isValued"] = ExprIsValued
+	ExprCatalog["isNotValued"] = ExprIsNotValued
+	ExprCatalog["concat"] = ExprConcat
+	ExprCatalog["element"] = ExprElement
+	ExprCatalog["lower"] = ExprLower
+	ExprCatalog["upper"] = ExprUpper
+	ExprCatalog["setPath"] = ExprSetPath
+	ExprCatalog["unsetPath"] = ExprUnsetPath
+}
+
+// -----------------------------------------------------
+
+func ExprAdd(lzVars *base.Vars, labels base.Labels,
+	params []interface{}, path string) (lzExprFunc base.ExprFunc) {
+	return ExprArith(lzVars, labels, params, path, "add")
+}
+
+func ExprSub(lzVars *base.Vars, labels base.Labels,
+	params []interface{}, path string) (lzExprFunc base.ExprFunc) {
+	return ExprArith(lzVars, labels, params, path, "sub")
+}
+
+func ExprMult(lzVars *base.Vars, labels base.Labels,
+	params []interface{}, path string) (lzExprFunc base.ExprFunc) {
+	return ExprArith(lzVars, labels, params, path, "mult")
+}
+
+func ExprDiv(lzVars *base.Vars, labels base.Labels,
+	params []interface{}, path string) (lzExprFunc base.ExprFunc) {
+	return ExprArith(lzVars, labels, params, path, "div")
+}
+
+func ExprMod(lzVars *base.Vars, labels base.Labels,
+	params []interface{}, path string) (lzExprFunc base.ExprFunc) {
+	return ExprArith(lzVars, labels, params, path, "mod")
+}
+
+// ExprArith evaluates the two params as numbers and applies the
+// arithmetic op, following the MISSING & NULL rules of
+// base.ValArith().
+func ExprArith(lzVars *base.Vars, labels base.Labels,
+	params []interface{}, path string, op string) (lzExprFunc base.ExprFunc) {
+	var lzBuf []byte // <== varLift: lzBuf by path
+
+	biExprFunc := func(lzA, lzB base.ExprFunc, lzVals base.Vals, lzYieldErr base.YieldErr) (lzVal base.Val) { // !lz
+		if LzScope {
+			lzVal = lzA(lzVals, lzYieldErr) // <== emitCaptured: path "A"
+
+			lzValA := lzVal
+
+			lzVal = lzB(lzVals, lzYieldErr) // <== emitCaptured: path "B"
+
+			lzBytes := lzBuf
+
+			lzVal, lzBytes = base.ValArith(op, lzValA, lzVal, lzBytes)
+
+			lzBuf = lzBytes
+		}
+
+		return lzVal
+	} // !lz
+
+	lzExprFunc =
+		MakeBiExprFunc(lzVars, labels, params, path, biExprFunc) // !lz
+
+	return lzExprFunc
+}
+
+// -----------------------------------------------------
+
+// ExprNot implements N1QL's logical NOT of its single param.
+func ExprNot(lzVars *base.Vars, labels base.Labels,
+	params []interface{}, path string) (lzExprFunc base.ExprFunc) {
+	exprX := params[0].([]interface{})
+
+	if LzScope {
+		lzExprFunc =
+			MakeExprFunc(lzVars, labels, exprX, path, "X") // !lz
+		lzX := lzExprFunc
+
+		lzExprFunc = func(lzVals base.Vals, lzYieldErr base.YieldErr) (lzVal base.Val) {
+			lzVal = lzX(lzVals, lzYieldErr) // <== emitCaptured: path "X"
+
+			lzVal = base.ValNot(lzVal)
+
+			return lzVal
+		}
+	}
+
+	return lzExprFunc
+}
+
+// -----------------------------------------------------
+
+func ExprIsMissing(lzVars *base.Vars, labels base.Labels,
+	params []interface{}, path string) (lzExprFunc base.ExprFunc) {
+	return ExprIs(lzVars, labels, params, path, "isMissing")
+}
+
+func ExprIsNotMissing(lzVars *base.Vars, labels base.Labels,
+	params []interface{}, path string) (lzExprFunc base.ExprFunc) {
+	return ExprIs(lzVars, labels, params, path, "isNotMissing")
+}
+
+func ExprIsNull(lzVars *base.Vars, labels base.Labels,
+	params []interface{}, path string) (lzExprFunc base.ExprFunc) {
+	return ExprIs(lzVars, labels, params, path, "isNull")
+}
+
+func ExprIsNotNull(lzVars *base.Vars, labels base.Labels,
+	params []interface{}, path string) (lzExprFunc base.ExprFunc) {
+	return ExprIs(lzVars, labels, params, path, "isNotNull")
+}
+
+func ExprIsValued(lzVars *base.Vars, labels base.Labels,
+	params []interface{}, path string) (lzExprFunc base.ExprFunc) {
+	return ExprIs(lzVars, labels, params, path, "isValued")
+}
+
+func ExprIsNotValued(lzVars *base.Vars, labels base.Labels,
+	params []interface{}, path string) (lzExprFunc base.ExprFunc) {
+	return ExprIs(lzVars, labels, params, path, "isNotValued")
+}
+
+// ExprIs tests its single param for MISSING or NULL, following the
+// rules of base.ValIs().
+func ExprIs(lzVars *base.Vars, labels base.Labels,
+	params []interface{}, path string, kind string) (lzExprFunc base.ExprFunc) {
+	exprX := params[0].([]interface{})
+
+	if LzScope {
+		lzExprFunc =
+			MakeExprFunc(lzVars, labels, exprX, path, "X") // !lz
+		lzX := lzExprFunc
+
+		lzExprFunc = func(lzVals base.Vals, lzYieldErr base.YieldErr) (lzVal base.Val) {
+			lzVal = lzX(lzVals, lzYieldErr) // <== emitCaptured: path "X"
+
+			lzVal = base.ValIs(kind, lzVal)
+
+			return lzVal
+		}
+	}
+
+	return lzExprFunc
+}
+
+// -----------------------------------------------------
+
+// ExprConcat concatenates its two string params, following the rules
+// of base.ValConcat().
+func ExprConcat(lzVars *base.Vars, labels base.Labels,
+	params []interface{}, path string) (lzExprFunc base.ExprFunc) {
+	var lzBuf []byte // <== varLift: lzBuf by path
+
+	biExprFunc := func(lzA, lzB base.ExprFunc, lzVals base.Vals, lzYieldErr base.YieldErr) (lzVal base.Val) { // !lz
+		if LzScope {
+			lzVal = lzA(lzVals, lzYieldErr) // <== emitCaptured: path "A"
+
+			lzValA := lzVal
+
+			lzVal = lzB(lzVals, lzYieldErr) // <== emitCaptured: path "B"
+
+			lzBytes := lzBuf
+
+			lzVal, lzBytes = base.ValConcat(lzValA, lzVal, lzBytes)
+
+			lzBuf = lzBytes
+		}
+
+		return lzVal
+	} // !lz
+
+	lzExprFunc =
+		MakeBiExprFunc(lzVars, labels, params, path, biExprFunc) // !lz
+
+	return lzExprFunc
+}
+
+// ExprElement navigates into its first param using its second param
+// as an array index or object field name, following the rules of
+// base.ValElement().
+func ExprElement(lzVars *base.Vars, labels base.Labels,
+	params []interface{}, path string) (lzExprFunc base.ExprFunc) {
+	biExprFunc := func(lzA, lzB base.ExprFunc, lzVals base.Vals, lzYieldErr base.YieldErr) (lzVal base.Val) { // !lz
+		lzVal = lzA(lzVals, lzYieldErr) // <== emitCaptured: path "A"
+
+		lzValA := lzVal
+
+		lzVal = lzB(lzVals, lzYieldErr) // <== emitCaptured: path "B"
+
+		lzVal = base.ValElement(lzValA, lzVal)
+
+		return lzVal
+	} // !lz
+
+	lzExprFunc =
+		MakeBiExprFunc(lzVars, labels, params, path, biExprFunc) // !lz
+
+	return lzExprFunc
+}
+
+// -----------------------------------------------------
+
+func ExprLower(lzVars *base.Vars, labels base.Labels,
+	params []interface{}, path string) (lzExprFunc base.ExprFunc) {
+	return ExprStringCase(lzVars, labels, params, path, false)
+}
+
+func ExprUpper(lzVars *base.Vars, labels base.Labels,
+	params []interface{}, path string) (lzExprFunc base.ExprFunc) {
+	return ExprStringCase(lzVars, labels, params, path, true)
+}
+
+// ExprStringCase converts the case of its single string param, where
+// a non-string leads to NULL, as in N1QL's LOWER() and UPPER().
+func ExprStringCase(lzVars *base.Vars, labels base.Labels,
+	params []interface{}, path string, upper bool) (lzExprFunc base.ExprFunc) {
+	exprX := params[0].([]interface{})
+
+	var lzBuf []byte // <== varLift: lzBuf by path
+
+	if LzScope {
+		lzExprFunc =
+			MakeExprFunc(lzVars, labels, exprX, path, "X") // !lz
+		lzX := lzExprFunc
+
+		lzExprFunc = func(lzVals base.Vals, lzYieldErr base.YieldErr) (lzVal base.Val) {
+			if LzScope {
+				lzVal = lzX(lzVals, lzYieldErr) // <== emitCaptured: path "X"
+
+				lzBytes := lzBuf
+
+				if upper { // !lz
+					lzVal, lzBytes = base.ValStringMap(lzVars.Ctx.ValComparer, lzVal, strings.ToUpper, lzBytes)
+				} else { // !lz
+					lzVal, lzBytes = base.ValStringMap(lzVars.Ctx.ValComparer, lzVal, strings.ToLower, lzBytes)
+				} // !lz
+
+				lzBuf = lzBytes
+			}
+
+			return lzVal
+		}
+	}
+
+	return lzExprFunc
+}
+
+// -----------------------------------------------------
+
+// ExprSetPath implements the SET of an UPDATE, where the params are
+// the object expression, the value expression, and then the field
+// path to set, following the rules of base.ValPathSet().
+func ExprSetPath(lzVars *base.Vars, labels base.Labels,
+	params []interface{}, path string) (lzExprFunc base.ExprFunc) {
+	fields := ExprPathFields(params[2:])
+
+	var lzBuf []byte // <== varLift: lzBuf by path
+
+	biExprFunc := func(lzA, lzB base.ExprFunc, lzVals base.Vals, lzYieldErr base.YieldErr) (lzVal base.Val) { // !lz
+		if LzScope {
+			lzVal = lzA(lzVals, lzYieldErr) // <== emitCaptured: path "A"
+
+			lzValA := lzVal
+
+			lzVal = lzB(lzVals, lzYieldErr) // <== emitCaptured: path "B"
+
+			lzBytes := lzBuf
+
+			lzVal, lzBytes = base.ValPathSet(lzVars.Ctx.ValComparer, lzValA, fields, lzVal, lzBytes)
+
+			lzBuf = lzBytes
+		}
+
+		return lzVal
+	} // !lz
+
+	lzExprFunc =
+		MakeBiExprFunc(lzVars, labels, params, path, biExprFunc) // !lz
+
+	return lzExprFunc
+}
+
+// ExprUnsetPath implements the UNSET of an UPDATE, where the params
+// are the object expression and then the field path to remove.
+func ExprUnsetPath(lzVars *base.Vars, labels base.Labels,
+	params []interface{}, path string) (lzExprFunc base.ExprFunc) {
+	exprX := params[0].([]interface{})
+
+	fields := ExprPathFields(params[1:])
+
+	var lzBuf []byte // <== varLift: lzBuf by path
+
+	if LzScope {
+		lzExprFunc =
+			MakeExprFunc(lzVars, labels, exprX, path, "X") // !lz
+		lzX := lzExprFunc
+
+		lzExprFunc = func(lzVals base.Vals, lzYieldErr base.YieldErr) (lzVal base.Val) {
+			if LzScope {
+				lzVal = lzX(lzVals, lzYieldErr) // <== emitCaptured: path "X"
+
+				lzBytes := lzBuf
+
+				lzVal, lzBytes = base.ValPathSet(lzVars.Ctx.ValComparer, lzVal, fields, base.ValMissing, lzBytes)
+
+				lzBuf = lzBytes
+			}
+
+			return lzVal
+		}
+	}
+
+	return lzExprFunc
+}
+
+// ExprPathFields converts params into a field path.
+func ExprPathFields(params []interface{}) (rv []string) {
+	for _, param := range params {
+		rv = append(rv, param.(string))
+	}
+
+	return rv
+}
@@ -0,0 +1,183 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the
+//  License. You may obtain a copy of the License at
+//  http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing,
+//  software distributed under the License is distributed on an "AS
+//  IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+//  express or implied. See the License for the specific language
+//  governing permissions and limitations under the License.
+
+package n1k1
+
+import (
+	"github.com/couchbase/n1k1/base"
+)
+
+func init() {
+	ExprCatalog["subquery"] = ExprSubquery
+	ExprCatalog["outerLabelPath"] = ExprOuterLabelPath
+}
+
+// -----------------------------------------------------
+
+// SubqueryCacheMax is the default max number of cached results of a
+// correlated subquery.
+var SubqueryCacheMax = 10000
+
+// ExprSubquery implements a (correlated) subquery, where params[0] is
+// the *base.Op of the subquery's plan, which is executed per outer
+// vals, and where the optional params[1] is a list of correlation key
+// exprs. The outer vals are exposed to the subquery's plan through
+// the vars.Outer binding (see ExprOuterLabelPath). The first val of
+// each of the subquery's yielded vals is collected into a JSON array,
+// like SELECT RAW, which is cached by the canonical correlation key,
+// so that repeated outer correlation vals are evaluated only once.
+// When there are no correlation key exprs, all the outer vals are
+// used as the correlation key. NULL and MISSING correlation key vals
+// are part of the cache key, and the cache is cleared when it grows
+// beyond the optional params[2] max number of entries, which defaults
+// to SubqueryCacheMax.
+func ExprSubquery(lzVars *base.Vars, labels base.Labels,
+	params []interface{}, path string) (lzExprFunc base.ExprFunc) {
+	subOp := params[0].(*base.Op)
+
+	keyExpr := []interface{}{"valsEncodeCanonical"}
+	if len(params) > 1 && params[1] != nil {
+		keyExpr = append([]interface{}{"keysEncodeCanonicalAll"},
+			params[1].([]interface{})...)
+	}
+
+	cacheMax := SubqueryCacheMax
+	if len(params) > 2 && params[2].(int) > 0 {
+		cacheMax = params[2].(int)
+	}
+
+	var lzKeyFunc base.ExprFunc
+
+	lzKeyFunc =
+		MakeExprFunc(lzVars, labels, keyExpr, path, "SK") // !lz
+
+	_ = lzKeyFunc
+
+	// Used only by the compiler, as the interpreter uses the
+	// subquery's own callbacks as declared below.
+	var lzYieldVals base.YieldVals // !lz
+	var lzYieldErr base.YieldErr   // !lz
+	_, _ = lzYieldVals, lzYieldErr // !lz
+
+	var lzCache map[string]base.Val = map[string]base.Val{} // <== varLift: lzCache by path
+	var lzItems []byte                                      // <== varLift: lzItems by path
+
+	lzExprFunc = func(lzVals base.Vals, lzYieldErr base.YieldErr) (lzVal base.Val) {
+		if LzScope {
+			lzVal = lzKeyFunc(lzVals, lzYieldErr) // <== emitCaptured: path "SK"
+
+			lzKey := lzVal
+
+			lzValCached, lzOk := lzCache[string(lzKey)]
+			if lzOk {
+				lzVal = lzValCached
+			} else {
+				var lzErr error
+
+				lzOuter := lzVars.Outer
+
+				lzVars.Outer = lzVals
+
+				outerLabels := lzVars.OuterLabels // !lz
+				lzVars.OuterLabels = labels       // !lz
+
+				lzBytes := append(lzItems[:0], '[')
+
+				lzItems = lzBytes
+
+				if LzScope {
+					lzYieldVals := func(lzVals base.Vals) {
+						if len(lzVals) > 0 && !base.ValEqualMissing(lzVals[0]) {
+							lzCur := lzItems
+							if len(lzCur) > 1 {
+								lzCur = append(lzCur, ',')
+							}
+
+							lzItems = append(lzCur, lzVals[0]...)
+						}
+					}
+
+					lzYieldErr := func(lzErrIn error) {
+						if lzErr == nil {
+							lzErr = lzErrIn // Capture the incoming error.
+						}
+					}
+
+					ExecOp(subOp, lzVars, lzYieldVals, lzYieldErr, path, "SQ") // !lz
+				}
+
+				lzVars.OuterLabels = outerLabels // !lz
+
+				lzVars.Outer = lzOuter
+
+				if lzErr != nil {
+					lzYieldErr(lzErr)
+
+					lzVal = base.ValMissing
+				} else {
+					lzCur := append(lzItems, ']')
+
+					lzItems = lzCur
+
+					lzVal = base.Val(append([]byte(nil), lzCur...))
+
+					if len(lzCache) >= cacheMax {
+						lzCache = make(map[string]base.Val)
+					}
+
+					lzCache[string(lzKey)] = lzVal
+				}
+			}
+		}
+
+		return lzVal
+	}
+
+	return lzExprFunc
+}
+
+// -----------------------------------------------------
+
+// ExprOuterLabelPath is like ExprLabelPath, but is resolved against
+// the outer vals of an enclosing subquery via the vars.Outer binding.
+func ExprOuterLabelPath(lzVars *base.Vars, labels base.Labels,
+	params []interface{}, path string) (lzExprFunc base.ExprFunc) {
+	outerLabels := lzVars.OuterLabels // !lz
+
+	idx := outerLabels.IndexOf(params[0].(string))
+	if idx >= 0 {
+		var valPath []string
+		for _, param := range params[1:] {
+			valPath = append(valPath, param.(string))
+		}
+
+		var lzValPath []string = valPath // <== varLift: lzValPath by path
+
+		lzExprFunc = func(lzVals base.Vals, lzYieldErr base.YieldErr) (lzVal base.Val) {
+			lzVal = lzVars.Outer[idx]
+
+			if len(params) > 1 { // !lz
+				lzVal = base.ValPathGet(lzVal, lzValPath)
+			} else { // !lz
+				_ = lzValPath
+			} // !lz
+
+			return lzVal
+		}
+	} else {
+		lzExprFunc = func(lzVals base.Vals, lzYieldErr base.YieldErr) (lzVal base.Val) {
+			lzVal = base.ValMissing
+			return lzVal
+		}
+	}
+
+	return lzExprFunc
+}
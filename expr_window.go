@@ -12,15 +12,22 @@
 package n1k1
 
 import (
+	"encoding/binary" // <== genCompiler:hide
+
 	"math"
 	"strconv"
+	"strings"
 
 	"github.com/couchbase/n1k1/base"
 )
 
 func init() {
 	ExprCatalog["window-partition-row-number"] = ExprWindowPartitionRowNumber
+	ExprCatalog["window-partition-percent-rank"] = ExprWindowPartitionPercentRank
+	ExprCatalog["window-partition-cume-dist"] = ExprWindowPartitionCumeDist
+	ExprCatalog["window-partition-ntile"] = ExprWindowPartitionNTile
 	ExprCatalog["window-frame-count"] = ExprWindowFrameCount
+	ExprCatalog["window-frame-agg"] = ExprWindowFrameAgg
 	ExprCatalog["window-frame-step-value"] = ExprWindowFrameStepValue
 }
 
@@ -93,6 +100,121 @@ func ExprWindowPartitionRowNumber(lzVars *base.Vars, labels base.Labels,
 
 // -----------------------------------------------------
 
+// ExprWindowPartitionPercentRank computes (rank - 1) / (partitionSize
+// - 1), where the rank and partitionSize are labeled vals that were
+// tracked by a window-partition operator.
+func ExprWindowPartitionPercentRank(lzVars *base.Vars, labels base.Labels,
+	params []interface{}, path string) (lzExprFunc base.ExprFunc) {
+	rankIdx := labels.IndexOf(params[0].(string))
+	partitionSizeIdx := labels.IndexOf(params[1].(string))
+
+	var lzBufPre []byte // <== varLift: lzBufPre by path
+
+	lzExprFunc = func(lzVals base.Vals, lzYieldErr base.YieldErr) (lzVal base.Val) {
+		lzRank := binary.LittleEndian.Uint64(lzVals[rankIdx])
+		lzPartitionSize := binary.LittleEndian.Uint64(lzVals[partitionSizeIdx])
+
+		var lzF64 float64
+		if lzPartitionSize > 1 {
+			lzF64 = float64(lzRank-1) / float64(lzPartitionSize-1)
+		}
+
+		lzBuf := strconv.AppendFloat(lzBufPre[:0], lzF64, 'f', -1, 64)
+
+		lzVal = base.Val(lzBuf)
+
+		lzBufPre = lzBuf
+
+		return lzVal
+	}
+
+	return lzExprFunc
+}
+
+// -----------------------------------------------------
+
+// ExprWindowPartitionCumeDist computes peerEnd / partitionSize, where
+// the peerEnd and partitionSize are labeled vals that were tracked by
+// a window-partition operator.
+func ExprWindowPartitionCumeDist(lzVars *base.Vars, labels base.Labels,
+	params []interface{}, path string) (lzExprFunc base.ExprFunc) {
+	peerEndIdx := labels.IndexOf(params[0].(string))
+	partitionSizeIdx := labels.IndexOf(params[1].(string))
+
+	var lzBufPre []byte // <== varLift: lzBufPre by path
+
+	lzExprFunc = func(lzVals base.Vals, lzYieldErr base.YieldErr) (lzVal base.Val) {
+		lzPeerEnd := binary.LittleEndian.Uint64(lzVals[peerEndIdx])
+		lzPartitionSize := binary.LittleEndian.Uint64(lzVals[partitionSizeIdx])
+
+		lzF64 := float64(lzPeerEnd) / float64(lzPartitionSize)
+
+		lzBuf := strconv.AppendFloat(lzBufPre[:0], lzF64, 'f', -1, 64)
+
+		lzVal = base.Val(lzBuf)
+
+		lzBufPre = lzBuf
+
+		return lzVal
+	}
+
+	return lzExprFunc
+}
+
+// -----------------------------------------------------
+
+// ExprWindowPartitionNTile implements NTILE(numBuckets), which
+// divides the window partition as evenly as possible into numbered
+// buckets, where the partitionSize is a labeled val that was tracked
+// by a window-partition operator. A numBuckets that's not a positive
+// number results in null.
+func ExprWindowPartitionNTile(lzVars *base.Vars, labels base.Labels,
+	params []interface{}, path string) (lzExprFunc base.ExprFunc) {
+	framesSlot, frameIdx := params[0].(int), params[1].(int)
+
+	// The expr that provides the number of buckets.
+	expr := params[2].([]interface{})
+
+	partitionSizeIdx := labels.IndexOf(params[3].(string))
+
+	if LzScope {
+		lzExprFunc =
+			MakeExprFunc(lzVars, labels, expr, path, "N") // !lz
+		lzExprNumFunc := lzExprFunc
+
+		var lzBufPre []byte // <== varLift: lzBufPre by path
+
+		lzExprFunc = func(lzVals base.Vals, lzYieldErr base.YieldErr) (lzVal base.Val) {
+			lzFrames := lzVars.Temps[framesSlot].([]base.WindowFrame)
+			lzFrame := &lzFrames[frameIdx]
+
+			lzVal = lzExprNumFunc(lzVals, lzYieldErr) // <== emitCaptured: path "N"
+
+			lzNum, lzErr := base.ParseFloat64(lzVal)
+			if lzErr != nil || lzNum < 1 {
+				lzVal = base.ValNull
+			} else {
+				lzPartitionSize := binary.LittleEndian.Uint64(lzVals[partitionSizeIdx])
+
+				lzBucket := base.NTile(uint64(lzFrame.Pos),
+					lzPartitionSize, uint64(lzNum))
+
+				lzBuf := strconv.AppendUint(lzBufPre[:0], lzBucket, 10)
+
+				lzVal = base.Val(lzBuf)
+
+				lzBufPre = lzBuf
+			}
+
+			return lzVal
+		}
+	}
+
+	return lzExprFunc
+}
+
+// -----------------------------------------------------
+
 func ExprWindowFrameCount(lzVars *base.Vars, labels base.Labels,
 	params []interface{}, path string) (lzExprFunc base.ExprFunc) {
 	framesSlot, frameIdx := params[0].(int), params[1].(int)
@@ -119,9 +241,135 @@ func ExprWindowFrameCount(lzVars *base.Vars, labels base.Labels,
 
 // -----------------------------------------------------
 
+// ExprWindowFrameAgg implements the aggregate window functions, such
+// as COUNT(expr), SUM, AVG, MIN and MAX, by updating a base.Aggs
+// aggregation with the expr's vals over the current window frame,
+// where NULL and MISSING vals are ignored. When the window frame
+// starts at the partition's start and excludes no rows, like for a
+// running total, the aggregation of the previous row is updated with
+// only the newly included rows, so the earlier rows aren't revisited
+// and the window partition can be streamed.
+func ExprWindowFrameAgg(lzVars *base.Vars, labels base.Labels,
+	params []interface{}, path string) (lzExprFunc base.ExprFunc) {
+	framesSlot, frameIdx := params[0].(int), params[1].(int)
+
+	aggName := params[2].(string)
+
+	aggIdx := base.AggCatalog[aggName]
+
+	// COUNT is 0 instead of NULL when there are no valued vals.
+	aggCount := aggName == "count"
+
+	// The expr to aggregate, evaluated on each row of the frame.
+	expr := params[3].([]interface{})
+
+	if LzScope {
+		lzExprFunc =
+			MakeExprFunc(lzVars, labels, expr, path, "E") // !lz
+		lzExprValFunc := lzExprFunc
+
+		var lzValsPre base.Vals // <== varLift: lzValsPre by path
+
+		var lzBufPre []byte // <== varLift: lzBufPre by path
+
+		var lzAggCurr []byte // <== varLift: lzAggCurr by path
+
+		var lzAggNext []byte // <== varLift: lzAggNext by path
+
+		// The # of valued vals in the lzAggCurr.
+		var lzAggCount int64 // <== varLift: lzAggCount by path
+
+		// The frame end that the lzAggCurr covers, or -1 when the
+		// lzAggCurr can't be incrementally updated.
+		var lzAggUntil = int64(-1) // <== varLift: lzAggUntil by path
+
+		lzExprFunc = func(lzVals base.Vals, lzYieldErr base.YieldErr) (lzVal base.Val) {
+			lzFrames := lzVars.Temps[framesSlot].([]base.WindowFrame)
+			lzFrame := &lzFrames[frameIdx]
+
+			lzAgg := base.Aggs[aggIdx]
+
+			// The incoming vals are restored after stepping.
+			lzValsRow := lzVals
+
+			lzCurr, lzNext := lzAggCurr, lzAggNext
+
+			lzCount, lzUntil := lzAggCount, lzAggUntil
+
+			lzIncremental := lzFrame.Include.Beg == 0 && len(lzFrame.Excludes) == 0
+
+			lzPos := int64(-1)
+
+			if lzIncremental && lzFrame.Pos > 0 &&
+				lzUntil >= 0 && lzFrame.Include.End >= lzUntil {
+				lzPos = lzUntil - 1
+			} else {
+				lzCurr = lzAgg.Init(lzVars, lzCurr[:0])
+				lzCount = 0
+			}
+
+			var lzOk bool
+			var lzErr error
+
+			for {
+				lzValsStep := lzValsPre[:0]
+
+				lzVals, lzPos, lzOk, lzErr = lzFrame.StepVals(true, lzPos, lzValsStep)
+				if !lzOk || lzErr != nil {
+					break
+				}
+
+				lzValsPre = lzVals
+
+				lzVal = lzExprValFunc(lzVals, lzYieldErr) // <== emitCaptured: path "E"
+
+				if base.ValHasValue(lzVal) {
+					lzNext, _, _ = lzAgg.Update(lzVars,
+						lzVal, lzNext[:0], lzCurr, lzVars.Ctx.ValComparer)
+
+					lzCurr, lzNext = lzNext, lzCurr
+
+					lzCount++
+				}
+			}
+
+			lzVals = lzValsRow
+
+			if lzErr != nil {
+				lzYieldErr(lzErr)
+			}
+
+			lzUntil = -1
+			if lzIncremental {
+				lzUntil = base.Int64Max(lzFrame.Include.End, 0)
+			}
+
+			lzAggCurr = lzCurr
+			lzAggNext = lzNext
+			lzAggCount = lzCount
+			lzAggUntil = lzUntil
+
+			lzVal = base.ValNull
+
+			if lzCount > 0 || aggCount {
+				lzVal, _, _ = lzAgg.Result(lzVars, lzCurr, lzBufPre[:0])
+
+				lzBufPre = lzVal
+			}
+
+			return lzVal
+		}
+	}
+
+	return lzExprFunc
+}
+
+// -----------------------------------------------------
+
 // ExprWindowFrameStepValue implements the navigation window
 // functions of FIRST_VALUE, LAST_VALUE, NTH_VALUE, LEAD and LAG for
-// window partitions of type ROWS.
+// window partitions of type ROWS, along with their optional IGNORE
+// NULLS and FROM LAST modifiers.
 func ExprWindowFrameStepValue(lzVars *base.Vars, labels base.Labels,
 	params []interface{}, path string) (lzExprFunc base.ExprFunc) {
 	framesSlot, frameIdx := params[0].(int), params[1].(int)
@@ -145,6 +393,23 @@ func ExprWindowFrameStepValue(lzVars *base.Vars, labels base.Labels,
 	// The expr to evaluate at the final position.
 	expr := params[5].([]interface{})
 
+	// The optional modifiers are a comma-separated list, such as
+	// "ignoreNulls,fromLast". With ignoreNulls, only the rows whose
+	// expr evaluates to a value other than NULL or MISSING are
+	// counted as steps. With fromLast, an initial starting position
+	// of -1 (FIRST_VALUE, NTH_VALUE) instead starts from the end and
+	// steps in the reverse direction.
+	var modifiers string
+	if len(params) > 6 {
+		modifiers = params[6].(string)
+	}
+
+	ignoreNulls := strings.Index(modifiers, "ignoreNulls") >= 0
+
+	if strings.Index(modifiers, "fromLast") >= 0 && initial == -1 {
+		initial, asc = 1, !asc
+	}
+
 	if LzScope {
 		lzExprFunc =
 			MakeExprFunc(lzVars, labels, expr, path, "E") // !lz
@@ -167,17 +432,50 @@ func ExprWindowFrameStepValue(lzVars *base.Vars, labels base.Labels,
 				lzPos = math.MaxInt64
 			} // !lz
 
-			for lzI := uint64(0); lzI < num && lzOk && lzErr == nil; lzI++ {
-				lzValsStep := lzValsPre[:0]
+			if ignoreNulls { // !lz
+				lzI := uint64(0)
 
-				lzVals, lzPos, lzOk, lzErr = lzFrame.StepVals(asc, lzPos, lzValsStep)
-			}
+				lzSkipped := false
 
-			if lzOk && lzErr == nil {
-				lzValsPre = lzVals
+				for lzI < num && lzOk && lzErr == nil {
+					lzValsStep := lzValsPre[:0]
 
-				lzVal = lzExprValFunc(lzVals, lzYieldErr) // <== emitCaptured: path "E"
-			}
+					lzVals, lzPos, lzOk, lzErr = lzFrame.StepVals(asc, lzPos, lzValsStep)
+
+					lzVal = base.ValMissing
+
+					if lzOk && lzErr == nil {
+						lzValsPre = lzVals
+
+						lzVal = lzExprValFunc(lzVals, lzYieldErr) // <== emitCaptured: path "E"
+
+						if base.ValHasValue(lzVal) {
+							lzI++
+						} else {
+							lzSkipped = true
+						}
+					}
+				}
+
+				// When the frame ran out after skipping NULL's, such as
+				// when every row in the frame is NULL, the result is
+				// NULL rather than MISSING.
+				if lzI < num && lzSkipped && lzErr == nil {
+					lzVal = base.ValNull
+				}
+			} else { // !lz
+				for lzI := uint64(0); lzI < num && lzOk && lzErr == nil; lzI++ {
+					lzValsStep := lzValsPre[:0]
+
+					lzVals, lzPos, lzOk, lzErr = lzFrame.StepVals(asc, lzPos, lzValsStep)
+				}
+
+				if lzOk && lzErr == nil {
+					lzValsPre = lzVals
+
+					lzVal = lzExprValFunc(lzVals, lzYieldErr) // <== emitCaptured: path "E"
+				}
+			} // !lz
 
 			return lzVal
 		}
@@ -22,9 +22,17 @@ func DatastoreOp(o *base.Op, vars *base.Vars, yieldVals base.YieldVals,
 		DatastoreScanPrimary(o, vars, yieldVals, yieldErr)
 	case "datastore-scan-index":
 		DatastoreScanIndex(o, vars, yieldVals, yieldErr)
+	case "datastore-scan-index2":
+		DatastoreScanIndex2(o, vars, yieldVals, yieldErr)
+	case "datastore-scan-index3":
+		DatastoreScanIndex3(o, vars, yieldVals, yieldErr)
 	case "datastore-scan-keys":
 		DatastoreScanKeys(o, vars, yieldVals, yieldErr)
+	case "datastore-count":
+		DatastoreCount(o, vars, yieldVals, yieldErr)
 	case "datastore-fetch":
 		DatastoreFetch(o, vars, yieldVals, yieldErr, path, pathNext)
+	case "datastore-insert", "datastore-upsert", "datastore-update", "datastore-delete":
+		DatastoreMutate(o, vars, yieldVals, yieldErr, path, pathNext)
 	}
 }
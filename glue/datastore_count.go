@@ -0,0 +1,112 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the
+//  License. You may obtain a copy of the License at
+//  http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing,
+//  software distributed under the License is distributed on an "AS
+//  IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+//  express or implied. See the License for the specific language
+//  governing permissions and limitations under the License.
+
+package glue
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/execution"
+	"github.com/couchbase/query/plan"
+	"github.com/couchbase/query/value"
+
+	"github.com/couchbase/n1k1/base"
+)
+
+// DatastoreCount yields a single vals of the count from the keyspace
+// or index count API's, for the count scan shortcuts of the planner,
+// such as for a `SELECT COUNT(*) FROM ks`.
+func DatastoreCount(o *base.Op, vars *base.Vars,
+	yieldVals base.YieldVals, yieldErr base.YieldErr) {
+	context := vars.TempGet(0).(*execution.Context)
+
+	count, err := DatastoreCountScan(context, vars.TempGet(o.Params[0].(int)))
+	if err != nil {
+		yieldErr(err)
+		return
+	}
+
+	yieldVals(base.Vals{base.Val(strconv.AppendInt(nil, count, 10))})
+
+	yieldErr(nil)
+}
+
+// DatastoreCountScan invokes the count API for a count scan plan.
+func DatastoreCountScan(context *execution.Context, p interface{}) (
+	count int64, err error) {
+	// TODO: For nested-loop join, pass in the outer values from the
+	// left-hand-side of the join for span evaluation.
+	var outerValue value.Value
+
+	var e errors.Error
+
+	switch scan := p.(type) {
+	case *plan.CountScan:
+		count, e = scan.Keyspace().Count(context)
+
+	case *plan.IndexCountScan:
+		nks := scan.Term()
+		vec := context.ScanVectorSource().ScanVector(nks.Namespace(), nks.Keyspace())
+
+		for _, span := range scan.Spans() {
+			dspan, empty, err := EvalSpan(context, span, outerValue)
+			if err != nil {
+				return 0, err
+			}
+
+			if empty {
+				continue
+			}
+
+			spanCount, e := scan.Index().Count(dspan, context.ScanConsistency(), vec)
+			if e != nil {
+				return 0, e
+			}
+
+			count += spanCount
+		}
+
+	case *plan.IndexCountScan2:
+		nks := scan.Term()
+		vec := context.ScanVectorSource().ScanVector(nks.Namespace(), nks.Keyspace())
+
+		dspans, empty, err := EvalSpans2(context, scan.Spans(), outerValue)
+		if err != nil || empty {
+			return 0, err
+		}
+
+		count, e = scan.Index().Count2(context.RequestId(), dspans,
+			context.ScanConsistency(), vec)
+
+	case *plan.IndexCountDistinctScan2:
+		nks := scan.Term()
+		vec := context.ScanVectorSource().ScanVector(nks.Namespace(), nks.Keyspace())
+
+		dspans, empty, err := EvalSpans2(context, scan.Spans(), outerValue)
+		if err != nil || empty {
+			return 0, err
+		}
+
+		count, e = scan.Index().CountDistinct(context.RequestId(), dspans,
+			context.ScanConsistency(), vec)
+
+	default:
+		return 0, fmt.Errorf("DatastoreCountScan, unknown plan: %#v", p)
+	}
+
+	if e != nil {
+		return 0, e
+	}
+
+	return count, nil
+}
@@ -32,9 +32,9 @@ type SubPathser interface {
 
 func DatastoreFetch(o *base.Op, vars *base.Vars, yieldVals base.YieldVals,
 	yieldErr base.YieldErr, path, pathNext string) {
-	context := vars.Temps[0].(*execution.Context)
+	context := vars.TempGet(0).(*execution.Context)
 
-	plan := vars.Temps[o.Params[0].(int)].(Keyspacer)
+	plan := vars.TempGet(o.Params[0].(int)).(Keyspacer)
 
 	keyspace := plan.Keyspace()
 
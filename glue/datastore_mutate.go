@@ -0,0 +1,175 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the
+//  License. You may obtain a copy of the License at
+//  http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing,
+//  software distributed under the License is distributed on an "AS
+//  IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+//  express or implied. See the License for the specific language
+//  governing permissions and limitations under the License.
+
+package glue
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/couchbase/query/errors"
+	"github.com/couchbase/query/execution"
+	"github.com/couchbase/query/value"
+
+	"github.com/couchbase/n1k1"
+	"github.com/couchbase/n1k1/base"
+)
+
+// DatastoreMutate handles the datastore-insert, datastore-upsert,
+// datastore-update and datastore-delete ops, where the vals from the
+// child are batched through a stage and each batch is written to the
+// keyspace. The params are the vars.Temps slot of the plan, the label
+// of the key and the label of the value (except for a delete). The
+// vals that were successfully mutated are yielded, such as for a
+// RETURNING clause. On the first error, such as a missing or
+// non-string key, no more batches are written and the error is
+// yielded once, after the child's remaining vals are drained.
+func DatastoreMutate(o *base.Op, vars *base.Vars, yieldVals base.YieldVals,
+	yieldErr base.YieldErr, path, pathNext string) {
+	context := vars.TempGet(0).(*execution.Context)
+
+	keyspace := vars.TempGet(o.Params[0].(int)).(Keyspacer).Keyspace()
+
+	keyIdx := o.Labels.IndexOf(o.Params[1].(string))
+
+	valueIdx := -1
+	if len(o.Params) > 2 {
+		valueIdx = o.Labels.IndexOf(o.Params[2].(string))
+		if valueIdx < 0 {
+			yieldErr(fmt.Errorf("DatastoreMutate, %s, unknown value label: %v",
+				o.Kind, o.Params[2]))
+			return
+		}
+	}
+
+	batchSize := 200 // TODO: Configurability.
+	batchChSize := 0 // TODO: Configurability.
+
+	stage := base.NewStage(1, batchChSize, vars, yieldVals, yieldErr)
+
+	stage.StartActor(func(vars *base.Vars, yieldVals base.YieldVals,
+		yieldErr base.YieldErr, actorData interface{}) {
+
+		// TODO: For compilation, this likely needs to be vars.ExecOp().
+		n1k1.ExecOp(o.Children[0], vars, yieldVals, yieldErr, pathNext, "DM")
+	}, nil, batchSize)
+
+	var keys []string // Same len() as batch.
+
+	var pairs []value.Pair
+
+	mutated := map[string]bool{}
+
+	stage.ProcessBatchesFromActors(func(batch []base.Vals) {
+		stage.M.Lock()
+		err := stage.Err
+		stage.M.Unlock()
+
+		if err != nil {
+			return // Drain the remaining batches after an error.
+		}
+
+		keys = keys[:0]
+		pairs = pairs[:0]
+
+		for _, vals := range batch {
+			key, err := MutateKey(o, vals, keyIdx)
+			if err != nil {
+				stage.SetErr(err)
+				return
+			}
+
+			keys = append(keys, key)
+
+			if valueIdx >= 0 {
+				if valueIdx >= len(vals) {
+					stage.SetErr(fmt.Errorf("DatastoreMutate, %s, missing value,"+
+						" key: %s", o.Kind, key))
+					return
+				}
+
+				pairs = append(pairs, value.Pair{
+					Name:  key,
+					Value: value.NewValue([]byte(vals[valueIdx])),
+				})
+			}
+		}
+
+		for k := range mutated {
+			delete(mutated, k)
+		}
+
+		var done []value.Pair
+		var doneKeys []string
+		var e errors.Error
+
+		// TODO: The datastore's mutation API's inherently allocate
+		// memory or create garbage, so they need a redesign.
+		switch o.Kind {
+		case "datastore-insert":
+			done, e = keyspace.Insert(pairs)
+		case "datastore-upsert":
+			done, e = keyspace.Upsert(pairs)
+		case "datastore-update":
+			done, e = keyspace.Update(pairs)
+		case "datastore-delete":
+			doneKeys, e = keyspace.Delete(keys, context)
+		}
+
+		if e != nil {
+			stage.SetErr(e)
+		}
+
+		for _, pair := range done {
+			mutated[pair.Name] = true
+		}
+
+		for _, key := range doneKeys {
+			mutated[key] = true
+		}
+
+		// Keep the same ordering as the batch, where the vals that
+		// were mutated before any error are also yielded.
+		for i, key := range keys {
+			if mutated[key] {
+				yieldVals(batch[i])
+			}
+		}
+	})
+
+	stage.M.Lock()
+	stage.YieldErr(stage.Err)
+	stage.M.Unlock()
+
+	// TODO: Recycle stage.
+}
+
+// MutateKey returns the key of the vals to be mutated, where a
+// missing or non-string key is an error.
+func MutateKey(o *base.Op, vals base.Vals, keyIdx int) (string, error) {
+	if keyIdx < 0 || keyIdx >= len(vals) || len(vals[keyIdx]) <= 0 {
+		return "", fmt.Errorf("DatastoreMutate, %s, missing key", o.Kind)
+	}
+
+	var key string
+
+	err := json.Unmarshal(vals[keyIdx], &key)
+	if err != nil {
+		return "", fmt.Errorf("DatastoreMutate, %s, non-string key: %s",
+			o.Kind, vals[keyIdx])
+	}
+
+	if key == "" {
+		return "", fmt.Errorf("DatastoreMutate, %s, missing key", o.Kind)
+	}
+
+	return key, nil
+}
@@ -14,6 +14,7 @@ package glue
 import (
 	"fmt"
 	"math"
+	"sort"
 	"strconv"
 
 	"github.com/couchbase/n1k1/base"
@@ -28,9 +29,9 @@ import (
 
 func DatastoreScanKeys(o *base.Op, vars *base.Vars,
 	yieldVals base.YieldVals, yieldErr base.YieldErr) {
-	context := vars.Temps[0].(*execution.Context)
+	context := vars.TempGet(0).(*execution.Context)
 
-	scan := vars.Temps[o.Params[0].(int)].(*plan.KeyScan)
+	scan := vars.TempGet(o.Params[0].(int)).(*plan.KeyScan)
 
 	var parent value.Value // TODO: handle parent?
 
@@ -83,7 +84,7 @@ func DatastoreScanPrimary(o *base.Op, vars *base.Vars,
 	yieldVals base.YieldVals, yieldErr base.YieldErr) {
 	DatastoreScan(o, vars, yieldVals, yieldErr,
 		func(context *execution.Context, conn *datastore.IndexConnection) {
-			scan := vars.Temps[o.Params[0].(int)].(*plan.PrimaryScan)
+			scan := vars.TempGet(o.Params[0].(int)).(*plan.PrimaryScan)
 
 			nks := scan.Term()
 			vec := context.ScanVectorSource().ScanVector(nks.Namespace(), nks.Keyspace())
@@ -101,7 +102,7 @@ func DatastoreScanIndex(o *base.Op, vars *base.Vars,
 	yieldVals base.YieldVals, yieldErr base.YieldErr) {
 	DatastoreScan(o, vars, yieldVals, yieldErr,
 		func(context *execution.Context, conn *datastore.IndexConnection) {
-			scan := vars.Temps[o.Params[0].(int)].(*plan.IndexScan)
+			scan := vars.TempGet(o.Params[0].(int)).(*plan.IndexScan)
 
 			/* covers := scan.Covers() // TODO: Do we care about covers?
 			if len(covers) > 0 {
@@ -146,10 +147,225 @@ func DatastoreScanIndex(o *base.Op, vars *base.Vars,
 
 // -------------------------------------------------------------------
 
+func DatastoreScanIndex2(o *base.Op, vars *base.Vars,
+	yieldVals base.YieldVals, yieldErr base.YieldErr) {
+	scan := vars.TempGet(o.Params[0].(int)).(*plan.IndexScan2)
+
+	DatastoreScanCovering(o, vars, yieldVals, yieldErr, scan,
+		func(context *execution.Context, conn *datastore.IndexConnection) {
+			nks := scan.Term()
+			vec := context.ScanVectorSource().ScanVector(nks.Namespace(), nks.Keyspace())
+
+			offset := EvalExprInt64(context, scan.Offset(), nil, 0)
+			limit := EvalExprInt64(context, scan.Limit(), nil, math.MaxInt64)
+
+			// TODO: For nested-loop join, pass in the outer values
+			// from the left-hand-side of the join for span evaluation.
+			var outerValue value.Value
+
+			dspans, empty, err := EvalSpans2(context, scan.Spans(), outerValue)
+			if err != nil || empty {
+				if err != nil {
+					context.Error(errors.NewEvaluationError(err, "span"))
+				}
+
+				conn.Sender().Close()
+
+				return
+			}
+
+			go scan.Index().Scan2(context.RequestId(), dspans,
+				scan.Reverse(), scan.Distinct(), scan.Ordered(),
+				IndexProjection(scan.Projection()), offset, limit,
+				context.ScanConsistency(), vec, conn)
+		})
+}
+
+// -------------------------------------------------------------------
+
+func DatastoreScanIndex3(o *base.Op, vars *base.Vars,
+	yieldVals base.YieldVals, yieldErr base.YieldErr) {
+	scan := vars.TempGet(o.Params[0].(int)).(*plan.IndexScan3)
+
+	DatastoreScanCovering(o, vars, yieldVals, yieldErr, scan,
+		func(context *execution.Context, conn *datastore.IndexConnection) {
+			nks := scan.Term()
+			vec := context.ScanVectorSource().ScanVector(nks.Namespace(), nks.Keyspace())
+
+			offset := EvalExprInt64(context, scan.Offset(), nil, 0)
+			limit := EvalExprInt64(context, scan.Limit(), nil, math.MaxInt64)
+
+			// TODO: For nested-loop join, pass in the outer values
+			// from the left-hand-side of the join for span evaluation.
+			var outerValue value.Value
+
+			dspans, empty, err := EvalSpans2(context, scan.Spans(), outerValue)
+			if err != nil || empty {
+				if err != nil {
+					context.Error(errors.NewEvaluationError(err, "span"))
+				}
+
+				conn.Sender().Close()
+
+				return
+			}
+
+			var indexOrders datastore.IndexKeyOrders
+			for _, orderTerm := range scan.OrderTerms() {
+				indexOrders = append(indexOrders, &datastore.IndexKeyOrder{
+					KeyPos: orderTerm.KeyPos,
+					Desc:   orderTerm.Desc,
+				})
+			}
+
+			// The index group aggregates are not pushed down, as the
+			// conversion rejects them, see VisitIndexScan3().
+			go scan.Index().Scan3(context.RequestId(), dspans,
+				scan.Reverse(), scan.Distinct(),
+				IndexProjection(scan.Projection()), offset, limit,
+				nil, indexOrders, context.ScanConsistency(), vec, conn)
+		})
+}
+
+// -------------------------------------------------------------------
+
+// CoveringScan is implemented by the index scans that are able to
+// cover a query, so that no fetch of the documents is needed.
+type CoveringScan interface {
+	Covers() expression.Covers
+	FilterCovers() map[*expression.Cover]value.Value
+	Projection() *plan.IndexProjection
+}
+
+// CoveringLabels returns the labels of the vals yielded by a covering
+// scan, which are one "~" label per cover (where the label suffix is
+// the cover's text), then one "~" label per filter cover (sorted by
+// the cover's text), and finally the "^id" label. The vals of the
+// filter covers, which are static, are also returned.
+func CoveringLabels(scan CoveringScan) (labels base.Labels, filterVals base.Vals, err error) {
+	for _, cover := range scan.Covers() {
+		labels = append(labels, "~"+cover.Text())
+	}
+
+	filterCovers := scan.FilterCovers()
+
+	filterTexts := make([]string, 0, len(filterCovers))
+	filterValues := make(map[string]value.Value, len(filterCovers))
+
+	for cover, v := range filterCovers {
+		filterTexts = append(filterTexts, cover.Text())
+		filterValues[cover.Text()] = v
+	}
+
+	sort.Strings(filterTexts)
+
+	for _, text := range filterTexts {
+		j, err := filterValues[text].MarshalJSON()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		labels = append(labels, "~"+text)
+		filterVals = append(filterVals, base.Val(j))
+	}
+
+	return append(labels, "^id"), filterVals, nil
+}
+
+// DatastoreScanCovering is like DatastoreScan, but when the op has
+// the labels of a covering scan (see CoveringLabels), the vals of the
+// covers are also yielded from the index entries.
+func DatastoreScanCovering(o *base.Op, vars *base.Vars,
+	yieldVals base.YieldVals, yieldErr base.YieldErr, scan CoveringScan,
+	cb func(*execution.Context, *datastore.IndexConnection)) {
+	if len(o.Labels) <= 1 {
+		DatastoreScan(o, vars, yieldVals, yieldErr, cb)
+		return
+	}
+
+	_, filterVals, err := CoveringLabels(scan)
+	if err != nil {
+		yieldErr(err)
+		return
+	}
+
+	numCovers := len(scan.Covers())
+
+	// The positions of the covers of the projected entry keys.
+	var projected []int
+	if p := scan.Projection(); p != nil {
+		projected = p.EntryKeys
+	}
+
+	vals := make(base.Vals, len(o.Labels))
+
+	var valId base.Val
+
+	DatastoreScanEntries(o, vars, yieldErr, cb,
+		func(entry *datastore.IndexEntry) error {
+			for i := range vals {
+				vals[i] = base.ValMissing
+			}
+
+			// Matches planner.builder.buildCoveringScan(), where the
+			// last cover is of the primary key.
+			for i, ek := range entry.EntryKey {
+				coveri := i
+				if projected != nil && len(entry.EntryKey) == len(projected) {
+					coveri = projected[i]
+				}
+
+				if coveri >= numCovers-1 || ek == nil {
+					continue
+				}
+
+				j, err := ek.MarshalJSON()
+				if err != nil {
+					return err
+				}
+
+				vals[coveri] = base.Val(j)
+			}
+
+			valId = strconv.AppendQuote(valId[:0], entry.PrimaryKey)
+
+			vals[numCovers-1] = valId
+
+			copy(vals[numCovers:], filterVals)
+
+			vals[len(vals)-1] = valId
+
+			yieldVals(vals)
+
+			return nil
+		})
+}
+
+// -------------------------------------------------------------------
+
 func DatastoreScan(o *base.Op, vars *base.Vars,
 	yieldVals base.YieldVals, yieldErr base.YieldErr,
 	cb func(*execution.Context, *datastore.IndexConnection)) {
-	context := vars.Temps[0].(*execution.Context)
+	var valId base.Val
+	var vals base.Vals
+
+	DatastoreScanEntries(o, vars, yieldErr, cb,
+		func(entry *datastore.IndexEntry) error {
+			valId = strconv.AppendQuote(valId[:0], entry.PrimaryKey)
+			vals = append(vals[:0], valId)
+
+			yieldVals(vals)
+
+			return nil
+		})
+}
+
+// DatastoreScanEntries invokes the entryCB for each of the index
+// entries that are sent by a scan that's started by the cb.
+func DatastoreScanEntries(o *base.Op, vars *base.Vars, yieldErr base.YieldErr,
+	cb func(*execution.Context, *datastore.IndexConnection),
+	entryCB func(*datastore.IndexEntry) error) {
+	context := vars.TempGet(0).(*execution.Context)
 
 	conn := datastore.NewIndexConnection(context)
 
@@ -160,19 +376,17 @@ func DatastoreScan(o *base.Op, vars *base.Vars,
 
 	sender := conn.Sender()
 
-	var valId base.Val
-	var vals base.Vals
-
 	for {
 		entry, ok := sender.GetEntry()
 		if !ok || entry == nil {
 			break
 		}
 
-		valId = strconv.AppendQuote(valId[:0], entry.PrimaryKey)
-		vals = append(vals[:0], valId)
-
-		yieldVals(vals)
+		err := entryCB(entry)
+		if err != nil {
+			yieldErr(err)
+			return
+		}
 
 		// TODO: Handle NL case.
 		// scopeValue := parent
@@ -182,32 +396,8 @@ func DatastoreScan(o *base.Op, vars *base.Vars,
 
 		// av := this.newEmptyDocumentWithKey(entry.PrimaryKey, scopeValue, context)
 
-		// TODO: The COVER() expression which accesses the SetCover()
-		// data appears in a GROUP BY & aggregate expr rewrite.
-		// Need to put this into the vals as meta-ish entries?
-		/*
-			covers := scan.Covers()
-			if len(covers) > 0 {
-				for c, v := range scan.FilterCovers() {
-					av.SetCover(c.Text(), v)
-				}
-
-				// Matches planner.builder.buildCoveringScan()
-				for i, ek := range entry.EntryKey {
-					av.SetCover(covers[i].Text(), ek)
-				}
-
-				// Matches planner.builder.buildCoveringScan()
-				av.SetCover(covers[len(covers)-1].Text(),
-					value.NewValue(entry.PrimaryKey))
-
-				av.SetField(this.plan.Term().Alias(), av) // TODO?
-			}
-
-			av.SetBit(this.bit) // TODO: Needed for intersect scan.
-
-			ok = this.sendItem(av)
-		*/
+		// TODO: Needed for intersect scan?
+		// av.SetBit(this.bit)
 	}
 
 	yieldErr(nil)
@@ -241,6 +431,69 @@ func EvalSpan(context *execution.Context, ps *plan.Span, parent value.Value) (
 
 // -------------------------------------------------------------------
 
+// EvalSpans2 evaluates the spans of an IndexScan2 or IndexScan3, where
+// empty is true when all the spans are empty.
+func EvalSpans2(context *execution.Context, spans plan.Spans2, parent value.Value) (
+	dspans datastore.Spans2, empty bool, err error) {
+	for _, ps := range spans {
+		dspan, spanEmpty, err := EvalSpan2(context, ps, parent)
+		if err != nil {
+			return nil, false, err
+		}
+
+		if !spanEmpty {
+			dspans = append(dspans, dspan)
+		}
+	}
+
+	return dspans, len(dspans) == 0, nil
+}
+
+func EvalSpan2(context *execution.Context, ps *plan.Span2, parent value.Value) (
+	dspan *datastore.Span2, empty bool, err error) {
+	dspan = &datastore.Span2{}
+
+	dspan.Seek, empty, err = EvalExprs(context, ps.Seek, parent)
+	if err != nil || empty {
+		return nil, empty, err
+	}
+
+	dspan.Ranges = make([]*datastore.Range2, 0, len(ps.Ranges))
+
+	for _, pr := range ps.Ranges {
+		dr := &datastore.Range2{Inclusion: pr.Inclusion}
+
+		dr.Low, empty, err = EvalExpr(context, pr.Low, parent)
+		if err != nil || empty {
+			return nil, empty, err
+		}
+
+		dr.High, empty, err = EvalExpr(context, pr.High, parent)
+		if err != nil || empty {
+			return nil, empty, err
+		}
+
+		dspan.Ranges = append(dspan.Ranges, dr)
+	}
+
+	return dspan, false, nil
+}
+
+// IndexProjection converts the projection of an index scan plan,
+// which might be nil, for the datastore.
+func IndexProjection(p *plan.IndexProjection) *datastore.IndexProjection {
+	if p == nil {
+		return nil
+	}
+
+	return &datastore.IndexProjection{
+		EntryKeys:  p.EntryKeys,
+		PrimaryKey: p.PrimaryKey,
+	}
+}
+
+// -------------------------------------------------------------------
+
 func EvalExprs(context *execution.Context, cx expression.Expressions,
 	parent value.Value) (cv value.Values, empty bool, err error) {
 	if len(cx) > 0 {
@@ -0,0 +1,207 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the
+//  License. You may obtain a copy of the License at
+//  http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing,
+//  software distributed under the License is distributed on an "AS
+//  IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+//  express or implied. See the License for the specific language
+//  governing permissions and limitations under the License.
+
+package glue
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/couchbase/query/expression"
+
+	"github.com/couchbase/n1k1"
+	"github.com/couchbase/n1k1/base"
+)
+
+// Explain is the JSON'able explanation of a converted base.Op tree.
+type Explain struct {
+	Op    *ExplainOp     `json:"op"`
+	Temps []*ExplainTemp `json:"temps"`
+
+	// NumNative and NumFallback count the expressions that are
+	// evaluated natively versus those that have an exprStr or
+	// exprTree fallback in their subtree.
+	NumNative   int `json:"numNative"`
+	NumFallback int `json:"numFallback"`
+}
+
+// ExplainOp explains a base.Op and its expressions.
+type ExplainOp struct {
+	Kind     string         `json:"kind"`
+	Labels   base.Labels    `json:"labels,omitempty"`
+	Params   []interface{}  `json:"params,omitempty"`
+	Exprs    []*ExplainExpr `json:"exprs,omitempty"`
+	Children []*ExplainOp   `json:"children,omitempty"`
+}
+
+// ExplainExpr explains an expression from the params of an op, where
+// Fallbacks holds the N1QL text of any subtrees that are evaluated by
+// exprStr or exprTree instead of natively.
+type ExplainExpr struct {
+	Expr      interface{} `json:"expr"`
+	Native    bool        `json:"native"`
+	Fallbacks []string    `json:"fallbacks,omitempty"`
+}
+
+// ExplainTemp summarizes a vars.Temps slot.
+type ExplainTemp struct {
+	Slot int    `json:"slot"`
+	Type string `json:"type"`
+	Val  string `json:"val,omitempty"`
+}
+
+// -----------------------------------------------------
+
+// Explain returns the JSON explanation of the converted base.Op tree.
+func (c *Conv) Explain() ([]byte, error) {
+	return json.Marshal(NewExplain(c.TopOp, c.Temps))
+}
+
+// NewExplain returns the explanation of a base.Op tree and its Temps.
+func NewExplain(op *base.Op, temps []interface{}) *Explain {
+	rv := &Explain{}
+
+	rv.Op = rv.ExplainOp(op)
+
+	for i, t := range temps {
+		et := &ExplainTemp{Slot: i, Type: fmt.Sprintf("%T", t)}
+
+		switch x := t.(type) {
+		case nil:
+			if i == 0 {
+				et.Type = "context" // Preallocated by NewConv().
+			}
+		case base.Val:
+			et.Val = string(x)
+		case fmt.Stringer:
+			et.Val = x.String()
+		}
+
+		rv.Temps = append(rv.Temps, et)
+	}
+
+	return rv
+}
+
+// ExplainOp recursively explains an op and its children.
+func (e *Explain) ExplainOp(op *base.Op) *ExplainOp {
+	if op == nil {
+		return nil
+	}
+
+	rv := &ExplainOp{
+		Kind:   op.Kind,
+		Labels: op.Labels,
+		Params: ExplainParams(op.Params),
+	}
+
+	e.ExplainExprs(rv, op.Params)
+
+	for _, child := range op.Children {
+		rv.Children = append(rv.Children, e.ExplainOp(child))
+	}
+
+	return rv
+}
+
+// ExplainExprs finds the expressions in the params, which might be
+// nested, such as in the params of an order-offset-limit.
+func (e *Explain) ExplainExprs(eop *ExplainOp, params []interface{}) {
+	if ExplainIsExpr(params) {
+		ee := &ExplainExpr{Expr: ExplainParams(params)}
+
+		ExplainFallbacks(params, &ee.Fallbacks)
+
+		ee.Native = len(ee.Fallbacks) <= 0
+		if ee.Native {
+			e.NumNative++
+		} else {
+			e.NumFallback++
+		}
+
+		eop.Exprs = append(eop.Exprs, ee)
+
+		return
+	}
+
+	for _, param := range params {
+		if x, ok := param.([]interface{}); ok {
+			e.ExplainExprs(eop, x)
+		}
+	}
+}
+
+// -----------------------------------------------------
+
+// ExplainIsExpr returns true if the params are an expression, which
+// is either a fallback or is registered in the n1k1.ExprCatalog.
+func ExplainIsExpr(params []interface{}) bool {
+	if len(params) <= 0 {
+		return false
+	}
+
+	kind, ok := params[0].(string)
+	if !ok {
+		return false
+	}
+
+	return kind == "exprStr" || kind == "exprTree" ||
+		n1k1.ExprCatalog[kind] != nil
+}
+
+// ExplainFallbacks appends the N1QL text of the exprStr and exprTree
+// subtrees of an expression.
+func ExplainFallbacks(params []interface{}, rv *[]string) {
+	if len(params) > 1 {
+		switch params[0] {
+		case "exprStr", "exprTree":
+			*rv = append(*rv, fmt.Sprintf("%v", ExplainParam(params[1])))
+			return
+		}
+	}
+
+	for _, param := range params {
+		if x, ok := param.([]interface{}); ok {
+			ExplainFallbacks(x, rv)
+		}
+	}
+}
+
+// ExplainParams returns a JSON'able copy of the params.
+func ExplainParams(params []interface{}) []interface{} {
+	if params == nil {
+		return nil
+	}
+
+	rv := make([]interface{}, 0, len(params))
+	for _, param := range params {
+		rv = append(rv, ExplainParam(param))
+	}
+
+	return rv
+}
+
+// ExplainParam returns a JSON'able version of a param, where an
+// expression tree is represented by its N1QL text.
+func ExplainParam(param interface{}) interface{} {
+	switch x := param.(type) {
+	case []interface{}:
+		return ExplainParams(x)
+	case expression.Expression:
+		return x.String()
+	case base.Val:
+		return string(x)
+	case fmt.Stringer:
+		return x.String()
+	}
+
+	return param
+}
@@ -63,6 +63,13 @@ func ExprTree(vars *base.Vars, labels base.Labels,
 
 	exprGlueContext := &ExprGlueContext{MyNow: vars.Ctx.Now}
 
+	// The request's args, if any, are from the execution.Context in
+	// the 0'th Temps slot, such as for an EXECUTE of a Prepared.
+	if ac, ok := vars.TempGet(0).(ArgsContext); ok {
+		exprGlueContext.MyNamedArgs = ac.NamedArgs()
+		exprGlueContext.MyPositionalArgs = ac.PositionalArgs()
+	}
+
 	// TODO: Need to propagate the vars to the expression, too,
 	// perhaps related to bindings?
 
@@ -238,6 +245,22 @@ OUTER:
 				v = av
 			}
 
+		case '~': // The label is the text of a cover for vals[i].
+			if len(vals[i]) > 0 {
+				if v == nil {
+					v = value.NewValue(map[string]interface{}{})
+				}
+
+				av, ok := v.(value.AnnotatedValue)
+				if !ok {
+					av = value.NewAnnotatedValue(v)
+				}
+
+				av.SetCover(label[1:], value.NewParsedValue(vals[i], false))
+
+				v = av
+			}
+
 		default:
 			return nil, fmt.Errorf("Convert, unknown label[0]: %s", label)
 		}
@@ -248,11 +271,62 @@ OUTER:
 
 // --------------------------------------------------------
 
+// ArgsContext provides the named and positional args of a request,
+// which an execution.Context implements.
+type ArgsContext interface {
+	NamedArgs() map[string]value.Value
+	PositionalArgs() value.Values
+}
+
+// SetCtxParams marshals the named and positional args of a request
+// into the bound query parameters of a base.Ctx, which should be
+// invoked before the base.Op is executed or compiled. Otherwise, the
+// args can be bound on first use via a CtxParamsBinder().
+func SetCtxParams(ctx *base.Ctx, namedArgs map[string]value.Value,
+	positionalArgs value.Values) error {
+	ctx.NamedParams = make(map[string]base.Val, len(namedArgs))
+	for name, arg := range namedArgs {
+		j, err := arg.MarshalJSON()
+		if err != nil {
+			return err
+		}
+
+		ctx.NamedParams[name] = base.Val(j)
+	}
+
+	ctx.PositionalParams = make(base.Vals, 0, len(positionalArgs))
+	for _, arg := range positionalArgs {
+		j, err := arg.MarshalJSON()
+		if err != nil {
+			return err
+		}
+
+		ctx.PositionalParams = append(ctx.PositionalParams, base.Val(j))
+	}
+
+	return nil
+}
+
+// CtxParamsBinder returns a base.Ctx.ParamsBinder that binds the
+// query parameters from the request args of an ArgsContext, such as
+// an execution.Context, for when SetCtxParams() isn't invoked before
+// the execution. An error from the binding is yielded by the exprs
+// and ops that use the query parameters.
+func CtxParamsBinder(ac ArgsContext) func(ctx *base.Ctx) error {
+	return func(ctx *base.Ctx) error {
+		return SetCtxParams(ctx, ac.NamedArgs(), ac.PositionalArgs())
+	}
+}
+
+// --------------------------------------------------------
+
 // ExprGlueContext implements query/expression.Context interface.
 type ExprGlueContext struct {
 	MyNow                time.Time
 	MyAuthenticatedUsers []string
 	MyDatastoreVersion   string
+	MyNamedArgs          map[string]value.Value
+	MyPositionalArgs     value.Values
 }
 
 func (e *ExprGlueContext) Now() time.Time {
@@ -267,6 +341,20 @@ func (e *ExprGlueContext) DatastoreVersion() string {
 	return e.MyDatastoreVersion
 }
 
+func (e *ExprGlueContext) NamedArg(name string) (value.Value, bool) {
+	v, ok := e.MyNamedArgs[name]
+	return v, ok
+}
+
+// PositionalArg follows N1QL, where the positions start at 1.
+func (e *ExprGlueContext) PositionalArg(position int) (value.Value, bool) {
+	if position < 1 || position > len(e.MyPositionalArgs) {
+		return nil, false
+	}
+
+	return e.MyPositionalArgs[position-1], true
+}
+
 func (e *ExprGlueContext) EvaluateStatement(statement string,
 	namedArgs map[string]value.Value,
 	positionalArgs value.Values,
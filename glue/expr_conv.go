@@ -0,0 +1,401 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the
+//  License. You may obtain a copy of the License at
+//  http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing,
+//  software distributed under the License is distributed on an "AS
+//  IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+//  express or implied. See the License for the specific language
+//  governing permissions and limitations under the License.
+
+package glue
+
+import (
+	"encoding/json"
+
+	"github.com/couchbase/query/expression"
+
+	"github.com/couchbase/n1k1/base"
+)
+
+// ExprConv converts a query/expression.Expression into the params of
+// a native n1k1 expression, where the labels are of the vals that the
+// expression will be evaluated against. Any subtree that's not
+// supported natively falls back to exprTree.
+func ExprConv(labels base.Labels, e expression.Expression) []interface{} {
+	rv, err := e.Accept(&ExprConvVisitor{Labels: labels})
+	if err == nil && rv != nil {
+		return rv.([]interface{})
+	}
+
+	return ExprConvFallback(e)
+}
+
+// ExprConvFallback returns the params of an exprTree expression, which
+// evaluates the query/expression.Expression tree as-is.
+func ExprConvFallback(e expression.Expression) []interface{} {
+	return []interface{}{"exprTree", e}
+}
+
+// ExprConvMap maps the lowercase names of query/expression functions
+// to the names of equivalent native n1k1 expressions. Other functions,
+// along with IN, BETWEEN and LIKE, fall back to exprTree.
+var ExprConvMap = map[string]string{
+	"lower": "lower",
+	"upper": "upper",
+}
+
+// -----------------------------------------------------
+
+// ExprConvVisitor implements the expression.Visitor interface to
+// lower a query/expression.Expression tree into native n1k1
+// expression params.
+type ExprConvVisitor struct {
+	Labels base.Labels
+}
+
+// Conv converts a child expression, so that an unsupported child
+// falls back to exprTree without affecting its supported parent.
+func (v *ExprConvVisitor) Conv(e expression.Expression) []interface{} {
+	return ExprConv(v.Labels, e)
+}
+
+// Chain converts the operands of an associative expression, like AND,
+// OR or concatenation, into a chain of native two-argument
+// expressions.
+func (v *ExprConvVisitor) Chain(kind string,
+	operands expression.Expressions) (interface{}, error) {
+	rv := v.Conv(operands[0])
+
+	for _, operand := range operands[1:] {
+		rv = []interface{}{kind, rv, v.Conv(operand)}
+	}
+
+	return rv, nil
+}
+
+// Bi converts a two-argument expression.
+func (v *ExprConvVisitor) Bi(kind string,
+	a, b expression.Expression) (interface{}, error) {
+	return []interface{}{kind, v.Conv(a), v.Conv(b)}, nil
+}
+
+// NA is used for expressions that aren't supported natively.
+func (v *ExprConvVisitor) NA(e expression.Expression) (interface{}, error) {
+	return ExprConvFallback(e), nil
+}
+
+// -----------------------------------------------------
+
+// Arithmetic
+
+func (v *ExprConvVisitor) VisitAdd(e *expression.Add) (interface{}, error) {
+	return v.Chain("add", e.Operands())
+}
+
+func (v *ExprConvVisitor) VisitDiv(e *expression.Div) (interface{}, error) {
+	return v.Bi("div", e.First(), e.Second())
+}
+
+func (v *ExprConvVisitor) VisitMod(e *expression.Mod) (interface{}, error) {
+	return v.Bi("mod", e.First(), e.Second())
+}
+
+func (v *ExprConvVisitor) VisitMult(e *expression.Mult) (interface{}, error) {
+	return v.Chain("mult", e.Operands())
+}
+
+func (v *ExprConvVisitor) VisitNeg(e *expression.Neg) (interface{}, error) {
+	return []interface{}{"sub",
+		[]interface{}{"json", "0"}, v.Conv(e.Operand())}, nil
+}
+
+func (v *ExprConvVisitor) VisitSub(e *expression.Sub) (interface{}, error) {
+	return v.Bi("sub", e.First(), e.Second())
+}
+
+// Case
+
+func (v *ExprConvVisitor) VisitSearchedCase(e *expression.SearchedCase) (interface{}, error) {
+	return v.NA(e)
+}
+
+func (v *ExprConvVisitor) VisitSimpleCase(e *expression.SimpleCase) (interface{}, error) {
+	return v.NA(e)
+}
+
+// Collection
+
+func (v *ExprConvVisitor) VisitAny(e *expression.Any) (interface{}, error) {
+	return v.NA(e)
+}
+
+func (v *ExprConvVisitor) VisitArray(e *expression.Array) (interface{}, error) {
+	return v.NA(e)
+}
+
+func (v *ExprConvVisitor) VisitEvery(e *expression.Every) (interface{}, error) {
+	return v.NA(e)
+}
+
+func (v *ExprConvVisitor) VisitAnyEvery(e *expression.AnyEvery) (interface{}, error) {
+	return v.NA(e)
+}
+
+func (v *ExprConvVisitor) VisitExists(e *expression.Exists) (interface{}, error) {
+	return v.NA(e)
+}
+
+func (v *ExprConvVisitor) VisitFirst(e *expression.First) (interface{}, error) {
+	return v.NA(e)
+}
+
+func (v *ExprConvVisitor) VisitObject(e *expression.Object) (interface{}, error) {
+	return v.NA(e)
+}
+
+func (v *ExprConvVisitor) VisitIn(e *expression.In) (interface{}, error) {
+	return v.NA(e)
+}
+
+func (v *ExprConvVisitor) VisitWithin(e *expression.Within) (interface{}, error) {
+	return v.NA(e)
+}
+
+// Comparison
+
+func (v *ExprConvVisitor) VisitBetween(e *expression.Between) (interface{}, error) {
+	return v.NA(e)
+}
+
+func (v *ExprConvVisitor) VisitEq(e *expression.Eq) (interface{}, error) {
+	return v.Bi("eq", e.First(), e.Second())
+}
+
+// The parser converts GE into LE.
+func (v *ExprConvVisitor) VisitLE(e *expression.LE) (interface{}, error) {
+	return v.Bi("le", e.First(), e.Second())
+}
+
+func (v *ExprConvVisitor) VisitLike(e *expression.Like) (interface{}, error) {
+	return v.NA(e)
+}
+
+// The parser converts GT into LT.
+func (v *ExprConvVisitor) VisitLT(e *expression.LT) (interface{}, error) {
+	return v.Bi("lt", e.First(), e.Second())
+}
+
+func (v *ExprConvVisitor) VisitIsMissing(e *expression.IsMissing) (interface{}, error) {
+	return []interface{}{"isMissing", v.Conv(e.Operand())}, nil
+}
+
+func (v *ExprConvVisitor) VisitIsNotMissing(e *expression.IsNotMissing) (interface{}, error) {
+	return []interface{}{"isNotMissing", v.Conv(e.Operand())}, nil
+}
+
+func (v *ExprConvVisitor) VisitIsNotNull(e *expression.IsNotNull) (interface{}, error) {
+	return []interface{}{"isNotNull", v.Conv(e.Operand())}, nil
+}
+
+func (v *ExprConvVisitor) VisitIsNotValued(e *expression.IsNotValued) (interface{}, error) {
+	return []interface{}{"isNotValued", v.Conv(e.Operand())}, nil
+}
+
+func (v *ExprConvVisitor) VisitIsNull(e *expression.IsNull) (interface{}, error) {
+	return []interface{}{"isNull", v.Conv(e.Operand())}, nil
+}
+
+func (v *ExprConvVisitor) VisitIsValued(e *expression.IsValued) (interface{}, error) {
+	return []interface{}{"isValued", v.Conv(e.Operand())}, nil
+}
+
+// Concat
+
+func (v *ExprConvVisitor) VisitConcat(e *expression.Concat) (interface{}, error) {
+	return v.Chain("concat", e.Operands())
+}
+
+// Constant
+
+func (v *ExprConvVisitor) VisitConstant(e *expression.Constant) (interface{}, error) {
+	j, err := e.Value().MarshalJSON()
+	if err != nil {
+		return v.NA(e)
+	}
+
+	return []interface{}{"json", string(j)}, nil
+}
+
+// Identifier
+
+func (v *ExprConvVisitor) VisitIdentifier(e *expression.Identifier) (interface{}, error) {
+	return v.LabelPath(e)
+}
+
+// Construction
+
+func (v *ExprConvVisitor) VisitArrayConstruct(e *expression.ArrayConstruct) (interface{}, error) {
+	return v.NA(e)
+}
+
+func (v *ExprConvVisitor) VisitObjectConstruct(e *expression.ObjectConstruct) (interface{}, error) {
+	return v.NA(e)
+}
+
+// Logic
+
+func (v *ExprConvVisitor) VisitAnd(e *expression.And) (interface{}, error) {
+	return v.Chain("and", e.Operands())
+}
+
+func (v *ExprConvVisitor) VisitNot(e *expression.Not) (interface{}, error) {
+	return []interface{}{"not", v.Conv(e.Operand())}, nil
+}
+
+func (v *ExprConvVisitor) VisitOr(e *expression.Or) (interface{}, error) {
+	return v.Chain("or", e.Operands())
+}
+
+// Navigation
+
+func (v *ExprConvVisitor) VisitElement(e *expression.Element) (interface{}, error) {
+	return v.Bi("element", e.First(), e.Second())
+}
+
+func (v *ExprConvVisitor) VisitField(e *expression.Field) (interface{}, error) {
+	return v.LabelPath(e)
+}
+
+func (v *ExprConvVisitor) VisitFieldName(e *expression.FieldName) (interface{}, error) {
+	return v.NA(e)
+}
+
+func (v *ExprConvVisitor) VisitSlice(e *expression.Slice) (interface{}, error) {
+	return v.NA(e)
+}
+
+// Self
+
+func (v *ExprConvVisitor) VisitSelf(e *expression.Self) (interface{}, error) {
+	return v.NA(e)
+}
+
+// Function
+
+func (v *ExprConvVisitor) VisitFunction(e expression.Function) (interface{}, error) {
+	kind, ok := ExprConvMap[e.Name()]
+	if !ok {
+		return v.NA(e)
+	}
+
+	rv := []interface{}{kind}
+	for _, operand := range e.Operands() {
+		rv = append(rv, v.Conv(operand))
+	}
+
+	return rv, nil
+}
+
+// Subquery
+
+func (v *ExprConvVisitor) VisitSubquery(e expression.Subquery) (interface{}, error) {
+	return v.NA(e)
+}
+
+// Parameters
+
+// A parameter is converted into a param expression, which is resolved
+// from the base.Ctx's bound query parameters, see SetCtxParams().
+
+func (v *ExprConvVisitor) VisitNamedParameter(e expression.NamedParameter) (interface{}, error) {
+	return []interface{}{"param", e.Name()}, nil
+}
+
+func (v *ExprConvVisitor) VisitPositionalParameter(e expression.PositionalParameter) (interface{}, error) {
+	return []interface{}{"param", e.Position()}, nil
+}
+
+// Cover
+
+// A cover is converted into a labelPath of the cover's "~" label from
+// a covering scan, otherwise the covered expression is converted.
+func (v *ExprConvVisitor) VisitCover(e *expression.Cover) (interface{}, error) {
+	label := "~" + e.Text()
+	if v.Labels.IndexOf(label) >= 0 {
+		return []interface{}{"labelPath", label}, nil
+	}
+
+	return v.Conv(e.Covered()), nil
+}
+
+// All
+
+func (v *ExprConvVisitor) VisitAll(e *expression.All) (interface{}, error) {
+	return v.NA(e)
+}
+
+// -----------------------------------------------------
+
+// LabelPath converts a field path expression, like `orders.custId`,
+// into a labelPath expression, where the first identifier of the
+// field path must match a label. A field of some other expression,
+// like `orders.orderlines[0].qty`, is converted into an element
+// expression. Otherwise, it falls back to exprTree.
+func (v *ExprConvVisitor) LabelPath(e expression.Expression) (interface{}, error) {
+	rv := ExprConvLabelPath(v.Labels, e)
+	if rv == nil {
+		if x, ok := e.(*expression.Field); ok && !x.CaseInsensitive() {
+			if fieldName, ok := x.Second().(*expression.FieldName); ok {
+				j, err := json.Marshal(fieldName.Alias())
+				if err == nil {
+					return []interface{}{"element",
+						v.Conv(x.First()), []interface{}{"json", string(j)}}, nil
+				}
+			}
+		}
+
+		return v.NA(e)
+	}
+
+	return rv, nil
+}
+
+// ExprConvLabelPath converts a field path expression, like
+// `orders.custId`, into a labelPath expression, where the first
+// identifier of the field path must match a label. Otherwise, nil is
+// returned.
+func ExprConvLabelPath(labels base.Labels, e expression.Expression) []interface{} {
+	var path []string
+
+	for {
+		switch x := e.(type) {
+		case *expression.Field:
+			fieldName, ok := x.Second().(*expression.FieldName)
+			if !ok || x.CaseInsensitive() {
+				return nil
+			}
+
+			path = append([]string{fieldName.Alias()}, path...)
+
+			e = x.First()
+
+		case *expression.Identifier:
+			label := "." + LabelSuffix(x.Identifier())
+			if labels.IndexOf(label) < 0 {
+				return nil
+			}
+
+			rv := []interface{}{"labelPath", label}
+			for _, field := range path {
+				rv = append(rv, field)
+			}
+
+			return rv
+
+		default:
+			return nil
+		}
+	}
+}
@@ -0,0 +1,176 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the
+//  License. You may obtain a copy of the License at
+//  http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing,
+//  software distributed under the License is distributed on an "AS
+//  IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+//  express or implied. See the License for the specific language
+//  governing permissions and limitations under the License.
+
+package glue
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/couchbase/query/plan"
+	"github.com/couchbase/query/value"
+
+	"github.com/couchbase/n1k1/base"
+)
+
+// Prepared is a statement that has been parsed, planned and
+// converted, so that it can be executed many times without
+// re-parsing, re-planning or re-converting.
+type Prepared struct {
+	Name string
+	Text string
+
+	Plan plan.Operator
+
+	// Op is the converted base.Op tree.
+	Op *base.Op
+
+	// Temps is the template of the vars.Temps slots for the Op, where
+	// the 0'th slot is for an execution.Context.
+	Temps []interface{}
+}
+
+// NewTemps returns a copy of the Temps template for an execution,
+// with the 0'th slot set to the execution.Context.
+func (p *Prepared) NewTemps(context interface{}) []interface{} {
+	rv := append([]interface{}(nil), p.Temps...)
+	rv[0] = context
+	return rv
+}
+
+// -------------------------------------------------------------------
+
+// PreparedKey is the key of a Prepared in a PreparedCache.
+type PreparedKey struct {
+	Name string
+	Text string
+}
+
+// PreparedCache holds Prepared's, keyed by their name and statement
+// text, and is concurrent safe.
+type PreparedCache struct {
+	m sync.Mutex // Protects the fields that follow.
+
+	prepareds map[PreparedKey]*Prepared
+
+	// names maps a name to its most recently put Prepared, for an
+	// EXECUTE by name only.
+	names map[string]*Prepared
+}
+
+// NewPreparedCache returns a ready-to-use PreparedCache.
+func NewPreparedCache() *PreparedCache {
+	return &PreparedCache{
+		prepareds: map[PreparedKey]*Prepared{},
+		names:     map[string]*Prepared{},
+	}
+}
+
+// Get returns the Prepared for a name and statement text, or nil if
+// it's not cached, where an empty text means lookup by name only.
+func (pc *PreparedCache) Get(name, text string) (rv *Prepared) {
+	pc.m.Lock()
+	if text == "" {
+		rv = pc.names[name]
+	} else {
+		rv = pc.prepareds[PreparedKey{Name: name, Text: text}]
+	}
+	pc.m.Unlock()
+
+	return rv
+}
+
+// Put adds a Prepared to the cache, replacing any Prepared that has
+// the same name.
+func (pc *PreparedCache) Put(p *Prepared) {
+	pc.m.Lock()
+	if prev := pc.names[p.Name]; prev != nil {
+		delete(pc.prepareds, PreparedKey{Name: prev.Name, Text: prev.Text})
+	}
+	pc.prepareds[PreparedKey{Name: p.Name, Text: p.Text}] = p
+	pc.names[p.Name] = p
+	pc.m.Unlock()
+}
+
+// -------------------------------------------------------------------
+
+// Prepare returns the cached Prepared for a name and statement text,
+// otherwise the statement is parsed, planned, converted and then
+// cached. As with a N1QL PREPARE, the statement is planned without
+// any args, which are instead bound for each execution, such as via
+// Execute(), so that the cached Prepared is not specific to the
+// args of its first caller.
+func (g *Store) Prepare(pc *PreparedCache, name, text, namespace string) (
+	*Prepared, error) {
+	if p := pc.Get(name, text); p != nil {
+		return p, nil
+	}
+
+	s, err := ParseStatement(text, namespace, true)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := g.PlanStatement(s, namespace, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	conv := NewConv()
+	conv.PreparedCache, conv.Store, conv.Namespace = pc, g, namespace
+
+	err = conv.Convert(p)
+	if err != nil {
+		return nil, err
+	}
+
+	rv := &Prepared{
+		Name:  name,
+		Text:  text,
+		Plan:  p,
+		Op:    conv.TopOp,
+		Temps: conv.Temps,
+	}
+
+	pc.Put(rv)
+
+	return rv, nil
+}
+
+// Execute returns the cached Prepared for an EXECUTE, where an empty
+// text means lookup by name only. On a cache miss with a statement
+// text, the statement is prepared. The args of the EXECUTE are bound
+// into the ctx via SetCtxParams(), which the returned Prepared's Op
+// should then be executed with.
+func (g *Store) Execute(pc *PreparedCache, name, text, namespace string,
+	ctx *base.Ctx, namedArgs map[string]value.Value,
+	positionalArgs value.Values) (*Prepared, error) {
+	p := pc.Get(name, text)
+	if p == nil {
+		if text == "" {
+			return nil, fmt.Errorf("Execute, unknown prepared name: %s", name)
+		}
+
+		var err error
+
+		p, err = g.Prepare(pc, name, text, namespace)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err := SetCtxParams(ctx, namedArgs, positionalArgs)
+	if err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
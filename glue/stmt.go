@@ -83,6 +83,18 @@ func (g *Store) PlanStatement(s algebra.Statement, namespace string,
 		g.IndexApiVersion, g.FeatureControls)
 }
 
+// PlanSubquery returns a plan.Operator tree for a subquery, such as
+// the subquery of a WITH binding.
+func (g *Store) PlanSubquery(s *algebra.Select, namespace string) (
+	plan.Operator, error) {
+	subquery := true
+	var stream bool
+
+	return planner.Build(s, g.Datastore, g.Systemstore,
+		namespace, subquery, stream, nil, nil,
+		g.IndexApiVersion, g.FeatureControls)
+}
+
 // ------------------------------------------------------------------
 
 // FileStore returns a store instance based on a file datastore.
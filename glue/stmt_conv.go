@@ -15,6 +15,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"math"
+	"sort"
 	"strings"
 
 	"github.com/couchbase/query/algebra"
@@ -36,6 +37,19 @@ type Conv struct {
 
 	// TopOp holds top of the converted base.Op tree.
 	TopOp *base.Op
+
+	// Withs maps a WITH alias to the vars.Temps slot that holds the
+	// vals captured for that WITH binding.
+	Withs map[string]int
+
+	// PreparedCache, when non-nil, caches the converted plans of any
+	// PREPARE statements.
+	PreparedCache *PreparedCache
+
+	// Store and Namespace, when Store is non-nil, are used to plan
+	// any subqueries of WITH bindings.
+	Store     *Store
+	Namespace string
 }
 
 // Allocates a new Conv instance, where the 0'th Temps slot is
@@ -75,6 +89,30 @@ func (c *Conv) TopSet(p plan.Operator, op *base.Op) (*base.Op, error) {
 	return op, nil
 }
 
+// ConvChild converts a plan.Operator into its own, separate base.Op
+// subtree, such as for a child of a set operator, where the Temps,
+// Withs and Store are shared with the parent Conv.
+func (c *Conv) ConvChild(p plan.Operator) (*base.Op, error) {
+	return c.ConvChildTop(p, nil)
+}
+
+// ConvChildTop is like ConvChild, but the conversion starts with the
+// given top operator, such as for a branch of a MERGE.
+func (c *Conv) ConvChildTop(p plan.Operator, top *base.Op) (*base.Op, error) {
+	cc := &Conv{Temps: c.Temps, Withs: c.Withs, TopOp: top,
+		Store: c.Store, Namespace: c.Namespace}
+
+	_, err := p.Accept(cc)
+
+	c.Temps, c.Withs = cc.Temps, cc.Withs
+
+	if err != nil {
+		return nil, err
+	}
+
+	return cc.TopOp, nil
+}
+
 // -------------------------------------------------------------------
 
 // Termer provides access to a keyspace term, since the plan package
@@ -117,8 +155,40 @@ func (c *Conv) VisitIndexScan(o *plan.IndexScan) (interface{}, error) {
 	})
 }
 
-func (c *Conv) VisitIndexScan2(o *plan.IndexScan2) (interface{}, error) { return NA(o) }
-func (c *Conv) VisitIndexScan3(o *plan.IndexScan3) (interface{}, error) { return NA(o) }
+func (c *Conv) VisitIndexScan2(o *plan.IndexScan2) (interface{}, error) {
+	return c.IndexScanCovering(o, "datastore-scan-index2", o.Covering(), o)
+}
+
+func (c *Conv) VisitIndexScan3(o *plan.IndexScan3) (interface{}, error) {
+	if o.GroupAggs() != nil { // TODO: Support index group aggregates.
+		return NA(o)
+	}
+
+	return c.IndexScanCovering(o, "datastore-scan-index3", o.Covering(), o)
+}
+
+// IndexScanCovering converts an index scan that might cover the
+// query, where a covering scan yields the vals of its covers as "~"
+// labels, so that no fetch of the documents is needed.
+func (c *Conv) IndexScanCovering(o plan.Operator, kind string,
+	covering bool, scan CoveringScan) (interface{}, error) {
+	labels := base.Labels{"^id"}
+
+	if covering && len(scan.Covers()) > 0 {
+		var err error
+
+		labels, _, err = CoveringLabels(scan)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return c.TopPush(o, &base.Op{
+		Kind:   kind,
+		Labels: labels,
+		Params: []interface{}{c.AddTemp(o)},
+	})
+}
 
 func (c *Conv) VisitKeyScan(o *plan.KeyScan) (interface{}, error) {
 	return c.TopPush(o, &base.Op{
@@ -128,28 +198,193 @@ func (c *Conv) VisitKeyScan(o *plan.KeyScan) (interface{}, error) {
 	})
 }
 
-func (c *Conv) VisitValueScan(o *plan.ValueScan) (interface{}, error) { return NA(o) } // Used for mutations (VALUES clause).
+// VisitValueScan converts the VALUES clause of an INSERT or UPSERT
+// into a project of each pair, labeled as "^key" and "^value".
+func (c *Conv) VisitValueScan(o *plan.ValueScan) (interface{}, error) {
+	labels := base.Labels{"^key", "^value"}
+
+	var children []*base.Op
+
+	for _, pair := range o.Values() {
+		children = append(children, &base.Op{
+			Kind:   "project",
+			Labels: labels,
+			Params: []interface{}{
+				ExprConv(nil, pair.Key),
+				ExprConv(nil, pair.Value),
+			},
+			Children: []*base.Op{&base.Op{Kind: "nil"}},
+		})
+	}
+
+	if len(children) == 1 {
+		return c.TopSet(o, children[0])
+	}
+
+	return c.TopSet(o, &base.Op{
+		Kind:     "union-all",
+		Labels:   labels,
+		Children: children,
+	})
+}
 
 func (c *Conv) VisitDummyScan(o *plan.DummyScan) (interface{}, error) {
 	return c.TopPush(o, &base.Op{Kind: "nil"})
 }
 
-func (c *Conv) VisitCountScan(o *plan.CountScan) (interface{}, error)           { return NA(o) }
-func (c *Conv) VisitIndexCountScan(o *plan.IndexCountScan) (interface{}, error) { return NA(o) }
+// The count scans are converted into a datastore-count, which yields
+// a single vals of the count, labeled as "^count".
+
+func (c *Conv) VisitCountScan(o *plan.CountScan) (interface{}, error) {
+	return c.CountScan(o)
+}
+
+func (c *Conv) VisitIndexCountScan(o *plan.IndexCountScan) (interface{}, error) {
+	return c.CountScan(o)
+}
+
 func (c *Conv) VisitIndexCountScan2(o *plan.IndexCountScan2) (interface{}, error) {
-	return NA(o)
+	return c.CountScan(o)
 }
+
 func (c *Conv) VisitIndexCountDistinctScan2(o *plan.IndexCountDistinctScan2) (interface{}, error) {
-	return NA(o)
+	return c.CountScan(o)
+}
+
+func (c *Conv) CountScan(o plan.Operator) (interface{}, error) {
+	return c.TopPush(o, &base.Op{
+		Kind:   "datastore-count",
+		Labels: base.Labels{"^count"},
+		Params: []interface{}{c.AddTemp(o)},
+	})
+}
+
+// The composite scans are converted into n1k1's own set operators on
+// the "^id" label of their child scans, so that the intersections are
+// of the keys only, and not of any bits that are tracked by the scans.
+
+func (c *Conv) VisitDistinctScan(o *plan.DistinctScan) (interface{}, error) {
+	children, err := c.ScanChildren([]plan.SecondaryScan{o.Scan()})
+	if err != nil {
+		return nil, err
+	}
+
+	return c.TopSet(o, ScanLimit(ScanDistinct(children[0]), o.Offset(), o.Limit()))
+}
+
+func (c *Conv) VisitUnionScan(o *plan.UnionScan) (interface{}, error) {
+	children, err := c.ScanChildren(o.Scans())
+	if err != nil {
+		return nil, err
+	}
+
+	return c.TopSet(o, ScanLimit(ScanDistinct(&base.Op{
+		Kind:     "union-all",
+		Labels:   base.Labels{"^id"},
+		Children: children,
+	}), o.Offset(), o.Limit()))
+}
+
+func (c *Conv) VisitIntersectScan(o *plan.IntersectScan) (interface{}, error) {
+	children, err := c.ScanChildren(o.Scans())
+	if err != nil {
+		return nil, err
+	}
+
+	return c.TopSet(o, ScanLimit(ScanIntersect(children), nil, o.Limit()))
 }
-func (c *Conv) VisitDistinctScan(o *plan.DistinctScan) (interface{}, error)   { return NA(o) }
-func (c *Conv) VisitUnionScan(o *plan.UnionScan) (interface{}, error)         { return NA(o) }
-func (c *Conv) VisitIntersectScan(o *plan.IntersectScan) (interface{}, error) { return NA(o) }
+
+// VisitOrderedIntersectScan keeps the order of its first scan, which
+// becomes the last, probing side of the intersect-distinct's, as those
+// yield the matching vals in the order of their probing side.
 func (c *Conv) VisitOrderedIntersectScan(o *plan.OrderedIntersectScan) (interface{}, error) {
-	return NA(o)
+	children, err := c.ScanChildren(o.Scans())
+	if err != nil {
+		return nil, err
+	}
+
+	children = append(children[1:], children[0])
+
+	return c.TopSet(o, ScanLimit(ScanIntersect(children), nil, o.Limit()))
+}
+
+// ScanChildren converts the child scans of a composite scan, where
+// each child scan yields only its "^id".
+func (c *Conv) ScanChildren(scans []plan.SecondaryScan) (rv []*base.Op, err error) {
+	for _, scan := range scans {
+		op, err := c.ConvChild(scan)
+		if err != nil {
+			return nil, err
+		}
+
+		rv = append(rv, SetOpAlign(base.Labels{"^id"}, op))
+	}
+
+	return rv, nil
+}
+
+// ScanDistinct returns a distinct on the "^id" of the op.
+func ScanDistinct(op *base.Op) *base.Op {
+	return &base.Op{
+		Kind:   "distinct",
+		Labels: base.Labels{"^id"},
+		Params: []interface{}{
+			[]interface{}{
+				[]interface{}{"labelPath", "^id"},
+			},
+		},
+		Children: []*base.Op{op},
+	}
+}
+
+// ScanIntersect returns a chain of intersect-distinct's on the "^id"
+// of the ops, where the last op is the probing side of the chain.
+func ScanIntersect(ops []*base.Op) *base.Op {
+	rv := ops[0]
+
+	for _, op := range ops[1:] {
+		rv = &base.Op{
+			Kind:     "intersect-distinct",
+			Labels:   base.Labels{"^id"},
+			Children: []*base.Op{rv, op},
+		}
+	}
+
+	return rv
+}
+
+// ScanLimit returns the op with an optional offset and limit, where
+// a parameterized offset or limit is resolved for each execution.
+func ScanLimit(op *base.Op, offset, limit expression.Expression) *base.Op {
+	if offset == nil && limit == nil {
+		return op
+	}
+
+	return &base.Op{
+		Kind:   "order-offset-limit",
+		Labels: op.Labels,
+		Params: []interface{}{
+			[]interface{}{},
+			[]interface{}{},
+			OffsetLimitParam(offset, 0),
+			OffsetLimitParam(limit, int64(math.MaxInt64)),
+		},
+		Children: []*base.Op{op},
+	}
 }
 
 func (c *Conv) VisitExpressionScan(o *plan.ExpressionScan) (interface{}, error) {
+	// A scan of a WITH alias yields the vals captured by VisitWith().
+	if ident, ok := o.FromExpr().(*expression.Identifier); ok {
+		if tempIdx, exists := c.Withs[ident.Identifier()]; exists {
+			return c.TopPush(o, &base.Op{
+				Kind:   "temp-yield",
+				Labels: base.Labels{"." + LabelSuffix(o.Alias())},
+				Params: []interface{}{tempIdx},
+			})
+		}
+	}
+
 	if o.IsCorrelated() { // TODO: Handle correlated expression scan?
 		return NA(o)
 	}
@@ -191,7 +426,18 @@ func (c *Conv) VisitFetch(o *plan.Fetch) (interface{}, error) {
 	})
 }
 
-func (c *Conv) VisitDummyFetch(o *plan.DummyFetch) (interface{}, error) { return NA(o) } // Used for mutations.
+// VisitDummyFetch converts the fetch of a mutation that doesn't need
+// the documents, where the alias label is an empty object.
+func (c *Conv) VisitDummyFetch(o *plan.DummyFetch) (interface{}, error) {
+	return c.TopPush(o, &base.Op{
+		Kind:   "project",
+		Labels: base.Labels{"." + LabelSuffix(o.Term().As()), "^id"},
+		Params: []interface{}{
+			[]interface{}{"json", "{}"},
+			[]interface{}{"labelPath", "^id"},
+		},
+	})
+}
 
 // Join
 
@@ -201,10 +447,8 @@ func (c *Conv) VisitJoin(o *plan.Join) (interface{}, error) {
 
 	rv := &base.Op{
 		Kind: "joinKeys-inner",
-		Labels: base.Labels{
-			"." + LabelSuffix(c.TopPlan.(Termer).Term().As()), "^id",
-			"." + LabelSuffix(o.Term().As()), "^id",
-		},
+		Labels: append(append(base.Labels(nil), c.TopOp.Labels...),
+			"."+LabelSuffix(o.Term().As()), "^id"),
 		Params: []interface{}{
 			// The vars.Temps slot that holds evaluated keys.
 			varsTempsSlot,
@@ -239,10 +483,8 @@ func (c *Conv) VisitIndexNest(o *plan.IndexNest) (interface{}, error) { return N
 func (c *Conv) VisitUnnest(o *plan.Unnest) (interface{}, error) {
 	rv := &base.Op{
 		Kind: "unnest-inner",
-		Labels: base.Labels{
-			"." + LabelSuffix(c.TopPlan.(Termer).Term().As()), "^id",
-			"." + LabelSuffix(o.Term().As()),
-		},
+		Labels: append(append(base.Labels(nil), c.TopOp.Labels...),
+			"."+LabelSuffix(o.Term().As())),
 		Params: []interface{}{
 			// The expression to unnest.
 			"exprStr", o.Term().Expression().String(),
@@ -262,19 +504,278 @@ func (c *Conv) VisitUnnest(o *plan.Unnest) (interface{}, error) {
 	return c.TopSet(o, rv)
 }
 
-func (c *Conv) VisitNLJoin(o *plan.NLJoin) (interface{}, error)     { return NA(o) }
-func (c *Conv) VisitNLNest(o *plan.NLNest) (interface{}, error)     { return NA(o) }
-func (c *Conv) VisitHashJoin(o *plan.HashJoin) (interface{}, error) { return NA(o) }
-func (c *Conv) VisitHashNest(o *plan.HashNest) (interface{}, error) { return NA(o) }
+// ANSI Join & Nest
+
+func (c *Conv) VisitNLJoin(o *plan.NLJoin) (interface{}, error) {
+	right, err := c.ConvChild(o.Child())
+	if err != nil {
+		return nil, err
+	}
+
+	labels := append(append(base.Labels(nil), c.TopOp.Labels...), right.Labels...)
+
+	rv := &base.Op{
+		Kind:     "joinNL-inner",
+		Labels:   labels,
+		Params:   ExprConv(labels, o.Onclause()),
+		Children: []*base.Op{c.TopOp, right},
+	}
+
+	if o.Outer() {
+		rv.Kind = "joinNL-leftOuter"
+	}
+
+	return c.TopSet(o, rv)
+}
+
+// VisitHashJoin converts a hash join, where the build exprs of the
+// plan are evaluated on the right side, which is the child plan, and
+// the probe exprs are evaluated on the left side. The whole ON clause
+// is also kept as the residual expr, as the ON clause might have more
+// than the equi-join conditions of the build & probe exprs.
+func (c *Conv) VisitHashJoin(o *plan.HashJoin) (interface{}, error) {
+	right, err := c.ConvChild(o.Child())
+	if err != nil {
+		return nil, err
+	}
+
+	labels := append(append(base.Labels(nil), c.TopOp.Labels...), right.Labels...)
+
+	var keysLeft, keysRight []interface{}
+
+	for i, probeExpr := range o.ProbeExprs() {
+		keysLeft = append(keysLeft, ExprConv(c.TopOp.Labels, probeExpr))
+		keysRight = append(keysRight, ExprConv(right.Labels, o.BuildExprs()[i]))
+	}
+
+	rv := &base.Op{
+		Kind:     "joinHash-inner",
+		Labels:   labels,
+		Params:   []interface{}{keysLeft, keysRight, ExprConv(labels, o.Onclause())},
+		Children: []*base.Op{c.TopOp, right},
+	}
+
+	if o.Outer() {
+		rv.Kind = "joinHash-leftOuter"
+	}
+
+	return c.TopSet(o, rv)
+}
+
+func (c *Conv) VisitNLNest(o *plan.NLNest) (interface{}, error) {
+	return c.NestNL(o, o.Alias(), o.Onclause(), o.Child(), o.Outer())
+}
+
+// NestNL converts a nested-loop nest of the child plan, which is the
+// right side, by the ON clause.
+func (c *Conv) NestNL(o plan.Operator, alias string,
+	onclause expression.Expression, child plan.Operator, outer bool) (
+	interface{}, error) {
+	right, err := c.ConvChild(child)
+	if err != nil {
+		return nil, err
+	}
+
+	right = NestAlign(alias, right)
+
+	labelsAB := append(append(base.Labels(nil), c.TopOp.Labels...), right.Labels...)
+
+	rv := &base.Op{
+		Kind:     "nestNL-inner",
+		Labels:   append(append(base.Labels(nil), c.TopOp.Labels...), "."+LabelSuffix(alias)),
+		Params:   ExprConv(labelsAB, onclause),
+		Children: []*base.Op{c.TopOp, right},
+	}
+
+	if outer {
+		rv.Kind = "nestNL-leftOuter"
+	}
+
+	return c.TopSet(o, rv)
+}
+
+// VisitHashNest converts a hash nest, which has no residual expr, so
+// an ON clause that's more than the equi-join condition of the build
+// & probe exprs is instead converted as a nested-loop nest.
+func (c *Conv) VisitHashNest(o *plan.HashNest) (interface{}, error) {
+	if _, ok := o.Onclause().(*expression.Eq); !ok {
+		return c.NestNL(o, o.BuildAlias(), o.Onclause(), o.Child(), o.Outer())
+	}
+
+	right, err := c.ConvChild(o.Child())
+	if err != nil {
+		return nil, err
+	}
+
+	right = NestAlign(o.BuildAlias(), right)
+
+	rv := &base.Op{
+		Kind:   "nestHash-inner",
+		Labels: append(append(base.Labels(nil), c.TopOp.Labels...), "."+LabelSuffix(o.BuildAlias())),
+		Params: []interface{}{
+			[]interface{}{ExprConv(c.TopOp.Labels, o.ProbeExpr())},
+			[]interface{}{ExprConv(right.Labels, o.BuildExpr())},
+		},
+		Children: []*base.Op{c.TopOp, right},
+	}
+
+	if o.Outer() {
+		rv.Kind = "nestHash-leftOuter"
+	}
+
+	return c.TopSet(o, rv)
+}
+
+// NestAlign returns the right side of a nest with its labels
+// reordered so that the alias label is last, as the nest ops collect
+// the last val of the right side into the nested array.
+func NestAlign(alias string, right *base.Op) *base.Op {
+	label := "." + LabelSuffix(alias)
+
+	if right.Labels.IndexOf(label) == len(right.Labels)-1 {
+		return right
+	}
+
+	var labels base.Labels
+	for _, l := range right.Labels {
+		if l != label {
+			labels = append(labels, l)
+		}
+	}
+
+	return SetOpAlign(append(labels, label), right)
+}
 
 // Let + Letting, With
 
-func (c *Conv) VisitLet(o *plan.Let) (interface{}, error)   { return NA(o) }
-func (c *Conv) VisitWith(o *plan.With) (interface{}, error) { return NA(o) }
+// VisitLet converts LET and LETTING, as the planner uses plan.Let
+// for both, where LETTING's plan.Let is on top of the final group.
+func (c *Conv) VisitLet(o *plan.Let) (interface{}, error) {
+	op := &base.Op{
+		Kind:   "let",
+		Labels: append(base.Labels(nil), c.TopOp.Labels...),
+		Params: make([]interface{}, 0, len(o.Bindings())),
+	}
+
+	// A binding's expr may refer to the earlier bindings.
+	for i, binding := range o.Bindings() {
+		op.Params = append(op.Params,
+			ExprConv(op.Labels[:len(c.TopOp.Labels)+i], binding.Expression()))
+		op.Labels = append(op.Labels, "."+LabelSuffix(binding.Variable()))
+	}
+
+	return c.TopPush(o, op)
+}
+
+// VisitWith converts the WITH bindings (common table expressions)
+// into temp-capture's that are sequenced before the main query, where
+// the main query's ExpressionScan of a WITH alias becomes a
+// temp-yield of the captured vals. A subquery binding captures one
+// object val per row of its own converted plan, and any other binding
+// captures the items of its value, which is evaluated at runtime so
+// that query parameters are supported. A WITH RECURSIVE isn't
+// represented by the plan package yet, so isn't converted, and the
+// recursive-union operator is reachable only from a base.Op tree.
+func (c *Conv) VisitWith(o *plan.With) (interface{}, error) {
+	var captures []*base.Op
+
+	for _, binding := range o.Bindings() {
+		labels := base.Labels{"." + LabelSuffix(binding.Variable())}
+
+		var op *base.Op
+
+		if sq, ok := binding.Expression().(*algebra.Subquery); ok {
+			if c.Store == nil {
+				return NA(o)
+			}
+
+			p, err := c.Store.PlanSubquery(sq.Select(), c.Namespace)
+			if err != nil {
+				return nil, err
+			}
+
+			child, err := c.ConvChild(p)
+			if err != nil {
+				return nil, err
+			}
+
+			// The vals of a row are merged into a single object.
+			op = &base.Op{
+				Kind:     "project",
+				Labels:   labels,
+				Params:   []interface{}{ExprConvFallback(expression.NewSelf())},
+				Children: []*base.Op{child},
+			}
+		} else {
+			op = WithItems(labels, binding.Expression())
+		}
+
+		tempIdx := c.AddTemp(nil)
+
+		captures = append(captures, &base.Op{
+			Kind:     "temp-capture",
+			Labels:   labels,
+			Params:   []interface{}{tempIdx},
+			Children: []*base.Op{op},
+		})
+
+		if c.Withs == nil {
+			c.Withs = map[string]int{}
+		}
+
+		c.Withs[binding.Variable()] = tempIdx
+	}
+
+	_, err := o.Child().Accept(c)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.TopSet(o, &base.Op{
+		Kind:     "sequence",
+		Labels:   c.TopOp.Labels,
+		Children: append(captures, c.TopOp),
+	})
+}
+
+// WithItems returns an op that yields the items of an expression's
+// value, or the value itself when it's not an array, matching the
+// FROM of an expression.
+func WithItems(labels base.Labels, e expression.Expression) *base.Op {
+	asArray := expression.NewSearchedCase(expression.WhenTerms{
+		&expression.WhenTerm{When: expression.NewIsArray(e), Then: e},
+	}, expression.NewArrayConstruct(e))
+
+	return &base.Op{
+		Kind:   "project",
+		Labels: labels,
+		Params: []interface{}{[]interface{}{"labelPath", labels[0]}},
+		Children: []*base.Op{&base.Op{
+			Kind:   "unnest-inner",
+			Labels: append(base.Labels{"^with"}, labels...),
+			Params: []interface{}{"labelPath", "^with"},
+			Children: []*base.Op{&base.Op{
+				Kind:     "project",
+				Labels:   base.Labels{"^with"},
+				Params:   []interface{}{ExprConv(nil, asArray)},
+				Children: []*base.Op{&base.Op{Kind: "nil"}},
+			}, &base.Op{
+				Kind:   "noop",
+				Labels: labels,
+			}},
+		}},
+	}
+}
 
 // Filter
 
-func (c *Conv) VisitFilter(o *plan.Filter) (interface{}, error) { return NA(o) }
+func (c *Conv) VisitFilter(o *plan.Filter) (interface{}, error) {
+	return c.TopPush(o, &base.Op{
+		Kind:   "filter",
+		Labels: c.TopOp.Labels,
+		Params: ExprConv(c.TopOp.Labels, o.Condition()),
+	})
+}
 
 // Group
 
@@ -319,134 +820,135 @@ func (c *Conv) VisitFinalGroup(o *plan.FinalGroup) (interface{}, error) {
 
 // Window functions
 
-var DefaultWindowFrame = algebra.NewWindowFrame(algebra.WINDOW_FRAME_RANGE,
-	algebra.WindowFrameExtents{
-		algebra.NewWindowFrameExtent(nil, algebra.WINDOW_FRAME_UNBOUNDED_PRECEDING),
-		algebra.NewWindowFrameExtent(nil, algebra.WINDOW_FRAME_CURRENT_ROW),
-	})
-
 func (c *Conv) VisitWindowAggregate(o *plan.WindowAggregate) (interface{}, error) {
 	if len(o.Aggregates()) <= 0 {
 		return c.TopOp, nil
 	}
 
-	var rv *base.Op
+	specs := WindowSpecs(o.Aggregates())
 
-	// All the o.Aggregates() have the same PARTITION BY.
-	var partitionBys []interface{}
-	for _, e := range o.Aggregates()[0].WindowTerm().PartitionBy() {
-		partitionBys = append(partitionBys, []interface{}{"exprStr", e.String()})
-	}
+	// The specs that need longer sorts are converted first, so that
+	// the later specs whose sorts are a prefix can share the sort.
+	sort.SliceStable(specs, func(i, j int) bool {
+		return len(specs[i].SortExprs) > len(specs[j].SortExprs)
+	})
 
-	for _, agg := range o.Aggregates() {
-		// TODO: Perhaps only need to append order-by's when we have
-		// extra trackings, for rank, denseRank?
-		partitionings := append([]interface{}(nil), partitionBys...) // Clone.
-		for _, e := range agg.WindowTerm().OrderBy().Expressions() {
-			// The asc vs desc is ignored as equality of vals is all
-			// that we need to check.
-			partitionings = append(partitionings, []interface{}{"exprStr", e.String()})
+	rv := c.TopOp
+
+	// Each spec has its own window-partition and window-frames pair,
+	// chained on top of the previous spec's pair, with a re-sort only
+	// when the incoming vals aren't already sorted as needed.
+	for _, spec := range specs {
+		if !SortSatisfies(rv, spec.SortExprs, spec.SortDirs) {
+			rv = &base.Op{
+				Kind:     "order-offset-limit",
+				Labels:   rv.Labels,
+				Params:   []interface{}{spec.SortExprs, spec.SortDirs},
+				Children: []*base.Op{rv},
+			}
 		}
 
 		partitionSlot := c.AddTemp(nil)
 
+		// The "range" frames compare the val of the ORDER BY term,
+		// which is appended as a label before the window-partition.
+		rangeIdx := -1
+
+		rangeExpr := WindowRangeExpr(spec.Aggs)
+		if rangeExpr != nil {
+			rangeIdx = len(rv.Labels)
+
+			rv = WindowProject(rv,
+				base.Labels{WindowTrackLabel(partitionSlot, "rangeKey")},
+				[]interface{}{ExprConv(rv.Labels, rangeExpr)})
+		}
+
+		// The tracked info, such as ranks, is appended by the
+		// window-partition as labels.
+		track := WindowTrack(spec.Aggs)
+
+		partitionLabels := append(append(base.Labels(nil), rv.Labels...),
+			WindowTrackLabels(partitionSlot, track)...)
+
+		peersIdx := partitionLabels.IndexOf(
+			WindowTrackLabel(partitionSlot, "denseRank"))
+
 		partitionOp := &base.Op{
 			Kind:   "window-partition",
-			Labels: c.TopOp.Labels,
+			Labels: partitionLabels,
 			Params: []interface{}{
 				partitionSlot,
-				partitionings,
-				len(partitionBys), // # of the partitioning exprs for PARTITION-BY.
-				"",                // TODO: Additional tracking ("rank,denseRank").
+				spec.SortExprs,
+				len(spec.PartitionBys), // # of the partitioning exprs for PARTITION-BY.
+				strings.Join(track, ","),
 			},
-			Children: []*base.Op{c.TopOp},
+			Children: []*base.Op{rv},
 		}
 
-		// Chain the window-partition to the previous round.
-		if rv != nil {
-			partitionOp.Children = append(partitionOp.Children, rv)
-		}
-
-		// TODO: Reuse of a window-partition by multiple
-		// aggregates and window-frame instances?
+		framesSlot := c.AddTemp(nil)
 
-		var wf *algebra.WindowFrame
-		if agg.WindowTerm() != nil {
-			wf = agg.WindowTerm().WindowFrame()
-		}
-		if wf == nil {
-			wf = DefaultWindowFrame
+		framesOp := &base.Op{
+			Kind:   "window-frames",
+			Labels: partitionOp.Labels,
+			Params: []interface{}{
+				partitionSlot,
+				framesSlot,
+				nil, // The frames cfg, filled in below.
+			},
+			Children: []*base.Op{partitionOp},
 		}
 
-		frameType := "rows"
-		if wf.HasModifier(algebra.WINDOW_FRAME_RANGE) {
-			frameType = "range"
-		} else if wf.HasModifier(algebra.WINDOW_FRAME_GROUPS) {
-			frameType = "groups"
-		}
+		var framesCfg, stepValues []interface{}
+		var stepLabels base.Labels
 
-		frameCfg := []interface{}{frameType}
+		var frameExprs [][]interface{}
 
-		appendExtent := func(wfe *algebra.WindowFrameExtent) {
-			if wfe.HasModifier(algebra.WINDOW_FRAME_CURRENT_ROW) {
-				frameCfg = append(frameCfg, "num", 0)
-			} else if wfe.HasModifier(algebra.WINDOW_FRAME_VALUE_PRECEDING) {
-				// TODO: Handle non-int64 RANGE extent.
-				n := EvalExprInt64(nil, wfe.ValueExpression(), nil, 0)
-				frameCfg = append(frameCfg, "num", -n)
-			} else if wfe.HasModifier(algebra.WINDOW_FRAME_VALUE_FOLLOWING) {
-				// TODO: Handle non-int64 RANGE extent.
-				n := EvalExprInt64(nil, wfe.ValueExpression(), nil, 0)
-				frameCfg = append(frameCfg, "num", n)
-			} else {
-				// Unbounded preceding or following.
-				frameCfg = append(frameCfg, "unbounded", 0)
+		// The aggregates of a spec share the window-partition and
+		// have their own frames in the window-frames.
+		for frameIdx, agg := range spec.Aggs {
+			frameCfg, err := WindowFrameCfg(agg, rangeIdx, peersIdx)
+			if err != nil {
+				return nil, err
 			}
-		}
-
-		wfes := wf.WindowFrameExtents()
 
-		appendExtent(wfes[0])
-
-		if len(wfes) > 1 {
-			appendExtent(wfes[1])
-		} else {
-			// Default to CURRENT ROW for end.
-			frameCfg = append(frameCfg, "num", 0)
-		}
+			framesCfg = append(framesCfg, frameCfg)
 
-		frameExclude := "no-others"
-		if wf.HasModifier(algebra.WINDOW_FRAME_EXCLUDE_CURRENT_ROW) {
-			frameExclude = "current-row"
-		} else if wf.HasModifier(algebra.WINDOW_FRAME_EXCLUDE_GROUP) {
-			frameExclude = "group"
-		} else if wf.HasModifier(algebra.WINDOW_FRAME_EXCLUDE_TIES) {
-			frameExclude = "ties"
-		}
+			stepValue := WindowStepValue(agg, framesSlot, frameIdx)
+			if stepValue == nil {
+				stepValue = WindowNumbering(agg, framesOp.Labels,
+					partitionSlot, framesSlot, frameIdx)
+			}
+			if stepValue == nil {
+				stepValue = WindowAgg(agg, framesOp.Labels, framesSlot, frameIdx)
+			}
 
-		frameCfg = append(frameCfg, frameExclude)
+			frameExprs = append(frameExprs, stepValue)
 
-		// TODO: Specify the val to compare for RANGE type.
-		valIdx := 0
+			if stepValue != nil {
+				stepValues = append(stepValues, stepValue)
+				stepLabels = append(stepLabels, "^aggregates|"+agg.String())
+			}
 
-		frameCfg = append(frameCfg, valIdx)
+			// TODO: agg modifier of DISTINCT.
+		}
 
-		framesSlot := c.AddTemp(nil)
+		framesOp.Params[2] = framesCfg
 
-		framesOp := &base.Op{
-			Kind:   "window-frames",
-			Labels: c.TopOp.Labels, // TODO: Handle extra trackings.
-			Params: []interface{}{
-				partitionSlot,
-				framesSlot,
-				[]interface{}{frameCfg},
-			},
-			Children: []*base.Op{partitionOp},
+		// The window partitions are streamed, instead of materialized,
+		// when the frames are bounded or incrementally aggregated.
+		streamCfg := WindowStreamCfg(track, framesCfg, frameExprs)
+		if streamCfg != nil {
+			partitionOp.Params = append(partitionOp.Params, streamCfg)
 		}
 
-		// TODO: agg modifiers are DISTINCT, RESPECT|IGNORE NULLS, FROM FIRST|LAST.
-
 		rv = framesOp
+
+		// The navigation and numbering functions are evaluated over
+		// their window frames by a project that appends their vals
+		// as labels, before any re-sort by a later spec.
+		if len(stepValues) > 0 {
+			rv = WindowProject(framesOp, stepLabels, stepValues)
+		}
 	}
 
 	return c.TopSet(o, rv)
@@ -455,27 +957,81 @@ func (c *Conv) VisitWindowAggregate(o *plan.WindowAggregate) (interface{}, error
 // Project
 
 func (c *Conv) VisitInitialProject(o *plan.InitialProject) (interface{}, error) {
+	return c.Project(o, o.Terms())
+}
+
+// Project converts projection terms, where the aggregates of the
+// terms on top of a datastore-count, such as the COUNT(*) of
+// `COUNT(*) + 1`, are first relabeled from the "^count".
+func (c *Conv) Project(o plan.Operator, terms plan.ProjectTerms) (interface{}, error) {
+	if c.TopOp != nil && c.TopOp.Kind == "datastore-count" {
+		var aggs []algebra.Aggregate
+		for _, term := range terms {
+			aggs = ExprAggregates(term.Result().Expression(), aggs)
+		}
+
+		if len(aggs) > 0 {
+			op := &base.Op{Kind: "project"}
+
+			for _, agg := range aggs {
+				op.Labels = append(op.Labels, "^aggregates|"+agg.String())
+				op.Params = append(op.Params, []interface{}{"labelPath", "^count"})
+			}
+
+			c.TopPush(o, op)
+		}
+	}
+
 	op := &base.Op{
 		Kind:   "project",
-		Params: make([]interface{}, 0, len(o.Terms())),
+		Params: make([]interface{}, 0, len(terms)),
 	}
 
-	for _, term := range o.Terms() {
+	for _, term := range terms {
 		op.Labels = append(op.Labels, "."+LabelSuffix(term.Result().Alias()))
-		op.Params = append(op.Params,
-			[]interface{}{"exprStr", term.Result().Expression().String()})
+
+		expr := term.Result().Expression()
+
+		if agg, ok := expr.(algebra.Aggregate); ok &&
+			c.TopOp != nil && c.TopOp.Labels.IndexOf("^aggregates|"+agg.String()) >= 0 {
+			op.Params = append(op.Params,
+				[]interface{}{"labelPath", "^aggregates|" + agg.String()})
+		} else {
+			op.Params = append(op.Params, []interface{}{"exprStr", expr.String()})
+		}
 	}
 
 	return c.TopPush(o, op)
 }
 
+// ExprAggregates appends the distinct aggregates that are in an
+// expression tree.
+func ExprAggregates(e expression.Expression,
+	rv []algebra.Aggregate) []algebra.Aggregate {
+	if agg, ok := e.(algebra.Aggregate); ok {
+		for _, x := range rv {
+			if x.String() == agg.String() {
+				return rv
+			}
+		}
+
+		return append(rv, agg)
+	}
+
+	for _, child := range e.Children() {
+		rv = ExprAggregates(child, rv)
+	}
+
+	return rv
+}
+
 func (c *Conv) VisitFinalProject(o *plan.FinalProject) (interface{}, error) {
 	// TODO: Need to convert projections back into a SELF'ish single object?
 	return c.TopOp, nil
 }
 
 func (c *Conv) VisitIndexCountProject(o *plan.IndexCountProject) (interface{}, error) {
-	return NA(o)
+	return c.Project(o, o.Terms())
 }
 
 // Distinct
@@ -488,23 +1044,126 @@ func (c *Conv) VisitDistinct(o *plan.Distinct) (interface{}, error) {
 		return c.TopOp, nil
 	}
 
+	// The distinct is keyed by all the labels, such as for a UNION
+	// (DISTINCT) of multiple projections.
+	exprs := make([]interface{}, 0, len(c.TopOp.Labels))
+	for _, label := range c.TopOp.Labels {
+		exprs = append(exprs, []interface{}{"labelPath", label})
+	}
+
 	return c.TopPush(o, &base.Op{
 		Kind:   "distinct",
 		Labels: c.TopOp.Labels,
-		Params: []interface{}{
-			[]interface{}{
-				// TODO: This expression might not be enough for the DISTINCT?
-				[]interface{}{"labelPath", c.TopOp.Labels[0]},
-			},
-		},
+		Params: []interface{}{exprs},
 	})
 }
 
 // Set operators
 
-func (c *Conv) VisitUnionAll(o *plan.UnionAll) (interface{}, error)         { return NA(o) }
-func (c *Conv) VisitIntersectAll(o *plan.IntersectAll) (interface{}, error) { return NA(o) }
-func (c *Conv) VisitExceptAll(o *plan.ExceptAll) (interface{}, error)       { return NA(o) }
+// VisitUnionAll converts a UNION ALL, where the union-all op remaps
+// the vals of each child by label. The planner represents a UNION
+// (DISTINCT) as a UnionAll followed by a Distinct.
+func (c *Conv) VisitUnionAll(o *plan.UnionAll) (interface{}, error) {
+	var children []*base.Op
+
+	for _, child := range o.Children() {
+		op, err := c.ConvChild(child)
+		if err != nil {
+			return nil, err
+		}
+
+		children = append(children, op)
+	}
+
+	return c.TopSet(o, &base.Op{
+		Kind:     "union-all",
+		Labels:   SetOpLabels(children),
+		Children: children,
+	})
+}
+
+func (c *Conv) VisitIntersectAll(o *plan.IntersectAll) (interface{}, error) {
+	return c.SetOp(o, "intersect", o.First(), o.Second(), o.Distinct())
+}
+
+func (c *Conv) VisitExceptAll(o *plan.ExceptAll) (interface{}, error) {
+	return c.SetOp(o, "except", o.First(), o.Second(), o.Distinct())
+}
+
+// SetOp converts an INTERSECT or EXCEPT, whose op compares the vals
+// of its children by position, so the children are first aligned to
+// the same labels.
+func (c *Conv) SetOp(o plan.Operator, kind string,
+	first, second plan.Operator, distinct bool) (interface{}, error) {
+	var children []*base.Op
+
+	for _, child := range []plan.Operator{first, second} {
+		op, err := c.ConvChild(child)
+		if err != nil {
+			return nil, err
+		}
+
+		children = append(children, op)
+	}
+
+	labels := SetOpLabels(children)
+
+	for i, child := range children {
+		children[i] = SetOpAlign(labels, child)
+	}
+
+	if distinct {
+		kind = kind + "-distinct"
+	} else {
+		kind = kind + "-all"
+	}
+
+	return c.TopSet(o, &base.Op{
+		Kind:     kind,
+		Labels:   labels,
+		Children: children,
+	})
+}
+
+// SetOpLabels returns the union of the labels of the children of a
+// set operator, in the order of their first appearance.
+func SetOpLabels(children []*base.Op) (rv base.Labels) {
+	for _, child := range children {
+		for _, label := range child.Labels {
+			if rv.IndexOf(label) < 0 {
+				rv = append(rv, label)
+			}
+		}
+	}
+
+	return rv
+}
+
+// SetOpAlign returns the op as-is if it already has the given labels,
+// or otherwise wraps it in a project that remaps its vals to the
+// given labels, where a label that the op doesn't have is MISSING.
+func SetOpAlign(labels base.Labels, op *base.Op) *base.Op {
+	aligned := len(labels) == len(op.Labels)
+	for i := 0; aligned && i < len(labels); i++ {
+		aligned = labels[i] == op.Labels[i]
+	}
+
+	if aligned {
+		return op
+	}
+
+	params := make([]interface{}, 0, len(labels))
+	for _, label := range labels {
+		params = append(params, []interface{}{"labelPath", label})
+	}
+
+	return &base.Op{
+		Kind:     "project",
+		Labels:   labels,
+		Params:   params,
+		Children: []*base.Op{op},
+	}
+}
 
 // Order, Paging
 
@@ -514,15 +1173,7 @@ func (c *Conv) VisitOrder(o *plan.Order) (interface{}, error) {
 	for _, term := range o.Terms() {
 		exprs = append(exprs, []interface{}{"exprStr", term.Expression().String()})
 
-		if term.Descending() {
-			dirs = append(dirs, "desc")
-		} else {
-			dirs = append(dirs, "asc")
-		}
-
-		if term.NullsPos() {
-			return NA(o) // TODO: One day handle non-natural nulls ordering.
-		}
+		dirs = append(dirs, SortTermDir(term))
 	}
 
 	return c.TopPush(o, &base.Op{
@@ -532,15 +1183,28 @@ func (c *Conv) VisitOrder(o *plan.Order) (interface{}, error) {
 	})
 }
 
+// OffsetLimitParam returns the order-offset-limit param of an OFFSET
+// or LIMIT expression, where a query parameter is resolved for each
+// execution, see n1k1.ParamInt64().
+func OffsetLimitParam(e expression.Expression, defval int64) interface{} {
+	if e != nil {
+		if x := ExprConv(nil, e); x[0] == "param" {
+			return x
+		}
+	}
+
+	return EvalExprInt64(nil, e, nil, defval)
+}
+
 func (c *Conv) VisitOffset(o *plan.Offset) (interface{}, error) {
-	offset := EvalExprInt64(nil, o.Expression(), nil, 0)
+	offset := OffsetLimitParam(o.Expression(), 0)
 
 	if c.TopOp != nil && c.TopOp.Kind == "order-offset-limit" {
 		for len(c.TopOp.Params) < 3 {
 			c.TopOp.Params = append(c.TopOp.Params, nil)
 		}
 
-		c.TopOp.Params[2] = int64(offset)
+		c.TopOp.Params[2] = offset
 
 		return c.TopOp, nil
 	}
@@ -548,19 +1212,19 @@ func (c *Conv) VisitOffset(o *plan.Offset) (interface{}, error) {
 	return c.TopPush(o, &base.Op{
 		Kind:   "order-offset-limit",
 		Labels: c.TopOp.Labels,
-		Params: []interface{}{nil, nil, int64(offset)},
+		Params: []interface{}{[]interface{}{}, []interface{}{}, offset},
 	})
 }
 
 func (c *Conv) VisitLimit(o *plan.Limit) (interface{}, error) {
-	limit := EvalExprInt64(nil, o.Expression(), nil, int64(math.MaxInt64))
+	limit := OffsetLimitParam(o.Expression(), int64(math.MaxInt64))
 
 	if c.TopOp != nil && c.TopOp.Kind == "order-offset-limit" {
 		for len(c.TopOp.Params) < 4 {
 			c.TopOp.Params = append(c.TopOp.Params, nil)
 		}
 
-		c.TopOp.Params[3] = int64(limit)
+		c.TopOp.Params[3] = limit
 
 		return c.TopOp, nil
 	}
@@ -568,20 +1232,265 @@ func (c *Conv) VisitLimit(o *plan.Limit) (interface{}, error) {
 	return c.TopPush(o, &base.Op{
 		Kind:   "order-offset-limit",
 		Labels: c.TopOp.Labels,
-		Params: []interface{}{nil, nil, int64(0), int64(limit)},
+		Params: []interface{}{[]interface{}{}, []interface{}{}, int64(0), limit},
 	})
 }
 
 // Mutations
 
-func (c *Conv) VisitSendInsert(o *plan.SendInsert) (interface{}, error) { return NA(o) }
-func (c *Conv) VisitSendUpsert(o *plan.SendUpsert) (interface{}, error) { return NA(o) }
-func (c *Conv) VisitSendDelete(o *plan.SendDelete) (interface{}, error) { return NA(o) }
-func (c *Conv) VisitClone(o *plan.Clone) (interface{}, error)           { return NA(o) }
-func (c *Conv) VisitSet(o *plan.Set) (interface{}, error)               { return NA(o) }
-func (c *Conv) VisitUnset(o *plan.Unset) (interface{}, error)           { return NA(o) }
-func (c *Conv) VisitSendUpdate(o *plan.SendUpdate) (interface{}, error) { return NA(o) }
-func (c *Conv) VisitMerge(o *plan.Merge) (interface{}, error)           { return NA(o) }
+// The sends of the mutations are converted into datastore-insert,
+// datastore-upsert, datastore-update and datastore-delete ops, which
+// write batches of their child's vals to the keyspace.
+
+func (c *Conv) VisitSendInsert(o *plan.SendInsert) (interface{}, error) {
+	return c.SendInsert(o, "datastore-insert", o.Alias(), o.Key(), o.Value(), o.Limit())
+}
+
+func (c *Conv) VisitSendUpsert(o *plan.SendUpsert) (interface{}, error) {
+	return c.SendInsert(o, "datastore-upsert", o.Alias(), o.Key(), o.Value(), o.Limit())
+}
+
+func (c *Conv) VisitSendDelete(o *plan.SendDelete) (interface{}, error) {
+	return c.SendMutation(o, "datastore-delete", o.Limit(), "^id")
+}
+
+func (c *Conv) VisitSendUpdate(o *plan.SendUpdate) (interface{}, error) {
+	return c.SendMutation(o, "datastore-update", o.Limit(),
+		"^id", "."+LabelSuffix(o.Alias()))
+}
+
+// SendInsert converts the send of an INSERT or UPSERT, where the key
+// and value are projected as the "^key" and alias labels. A nil key
+// or value means the "^key" or "^value" from a VALUES clause.
+func (c *Conv) SendInsert(o plan.Operator, kind, alias string,
+	key, value, limit expression.Expression) (interface{}, error) {
+	labels := c.TopOp.Labels
+
+	aliasLabel := "." + LabelSuffix(alias)
+
+	keyExpr := []interface{}{"labelPath", "^key"}
+	if key != nil {
+		keyExpr = ExprConv(labels, key)
+	}
+
+	valueExpr := []interface{}{"labelPath", "^value"}
+	if value != nil {
+		valueExpr = ExprConv(labels, value)
+	}
+
+	op := &base.Op{Kind: "project"}
+
+	for _, label := range labels {
+		if label != "^key" && label != aliasLabel {
+			op.Labels = append(op.Labels, label)
+			op.Params = append(op.Params, []interface{}{"labelPath", label})
+		}
+	}
+
+	op.Labels = append(op.Labels, "^key", aliasLabel)
+	op.Params = append(op.Params, keyExpr, valueExpr)
+
+	c.TopPush(o, op)
+
+	return c.SendMutation(o, kind, limit, "^key", aliasLabel)
+}
+
+// SendMutation pushes a datastore mutation op, whose params are the
+// vars.Temps slot of the plan and then the labels of the key and of
+// the value, if any.
+func (c *Conv) SendMutation(o plan.Operator, kind string,
+	limit expression.Expression, labels ...string) (interface{}, error) {
+	child := ScanLimit(c.TopOp, nil, limit)
+
+	params := []interface{}{c.AddTemp(o)}
+	for _, label := range labels {
+		params = append(params, label)
+	}
+
+	return c.TopSet(o, &base.Op{
+		Kind:     kind,
+		Labels:   child.Labels,
+		Params:   params,
+		Children: []*base.Op{child},
+	})
+}
+
+// VisitClone is a no-op, as the SET and UNSET of an UPDATE are
+// converted into setPath and unsetPath expressions, which produce
+// copies of the vals rather than modifying them in-place.
+func (c *Conv) VisitClone(o *plan.Clone) (interface{}, error) {
+	return c.TopOp, nil
+}
+
+func (c *Conv) VisitSet(o *plan.Set) (interface{}, error) {
+	var paths, values expression.Expressions
+
+	for _, term := range o.Node().Terms() {
+		if term.UpdateFor() != nil { // TODO: Support SET ... FOR.
+			return NA(o)
+		}
+
+		paths = append(paths, term.Path())
+		values = append(values, term.Value())
+	}
+
+	return c.SetPaths(o, paths, values)
+}
+
+func (c *Conv) VisitUnset(o *plan.Unset) (interface{}, error) {
+	var paths expression.Expressions
+
+	for _, term := range o.Node().Terms() {
+		if term.UpdateFor() != nil { // TODO: Support UNSET ... FOR.
+			return NA(o)
+		}
+
+		paths = append(paths, term.Path())
+	}
+
+	return c.SetPaths(o, paths, nil)
+}
+
+// SetPaths pushes a project that sets the field paths to the values,
+// or that unsets the field paths when there are no values, where
+// each path must start with a label, such as the alias of an UPDATE.
+func (c *Conv) SetPaths(o plan.Operator,
+	paths, values expression.Expressions) (interface{}, error) {
+	labels := c.TopOp.Labels
+
+	params := make([]interface{}, 0, len(labels))
+	for _, label := range labels {
+		params = append(params, []interface{}{"labelPath", label})
+	}
+
+	for i, path := range paths {
+		labelPath := ExprConvLabelPath(labels, path)
+		if len(labelPath) < 3 {
+			return NA(o)
+		}
+
+		j := labels.IndexOf(labelPath[1].(string))
+
+		if values != nil {
+			params[j] = append([]interface{}{"setPath",
+				params[j], ExprConv(labels, values[i])}, labelPath[2:]...)
+		} else {
+			params[j] = append([]interface{}{"unsetPath",
+				params[j]}, labelPath[2:]...)
+		}
+	}
+
+	return c.TopPush(o, &base.Op{
+		Kind:   "project",
+		Labels: labels,
+		Params: params,
+	})
+}
+
+// VisitMerge converts a MERGE into a left outer join of the source
+// with the target's documents by the merge key, which is captured and
+// then yielded to the update, delete and insert branches, where a
+// matched target goes to the update and delete branches and an
+// unmatched target goes to the insert branch.
+func (c *Conv) VisitMerge(o *plan.Merge) (interface{}, error) {
+	alias := o.KeyspaceRef().Alias()
+
+	aliasLabel := "." + LabelSuffix(alias)
+
+	// The source's "^id" and alias labels, if any, are dropped so
+	// they aren't confused with the target's.
+	var sourceLabels base.Labels
+	for _, label := range c.TopOp.Labels {
+		if label != "^id" && label != aliasLabel {
+			sourceLabels = append(sourceLabels, label)
+		}
+	}
+
+	source := ScanLimit(SetOpAlign(sourceLabels, c.TopOp), nil, o.Limit())
+
+	labels := append(append(base.Labels(nil), sourceLabels...), aliasLabel, "^id")
+
+	// Allocate a vars.Temps slot to hold evaluated keys.
+	varsTempsSlot := c.AddTemp(nil)
+
+	join := &base.Op{
+		Kind:   "joinKeys-leftOuter",
+		Labels: labels,
+		Params: []interface{}{
+			// The vars.Temps slot that holds evaluated keys.
+			varsTempsSlot,
+			// The expression that will evaluate to the keys.
+			ExprConv(sourceLabels, o.Key()),
+		},
+		Children: []*base.Op{
+			source,
+			&base.Op{
+				Kind:   "datastore-fetch",
+				Labels: base.Labels{aliasLabel, "^id"},
+				Params: []interface{}{c.AddTemp(o)},
+				Children: []*base.Op{&base.Op{
+					Kind:   "temp-yield-var",
+					Labels: base.Labels{"^id"},
+					Params: []interface{}{varsTempsSlot},
+				}},
+			}},
+	}
+
+	captureSlot := c.AddTemp(nil)
+
+	matched := expression.NewIsNotMissing(expression.NewIdentifier(alias))
+	unmatched := expression.NewIsMissing(expression.NewIdentifier(alias))
+
+	var branches []*base.Op
+
+	for _, b := range []struct {
+		p    plan.Operator
+		cond expression.Expression
+	}{
+		{o.Update(), matched},
+		{o.Delete(), matched},
+		{o.Insert(), unmatched},
+	} {
+		if b.p == nil {
+			continue
+		}
+
+		branch, err := c.ConvChildTop(b.p, &base.Op{
+			Kind:   "filter",
+			Labels: labels,
+			Params: ExprConv(labels, b.cond),
+			Children: []*base.Op{&base.Op{
+				Kind:   "temp-yield",
+				Labels: labels,
+				Params: []interface{}{captureSlot},
+			}},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		branches = append(branches, branch)
+	}
+
+	children := []*base.Op{&base.Op{
+		Kind:     "temp-capture",
+		Labels:   labels,
+		Params:   []interface{}{captureSlot},
+		Children: []*base.Op{join},
+	}}
+
+	branchLabels := SetOpLabels(branches)
+
+	for _, branch := range branches {
+		children = append(children, SetOpAlign(branchLabels, branch))
+	}
+
+	return c.TopSet(o, &base.Op{
+		Kind:     "sequence",
+		Labels:   branchLabels,
+		Children: children,
+	})
+}
 
 // Framework
 
@@ -609,7 +1518,16 @@ func (c *Conv) VisitSequence(o *plan.Sequence) (rv interface{}, err error) {
 	return c.TopOp, nil
 }
 
-func (c *Conv) VisitDiscard(o *plan.Discard) (interface{}, error) { return NA(o) }
+// VisitDiscard drops all the vals, such as the vals from the send of
+// a mutation that has no RETURNING clause.
+func (c *Conv) VisitDiscard(o *plan.Discard) (interface{}, error) {
+	return c.TopPush(o, &base.Op{
+		Kind:   "filter",
+		Labels: c.TopOp.Labels,
+		Params: []interface{}{"json", "false"},
+	})
+}
+
 func (c *Conv) VisitStream(o *plan.Stream) (interface{}, error)   { return NA(o) }
 func (c *Conv) VisitCollect(o *plan.Collect) (interface{}, error) { return NA(o) }
 
@@ -631,11 +1549,64 @@ func (c *Conv) VisitRevokeRole(o *plan.RevokeRole) (interface{}, error) { return
 
 // Explain
 
-func (c *Conv) VisitExplain(o *plan.Explain) (interface{}, error) { return NA(o) }
+// VisitExplain converts the plan to be explained, and then yields the
+// JSON explanation of the converted base.Op tree as a single val.
+func (c *Conv) VisitExplain(o *plan.Explain) (interface{}, error) {
+	op, err := c.ConvChild(o.Plan())
+	if err != nil {
+		return nil, err
+	}
+
+	j, err := json.Marshal(NewExplain(op, c.Temps))
+	if err != nil {
+		return nil, fmt.Errorf("VisitExplain, json.Marshal, err: %v", err)
+	}
+
+	return c.TopSet(o, &base.Op{
+		Kind:   "temp-yield-var",
+		Labels: base.Labels{"."},
+		Params: []interface{}{c.AddTemp(base.Val(j))},
+	})
+}
 
 // Prepare
 
-func (c *Conv) VisitPrepare(o *plan.Prepare) (interface{}, error) { return NA(o) }
+// VisitPrepare converts the plan of a PREPARE statement, with its own
+// Temps, which is put into the PreparedCache, if any. The prepared
+// statement's JSON is yielded as a single val.
+func (c *Conv) VisitPrepare(o *plan.Prepare) (interface{}, error) {
+	prepared := o.Plan()
+
+	cc := NewConv()
+	cc.PreparedCache, cc.Store, cc.Namespace =
+		c.PreparedCache, c.Store, c.Namespace
+
+	err := cc.Convert(prepared.Operator)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.PreparedCache != nil {
+		c.PreparedCache.Put(&Prepared{
+			Name:  prepared.Name(),
+			Text:  prepared.Text(),
+			Plan:  prepared.Operator,
+			Op:    cc.TopOp,
+			Temps: cc.Temps,
+		})
+	}
+
+	j, err := o.Prepared().MarshalJSON()
+	if err != nil {
+		return nil, fmt.Errorf("VisitPrepare, MarshalJSON, err: %v", err)
+	}
+
+	return c.TopSet(o, &base.Op{
+		Kind:   "temp-yield-var",
+		Labels: base.Labels{"."},
+		Params: []interface{}{c.AddTemp(base.Val(j))},
+	})
+}
 
 // Infer
 
@@ -0,0 +1,670 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the
+//  License. You may obtain a copy of the License at
+//  http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing,
+//  software distributed under the License is distributed on an "AS
+//  IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+//  express or implied. See the License for the specific language
+//  governing permissions and limitations under the License.
+
+package glue
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/couchbase/query/algebra"
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/value"
+
+	"github.com/couchbase/n1k1/base"
+)
+
+var DefaultWindowFrame = algebra.NewWindowFrame(algebra.WINDOW_FRAME_RANGE,
+	algebra.WindowFrameExtents{
+		algebra.NewWindowFrameExtent(nil, algebra.WINDOW_FRAME_UNBOUNDED_PRECEDING),
+		algebra.NewWindowFrameExtent(nil, algebra.WINDOW_FRAME_CURRENT_ROW),
+	})
+
+// -------------------------------------------------------------------
+
+// WindowSpec represents a distinct window specification of PARTITION
+// BY and ORDER BY, which is shared by one or more window aggregates.
+type WindowSpec struct {
+	PartitionBys []interface{} // The PARTITION BY exprs.
+
+	// The SortExprs are the PARTITION BY exprs followed by the ORDER
+	// BY exprs, where SortDirs has the same len as SortExprs.
+	SortExprs []interface{}
+	SortDirs  []interface{}
+
+	Aggs []algebra.Aggregate
+}
+
+// WindowSpecs groups the window aggregates by their distinct window
+// specs, in the order of first appearance. A reference to a named
+// WINDOW clause has already been resolved by the query rewriter into
+// the aggregate's WindowTerm, so aggregates that use the same named
+// window, or that spell out an equivalent spec, share a WindowSpec.
+func WindowSpecs(aggs algebra.Aggregates) (rv []*WindowSpec) {
+	specs := map[string]*WindowSpec{}
+
+	for _, agg := range aggs {
+		spec := &WindowSpec{}
+
+		if agg.WindowTerm() != nil {
+			for _, e := range agg.WindowTerm().PartitionBy() {
+				spec.PartitionBys = append(spec.PartitionBys,
+					[]interface{}{"exprStr", e.String()})
+			}
+
+			spec.SortExprs = append(spec.SortExprs, spec.PartitionBys...)
+			for range spec.PartitionBys {
+				spec.SortDirs = append(spec.SortDirs, "asc")
+			}
+
+			if agg.WindowTerm().OrderBy() != nil {
+				for _, term := range agg.WindowTerm().OrderBy().Terms() {
+					spec.SortExprs = append(spec.SortExprs,
+						[]interface{}{"exprStr", term.Expression().String()})
+					spec.SortDirs = append(spec.SortDirs, SortTermDir(term))
+				}
+			}
+		}
+
+		key := fmt.Sprintf("%v %v %d",
+			spec.SortExprs, spec.SortDirs, len(spec.PartitionBys))
+
+		prev, exists := specs[key]
+		if exists {
+			spec = prev
+		} else {
+			specs[key] = spec
+
+			rv = append(rv, spec)
+		}
+
+		spec.Aggs = append(spec.Aggs, agg)
+	}
+
+	return rv
+}
+
+// -------------------------------------------------------------------
+
+// SortTermDir returns the direction of a sort term as used by the
+// order-offset-limit operator, such as "asc" or "desc-nulls-first".
+func SortTermDir(term *algebra.SortTerm) string {
+	// The NullsPos() is true for the non-natural nulls ordering
+	// of ASC NULLS LAST or DESC NULLS FIRST.
+	if term.Descending() {
+		if term.NullsPos() {
+			return "desc-nulls-first"
+		}
+
+		return "desc"
+	}
+
+	if term.NullsPos() {
+		return "asc-nulls-last"
+	}
+
+	return "asc"
+}
+
+// SortSatisfies returns true when the vals yielded by the op are
+// known to be sorted by the given sort exprs and dirs, because the op
+// or one of its order-preserving descendants is an order-offset-limit
+// whose sort has the given sort as a prefix.
+func SortSatisfies(op *base.Op, exprs, dirs []interface{}) bool {
+	for op != nil {
+		switch op.Kind {
+		case "order-offset-limit":
+			opExprs := op.Params[0].([]interface{})
+			opDirs := op.Params[1].([]interface{})
+
+			return len(exprs) <= len(opExprs) &&
+				reflect.DeepEqual(exprs, opExprs[:len(exprs)]) &&
+				reflect.DeepEqual(dirs, opDirs[:len(dirs)])
+
+		case "window-partition", "window-frames", "filter":
+			// Order-preserving operators that keep the labels.
+
+		case "project", "let":
+			// Only a project that passes through its child's labels
+			// as a prefix, like a navigation functions project, or
+			// a let, which always does.
+			if len(op.Children) <= 0 ||
+				len(op.Labels) < len(op.Children[0].Labels) ||
+				!reflect.DeepEqual(op.Labels[:len(op.Children[0].Labels)],
+					op.Children[0].Labels) {
+				return false
+			}
+
+		default:
+			return false
+		}
+
+		if len(op.Children) <= 0 {
+			return false
+		}
+
+		op = op.Children[0]
+	}
+
+	return false
+}
+
+// -------------------------------------------------------------------
+
+// WindowProject returns a project op that passes through the labels
+// of its child as a prefix, so that SortSatisfies() sees through it,
+// and appends the vals of the given exprs as the given labels.
+func WindowProject(child *base.Op, labels base.Labels,
+	exprs []interface{}) *base.Op {
+	params := make([]interface{}, 0, len(child.Labels)+len(exprs))
+	for _, label := range child.Labels {
+		params = append(params, []interface{}{"labelPath", label})
+	}
+
+	return &base.Op{
+		Kind:     "project",
+		Labels:   append(append(base.Labels(nil), child.Labels...), labels...),
+		Params:   append(params, exprs...),
+		Children: []*base.Op{child},
+	}
+}
+
+// -------------------------------------------------------------------
+
+// WindowFrameOf returns the window frame of a window aggregate,
+// which defaults to RANGE BETWEEN UNBOUNDED PRECEDING AND CURRENT ROW.
+func WindowFrameOf(agg algebra.Aggregate) *algebra.WindowFrame {
+	if agg.WindowTerm() != nil && agg.WindowTerm().WindowFrame() != nil {
+		return agg.WindowTerm().WindowFrame()
+	}
+
+	return DefaultWindowFrame
+}
+
+// WindowFrameType returns the window-frames type for a window
+// aggregate. A RANGE frame whose extents are only CURRENT ROW or
+// UNBOUNDED is converted as a "groups" frame, as the peers of the
+// current row are then the rows with an equal ORDER BY val, which
+// works for non-numeric ORDER BY vals, too.
+func WindowFrameType(agg algebra.Aggregate) string {
+	if WindowNumberingNames[strings.ToLower(agg.Name())] {
+		return "rows"
+	}
+
+	wf := WindowFrameOf(agg)
+
+	if wf.HasModifier(algebra.WINDOW_FRAME_RANGE) {
+		for _, wfe := range wf.WindowFrameExtents() {
+			if wfe.ValueExpression() != nil {
+				return "range"
+			}
+		}
+
+		return "groups"
+	}
+
+	if wf.HasModifier(algebra.WINDOW_FRAME_GROUPS) {
+		return "groups"
+	}
+
+	return "rows"
+}
+
+// WindowFramePeers returns true when the window frame of a window
+// aggregate needs to find the peers of the current row, which are
+// identified by the tracked denseRank.
+func WindowFramePeers(agg algebra.Aggregate) bool {
+	if WindowFrameType(agg) == "groups" {
+		return true
+	}
+
+	if WindowNumberingNames[strings.ToLower(agg.Name())] {
+		return false
+	}
+
+	wf := WindowFrameOf(agg)
+
+	return wf.HasModifier(algebra.WINDOW_FRAME_EXCLUDE_GROUP) ||
+		wf.HasModifier(algebra.WINDOW_FRAME_EXCLUDE_TIES)
+}
+
+// WindowRangeExpr returns the ORDER BY expression that the "range"
+// frames of a spec's aggregates compare, or nil when there are no
+// "range" frames.
+func WindowRangeExpr(aggs []algebra.Aggregate) expression.Expression {
+	for _, agg := range aggs {
+		if WindowFrameType(agg) == "range" &&
+			agg.WindowTerm() != nil && agg.WindowTerm().OrderBy() != nil {
+			terms := agg.WindowTerm().OrderBy().Terms()
+			if len(terms) > 0 {
+				return terms[0].Expression()
+			}
+		}
+	}
+
+	return nil
+}
+
+// WindowFrameCfg returns the window-frames cfg for a window aggregate,
+// where the rangeIdx is the position of the val of the ORDER BY term
+// for a "range" frame, and the peersIdx is the position of the
+// tracked denseRank, which identifies the peers of the current row.
+func WindowFrameCfg(agg algebra.Aggregate, rangeIdx, peersIdx int) (
+	[]interface{}, error) {
+	// The numbering functions don't have a window frame clause and
+	// use only the current position, so a ROWS frame of only the
+	// current row is enough.
+	if WindowNumberingNames[strings.ToLower(agg.Name())] {
+		return []interface{}{"rows", "num", 0, "num", 0, "no-others", 0}, nil
+	}
+
+	// LEAD and LAG don't have a window frame clause and step from the
+	// current position by their offset.
+	if name := strings.ToLower(agg.Name()); name == "lead" || name == "lag" {
+		var n int64 = 1
+		if len(agg.Operands()) > 1 {
+			n = EvalExprInt64(nil, agg.Operands()[1], nil, 1)
+		}
+
+		if name == "lead" {
+			return []interface{}{"rows", "num", 0, "num", int(n), "no-others", 0}, nil
+		}
+
+		return []interface{}{"rows", "num", -int(n), "num", 0, "no-others", 0}, nil
+	}
+
+	wf := WindowFrameOf(agg)
+
+	frameType := WindowFrameType(agg)
+
+	frameCfg := []interface{}{frameType}
+
+	var rangeUnit string // The unit of the value extents.
+	var numValues int    // The # of value extents.
+
+	appendExtent := func(wfe *algebra.WindowFrameExtent) error {
+		if wfe.HasModifier(algebra.WINDOW_FRAME_CURRENT_ROW) {
+			frameCfg = append(frameCfg, "num", 0)
+		} else if wfe.HasModifier(algebra.WINDOW_FRAME_VALUE_PRECEDING) ||
+			wfe.HasModifier(algebra.WINDOW_FRAME_VALUE_FOLLOWING) {
+			n, unit, err := WindowFrameExtentNum(wfe, frameType,
+				wfe.HasModifier(algebra.WINDOW_FRAME_VALUE_PRECEDING))
+			if err != nil {
+				return err
+			}
+
+			if numValues > 0 && unit != rangeUnit {
+				return fmt.Errorf("WindowFrameCfg, mismatched"+
+					" range units, agg: %s", agg.String())
+			}
+
+			rangeUnit, numValues = unit, numValues+1
+
+			frameCfg = append(frameCfg, "num", n)
+		} else {
+			// Unbounded preceding or following.
+			frameCfg = append(frameCfg, "unbounded", 0)
+		}
+
+		return nil
+	}
+
+	wfes := wf.WindowFrameExtents()
+
+	err := appendExtent(wfes[0])
+	if err != nil {
+		return nil, err
+	}
+
+	if len(wfes) > 1 {
+		err = appendExtent(wfes[1])
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		// Default to CURRENT ROW for end.
+		frameCfg = append(frameCfg, "num", 0)
+	}
+
+	frameExclude := "no-others"
+	if wf.HasModifier(algebra.WINDOW_FRAME_EXCLUDE_CURRENT_ROW) {
+		frameExclude = "current-row"
+	} else if wf.HasModifier(algebra.WINDOW_FRAME_EXCLUDE_GROUP) {
+		frameExclude = "group"
+	} else if wf.HasModifier(algebra.WINDOW_FRAME_EXCLUDE_TIES) {
+		frameExclude = "ties"
+	}
+
+	frameCfg = append(frameCfg, frameExclude)
+
+	// The "range" type compares the val of the ORDER BY term, while
+	// the "groups" type and the excluded peers use the denseRank.
+	valIdx := 0
+	if frameType == "range" {
+		valIdx = rangeIdx
+	} else if WindowFramePeers(agg) {
+		valIdx = peersIdx
+	}
+
+	frameCfg = append(frameCfg, valIdx)
+
+	// The direction of the ORDER BY is needed for RANGE type.
+	frameDir := "asc"
+	if agg.WindowTerm() != nil && agg.WindowTerm().OrderBy() != nil {
+		terms := agg.WindowTerm().OrderBy().Terms()
+		if len(terms) > 0 && terms[0].Descending() {
+			frameDir = "desc"
+		}
+	}
+
+	frameCfg = append(frameCfg, frameDir)
+
+	if rangeUnit != "" {
+		frameCfg = append(frameCfg, rangeUnit)
+	}
+
+	return frameCfg, nil
+}
+
+// WindowFrameExtentNum returns the offset of a value PRECEDING or
+// FOLLOWING window frame extent, which is negative when preceding. A
+// "range" frame offset is a float64, or, for an ORDER BY of ISO-8601
+// date strings, a string of a number and a unit, like "7 days" or
+// "1 month", where the unit is also returned.
+func WindowFrameExtentNum(wfe *algebra.WindowFrameExtent,
+	frameType string, preceding bool) (interface{}, string, error) {
+	v, err := wfe.ValueExpression().Evaluate(nil, nil)
+	if err != nil {
+		return nil, "", err
+	}
+
+	sign := 1.0
+	if preceding {
+		sign = -1.0
+	}
+
+	if v.Type() == value.NUMBER {
+		f := v.(value.NumberValue).Float64()
+		if frameType == "range" {
+			return sign * f, "", nil
+		}
+
+		return int(sign) * int(f), "", nil
+	}
+
+	if s, ok := v.Actual().(string); ok && frameType == "range" {
+		parts := strings.Fields(s)
+		if len(parts) == 2 {
+			f, err := strconv.ParseFloat(parts[0], 64)
+			if err == nil {
+				unit := strings.TrimSuffix(strings.ToLower(parts[1]), "s")
+
+				_, exists := base.RangeUnitDurations[unit]
+				if exists || unit == "month" || unit == "year" {
+					return sign * f, unit, nil
+				}
+			}
+		}
+	}
+
+	return nil, "", fmt.Errorf("WindowFrameExtentNum,"+
+		" unsupported extent: %s", wfe.ValueExpression().String())
+}
+
+// -------------------------------------------------------------------
+
+// WindowTrack returns the info that the window-partition needs to
+// track for the numbering functions and the peers of a spec's
+// aggregates, in the order that the window-partition appends the
+// tracked vals.
+func WindowTrack(aggs []algebra.Aggregate) (rv []string) {
+	needs := map[string]bool{}
+
+	for _, agg := range aggs {
+		if WindowFramePeers(agg) {
+			needs["denseRank"] = true
+		}
+
+		switch strings.ToLower(agg.Name()) {
+		case "rank":
+			needs["rank"] = true
+		case "dense_rank":
+			needs["denseRank"] = true
+		case "percent_rank":
+			needs["rank"], needs["partitionSize"] = true, true
+		case "cume_dist":
+			needs["peerEnd"], needs["partitionSize"] = true, true
+		case "ntile":
+			needs["partitionSize"] = true
+		}
+	}
+
+	for _, t := range []string{"rank", "denseRank", "partitionSize", "peerEnd"} {
+		if needs[t] {
+			rv = append(rv, t)
+		}
+	}
+
+	return rv
+}
+
+// WindowTrackLabel returns the label of a tracked info, like "rank",
+// of the window-partition in the given partitionSlot.
+func WindowTrackLabel(partitionSlot int, t string) string {
+	return fmt.Sprintf("^window%d|%s", partitionSlot, t)
+}
+
+// WindowTrackLabels returns the labels of the tracked infos.
+func WindowTrackLabels(partitionSlot int, track []string) (rv base.Labels) {
+	for _, t := range track {
+		rv = append(rv, WindowTrackLabel(partitionSlot, t))
+	}
+
+	return rv
+}
+
+// WindowNumberingNames are the lowercase names of the numbering
+// window functions.
+var WindowNumberingNames = map[string]bool{
+	"row_number":   true,
+	"rank":         true,
+	"dense_rank":   true,
+	"percent_rank": true,
+	"cume_dist":    true,
+	"ntile":        true,
+}
+
+// WindowNumbering returns the expr params for a numbering window
+// function (ROW_NUMBER, RANK, DENSE_RANK, PERCENT_RANK, CUME_DIST,
+// NTILE), or nil for other aggregates, where the labels are of the
+// window-frames that includes the tracked infos from WindowTrack().
+func WindowNumbering(agg algebra.Aggregate, labels base.Labels,
+	partitionSlot, framesSlot, frameIdx int) []interface{} {
+	label := func(t string) string {
+		return WindowTrackLabel(partitionSlot, t)
+	}
+
+	switch strings.ToLower(agg.Name()) {
+	case "row_number":
+		return []interface{}{"window-partition-row-number", framesSlot, frameIdx}
+	case "rank":
+		return []interface{}{"labelUint64", label("rank")}
+	case "dense_rank":
+		return []interface{}{"labelUint64", label("denseRank")}
+	case "percent_rank":
+		return []interface{}{"window-partition-percent-rank",
+			label("rank"), label("partitionSize")}
+	case "cume_dist":
+		return []interface{}{"window-partition-cume-dist",
+			label("peerEnd"), label("partitionSize")}
+	case "ntile":
+		if len(agg.Operands()) <= 0 {
+			return nil
+		}
+
+		return []interface{}{"window-partition-ntile", framesSlot, frameIdx,
+			ExprConv(labels, agg.Operands()[0]), label("partitionSize")}
+	}
+
+	return nil
+}
+
+// -------------------------------------------------------------------
+
+// WindowAggNames maps the lowercase names of the aggregate window
+// functions to their base.AggCatalog names.
+var WindowAggNames = map[string]string{
+	"count": "count",
+	"sum":   "sum",
+	"avg":   "avg",
+	"min":   "min",
+	"max":   "max",
+}
+
+// WindowAgg returns the expr params for an aggregate window function
+// (COUNT, SUM, AVG, MIN, MAX), or nil for other aggregates, where the
+// labels are of the window-frames. COUNT(*) counts the rows of the
+// window frame, while the others aggregate their operand's vals.
+func WindowAgg(agg algebra.Aggregate, labels base.Labels,
+	framesSlot, frameIdx int) []interface{} {
+	aggName, exists := WindowAggNames[strings.ToLower(agg.Name())]
+	if !exists || agg.HasFlags(algebra.AGGREGATE_DISTINCT) {
+		return nil
+	}
+
+	operands := agg.Operands()
+	if len(operands) <= 0 || operands[0] == nil {
+		if aggName != "count" {
+			return nil
+		}
+
+		return []interface{}{"window-frame-count", framesSlot, frameIdx}
+	}
+
+	return []interface{}{"window-frame-agg", framesSlot, frameIdx,
+		aggName, ExprConv(labels, operands[0])}
+}
+
+// WindowStreamCfg returns the stream cfg of [numPreceding,
+// numFollowing] for a window-partition, or nil when its window
+// partitions need to be materialized. The frameExprs are the
+// converted exprs of the spec's aggregates, with a nil for an
+// unconverted aggregate, and use the window frames of the framesCfg.
+// A frame of UNBOUNDED PRECEDING is streamable only for the
+// incremental window-frame-agg and window-frame-count exprs.
+func WindowStreamCfg(track []string, framesCfg []interface{},
+	frameExprs [][]interface{}) []interface{} {
+	for _, t := range track {
+		if t == "partitionSize" || t == "peerEnd" {
+			return nil
+		}
+	}
+
+	var numPreceding, numFollowing int64
+
+	frames := make([]base.WindowFrame, len(framesCfg))
+
+	for i, frameCfg := range framesCfg {
+		if frameExprs[i] == nil {
+			return nil
+		}
+
+		wf := &frames[i]
+
+		wf.Init(frameCfg, nil)
+
+		if wf.BegBoundary == base.WTokUnbounded {
+			kind := frameExprs[i][0]
+			if kind != "window-frame-agg" && kind != "window-frame-count" {
+				return nil
+			}
+		} else {
+			numPreceding = base.Int64Max(numPreceding, -wf.BegNum)
+			numFollowing = base.Int64Max(numFollowing, wf.BegNum)
+		}
+
+		numPreceding = base.Int64Max(numPreceding, -wf.EndNum)
+		numFollowing = base.Int64Max(numFollowing, wf.EndNum)
+	}
+
+	for i := range frames {
+		if !frames[i].Streamable(int(numPreceding), int(numFollowing)) {
+			return nil
+		}
+	}
+
+	return []interface{}{int(numPreceding), int(numFollowing)}
+}
+
+// -------------------------------------------------------------------
+
+// WindowStepValue returns the window-frame-step-value expr params
+// for a navigation window function (FIRST_VALUE, LAST_VALUE,
+// NTH_VALUE, LEAD, LAG), or nil for other aggregates. The aggregate's
+// IGNORE NULLS and FROM LAST modifiers are mapped to the expr's
+// optional modifiers.
+func WindowStepValue(agg algebra.Aggregate, framesSlot, frameIdx int) []interface{} {
+	operands := agg.Operands()
+	if len(operands) <= 0 {
+		return nil
+	}
+
+	var initial int
+	var asc bool
+	var num int64
+
+	name := strings.ToLower(agg.Name())
+
+	switch name {
+	case "first_value":
+		initial, asc, num = -1, true, 1
+	case "last_value":
+		initial, asc, num = 1, false, 1
+	case "nth_value":
+		initial, asc, num = -1, true, 1
+		if len(operands) > 1 {
+			num = EvalExprInt64(nil, operands[1], nil, 1)
+		}
+	case "lead", "lag":
+		// TODO: Handle the default val operand of LEAD and LAG.
+		initial, asc, num = 0, name == "lead", 1
+		if len(operands) > 1 {
+			num = EvalExprInt64(nil, operands[1], nil, 1)
+		}
+	default:
+		return nil
+	}
+
+	if num < 0 {
+		num = 0
+	}
+
+	var modifiers []string
+	if agg.HasFlags(algebra.AGGREGATE_IGNORENULLS) {
+		modifiers = append(modifiers, "ignoreNulls")
+	}
+	if agg.HasFlags(algebra.AGGREGATE_FROMLAST) {
+		modifiers = append(modifiers, "fromLast")
+	}
+
+	return []interface{}{
+		"window-frame-step-value",
+		framesSlot,
+		frameIdx,
+		initial,
+		asc,
+		uint64(num),
+		[]interface{}{"exprStr", operands[0].String()},
+		strings.Join(modifiers, ","),
+	}
+}
@@ -35,22 +35,34 @@ func ExecOp(o *base.Op, lzVars *base.Vars, lzYieldVals base.YieldVals,
 	case "project":
 		OpProject(o, lzVars, lzYieldVals, lzYieldErr, path, pathNext) // !lz
 
+	case "let":
+		OpLet(o, lzVars, lzYieldVals, lzYieldErr, path, pathNext) // !lz
+
 	case "order-offset-limit":
 		OpOrderOffsetLimit(o, lzVars, lzYieldVals, lzYieldErr, path, pathNext) // !lz
 
-	case "joinNL-inner", "joinNL-leftOuter", "joinKeys-inner", "joinKeys-leftOuter",
+	case "joinNL-inner", "joinNL-leftOuter", "joinNL-rightOuter", "joinNL-fullOuter",
+		"joinNL-cross", "joinKeys-inner", "joinKeys-leftOuter",
 		"nestNL-inner", "nestNL-leftOuter", "nestKeys-inner", "nestKeys-leftOuter",
 		"unnest-inner", "unnest-leftOuter":
 		OpJoinNestedLoop(o, lzVars, lzYieldVals, lzYieldErr, path, pathNext) // !lz
 
-	case "joinHash-inner", "joinHash-leftOuter",
+	case "joinHash-inner", "joinHash-leftOuter", "joinHash-rightOuter", "joinHash-fullOuter",
+		"joinHash-semi", "joinHash-anti",
+		"nestHash-inner", "nestHash-leftOuter",
 		"intersect-distinct", "intersect-all",
 		"except-distinct", "except-all":
 		OpJoinHash(o, lzVars, lzYieldVals, lzYieldErr, path, pathNext) // !lz
 
+	case "joinMerge-inner", "joinMerge-leftOuter", "joinMerge-fullOuter":
+		OpJoinMerge(o, lzVars, lzYieldVals, lzYieldErr, path, pathNext) // !lz
+
 	case "union-all":
 		OpUnionAll(o, lzVars, lzYieldVals, lzYieldErr, path, pathNext) // !lz
 
+	case "recursive-union":
+		OpRecursiveUnion(o, lzVars, lzYieldVals, lzYieldErr, path, pathNext) // !lz
+
 	case "group", "distinct":
 		OpGroup(o, lzVars, lzYieldVals, lzYieldErr, path, pathNext) // !lz
 
@@ -22,35 +22,127 @@ import (
 )
 
 // OpJoinHash implements...
-//  o.Kind:             info tracked in probe map values:  yieldsUnprobed:
-//   joinHash-inner      [                      leftVals ]  f
-//   joinHash-leftOuter  [ probeCount           leftVals ]  t
-//   intersect-all       [ probeCount leftCount          ]  f
-//   intersect-distinct  [ probeCount                    ]  f
-//   except-all          [ probeCount leftCount          ]  t
-//   except-distinct     [ probeCount                    ]  t
+//  o.Kind:             info tracked in probe map values:  yieldsUnprobed:  yieldsUnmatched:
+//   joinHash-inner      [                      leftVals ]  f                f
+//   joinHash-leftOuter  [ probeCount           leftVals ]  t                f
+//   joinHash-rightOuter [                      leftVals ]  f                t
+//   joinHash-fullOuter  [ probeCount           leftVals ]  t                t
+//   joinHash-semi       [                               ]  f                f
+//   joinHash-anti       [                               ]  f                t
+//   nestHash-inner      [                      leftVals ]  f                f
+//   nestHash-leftOuter  [                      leftVals ]  f                t
+//   intersect-all       [ probeCount leftCount          ]  f                f
+//   intersect-distinct  [ probeCount                    ]  f                f
+//   except-all          [ probeCount leftCount          ]  t                f
+//   except-distinct     [ probeCount                    ]  t                f
+//
+// The left side is the build side that fills the probe map, so the
+// yieldsUnprobed flag means the unprobed left vals are yielded after
+// the right side is done, with MISSING's for the right vals. The
+// yieldsUnmatched flag means the right vals that didn't find any
+// probe map entry are yielded immediately, with MISSING's for the
+// left vals.
+//
+// The semi-join and anti-join are the exception, where the right side
+// is instead the build side, whose probe map entries have only keys
+// and no vals. The left side then probes the probe map, where a
+// semi-join yields each left vals that has a match and an anti-join
+// yields each left vals that has no match, without any right vals.
+//
+// The hash-based NEST similarly builds the probe map from the right
+// side, where the chains in the lzChunks (which can spill to files)
+// hold only each right-side nest val. Each left vals is then yielded
+// with the JSON array of its key's chain of nest vals.
+//
+// The join key params of the joinHash and nestHash kinds are either a
+// single key expr or a list of key exprs, which is encoded as a
+// canonical composite key. The joinHash kinds also take an optional
+// residual expr as o.Params[2], which is evaluated on the joined vals
+// of each key match, so that a match also needs a true residual. For
+// the left outer joins, where a key can match without any of its left
+// vals passing the residual, the left vals that passed are tracked by
+// their chain item offsets in a separate map instead of a probeCount.
+//
+// The joinHash and nestHash kinds can also be partitioned, when
+// configured via o.Params[3]. See OpJoinHashPartitioned().
 func OpJoinHash(o *base.Op, lzVars *base.Vars, lzYieldVals base.YieldVals,
 	lzYieldErr base.YieldErr, path, pathNext string) {
 	kindParts := strings.Split(o.Kind, "-")
 
+	if (kindParts[0] == "joinHash" || kindParts[0] == "nestHash") &&
+		len(o.Params) > 3 && o.Params[3] != nil {
+		OpJoinHashPartitioned(o, lzVars, lzYieldVals, lzYieldErr, path, pathNext) // !lz
+
+		return
+	}
+
 	opIntersect := kindParts[0] == "intersect"
 
 	var exprLeft, exprRight []interface{}
 
 	// Analyze the Op's config according to the above table of flags.
-	var canonical, probeCount, leftCount, leftVals, yieldsUnprobed bool
+	var canonical, probeCount, leftCount, leftVals, yieldsUnprobed, yieldsUnmatched bool
+
+	// The build side fills the probe map and the probe side probes it.
+	childBuild, childProbe := o.Children[0], o.Children[1]
+
+	// The semi-join yields the probe side's vals on a match.
+	var yieldsMatched bool
+
+	// The NEST yields the probe side's vals with an array of the
+	// build side's nest vals.
+	var nest bool
+
+	// The optional residual expr of a joinHash.
+	var residual []interface{}
 
 	if kindParts[0] == "joinHash" {
-		exprLeft = o.Params[0].([]interface{})
-		exprRight = o.Params[1].([]interface{})
+		exprLeft, canonical = JoinKeysExpr(o.Params[0])
+		exprRight, _ = JoinKeysExpr(o.Params[1])
+
+		if len(o.Params) > 2 && o.Params[2] != nil {
+			residual = o.Params[2].([]interface{})
+		}
+
+		if kindParts[1] == "semi" || kindParts[1] == "anti" {
+			// The left side probes, so the exprLeft & exprRight are
+			// also swapped to follow the children.
+			childBuild, childProbe = o.Children[1], o.Children[0]
 
-		canonical = false
+			exprLeft, exprRight = exprRight, exprLeft
 
-		if kindParts[1] == "leftOuter" {
-			probeCount, yieldsUnprobed = true, true
+			yieldsMatched = kindParts[1] == "semi"
+			yieldsUnmatched = kindParts[1] == "anti"
+
+			// The residual is evaluated with the build side's vals.
+			leftVals = residual != nil
+		} else {
+			if kindParts[1] == "leftOuter" || kindParts[1] == "fullOuter" {
+				probeCount, yieldsUnprobed = true, true
+			}
+
+			if kindParts[1] == "rightOuter" || kindParts[1] == "fullOuter" {
+				yieldsUnmatched = true
+			}
+
+			leftVals = true
+
+			if residual != nil {
+				// The left vals that passed the residual are tracked
+				// instead of a probeCount.
+				probeCount = false
+			}
 		}
+	} else if kindParts[0] == "nestHash" {
+		// The left side probes, like for a semi-join.
+		childBuild, childProbe = o.Children[1], o.Children[0]
+
+		exprLeft, canonical = JoinKeysExpr(o.Params[1])
+		exprRight, _ = JoinKeysExpr(o.Params[0])
+
+		leftVals, nest = true, true
 
-		leftVals = true
+		yieldsUnmatched = kindParts[1] == "leftOuter"
 	} else {
 		// INTERSECT & EXCEPT canonicalize their incoming vals so
 		// they're usable as map lookup keys.
@@ -98,21 +190,55 @@ func OpJoinHash(o *base.Op, lzVars *base.Vars, lzYieldVals base.YieldVals,
 			}
 		}
 
+		// The offsets of the left vals that passed the residual.
+		var lzMatchedMap *store.RHStore
+		_ = lzMatchedMap
+
+		if residual != nil && yieldsUnprobed { // !lz
+			if lzErr == nil {
+				lzMatchedMap, lzErr = lzVars.Ctx.AllocMap()
+				if lzErr != nil {
+					lzYieldErr(lzErr)
+				}
+			}
+		} // !lz
+
 		var lzVal, lzValOut base.Val
 
 		var lzValsOut base.Vals
+		_ = lzValsOut
 
 		var lzProbeValNew, lzLeftBytes []byte
 
+		var lzNestBytes, lzNestScratch []byte // Used only when nest is true.
+
+		_, _ = lzNestBytes, lzNestScratch
+
 		var lzProbeCount uint64
 
 		_, _, _ = lzValOut, lzLeftBytes, lzProbeCount
 
 		exprLeftFunc :=
-			MakeExprFunc(lzVars, o.Children[0].Labels, exprLeft, pathNext, "JHL") // !lz
+			MakeExprFunc(lzVars, childBuild.Labels, exprLeft, pathNext, "JHL") // !lz
 
 		exprRightFunc :=
-			MakeExprFunc(lzVars, o.Children[1].Labels, exprRight, pathNext, "JHR") // !lz
+			MakeExprFunc(lzVars, childProbe.Labels, exprRight, pathNext, "JHR") // !lz
+
+		var residualFunc base.ExprFunc // !lz
+
+		if residual != nil { // !lz
+			// The residual is evaluated on the build side's vals
+			// followed by the probe side's vals.
+			labelsBP := append(append(base.Labels(nil), childBuild.Labels...), childProbe.Labels...) // !lz
+
+			residualFunc =
+				MakeExprFunc(lzVars, labelsBP, residual, pathNext, "JHX") // !lz
+		} // !lz
+
+		_ = residualFunc // !lz
+
+		// The semi-join and anti-join need only one residual match.
+		semiOrAnti := yieldsMatched || (yieldsUnmatched && childProbe == o.Children[0] && !nest) // !lz
 
 		EmitPush(pathNext, "JHF") // !lz
 
@@ -132,6 +258,17 @@ func OpJoinHash(o *base.Op, lzVars *base.Vars, lzYieldVals base.YieldVals,
 				lzValOut = lzProbeKey[:0]
 			} // !lz
 
+			if nest { // !lz
+				// NOTE: Assume right-side nest val will be in lzVals[-1],
+				// which is the only val that's kept, and a MISSING nest
+				// val is skipped.
+				if len(lzVals) > 0 && len(lzVals[len(lzVals)-1]) > 0 {
+					lzVals = lzVals[len(lzVals)-1:]
+				} else {
+					lzProbeKey = nil
+				}
+			} // !lz
+
 			// The probe key must be valued.
 			if lzErr == nil && base.ValHasValue(lzProbeKey) {
 				// Check if we have an entry for the probe key.
@@ -171,6 +308,7 @@ func OpJoinHash(o *base.Op, lzVars *base.Vars, lzYieldVals base.YieldVals,
 					lzProbeValNew = lzProbeValNew[:0]
 
 					lzProbeValOld := lzProbeVal
+					_ = lzProbeValOld
 
 					if probeCount { // !lz
 						lzProbeValNew = append(lzProbeValNew, lzZero16[:8]...)
@@ -219,7 +357,7 @@ func OpJoinHash(o *base.Op, lzVars *base.Vars, lzYieldVals base.YieldVals,
 
 		if lzErr == nil {
 			// Run the left side to fill the probe map.
-			ExecOp(o.Children[0], lzVars, lzYieldVals, lzYieldErr, pathNext, "JHL") // !lz
+			ExecOp(childBuild, lzVars, lzYieldVals, lzYieldErr, pathNext, "JHL") // !lz
 		}
 
 		// -----------------------------------------------------------
@@ -231,10 +369,71 @@ func OpJoinHash(o *base.Op, lzVars *base.Vars, lzYieldVals base.YieldVals,
 
 			EmitPush(pathNext, "JHP") // !lz
 
+			// The semi-join, anti-join and NEST yield no build side vals.
+			leftLabelsLen := 0                           // !lz
+			if leftVals && childBuild == o.Children[0] { // !lz
+				leftLabelsLen = len(childBuild.Labels) // !lz
+			} // !lz
+
+			lzLeftPrefix := make(base.Vals, leftLabelsLen)
+			_ = lzLeftPrefix
+
+			var lzOffsetKey [8]byte
+
+			var lzResidualMatched bool
+
+			var lzResidualVisitor, lzUnmatchedVisitor func(uint64, base.Vals) bool
+
+			_, _, _, _ = lzOffsetKey, lzResidualMatched, lzResidualVisitor, lzUnmatchedVisitor
+
+			if residual != nil { // !lz
+				// Callback for the joined vals of each key match.
+				lzResidualVisitor = func(lzOffset uint64, lzVals base.Vals) bool {
+					lzVal = residualFunc(lzVals, lzYieldErr) // <== emitCaptured: pathNext "JHX"
+					if !base.ValEqualTrue(lzVal) {
+						return true
+					}
+
+					lzResidualMatched = true
+
+					if yieldsUnprobed { // !lz
+						binary.LittleEndian.PutUint64(lzOffsetKey[:], lzOffset)
+
+						_, lzErrSet := lzMatchedMap.Set(store.Key(lzOffsetKey[:]), nil)
+						if lzErrSet != nil {
+							lzYieldErr(lzErrSet)
+						}
+					} // !lz
+
+					if !semiOrAnti { // !lz
+						lzYieldValsOrig(lzVals)
+					} // !lz
+
+					return !semiOrAnti
+				}
+
+				if yieldsUnprobed { // !lz
+					// Callback for each left vals of the probe map, which
+					// is yielded if it never passed the residual.
+					lzUnmatchedVisitor = func(lzOffset uint64, lzVals base.Vals) bool {
+						binary.LittleEndian.PutUint64(lzOffsetKey[:], lzOffset)
+
+						_, lzMatched := lzMatchedMap.Get(store.Key(lzOffsetKey[:]))
+						if !lzMatched {
+							lzYieldValsOrig(lzVals)
+						}
+
+						return true
+					}
+				} // !lz
+			} // !lz
+
 			// Callback for right side, which probes the probe map.
 			lzYieldVals = func(lzVals base.Vals) {
 				var lzErr error
 
+				var lzProbeKeyFound bool
+
 				// Prepare the probe key.
 				lzVal = exprRightFunc(lzVals, lzYieldErr) // <== emitCaptured: pathNext "JHR"
 
@@ -247,7 +446,11 @@ func OpJoinHash(o *base.Op, lzVars *base.Vars, lzYieldVals base.YieldVals,
 
 				// The probe key must be valued.
 				if lzErr == nil && base.ValHasValue(lzProbeKey) {
-					lzProbeVal, lzProbeKeyFound := lzMap.Get([]byte(lzProbeKey))
+					var lzProbeVal store.Val
+
+					lzProbeVal, lzProbeKeyFound = lzMap.Get([]byte(lzProbeKey))
+					_ = lzProbeVal
+
 					if lzProbeKeyFound {
 						if probeCount { // !lz
 							// Increment probe count.
@@ -280,25 +483,94 @@ func OpJoinHash(o *base.Op, lzVars *base.Vars, lzYieldVals base.YieldVals,
 							lzProbeVal = lzProbeVal[8:]
 						} // !lz
 
-						if leftVals { // !lz
+						if nest { // !lz
+							// Ex: nestHash-inner, nestHash-leftOuter.
+							lzNestBytes, lzNestScratch, lzErr = base.ChainedValsArray(
+								lzNestBytes[:0], lzChunks, lzProbeVal, lzNestScratch)
+							if lzErr != nil {
+								lzYieldErr(lzErr)
+							} else {
+								lzValsOut = append(lzValsOut[:0], lzVals...)
+								lzValsOut = append(lzValsOut, base.Val(lzNestBytes))
+
+								lzYieldValsOrig(lzValsOut)
+							}
+						} else if residual != nil { // !lz
+							// Ex: joinHash-inner with a residual.
+							lzResidualMatched = false
+
+							lzValsOut, lzErr = base.VisitChainedVals(lzResidualVisitor,
+								lzVals, lzChunks, lzProbeVal, lzValsOut)
+							if lzErr != nil {
+								lzYieldErr(lzErr)
+							}
+
+							// A key match without any residual match is
+							// treated as no match.
+							lzProbeKeyFound = lzResidualMatched
+
+							if yieldsMatched { // !lz
+								// Ex: joinHash-semi with a residual.
+								if lzProbeKeyFound {
+									lzYieldValsOrig(lzVals)
+								}
+							} // !lz
+						} else if leftVals { // !lz
 							// Ex: joinHash-inner, joinHash-leftOuter.
 							lzValsOut, lzErr = base.YieldChainedVals(lzYieldValsOrig,
 								lzVals, lzChunks, lzProbeVal, lzValsOut)
 							if lzErr != nil {
 								lzYieldErr(lzErr)
 							}
+						} else if yieldsMatched { // !lz
+							// Ex: joinHash-semi.
+							lzYieldValsOrig(lzVals)
 						} // !lz
 					}
 				}
+
+				if yieldsUnmatched { // !lz
+					// Ex: joinHash-rightOuter, joinHash-fullOuter, joinHash-anti,
+					// nestHash-leftOuter.
+					if lzErr == nil && !lzProbeKeyFound {
+						lzValsOut = append(lzValsOut[:0], lzLeftPrefix...)
+						lzValsOut = append(lzValsOut, lzVals...)
+
+						if nest { // !lz
+							lzValsOut = append(lzValsOut, base.ValArrayEmpty)
+						} // !lz
+
+						lzYieldValsOrig(lzValsOut)
+					}
+				} // !lz
 			}
 
 			lzYieldErr = func(lzErrIn error) {
 				if lzErrIn == nil {
 					// No error, so yield unprobed items if needed.
-					// Ex: joinHash-leftOuter, except.
+					// Ex: joinHash-leftOuter, joinHash-fullOuter, except.
+					if residual != nil && yieldsUnprobed { // !lz
+						rightLabelsLen := len(childProbe.Labels) // !lz
+
+						lzRightSuffix := make(base.Vals, rightLabelsLen)
+
+						// Callback for entries in the probe map.
+						lzMapVisitor := func(lzProbeKey store.Key, lzProbeVal store.Val) bool {
+							lzValsOut, lzErr = base.VisitChainedVals(lzUnmatchedVisitor,
+								lzRightSuffix, lzChunks, lzProbeVal, lzValsOut)
+							if lzErr != nil {
+								lzYieldErrOrig(lzErr)
+							}
+
+							return true
+						}
+
+						lzMap.Visit(lzMapVisitor)
+					} // !lz
+
 					if probeCount && yieldsUnprobed { // !lz
-						rightLabelsLen := len(o.Children[1].Labels) // !lz
-						_ = rightLabelsLen                          // !lz
+						rightLabelsLen := len(childProbe.Labels) // !lz
+						_ = rightLabelsLen                       // !lz
 
 						lzRightSuffix := make(base.Vals, rightLabelsLen)
 						_ = lzRightSuffix
@@ -323,7 +595,7 @@ func OpJoinHash(o *base.Op, lzVars *base.Vars, lzYieldVals base.YieldVals,
 								} // !lz
 
 								if leftVals { // !lz
-									// Ex: joinHash-leftOuter.
+									// Ex: joinHash-leftOuter, joinHash-fullOuter.
 									lzValsOut, lzErr = base.YieldChainedVals(lzYieldValsOrig,
 										lzRightSuffix, lzChunks, lzProbeVal, lzValsOut)
 									if lzErr != nil {
@@ -353,11 +625,153 @@ func OpJoinHash(o *base.Op, lzVars *base.Vars, lzYieldVals base.YieldVals,
 
 			if lzErr == nil {
 				// Run the right side to probe the probe map.
-				ExecOp(o.Children[1], lzVars, lzYieldVals, lzYieldErr, pathNext, "JHR") // !lz
+				ExecOp(childProbe, lzVars, lzYieldVals, lzYieldErr, pathNext, "JHR") // !lz
 			}
 		}
 
 		lzVars.Ctx.RecycleMap(lzMap)
+
+		if residual != nil && yieldsUnprobed { // !lz
+			lzVars.Ctx.RecycleMap(lzMatchedMap)
+		} // !lz
 		lzVars.Ctx.RecycleChunks(lzChunks)
 	}
 }
+
+// JoinKeysExpr returns the expr for a join key param, where a list of
+// key exprs, such as for a composite join key, is turned into a
+// keysEncodeCanonical expr whose result is already canonical.
+func JoinKeysExpr(param interface{}) (expr []interface{}, canonical bool) {
+	expr = param.([]interface{})
+
+	if len(expr) > 0 {
+		if _, ok := expr[0].(string); !ok {
+			return append([]interface{}{"keysEncodeCanonical"}, expr...), true
+		}
+	}
+
+	return expr, false
+}
+
+// -----------------------------------------------------
+
+// OpJoinHashPartitioned implements a partitioned (Grace) hash join,
+// for when the build side is far larger than memory, where random
+// access probing into a spilled probe map would be slow. Both sides
+// are first hashed by their join keys into partitions, which spill
+// sequentially to temp files in the Ctx.TempDir when they exceed a
+// memory budget. Then, each pair of left and right partitions, which
+// have the same range of keys, is joined by a non-partitioned hash
+// join, whose children are temp-yield's of the pair of partitions.
+//
+// The o.Params[3] is the partitioning config of...
+//   [leftTempIdx, rightTempIdx, numPartitions, budgetBytes]
+// where the temp idx's are the vars.Temps slots for the partitions.
+func OpJoinHashPartitioned(o *base.Op, lzVars *base.Vars, lzYieldVals base.YieldVals,
+	lzYieldErr base.YieldErr, path, pathNext string) {
+	cfg := o.Params[3].([]interface{})
+
+	leftTempIdx := cfg[0].(int)
+	rightTempIdx := cfg[1].(int)
+	numPartitions := cfg[2].(int)
+	budgetBytes := cfg[3].(int)
+
+	exprLeft, canonical := JoinKeysExpr(o.Params[0])
+	exprRight, _ := JoinKeysExpr(o.Params[1])
+
+	// The temp file name prefixes of the partitions.
+	prefixLeft, prefixRight := "jhpl", "jhpr"
+
+	// The non-partitioned hash join of a pair of partitions.
+	opPair := &base.Op{
+		Kind:   o.Kind,
+		Labels: o.Labels,
+		Params: o.Params[:3],
+		Children: []*base.Op{&base.Op{
+			Kind:   "temp-yield",
+			Labels: o.Children[0].Labels,
+			Params: []interface{}{leftTempIdx},
+		}, &base.Op{
+			Kind:   "temp-yield",
+			Labels: o.Children[1].Labels,
+			Params: []interface{}{rightTempIdx},
+		}},
+	}
+
+	if LzScope {
+		var lzErr error
+
+		lzYieldErrOrig := lzYieldErr
+
+		// Captures the first error, as the children and each
+		// partition pair's join are expected to yield nil errors
+		// when they're done.
+		lzYieldErr = func(lzErrIn error) {
+			if lzErr == nil {
+				lzErr = lzErrIn
+			}
+		}
+
+		lzYieldErrCapture := lzYieldErr
+
+		lzPartsLeft := base.NewPartitions(lzVars.Ctx.TempDir, prefixLeft, numPartitions, budgetBytes)
+		lzPartsRight := base.NewPartitions(lzVars.Ctx.TempDir, prefixRight, numPartitions, budgetBytes)
+
+		exprLeftFunc :=
+			MakeExprFunc(lzVars, o.Children[0].Labels, exprLeft, pathNext, "JHPL") // !lz
+
+		exprRightFunc :=
+			MakeExprFunc(lzVars, o.Children[1].Labels, exprRight, pathNext, "JHPR") // !lz
+
+		var lzVal base.Val
+
+		lzYieldValsOrig := lzYieldVals
+
+		// Callback for left side, which fills the left partitions.
+		lzYieldVals = func(lzVals base.Vals) {
+			if lzErr == nil {
+				lzVal = exprLeftFunc(lzVals, lzYieldErr) // <== emitCaptured: pathNext "JHPL"
+
+				lzErr = lzPartsLeft.Add(lzVal, canonical, lzVals, lzVars.Ctx.ValComparer)
+			}
+		}
+
+		ExecOp(o.Children[0], lzVars, lzYieldVals, lzYieldErr, pathNext, "JHPLO") // !lz
+
+		// Callback for right side, which fills the right partitions.
+		lzYieldVals = func(lzVals base.Vals) {
+			if lzErr == nil {
+				lzVal = exprRightFunc(lzVals, lzYieldErr) // <== emitCaptured: pathNext "JHPR"
+
+				lzErr = lzPartsRight.Add(lzVal, canonical, lzVals, lzVars.Ctx.ValComparer)
+			}
+		}
+
+		if lzErr == nil {
+			ExecOp(o.Children[1], lzVars, lzYieldVals, lzYieldErr, pathNext, "JHPRO") // !lz
+		}
+
+		lzYieldVals = lzYieldValsOrig
+
+		for lzI := 0; lzI < numPartitions && lzErr == nil; lzI++ {
+			// Any previous pair of partitions are closed by TempSet().
+			lzVars.TempSet(leftTempIdx, lzPartsLeft.Parts[lzI])
+			lzVars.TempSet(rightTempIdx, lzPartsRight.Parts[lzI])
+
+			// Restore the callbacks, which might have been replaced
+			// by the previous partition pair's join when compiled.
+			lzYieldVals = lzYieldValsOrig
+			lzYieldErr = lzYieldErrCapture
+
+			ExecOp(opPair, lzVars, lzYieldVals, lzYieldErr, pathNext, "JHPP") // !lz
+		}
+
+		lzVars.TempSet(leftTempIdx, nil)
+		lzVars.TempSet(rightTempIdx, nil)
+
+		lzPartsLeft.Close()
+		lzPartsRight.Close()
+
+		lzYieldErrOrig(lzErr)
+	}
+}
@@ -0,0 +1,220 @@
+//  Copyright (c) 2019 Couchbase, Inc.
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the
+//  License. You may obtain a copy of the License at
+//  http://www.apache.org/licenses/LICENSE-2.0
+//  Unless required by applicable law or agreed to in writing,
+//  software distributed under the License is distributed on an "AS
+//  IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+//  express or implied. See the License for the specific language
+//  governing permissions and limitations under the License.
+
+package n1k1
+
+import (
+	"strings"
+
+	"github.com/couchbase/n1k1/base"
+)
+
+// OpJoinMerge implements...
+//  o.Kind:             via flags that control if-else codepaths:
+//   joinMerge-inner
+//   joinMerge-leftOuter  isLeftOuter
+//   joinMerge-fullOuter  isLeftOuter isFullOuter
+//
+// Both children must yield vals that are already sorted in ascending
+// order by their join key exprs, o.Params[0] for the left side and
+// o.Params[1] for the right side, as compared by the ValComparer.
+//
+// The left side pushes its vals as usual, while the right side is
+// run by a concurrent actor whose vals are pulled in lockstep via a
+// base.StageCursor. A run of right vals that have the same key is
+// buffered in a heap data structure (used merely as an appendable
+// sequence of []byte items) so that the run can be joined with each
+// of the left vals that have the same key, with bounded memory
+// usage when there are no large runs.
+func OpJoinMerge(o *base.Op, lzVars *base.Vars, lzYieldVals base.YieldVals,
+	lzYieldErr base.YieldErr, path, pathNext string) {
+	joinKind := strings.Split(o.Kind, "-")
+
+	isLeftOuter := joinKind[1] == "leftOuter" || joinKind[1] == "fullOuter"
+	isFullOuter := joinKind[1] == "fullOuter"
+
+	lenLabelsA := len(o.Children[0].Labels) // "A" means left side.
+	lenLabelsB := len(o.Children[1].Labels) // "B" means right side.
+
+	exprLeft := o.Params[0].([]interface{})
+	exprRight := o.Params[1].([]interface{})
+
+	if LzScope {
+		var lzErr error
+
+		lzYieldErrOrig := lzYieldErr
+
+		lzYieldErr = func(lzErrIn error) {
+			if lzErr == nil {
+				lzErr = lzErrIn // Capture the incoming error.
+			}
+		}
+
+		lzHeap, lzErr := lzVars.Ctx.AllocHeap()
+		if lzErr == nil {
+			exprLeftFunc :=
+				MakeExprFunc(lzVars, o.Children[0].Labels, exprLeft, pathNext, "JML") // !lz
+
+			exprRightFunc :=
+				MakeExprFunc(lzVars, o.Children[1].Labels, exprRight, pathNext, "JMR") // !lz
+
+			// The right side is run by a concurrent actor.
+			lzStage := base.NewStage(1, 0, lzVars, nil, nil)
+
+			lzActorFunc := func(lzVars *base.Vars, lzYieldVals base.YieldVals, lzYieldErr base.YieldErr, lzActorData interface{}) {
+				lzVars = lzVars.ChainExtend() // For concurrent usage.
+
+				ExecOp(o.Children[1], lzVars, lzYieldVals, lzYieldErr, pathNext, "JMRO") // !lz
+			}
+
+			// TODO: Configure actor batch size.
+			lzStage.StartActor(lzActorFunc, nil, 256)
+
+			lzCursor := base.NewStageCursor(lzStage)
+
+			var lzVal base.Val
+
+			var lzValsB base.Vals // The current right vals, or nil when done.
+
+			var lzKeyB base.Val // The join key of the current right vals.
+
+			var lzRunKey []byte // The join key of the buffered run.
+
+			var lzHasRun bool
+
+			var lzValsJoin, lzValsRun base.Vals
+
+			var lzBytes, lzEncoded []byte
+
+			lzValsMissingA := make(base.Vals, lenLabelsA)
+			lzValsMissingB := make(base.Vals, lenLabelsB)
+
+			_, _ = lzValsMissingA, lzValsMissingB
+
+			lzYieldValsOrig := lzYieldVals
+
+			// Advances to the next right vals.
+			lzRightNext := func() {
+				lzValsB = lzCursor.Next()
+				if lzValsB != nil {
+					lzVals := lzValsB
+
+					lzVal = exprRightFunc(lzVals, lzYieldErr) // <== emitCaptured: pathNext "JMR"
+
+					lzKeyB = lzVal
+				}
+			}
+
+			// Yields the current right vals as unmatched, and advances.
+			lzRightUnmatched := func() {
+				if isFullOuter { // !lz
+					lzValsJoin = append(lzValsJoin[:0], lzValsMissingA...)
+					lzValsJoin = append(lzValsJoin, lzValsB...)
+
+					lzYieldValsOrig(lzValsJoin)
+				} // !lz
+
+				lzRightNext()
+			}
+
+			lzRightNext()
+
+			lzYieldVals = func(lzVals base.Vals) {
+				if lzErr != nil {
+					return
+				}
+
+				lzVal = exprLeftFunc(lzVals, lzYieldErr) // <== emitCaptured: pathNext "JML"
+
+				lzKeyA := lzVal
+
+				// A non-valued key never matches.
+				lzMatched := base.ValHasValue(lzKeyA)
+				if lzMatched {
+					if !lzHasRun || lzVars.Ctx.ValComparer.Compare(lzKeyA, lzRunKey) != 0 {
+						// The left key moved past the buffered run.
+						lzHasRun = false
+
+						lzHeap.Reset()
+
+						// Skip over any right vals with smaller keys.
+						for lzValsB != nil && (!base.ValHasValue(lzKeyB) ||
+							lzVars.Ctx.ValComparer.Compare(lzKeyB, lzKeyA) < 0) {
+							lzRightUnmatched()
+						}
+
+						// Buffer the run of right vals with the same key.
+						if lzValsB != nil && lzVars.Ctx.ValComparer.Compare(lzKeyB, lzKeyA) == 0 {
+							lzRunKey = append(lzRunKey[:0], lzKeyA...)
+
+							lzHasRun = true
+
+							for lzValsB != nil && lzErr == nil &&
+								lzVars.Ctx.ValComparer.Compare(lzKeyB, lzRunKey) == 0 {
+								lzEncoded = base.ValsEncode(lzValsB, lzEncoded[:0])
+
+								lzErr = lzHeap.PushBytes(lzEncoded)
+
+								lzRightNext()
+							}
+						}
+					}
+
+					lzMatched = lzHasRun
+				}
+
+				if lzMatched && lzErr == nil {
+					for lzI := int64(0); lzI < lzHeap.CurItems && lzErr == nil; lzI++ {
+						lzBytes, lzErr = lzHeap.Get(lzI)
+						if lzErr == nil {
+							lzValsRun = base.ValsDecode(lzBytes, lzValsRun[:0])
+
+							lzValsJoin = append(lzValsJoin[:0], lzVals...)
+							lzValsJoin = append(lzValsJoin, lzValsRun...)
+
+							lzYieldValsOrig(lzValsJoin)
+						}
+					}
+				}
+
+				if isLeftOuter { // !lz
+					if !lzMatched && lzErr == nil {
+						lzValsJoin = append(lzValsJoin[:0], lzVals...)
+						lzValsJoin = append(lzValsJoin, lzValsMissingB...)
+
+						lzYieldValsOrig(lzValsJoin)
+					}
+				} // !lz
+			}
+
+			// The left driver.
+			ExecOp(o.Children[0], lzVars, lzYieldVals, lzYieldErr, pathNext, "JMLO") // !lz
+
+			// Case of fullOuter join, the remaining right vals are
+			// unmatched.
+			if isFullOuter { // !lz
+				for lzValsB != nil && lzErr == nil {
+					lzRightUnmatched()
+				}
+			} // !lz
+
+			// Drain the right side so that its actor can finish.
+			lzErrDrain := lzCursor.Drain()
+			if lzErr == nil {
+				lzErr = lzErrDrain
+			}
+
+			lzVars.Ctx.RecycleHeap(lzHeap)
+		}
+
+		lzYieldErrOrig(lzErr)
+	}
+}
@@ -14,6 +14,8 @@ package n1k1
 import (
 	"strings"
 
+	"github.com/couchbase/rhmap/store" // <== genCompiler:hide
+
 	"github.com/couchbase/n1k1/base"
 )
 
@@ -21,6 +23,9 @@ import (
 //  o.Kind:             via flags that control if-else codepaths:
 //   joinNL-inner
 //   joinNL-leftOuter                           isLeftOuter
+//   joinNL-rightOuter                                      isRightOuter
+//   joinNL-fullOuter                           isLeftOuter isRightOuter
+//   joinNL-cross                                           isCross
 //   joinKeys-inner                      isKeys
 //   joinKeys-leftOuter                  isKeys isLeftOuter
 //   nestNL-inner        isNest
@@ -29,6 +34,10 @@ import (
 //   nestKeys-leftOuter  isNest          isKeys isLeftOuter
 //   unnest-inner               isUnnest
 //   unnest-leftOuter           isUnnest        isLeftOuter
+//
+// The isRightOuter joins execute the right side only once, into a
+// heap where each entry is a matched flag byte followed by the
+// encoded right-side vals, and the matched flag is updated in place.
 func OpJoinNestedLoop(o *base.Op, lzVars *base.Vars, lzYieldVals base.YieldVals,
 	lzYieldErr base.YieldErr, path, pathNext string) {
 	var lzErr error
@@ -47,7 +56,9 @@ func OpJoinNestedLoop(o *base.Op, lzVars *base.Vars, lzYieldVals base.YieldVals,
 	isKeys := strings.HasSuffix(joinKind[0], "Keys") // Ex: "joinKeys", "nestKeys".
 
 	isUnnest := joinKind[0] == "unnest"
-	isLeftOuter := joinKind[1] == "leftOuter"
+	isLeftOuter := joinKind[1] == "leftOuter" || joinKind[1] == "fullOuter"
+	isRightOuter := joinKind[1] == "rightOuter" || joinKind[1] == "fullOuter"
+	isCross := joinKind[1] == "cross"
 
 	lenLabelsA := len(o.Children[0].Labels) // "A" means left side.
 	lenLabelsB := len(o.Children[1].Labels) // "B" means right side.
@@ -68,8 +79,9 @@ func OpJoinNestedLoop(o *base.Op, lzVars *base.Vars, lzYieldVals base.YieldVals,
 		// UNNEST and ON KEYS evaluate the expr on the left-side vals only.
 		exprFunc =
 			MakeExprFunc(lzVars, o.Children[0].Labels, exprParams, pathNext, "JF") // !lz
-	} else {
-		// Other modes evaluate the expr on the fully joined left+right vals.
+	} else if !isCross {
+		// Other modes evaluate the expr on the fully joined left+right
+		// vals, except for CROSS join, which has no expr.
 		exprFunc =
 			MakeExprFunc(lzVars, labelsAB, exprParams, pathNext, "JF") // !lz
 	}
@@ -81,12 +93,42 @@ func OpJoinNestedLoop(o *base.Op, lzVars *base.Vars, lzYieldVals base.YieldVals,
 
 	var lzNestBytes []byte // Used only when isNest is true.
 
+	// The materialized right-side vals and the current right-side
+	// entry, which are used only during RIGHT OUTER joins.
+	var lzHeapB *store.Heap
+
+	var lzBytesB, lzEncodedB []byte
+
+	var lzValsB base.Vals
+
 	_, _, _, _ = exprFunc, lzHadInner, lzValsPre, lzNestBytes
 
+	_, _, _, _ = lzHeapB, lzBytesB, lzEncodedB, lzValsB
+
 	lzValsJoin := make(base.Vals, lenLabelsAB)
 
 	lzYieldValsOrig := lzYieldVals
 
+	// Case of rightOuter join, where the right driver is executed
+	// only once, with its vals pushed into a heap with a cleared
+	// matched flag byte.
+	if isRightOuter { // !lz
+		lzHeapB, lzErr = lzVars.Ctx.AllocHeap()
+
+		lzYieldVals = func(lzValsB base.Vals) {
+			if lzErr == nil {
+				lzEncodedB = append(lzEncodedB[:0], 0)
+				lzEncodedB = base.ValsEncode(lzValsB, lzEncodedB)
+
+				lzErr = lzHeapB.PushBytes(lzEncodedB)
+			}
+		}
+
+		if lzErr == nil {
+			ExecOp(o.Children[1], lzVars, lzYieldVals, lzYieldErr, pathNext, "JNLB") // !lz
+		}
+	} // !lz
+
 	lzYieldVals = func(lzValsA base.Vals) {
 		if lzErr != nil {
 			return
@@ -112,7 +154,10 @@ func OpJoinNestedLoop(o *base.Op, lzVars *base.Vars, lzYieldVals base.YieldVals,
 
 			lzVals := lzValsJoin
 
-			if isUnnest || isKeys { // !lz
+			if isCross { // !lz
+				// CROSS join has a join condition that's always true.
+				lzVal = base.ValTrue
+			} else if isUnnest || isKeys { // !lz
 				// UNNEST is a self-join, so the join condition is always true.
 				//
 				// ON KEYS has the right-driver only providing items with keys
@@ -128,6 +173,10 @@ func OpJoinNestedLoop(o *base.Op, lzVars *base.Vars, lzYieldVals base.YieldVals,
 					lzHadInner = true
 				} // !lz
 
+				if isRightOuter { // !lz
+					lzBytesB[0] = 1 // Mark the right-side entry as matched.
+				} // !lz
+
 				if isNest { // !lz
 					// Append right-side val into lzNestBytes, comma separated.
 					//
@@ -170,6 +219,17 @@ func OpJoinNestedLoop(o *base.Op, lzVars *base.Vars, lzYieldVals base.YieldVals,
 
 				lzVars.Temps[o.Params[0].(int)] = nil
 			} // !lz
+		} else if isRightOuter { // !lz
+			// Case of rightOuter join, the right driver is the
+			// materialized right-side vals.
+			for lzI := int64(0); lzI < lzHeapB.CurItems && lzErr == nil; lzI++ {
+				lzBytesB, lzErr = lzHeapB.Get(lzI)
+				if lzErr == nil {
+					lzValsB = base.ValsDecode(lzBytesB[1:], lzValsB[:0])
+
+					lzYieldVals(lzValsB)
+				}
+			}
 		} else { // !lz
 			ExecOp(o.Children[1], lzVars, lzYieldVals, lzYieldErr, pathNext, "JNLI") // !lz
 		} // !lz
@@ -207,7 +267,33 @@ func OpJoinNestedLoop(o *base.Op, lzVars *base.Vars, lzYieldVals base.YieldVals,
 	}
 
 	// The left driver.
-	ExecOp(o.Children[0], lzVars, lzYieldVals, lzYieldErr, pathNext, "JNLO") // !lz
+	if lzErr == nil {
+		ExecOp(o.Children[0], lzVars, lzYieldVals, lzYieldErr, pathNext, "JNLO") // !lz
+	}
+
+	// Case of rightOuter join, where the materialized right-side
+	// vals are visited once more to yield its unmatched vals, even if
+	// the left driver was empty.
+	if isRightOuter { // !lz
+		for lzI := int64(0); lzI < lzHeapB.CurItems && lzErr == nil; lzI++ {
+			lzBytesB, lzErr = lzHeapB.Get(lzI)
+			if lzErr == nil && lzBytesB[0] == 0 {
+				lzValsJoin = lzValsJoin[:0]
+
+				for i := 0; i < lenLabelsA; i++ { // !lz
+					lzValsJoin = append(lzValsJoin, base.ValMissing)
+				} // !lz
+
+				lzValsJoin = base.ValsDecode(lzBytesB[1:], lzValsJoin)
+
+				lzYieldValsOrig(lzValsJoin)
+			}
+		}
+
+		if lzHeapB != nil {
+			lzVars.Ctx.RecycleHeap(lzHeapB)
+		}
+	} // !lz
 
 	lzYieldErrOrig(lzErr)
 }
@@ -23,19 +23,26 @@ func OpOrderOffsetLimit(o *base.Op, lzVars *base.Vars, lzYieldVals base.YieldVal
 	orders := o.Params[0].([]interface{}) // ORDER BY expressions.
 
 	// The directions has same len as orders, ex: ["asc", "desc", "asc"].
+	// NULL's and MISSING's are ordered first for "asc" and last for
+	// "desc", unless the direction is "asc-nulls-last" or
+	// "desc-nulls-first".
 	directions := o.Params[1].([]interface{})
 
 	offset := int64(0)
 
 	limit := int64(math.MaxInt64)
 
+	// The offset and limit are either an int64 or a param expression,
+	// such as from the LIMIT $n of a prepared statement.
+	var errParams error
+
 	if len(o.Params) >= 3 {
 		if o.Params[2] != nil {
-			offset = o.Params[2].(int64)
+			offset, errParams = ParamInt64(lzVars, o.Params[2], 0) // !lz
 		}
 
-		if len(o.Params) >= 4 && o.Params[3] != nil {
-			limit = o.Params[3].(int64)
+		if len(o.Params) >= 4 && o.Params[3] != nil && errParams == nil {
+			limit, errParams = ParamInt64(lzVars, o.Params[3], math.MaxInt64) // !lz
 		}
 	}
 
@@ -45,6 +52,15 @@ func OpOrderOffsetLimit(o *base.Op, lzVars *base.Vars, lzYieldVals base.YieldVal
 	}
 
 	if LzScope {
+		if errParams != nil { // !lz
+			// The query parameters of the offset or limit failed to
+			// be bound, so the error is yielded.
+			lzErr := lzVars.Ctx.BindParams()
+			if lzErr != nil {
+				lzYieldErr(lzErr)
+			}
+		} // !lz
+
 		pathNextOOL := EmitPush(pathNext, "OOL") // !lz
 
 		var lzProjectFunc base.ProjectFunc
@@ -204,9 +220,24 @@ func MakeValsLessFunc(lzVars *base.Vars, directions []interface{}) (
 			for idx := range directions { // !lz
 				direction := directions[idx] // !lz
 
-				lt, gt := true, false             // !lz
-				if direction.(string) == "desc" { // !lz
+				lt, gt := true, false       // !lz
+				nullsPos := false           // !lz
+				switch direction.(string) { // !lz
+				case "desc": // !lz
 					lt, gt = false, true // !lz
+				case "desc-nulls-first": // !lz
+					lt, gt, nullsPos = false, true, true // !lz
+				case "asc-nulls-last": // !lz
+					nullsPos = true // !lz
+				} // !lz
+
+				if nullsPos { // !lz
+					// The non-default nulls position overrides the
+					// direction only when exactly one val is a NULL or
+					// MISSING, where gt is also whether nulls go first.
+					if base.ValHasValue(lzValsA[idx]) != base.ValHasValue(lzValsB[idx]) {
+						return base.ValHasValue(lzValsB[idx]) == gt
+					}
 				} // !lz
 
 				lzCmp = lzVars.Ctx.ValComparer.Compare(lzValsA[idx], lzValsB[idx])
@@ -225,3 +256,32 @@ func MakeValsLessFunc(lzVars *base.Vars, directions []interface{}) (
 
 	return lzValsLessFunc
 }
+
+// -----------------------------------------------------
+
+// ParamInt64 returns the int64 of a param, which is either an int64
+// or a param expression that's resolved from the Ctx's bound query
+// parameters, otherwise the defval is returned, along with the error
+// when the query parameters failed to be bound.
+func ParamInt64(vars *base.Vars, param interface{}, defval int64) (int64, error) {
+	if x, ok := param.(int64); ok {
+		return x, nil
+	}
+
+	if x, ok := param.([]interface{}); ok && len(x) > 1 && x[0] == "param" {
+		pv, err := ExprParamVal(vars, x[1])
+		if err != nil {
+			return defval, err
+		}
+
+		v, vType := base.Parse(pv)
+		if base.ParseTypeToValType[vType] == base.ValTypeNumber {
+			f, err := base.ParseFloat64(v)
+			if err == nil {
+				return int64(f), nil
+			}
+		}
+	}
+
+	return defval, nil
+}
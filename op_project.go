@@ -47,6 +47,61 @@ func OpProject(o *base.Op, lzVars *base.Vars, lzYieldVals base.YieldVals,
 
 // -----------------------------------------------------
 
+// OpLet implements LET and LETTING, by evaluating the o.Params exprs
+// once per incoming vals and appending the results, so that later
+// operators can reference them via labelPath without re-evaluation.
+// The o.Labels are the child's labels followed by a label per expr,
+// and an expr can reference the labels of the earlier exprs. LETTING
+// is the same op, but on top of a group op.
+func OpLet(o *base.Op, lzVars *base.Vars, lzYieldVals base.YieldVals,
+	lzYieldErr base.YieldErr, path, pathNext string) {
+	if LzScope {
+		pathNextL := EmitPush(pathNext, "L") // !lz
+
+		var lzValsReuse base.Vals // <== varLift: lzValsReuse by path
+
+		numLabels := len(o.Children[0].Labels) // !lz
+
+		var exprFuncs []base.ExprFunc // !lz
+		var lzExprFunc base.ExprFunc  // !lz
+
+		for i, param := range o.Params { // !lz
+			lzExprFunc =
+				MakeExprFunc(lzVars, o.Labels[:numLabels+i], param.([]interface{}), pathNextL, strconv.Itoa(i)) // !lz
+
+			exprFuncs = append(exprFuncs, lzExprFunc) // !lz
+		} // !lz
+
+		lzYieldValsOrig := lzYieldVals
+
+		lzYieldVals = func(lzVals base.Vals) {
+			lzValsOut := append(lzValsReuse[:0], lzVals...)
+
+			for i := range exprFuncs { // !lz
+				if LzScope {
+					var lzVal base.Val
+
+					lzVals := lzValsOut // Includes the earlier exprs.
+
+					lzVal = exprFuncs[i](lzVals, lzYieldErr) // <== emitCaptured: pathNextL strconv.Itoa(i)
+
+					lzValsOut = append(lzValsOut, lzVal)
+				}
+			} // !lz
+
+			lzValsReuse = lzValsOut
+
+			lzYieldValsOrig(lzValsOut)
+		}
+
+		EmitPop(pathNext, "L") // !lz
+
+		ExecOp(o.Children[0], lzVars, lzYieldVals, lzYieldErr, pathNextL, "") // !lz
+	}
+}
+
+// -----------------------------------------------------
+
 func MakeProjectFunc(lzVars *base.Vars, labels base.Labels,
 	projections []interface{}, path, pathItem string) (
 	lzProjectFunc base.ProjectFunc) {
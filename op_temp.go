@@ -48,7 +48,8 @@ func OpTempCapture(o *base.Op, lzVars *base.Vars, lzYieldVals base.YieldVals,
 
 // -----------------------------------------------------
 
-// OpTempYield yields vals previously captured by OpTempCapture.
+// OpTempYield yields vals previously captured by OpTempCapture, or
+// the vals of a partition, such as from a partitioned hash join.
 func OpTempYield(o *base.Op, lzVars *base.Vars, lzYieldVals base.YieldVals,
 	lzYieldErr base.YieldErr, path, pathNext string) {
 	tempIdx := o.Params[0].(int)
@@ -70,6 +71,14 @@ func OpTempYield(o *base.Op, lzVars *base.Vars, lzYieldVals base.YieldVals,
 		}
 	}
 
+	lzPartition := lzVars.TempGetPartition(tempIdx)
+	if lzPartition != nil {
+		lzErr = lzPartition.Visit(lzYieldVals)
+		if lzErr != nil {
+			lzYieldErr(lzErr)
+		}
+	}
+
 	lzYieldErr(lzErr)
 }
 
@@ -14,6 +14,8 @@ package n1k1
 import (
 	"strconv"
 
+	"github.com/couchbase/rhmap/store" // <== genCompiler:hide
+
 	"github.com/couchbase/n1k1/base"
 )
 
@@ -94,3 +96,171 @@ func OpUnionAll(o *base.Op, lzVars *base.Vars, lzYieldVals base.YieldVals,
 
 	EmitPop(pathNext, "U") // !lz
 }
+
+// -----------------------------------------------------
+
+// OpRecursiveUnion implements WITH RECURSIVE, where o.Children[0] is
+// the anchor (non-recursive) part and o.Children[1] is the recursive
+// part. The recursive part reads the working table, which holds the
+// new vals from the previous iteration, via a temp-yield of the
+// vars.Temps slot at o.Params[0]. The iterations continue until an
+// iteration yields no new vals, or until there are more than
+// o.Params[1] iterations, which yields base.ErrMaxIterations. When
+// o.Params[2] is true, as with a UNION instead of a UNION ALL, vals
+// that were seen already are ignored, as tracked by a map keyed by
+// their canonical encoding. The optional o.Params[3] is a list of
+// cycle key exprs, like a CYCLE clause, where a val whose cycle key
+// was seen already is ignored even if the rest of the val is new.
+func OpRecursiveUnion(o *base.Op, lzVars *base.Vars, lzYieldVals base.YieldVals,
+	lzYieldErr base.YieldErr, path, pathNext string) {
+	workTempIdx := o.Params[0].(int)
+	maxIterations := o.Params[1].(int)
+	distinct := o.Params[2].(bool)
+
+	var cycleKeys []interface{}
+	if len(o.Params) > 3 && o.Params[3] != nil {
+		cycleKeys = o.Params[3].([]interface{})
+	}
+
+	if LzScope {
+		var lzErr error
+
+		lzYieldErrOrig := lzYieldErr
+
+		lzYieldErr = func(lzErrIn error) {
+			if lzErr == nil {
+				lzErr = lzErrIn // Capture the incoming error.
+			}
+		}
+
+		lzYieldErrCapture := lzYieldErr
+
+		var lzSeen *store.RHStore // The seen vals, for a UNION.
+
+		var lzSeenKey []byte
+
+		_ = lzSeenKey
+
+		if distinct { // !lz
+			lzSeen, lzErr = lzVars.Ctx.AllocMap()
+		} // !lz
+
+		var lzCycles *store.RHStore // The seen cycle keys.
+
+		var lzVal base.Val
+
+		_ = lzVal
+
+		var cycleKeyFunc base.ExprFunc // !lz
+
+		if cycleKeys != nil { // !lz
+			cycleKeyFunc =
+				MakeExprFunc(lzVars, o.Labels, append([]interface{}{"keysEncodeCanonicalAll"}, cycleKeys...), pathNext, "RUC") // !lz
+
+			if lzErr == nil {
+				lzCycles, lzErr = lzVars.Ctx.AllocMap()
+			}
+		} // !lz
+
+		_ = cycleKeyFunc // !lz
+
+		// The working table of the new vals from the current iteration.
+		var lzWork *store.Heap
+
+		if lzErr == nil {
+			lzWork, lzErr = lzVars.Ctx.AllocHeap()
+		}
+
+		var lzBytes []byte
+
+		lzYieldValsOrig := lzYieldVals
+
+		// Yields the vals if they're new, and appends them to the
+		// working table for the next iteration.
+		lzYieldVals = func(lzVals base.Vals) {
+			if lzErr != nil {
+				return
+			}
+
+			if distinct { // !lz
+				lzSeenKey, lzErr = base.ValsEncodeCanonical(lzVals, lzSeenKey[:0], lzVars.Ctx.ValComparer)
+				if lzErr != nil {
+					return
+				}
+
+				_, lzSeenFound := lzSeen.Get(store.Key(lzSeenKey))
+				if lzSeenFound {
+					return
+				}
+
+				_, lzErr = lzSeen.Set(store.Key(lzSeenKey), nil)
+				if lzErr != nil {
+					return
+				}
+			} // !lz
+
+			if cycleKeys != nil { // !lz
+				lzVal = cycleKeyFunc(lzVals, lzYieldErr) // <== emitCaptured: pathNext "RUC"
+
+				_, lzCycleFound := lzCycles.Get(store.Key(lzVal))
+				if lzCycleFound {
+					return
+				}
+
+				_, lzErr = lzCycles.Set(store.Key(lzVal), nil)
+				if lzErr != nil {
+					return
+				}
+			} // !lz
+
+			lzBytes = base.ValsEncode(lzVals, lzBytes[:0])
+
+			lzErr = lzWork.PushBytes(lzBytes)
+			if lzErr == nil {
+				lzYieldValsOrig(lzVals)
+			}
+		}
+
+		lzYieldValsCapture := lzYieldVals
+
+		if lzErr == nil {
+			ExecOp(o.Children[0], lzVars, lzYieldVals, lzYieldErr, pathNext, "RUA") // !lz
+		}
+
+		for lzIterations := 0; lzErr == nil && lzWork.CurItems > 0; lzIterations++ {
+			if lzIterations >= maxIterations {
+				lzErr = base.ErrMaxIterations
+			} else {
+				// The new vals become the working table, where
+				// TempSet() closes the previous working table.
+				lzVars.TempSet(workTempIdx, lzWork)
+
+				lzWork, lzErr = lzVars.Ctx.AllocHeap()
+				if lzErr == nil {
+					// Restore the callbacks, which might have been
+					// replaced by the previous iteration when compiled.
+					lzYieldVals = lzYieldValsCapture
+					lzYieldErr = lzYieldErrCapture
+
+					ExecOp(o.Children[1], lzVars, lzYieldVals, lzYieldErr, pathNext, "RUR") // !lz
+				}
+			}
+		}
+
+		if lzWork != nil {
+			lzVars.Ctx.RecycleHeap(lzWork)
+		}
+
+		lzVars.TempSet(workTempIdx, nil)
+
+		if lzSeen != nil {
+			lzVars.Ctx.RecycleMap(lzSeen)
+		}
+
+		if lzCycles != nil {
+			lzVars.Ctx.RecycleMap(lzCycles)
+		}
+
+		lzYieldErrOrig(lzErr)
+	}
+}
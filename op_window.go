@@ -19,8 +19,6 @@ import (
 	"strings"
 
 	"github.com/couchbase/n1k1/base"
-
-	"github.com/couchbase/rhmap/store" // <== genCompiler:hide
 )
 
 // OpWindowPartition maintains a current window partition in a vars
@@ -30,7 +28,9 @@ import (
 // expressions. When a vals from the next partition appears, all the
 // collected vals from the current partition are yielded before
 // reseting the current partition to reuse it as the next partition.
-// This operator can optionally track rank / numbering related info.
+// This operator can optionally track rank / numbering related info,
+// including info that's only known after the whole partition has
+// been seen, like the partition size or the end of a peer group.
 func OpWindowPartition(o *base.Op, lzVars *base.Vars, lzYieldVals base.YieldVals,
 	lzYieldErr base.YieldErr, path, pathNext string) {
 	partitionSlot := o.Params[0].(int) // Vars.Temps slot number.
@@ -45,7 +45,10 @@ func OpWindowPartition(o *base.Op, lzVars *base.Vars, lzYieldVals base.YieldVals
 
 	// The track config is a comma-separated list of additional
 	// information to track for each partition entry, such as
-	// different kinds of ranks and numberings.
+	// different kinds of ranks and numberings. The tracked vals are
+	// appended in the order of rank, denseRank, partitionSize and
+	// peerEnd, where peerEnd is the 1-based position of the last
+	// entry in the current entry's peer group.
 	track := o.Params[3].(string)
 
 	trackOn := len(track) > 0
@@ -54,6 +57,35 @@ func OpWindowPartition(o *base.Op, lzVars *base.Vars, lzYieldVals base.YieldVals
 
 	trackDenseRank := strings.Index(track, "denseRank") >= 0
 
+	trackPartitionSize := strings.Index(track, "partitionSize") >= 0
+
+	trackPeerEnd := strings.Index(track, "peerEnd") >= 0
+
+	// The optional stream config enables the streaming mode, which is
+	// ignored when the partitionSize or peerEnd are tracked, as
+	// they're known only after the whole partition has been seen.
+	streaming := len(o.Params) > 4 && o.Params[4] != nil &&
+		!trackPartitionSize && !trackPeerEnd
+
+	if streaming { // !lz
+		OpWindowPartitionStream(o, lzVars, lzYieldVals, lzYieldErr, path, pathNext) // !lz
+
+		return // !lz
+	} // !lz
+
+	// The peerEnd is found by scanning ahead for a rank change, so a
+	// rank is stored even when it's not tracked, and is hidden or
+	// removed from the yielded vals.
+	rankStore := trackRank || trackPeerEnd
+
+	rankHidden := rankStore && !trackRank
+
+	// The position of the stored rank in a partition entry.
+	rankIdx := len(o.Children[0].Labels)
+	if rankHidden && trackDenseRank {
+		rankIdx++
+	}
+
 	// A heap data structure is allocated but is used merely as an
 	// appendable sequence of []byte items, not as an actual heap.
 	lzHeap, lzErr := lzVars.Ctx.AllocHeap()
@@ -85,12 +117,70 @@ func OpWindowPartition(o *base.Op, lzVars *base.Vars, lzYieldVals base.YieldVals
 
 		var lzRank, lzDenseRank uint64
 
-		var lzBuf8Rank, lzBuf8DenseRank [8]byte
+		var lzBuf8Rank, lzBuf8DenseRank, lzBuf8PartitionSize, lzBuf8PeerEnd [8]byte
+
+		_, _, _, _ = lzRank, lzDenseRank, lzBuf8Rank, lzBuf8DenseRank
 
-		_, _ = lzBuf8Rank, lzBuf8DenseRank
+		_, _ = lzBuf8PartitionSize, lzBuf8PeerEnd
 
 		lzYieldValsOrig := lzYieldVals
 
+		// Yields all the collected vals of the current partition,
+		// appending any tracking info that's known only now.
+		lzPartitionYield := func() {
+			lzPartitionSize := uint64(lzHeap.CurItems)
+
+			var lzPeerEnd int64
+
+			_, _ = lzPartitionSize, lzPeerEnd
+
+			for lzI := int64(0); lzI < lzHeap.CurItems && lzErr == nil; lzI++ {
+				if trackPeerEnd { // !lz
+					if lzI >= lzPeerEnd {
+						// Scan ahead for the next peer group, whose
+						// first entry has a rank that equals its
+						// 1-based position.
+						for lzPeerEnd = lzI + 1; lzPeerEnd < lzHeap.CurItems && lzErr == nil; lzPeerEnd++ {
+							lzHeapBytes, lzErr = lzHeap.Get(lzPeerEnd)
+							if lzErr == nil {
+								lzValsOut = base.ValsDecode(lzHeapBytes, lzValsOut[:0])
+
+								if binary.LittleEndian.Uint64(lzValsOut[rankIdx]) == uint64(lzPeerEnd+1) {
+									break
+								}
+							}
+						}
+					}
+				} // !lz
+
+				if lzErr == nil {
+					lzHeapBytes, lzErr = lzHeap.Get(lzI)
+				}
+
+				if lzErr != nil {
+					lzYieldErr(lzErr)
+				} else {
+					lzValsOut = base.ValsDecode(lzHeapBytes, lzValsOut[:0])
+
+					if rankHidden { // !lz
+						lzValsOut = lzValsOut[:len(lzValsOut)-1]
+					} // !lz
+
+					if trackPartitionSize { // !lz
+						binary.LittleEndian.PutUint64(lzBuf8PartitionSize[:], lzPartitionSize)
+						lzValsOut = append(lzValsOut, base.Val(lzBuf8PartitionSize[:]))
+					} // !lz
+
+					if trackPeerEnd { // !lz
+						binary.LittleEndian.PutUint64(lzBuf8PeerEnd[:], uint64(lzPeerEnd))
+						lzValsOut = append(lzValsOut, base.Val(lzBuf8PeerEnd[:]))
+					} // !lz
+
+					lzYieldValsOrig(lzValsOut)
+				}
+			}
+		}
+
 		lzYieldVals = func(lzVals base.Vals) {
 			lzPartitionNext = lzPartitionNext[:0]
 			lzOrderNext = lzOrderNext[:0]
@@ -113,16 +203,7 @@ func OpWindowPartition(o *base.Op, lzVars *base.Vars, lzYieldVals base.YieldVals
 					// The incoming lzVals represents a new partition,
 					// so emit the current partition and reset the
 					// current partition before the next PushBytes().
-					for lzI := int64(0); lzI < lzHeap.CurItems && lzErr == nil; lzI++ {
-						lzHeapBytes, lzErr = lzHeap.Get(lzI)
-						if lzErr != nil {
-							lzYieldErr(lzErr)
-						} else {
-							lzValsOut = base.ValsDecode(lzHeapBytes, lzValsOut[:0])
-
-							lzYieldValsOrig(lzValsOut)
-						}
-					}
+					lzPartitionYield()
 
 					lzHeap.Reset()
 
@@ -141,7 +222,7 @@ func OpWindowPartition(o *base.Op, lzVars *base.Vars, lzYieldVals base.YieldVals
 					if !bytes.Equal(lzOrderCurr, lzOrderNext) {
 						lzOrderCurr = append(lzOrderCurr[:0], lzOrderNext...)
 
-						if trackRank { // !lz
+						if rankStore { // !lz
 							lzRank = uint64(lzHeap.Len()) + 1
 						} // !lz
 
@@ -162,6 +243,11 @@ func OpWindowPartition(o *base.Op, lzVars *base.Vars, lzYieldVals base.YieldVals
 						lzValsOut = append(lzValsOut, base.Val(lzBuf8DenseRank[:]))
 					} // !lz
 
+					if rankHidden { // !lz
+						binary.LittleEndian.PutUint64(lzBuf8Rank[:], lzRank)
+						lzValsOut = append(lzValsOut, base.Val(lzBuf8Rank[:]))
+					} // !lz
+
 					lzVals = lzValsOut
 				} // !lz
 
@@ -178,16 +264,186 @@ func OpWindowPartition(o *base.Op, lzVars *base.Vars, lzYieldVals base.YieldVals
 
 		ExecOp(o.Children[0], lzVars, lzYieldVals, lzYieldErr, pathNext, "WPC") // !lz
 
-		for lzI := int64(0); lzI < lzHeap.CurItems && lzErr == nil; lzI++ {
-			lzHeapBytes, lzErr = lzHeap.Get(lzI)
-			if lzErr != nil {
-				lzYieldErr(lzErr)
-			} else {
-				lzValsOut = base.ValsDecode(lzHeapBytes, lzValsOut[:0])
+		lzPartitionYield()
+	}
+}
+
+// -------------------------------------------------------------------
+
+// OpWindowPartitionStream is the streaming mode of OpWindowPartition,
+// which avoids materializing whole partitions when the window frames
+// are ROWS frames bounded by small PRECEDING and FOLLOWING offsets,
+// such as for ROW_NUMBER, RANK or moving aggregates over huge
+// partitions, or are the UNBOUNDED PRECEDING frames of incrementally
+// updated running totals, see WindowPartitionStreamCheck() and
+// ExprWindowFrameAgg(). Only the recent vals
+// of the current partition are kept in a base.WindowRing, and each
+// vals is yielded as soon as its following vals are seen.
+func OpWindowPartitionStream(o *base.Op, lzVars *base.Vars, lzYieldVals base.YieldVals,
+	lzYieldErr base.YieldErr, path, pathNext string) {
+	partitionSlot := o.Params[0].(int) // Vars.Temps slot number.
+
+	// PARTITION-BY & ORDER-BY expressions.
+	partitionExprs := o.Params[1].([]interface{}) // Can be 0 length.
+
+	partitionPrefix := o.Params[2].(int)
+
+	// Only the rank and denseRank tracking is supported when streaming.
+	track := o.Params[3].(string)
+
+	trackOn := len(track) > 0
+
+	trackRank := strings.Index(track, "rank") >= 0
+
+	trackDenseRank := strings.Index(track, "denseRank") >= 0
+
+	// The stream config is [numPreceding, numFollowing], which are
+	// the max number of vals before and after the current vals that
+	// the window frames will access, so ROWS BETWEEN 2 PRECEDING AND
+	// 1 FOLLOWING needs [2, 1]. A vals is yielded only after its
+	// numFollowing vals are seen or when its partition is complete.
+	streamCfg := o.Params[4].([]interface{})
+
+	numPreceding, numFollowing := streamCfg[0].(int), streamCfg[1].(int)
+
+	ringSize := numPreceding + 1 + numFollowing
+	if LzScope {
+		lzRing := base.NewWindowRing(ringSize)
+
+		// Incremented whenever we start a new partition.
+		var lzPartitionId uint64
+
+		lzRing.Extra = lzPartitionId
+
+		lzVars.TempSet(partitionSlot, lzRing)
+
+		pathNextWP := EmitPush(pathNext, "WP") // !lz
+
+		// The partitioning exprs are treated as a projection.
+		var partitionExprsFunc base.ProjectFunc // !lz
 
-				lzYieldValsOrig(lzValsOut)
+		if len(partitionExprs) > 0 { // !lz
+			partitionExprsFunc =
+				MakeProjectFunc(lzVars, o.Children[0].Labels, partitionExprs, pathNextWP, "PF") // !lz
+		} // !lz
+
+		_ = partitionExprsFunc // !lz
+
+		var lzValsOut base.Vals
+
+		var lzPartitionNext, lzPartitionCurr, lzOrderNext, lzOrderCurr, lzRingBytes, lzBytes []byte
+
+		var lzRank, lzDenseRank uint64
+
+		var lzBuf8Rank, lzBuf8DenseRank [8]byte
+
+		_, _, _, _ = lzRank, lzDenseRank, lzBuf8Rank, lzBuf8DenseRank
+
+		var lzErr error
+
+		// The position of the next vals in the current partition to yield.
+		var lzYieldPos int64
+
+		lzYieldValsOrig := lzYieldVals
+
+		// Yields the collected vals of the current partition that are
+		// before the given end position.
+		lzPartitionYield := func(lzEnd int64) {
+			for lzYieldPos < lzEnd && lzErr == nil {
+				lzRingBytes, lzErr = lzRing.Get(lzYieldPos)
+				if lzErr != nil {
+					lzYieldErr(lzErr)
+				} else {
+					lzValsOut = base.ValsDecode(lzRingBytes, lzValsOut[:0])
+
+					lzYieldPos++
+
+					lzYieldValsOrig(lzValsOut)
+				}
 			}
 		}
+
+		lzYieldVals = func(lzVals base.Vals) {
+			lzPartitionNext = lzPartitionNext[:0]
+			lzOrderNext = lzOrderNext[:0]
+
+			if len(partitionExprs) > 0 { // !lz
+				lzValsOut = lzValsOut[:0]
+
+				lzValsOut = partitionExprsFunc(lzVals, lzValsOut, lzYieldErr) // <== emitCaptured: pathNextWP "PF"
+
+				lzPartitionNext, lzErr = base.ValsEncodeCanonical(
+					lzValsOut[:partitionPrefix], lzPartitionNext[:0], lzVars.Ctx.ValComparer)
+				if lzErr == nil {
+					lzOrderNext, lzErr = base.ValsEncodeCanonical(
+						lzValsOut[partitionPrefix:], lzOrderNext[:0], lzVars.Ctx.ValComparer)
+				}
+			} // !lz
+
+			if lzErr == nil {
+				if !bytes.Equal(lzPartitionCurr, lzPartitionNext) {
+					// The incoming lzVals represents a new partition,
+					// so emit the rest of the current partition and
+					// reset the ring before the next PushBytes().
+					lzPartitionYield(int64(lzRing.Len()))
+
+					lzRing.Reset()
+
+					lzYieldPos = 0
+
+					lzPartitionId++
+
+					lzRing.Extra = lzPartitionId
+
+					lzPartitionCurr = append(lzPartitionCurr[:0], lzPartitionNext...)
+
+					lzOrderCurr, lzRank, lzDenseRank = lzOrderCurr[:0], 0, 0
+				}
+
+				if trackOn { // !lz
+					if !bytes.Equal(lzOrderCurr, lzOrderNext) {
+						lzOrderCurr = append(lzOrderCurr[:0], lzOrderNext...)
+
+						if trackRank { // !lz
+							lzRank = uint64(lzRing.Len()) + 1
+						} // !lz
+
+						if trackDenseRank { // !lz
+							lzDenseRank++
+						} // !lz
+					}
+
+					lzValsOut = append(lzValsOut[:0], lzVals...)
+
+					if trackRank { // !lz
+						binary.LittleEndian.PutUint64(lzBuf8Rank[:], lzRank)
+						lzValsOut = append(lzValsOut, base.Val(lzBuf8Rank[:]))
+					} // !lz
+
+					if trackDenseRank { // !lz
+						binary.LittleEndian.PutUint64(lzBuf8DenseRank[:], lzDenseRank)
+						lzValsOut = append(lzValsOut, base.Val(lzBuf8DenseRank[:]))
+					} // !lz
+
+					lzVals = lzValsOut
+				} // !lz
+
+				lzBytes = base.ValsEncode(lzVals, lzBytes[:0])
+
+				lzErr = lzRing.PushBytes(lzBytes)
+				if lzErr != nil {
+					lzYieldErr(lzErr)
+				} else {
+					lzPartitionYield(int64(lzRing.Len() - numFollowing))
+				}
+			}
+		}
+
+		EmitPop(pathNext, "WP") // !lz
+
+		ExecOp(o.Children[0], lzVars, lzYieldVals, lzYieldErr, pathNext, "WPC") // !lz
+
+		lzPartitionYield(int64(lzRing.Len()))
 	}
 }
 
@@ -204,7 +460,9 @@ func OpWindowFrames(o *base.Op, lzVars *base.Vars, lzYieldVals base.YieldVals,
 	framesLen := len(framesCfg)
 
 	if LzScope {
-		var lzHeap *store.Heap
+		var lzPartition base.WindowPartition
+
+		var lzPartitionExtra *interface{}
 
 		var lzFrames []base.WindowFrame
 
@@ -213,21 +471,21 @@ func OpWindowFrames(o *base.Op, lzVars *base.Vars, lzYieldVals base.YieldVals,
 		lzYieldValsOrig := lzYieldVals
 
 		lzYieldVals = func(lzVals base.Vals) {
-			if lzHeap == nil {
-				lzHeap = lzVars.TempGetHeap(partitionSlot)
+			if lzPartition == nil {
+				lzPartition, lzPartitionExtra = lzVars.TempGetWindowPartition(partitionSlot)
 
 				lzFrames = make([]base.WindowFrame, framesLen)
 				for lzI := range lzFrames {
 					lzFrame := &lzFrames[lzI]
-					lzFrame.Init(framesCfg[lzI], lzHeap)
+					lzFrame.Init(framesCfg[lzI], lzPartition)
 				}
 
 				lzVars.TempSet(framesSlot, lzFrames)
 			}
 
-			if lzPartitionId != lzHeap.Extra.(uint64) {
+			if lzPartitionId != (*lzPartitionExtra).(uint64) {
 				// We've encountered a new partition.
-				lzPartitionId = lzHeap.Extra.(uint64)
+				lzPartitionId = (*lzPartitionExtra).(uint64)
 
 				for lzI := range lzFrames {
 					lzFrame := &lzFrames[lzI]
@@ -252,5 +510,73 @@ func OpWindowFrames(o *base.Op, lzVars *base.Vars, lzYieldVals base.YieldVals,
 		}
 	}
 
-	ExecOp(o.Children[0], lzVars, lzYieldVals, lzYieldErr, pathNext, "WFC") // !lz
+	// A streaming window partition retains only the recent vals, so
+	// it falls back to a materialized window partition when any of
+	// the window frames might access other vals.
+	child := WindowPartitionStreamCheck(o.Children[0], partitionSlot, framesCfg)
+
+	ExecOp(child, lzVars, lzYieldVals, lzYieldErr, pathNext, "WFC") // !lz
+}
+
+// WindowPartitionStreamCheck finds the window-partition op for the
+// partitionSlot in the chain of the given op and its first
+// descendants. If that window-partition op is in streaming mode but
+// its stream config doesn't retain enough vals for the window frames,
+// or it tracks info that's known only after the whole partition has
+// been seen, the chain is returned as a copy where the
+// window-partition op is in materialized mode. Otherwise, the given
+// op is returned.
+func WindowPartitionStreamCheck(o *base.Op, partitionSlot int,
+	framesCfg []interface{}) *base.Op {
+	if o == nil {
+		return o
+	}
+
+	if o.Kind == "window-partition" && o.Params[0].(int) == partitionSlot {
+		if len(o.Params) <= 4 || o.Params[4] == nil {
+			return o
+		}
+
+		// The partitionSize and peerEnd, as used by PERCENT_RANK,
+		// CUME_DIST and NTILE, need the whole partition.
+		track := o.Params[3].(string)
+
+		streamable := strings.Index(track, "partitionSize") < 0 &&
+			strings.Index(track, "peerEnd") < 0
+
+		streamCfg := o.Params[4].([]interface{})
+
+		numPreceding, numFollowing := streamCfg[0].(int), streamCfg[1].(int)
+
+		for _, frameCfg := range framesCfg {
+			var wf base.WindowFrame
+
+			wf.Init(frameCfg, nil)
+
+			streamable = streamable && wf.Streamable(numPreceding, numFollowing)
+		}
+
+		if !streamable {
+			oCopy := *o
+			oCopy.Params = o.Params[:4]
+
+			return &oCopy
+		}
+
+		return o
+	}
+
+	if len(o.Children) <= 0 {
+		return o
+	}
+
+	child := WindowPartitionStreamCheck(o.Children[0], partitionSlot, framesCfg)
+	if child == o.Children[0] {
+		return o
+	}
+
+	oCopy := *o
+	oCopy.Children = append([]*base.Op{child}, o.Children[1:]...)
+
+	return &oCopy
 }
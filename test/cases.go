@@ -22,6 +22,10 @@ func MakeYieldCaptureFuncs(t *testing.T, testi int, expectErr string) (
 		n1k1.ExprCatalog["exprStr"] = glue.ExprStr
 	}
 
+	if n1k1.ExprCatalog["exprTree"] == nil {
+		n1k1.ExprCatalog["exprTree"] = glue.ExprTree
+	}
+
 	var yields []base.Vals
 
 	yieldVals := func(lzVals base.Vals) {
@@ -67,6 +71,10 @@ func MakeVars() (string, *base.Vars) {
 			ExprCatalog: n1k1.ExprCatalog,
 			YieldStats:  func(stats *base.Stats) error { return nil },
 			TempDir:     tmpDir,
+
+			NamedParams:      TestNamedParams,
+			PositionalParams: TestPositionalParams,
+
 			AllocMap: func() (*store.RHStore, error) {
 				mm.Lock()
 				defer mm.Unlock()
@@ -192,6 +200,12 @@ func MakeVars() (string, *base.Vars) {
 	}
 }
 
+// TestNamedParams and TestPositionalParams are the bound query
+// parameters of the test cases.
+var TestNamedParams = map[string]base.Val{"b": base.Val(`21`), "n": base.Val(`2`)}
+
+var TestPositionalParams = base.Vals{base.Val(`"x"`), base.Val(`30`)}
+
 func StringsToVals(a []string, valsPre base.Vals) base.Vals {
 	vals := valsPre
 	for _, v := range a {
@@ -319,6 +333,83 @@ var TestCasesSimple = []TestCaseSimple{
 		},
 		expectYields: []base.Vals(nil),
 	},
+	{
+		about: "test csv-data scan->filter with labelB = named param",
+		o: base.Op{
+			Kind:   "filter",
+			Labels: base.Labels{"a", "b", "c"},
+			Params: []interface{}{
+				"eq",
+				[]interface{}{"labelPath", "b"},
+				[]interface{}{"param", "b"},
+			},
+			Children: []*base.Op{&base.Op{
+				Kind:   "scan",
+				Labels: base.Labels{"a", "b", "c"},
+				Params: []interface{}{
+					"csvData",
+					`
+10,20,30
+11,21,31
+`,
+				},
+			}},
+		},
+		expectYields: []base.Vals{
+			base.Vals{[]byte("11"), []byte("21"), []byte("31")},
+		},
+	},
+	{
+		about: "test csv-data scan->filter with positional param < labelC",
+		o: base.Op{
+			Kind:   "filter",
+			Labels: base.Labels{"a", "b", "c"},
+			Params: []interface{}{
+				"lt",
+				[]interface{}{"param", 2},
+				[]interface{}{"labelPath", "c"},
+			},
+			Children: []*base.Op{&base.Op{
+				Kind:   "scan",
+				Labels: base.Labels{"a", "b", "c"},
+				Params: []interface{}{
+					"csvData",
+					`
+10,20,30
+11,21,31
+`,
+				},
+			}},
+		},
+		expectYields: []base.Vals{
+			base.Vals{[]byte("11"), []byte("21"), []byte("31")},
+		},
+	},
+	{
+		about: "test csv-data scan->project params",
+		o: base.Op{
+			Kind:   "project",
+			Labels: base.Labels{"a", "x", "y"},
+			Params: []interface{}{
+				[]interface{}{"labelPath", "a"},
+				[]interface{}{"param", 1},
+				[]interface{}{"param", "unknown"},
+			},
+			Children: []*base.Op{&base.Op{
+				Kind:   "scan",
+				Labels: base.Labels{"a", "b", "c"},
+				Params: []interface{}{
+					"csvData",
+					`
+10,20,30
+`,
+				},
+			}},
+		},
+		expectYields: []base.Vals{
+			base.Vals{[]byte("10"), []byte(`"x"`), nil},
+		},
+	},
 	{
 		about: "test csv-data scan->filter on const == const",
 		o: base.Op{
@@ -538,8 +629,8 @@ var TestCasesSimple = []TestCaseSimple{
 			}},
 		},
 		expectYields: []base.Vals{
-			base.Vals{[]byte("sf")},
-			base.Vals{[]byte("sj")},
+			base.Vals{[]byte(`"sf"`)},
+			base.Vals{[]byte(`"sj"`)},
 		},
 	}, {
 		about: "test csv-data scan->project deeper labelPath",
@@ -848,6 +939,169 @@ var TestCasesSimple = []TestCaseSimple{
 			StringsToVals([]string{`"sales"`, `"san diego"`, ``, ``}, nil),
 		},
 	},
+	{
+		about: "test right outer joinNL on dept",
+		o: base.Op{
+			Kind:   "joinNL-rightOuter",
+			Labels: base.Labels{"dept", "city", "emp", "empDept"},
+			Params: []interface{}{
+				"eq",
+				[]interface{}{"labelPath", `dept`},
+				[]interface{}{"labelPath", `empDept`},
+			},
+			Children: []*base.Op{&base.Op{
+				Kind:   "scan",
+				Labels: base.Labels{"dept", "city"},
+				Params: []interface{}{
+					"csvData",
+					`
+"dev","paris"
+"finance","london"
+"sales","san diego"
+`,
+				},
+			}, &base.Op{
+				Kind:   "scan",
+				Labels: base.Labels{"emp", "empDept"},
+				Params: []interface{}{
+					"csvData",
+					`
+"dan","dev"
+"doug","dev"
+"frank","finance"
+"fred","finance"
+"mary","marketing"
+`,
+				},
+			}},
+		},
+		expectYields: []base.Vals{
+			StringsToVals([]string{`"dev"`, `"paris"`, `"dan"`, `"dev"`}, nil),
+			StringsToVals([]string{`"dev"`, `"paris"`, `"doug"`, `"dev"`}, nil),
+
+			StringsToVals([]string{`"finance"`, `"london"`, `"frank"`, `"finance"`}, nil),
+			StringsToVals([]string{`"finance"`, `"london"`, `"fred"`, `"finance"`}, nil),
+
+			StringsToVals([]string{``, ``, `"mary"`, `"marketing"`}, nil),
+		},
+	},
+	{
+		about: "test full outer joinNL on dept",
+		o: base.Op{
+			Kind:   "joinNL-fullOuter",
+			Labels: base.Labels{"dept", "city", "emp", "empDept"},
+			Params: []interface{}{
+				"eq",
+				[]interface{}{"labelPath", `dept`},
+				[]interface{}{"labelPath", `empDept`},
+			},
+			Children: []*base.Op{&base.Op{
+				Kind:   "scan",
+				Labels: base.Labels{"dept", "city"},
+				Params: []interface{}{
+					"csvData",
+					`
+"dev","paris"
+"finance","london"
+"sales","san diego"
+`,
+				},
+			}, &base.Op{
+				Kind:   "scan",
+				Labels: base.Labels{"emp", "empDept"},
+				Params: []interface{}{
+					"csvData",
+					`
+"dan","dev"
+"doug","dev"
+"frank","finance"
+"fred","finance"
+"mary","marketing"
+`,
+				},
+			}},
+		},
+		expectYields: []base.Vals{
+			StringsToVals([]string{`"dev"`, `"paris"`, `"dan"`, `"dev"`}, nil),
+			StringsToVals([]string{`"dev"`, `"paris"`, `"doug"`, `"dev"`}, nil),
+
+			StringsToVals([]string{`"finance"`, `"london"`, `"frank"`, `"finance"`}, nil),
+			StringsToVals([]string{`"finance"`, `"london"`, `"fred"`, `"finance"`}, nil),
+
+			StringsToVals([]string{`"sales"`, `"san diego"`, ``, ``}, nil),
+
+			StringsToVals([]string{``, ``, `"mary"`, `"marketing"`}, nil),
+		},
+	},
+	{
+		about: "test cross joinNL",
+		o: base.Op{
+			Kind:   "joinNL-cross",
+			Labels: base.Labels{"dept", "city", "emp", "empDept"},
+			Children: []*base.Op{&base.Op{
+				Kind:   "scan",
+				Labels: base.Labels{"dept", "city"},
+				Params: []interface{}{
+					"csvData",
+					`
+"dev","paris"
+"finance","london"
+`,
+				},
+			}, &base.Op{
+				Kind:   "scan",
+				Labels: base.Labels{"emp", "empDept"},
+				Params: []interface{}{
+					"csvData",
+					`
+"dan","dev"
+"mary","marketing"
+`,
+				},
+			}},
+		},
+		expectYields: []base.Vals{
+			StringsToVals([]string{`"dev"`, `"paris"`, `"dan"`, `"dev"`}, nil),
+			StringsToVals([]string{`"dev"`, `"paris"`, `"mary"`, `"marketing"`}, nil),
+			StringsToVals([]string{`"finance"`, `"london"`, `"dan"`, `"dev"`}, nil),
+			StringsToVals([]string{`"finance"`, `"london"`, `"mary"`, `"marketing"`}, nil),
+		},
+	},
+	{
+		about: "test right outer joinNL on dept with empty LHS",
+		o: base.Op{
+			Kind:   "joinNL-rightOuter",
+			Labels: base.Labels{"dept", "city", "emp", "empDept"},
+			Params: []interface{}{
+				"eq",
+				[]interface{}{"labelPath", `dept`},
+				[]interface{}{"labelPath", `empDept`},
+			},
+			Children: []*base.Op{&base.Op{
+				Kind:   "scan",
+				Labels: base.Labels{"dept", "city"},
+				Params: []interface{}{
+					"csvData",
+					`
+`,
+				},
+			}, &base.Op{
+				Kind:   "scan",
+				Labels: base.Labels{"emp", "empDept"},
+				Params: []interface{}{
+					"csvData",
+					`
+"dan","dev"
+"mary","marketing"
+`,
+				},
+			}},
+		},
+		expectYields: []base.Vals{
+			StringsToVals([]string{``, ``, `"dan"`, `"dev"`}, nil),
+			StringsToVals([]string{``, ``, `"mary"`, `"marketing"`}, nil),
+		},
+	},
 	{
 		about: "test left outer join on dept with empty RHS",
 		o: base.Op{
@@ -1474,6 +1728,44 @@ var TestCasesSimple = []TestCaseSimple{
 			base.Vals{[]byte("12"), []byte("22")},
 		},
 	},
+	{
+		about: "test csv-data scan->order-by NULLS LAST, NULLS FIRST",
+		o: base.Op{
+			Kind:   "order-offset-limit",
+			Labels: base.Labels{"a", "b"},
+			Params: []interface{}{
+				[]interface{}{
+					[]interface{}{"labelPath", "a"},
+					[]interface{}{"labelPath", "b"},
+				},
+				[]interface{}{
+					"asc-nulls-last",
+					"desc-nulls-first",
+				},
+			},
+			Children: []*base.Op{&base.Op{
+				Kind:   "scan",
+				Labels: base.Labels{"a", "b"},
+				Params: []interface{}{
+					"csvData",
+					`
+1,5
+null,1
+1,null
+2,3
+null,null
+`,
+				},
+			}},
+		},
+		expectYields: []base.Vals{
+			base.Vals{[]byte("1"), []byte("null")},
+			base.Vals{[]byte("1"), []byte("5")},
+			base.Vals{[]byte("2"), []byte("3")},
+			base.Vals{[]byte("null"), []byte("null")},
+			base.Vals{[]byte("null"), []byte("1")},
+		},
+	},
 	{
 		about: "test csv-data scan->order-by 1 record",
 		o: base.Op{
@@ -1863,18 +2155,52 @@ var TestCasesSimple = []TestCaseSimple{
 		},
 	},
 	{
-		about: "test csv-data scan->NIL-order-by OFFSET 1 LIMIT 1",
+		about: "test csv-data scan->order-by DESC OFFSET param LIMIT param",
 		o: base.Op{
 			Kind:   "order-offset-limit",
 			Labels: base.Labels{"a", "b"},
 			Params: []interface{}{
-				[]interface{}{},
-				[]interface{}{},
-				int64(1),
-				int64(1),
-			},
-			Children: []*base.Op{&base.Op{
-				Kind:   "scan",
+				[]interface{}{
+					[]interface{}{"labelPath", "a"},
+				},
+				[]interface{}{
+					"desc",
+				},
+				// A non-number param is treated as the default.
+				[]interface{}{"param", 1},
+				[]interface{}{"param", "n"},
+			},
+			Children: []*base.Op{&base.Op{
+				Kind:   "scan",
+				Labels: base.Labels{"a", "b"},
+				Params: []interface{}{
+					"csvData",
+					`
+10,20
+11,21
+12,22
+`,
+				},
+			}},
+		},
+		expectYields: []base.Vals{
+			base.Vals{[]byte("12"), []byte("22")},
+			base.Vals{[]byte("11"), []byte("21")},
+		},
+	},
+	{
+		about: "test csv-data scan->NIL-order-by OFFSET 1 LIMIT 1",
+		o: base.Op{
+			Kind:   "order-offset-limit",
+			Labels: base.Labels{"a", "b"},
+			Params: []interface{}{
+				[]interface{}{},
+				[]interface{}{},
+				int64(1),
+				int64(1),
+			},
+			Children: []*base.Op{&base.Op{
+				Kind:   "scan",
 				Labels: base.Labels{"a", "b"},
 				Params: []interface{}{
 					"csvData",
@@ -2966,61 +3292,54 @@ var TestCasesSimple = []TestCaseSimple{
 		},
 	},
 	{
-		about: "test left outer joinHash on dept with empty RHS",
+		about: "test right outer joinHash on dept",
 		o: base.Op{
-			Kind:   "order-offset-limit",
+			Kind:   "joinHash-rightOuter",
 			Labels: base.Labels{"dept", "city", "emp", "empDept"},
 			Params: []interface{}{
-				[]interface{}{
-					[]interface{}{"labelPath", "dept"},
-					[]interface{}{"labelPath", "city"},
-					[]interface{}{"labelPath", "emp"},
-					[]interface{}{"labelPath", "empDept"},
-				},
-				[]interface{}{
-					"asc",
-					"asc",
-					"asc",
-					"asc",
-				},
+				[]interface{}{"labelPath", `dept`},
+				[]interface{}{"labelPath", `empDept`},
 			},
 			Children: []*base.Op{&base.Op{
-				Kind:   "joinHash-leftOuter",
-				Labels: base.Labels{"dept", "city", "emp", "empDept"},
+				Kind:   "scan",
+				Labels: base.Labels{"dept", "city"},
 				Params: []interface{}{
-					[]interface{}{"labelPath", `dept`},
-					[]interface{}{"labelPath", `empDept`},
-				},
-				Children: []*base.Op{&base.Op{
-					Kind:   "scan",
-					Labels: base.Labels{"dept", "city"},
-					Params: []interface{}{
-						"csvData",
-						`
+					"csvData",
+					`
 "dev","paris"
 "finance","london"
+"sales","san diego"
 `,
-					},
-				}, &base.Op{
-					Kind:   "scan",
-					Labels: base.Labels{"emp", "empDept"},
-					Params: []interface{}{
-						"csvData",
-						`
+				},
+			}, &base.Op{
+				Kind:   "scan",
+				Labels: base.Labels{"emp", "empDept"},
+				Params: []interface{}{
+					"csvData",
+					`
+"dan","dev"
+"doug","dev"
+"frank","finance"
+"fred","finance"
+"mary","marketing"
 `,
-					},
-				}},
+				},
 			}},
 		},
 		expectYields: []base.Vals{
-			StringsToVals([]string{`"dev"`, `"paris"`, ``, ``}, nil),
-			StringsToVals([]string{`"finance"`, `"london"`, ``, ``}, nil),
+			StringsToVals([]string{`"dev"`, `"paris"`, `"dan"`, `"dev"`}, nil),
+			StringsToVals([]string{`"dev"`, `"paris"`, `"doug"`, `"dev"`}, nil),
+
+			StringsToVals([]string{`"finance"`, `"london"`, `"frank"`, `"finance"`}, nil),
+			StringsToVals([]string{`"finance"`, `"london"`, `"fred"`, `"finance"`}, nil),
+
+			StringsToVals([]string{``, ``, `"mary"`, `"marketing"`}, nil),
 		},
 	},
 	{
-		about: "test left outer joinHash on dept with empty LHS",
+		about: "test full outer joinHash on dept",
 		o: base.Op{
-			Kind:   "joinHash-leftOuter",
+			Kind:   "joinHash-fullOuter",
 			Labels: base.Labels{"dept", "city", "emp", "empDept"},
 			Params: []interface{}{
 				[]interface{}{"labelPath", `dept`},
@@ -3032,6 +3351,9 @@ var TestCasesSimple = []TestCaseSimple{
 				Params: []interface{}{
 					"csvData",
 					`
+"dev","paris"
+"finance","london"
+"sales","san diego"
 `,
 				},
 			}, &base.Op{
@@ -3044,379 +3366,550 @@ var TestCasesSimple = []TestCaseSimple{
 "doug","dev"
 "frank","finance"
 "fred","finance"
+"mary","marketing"
 `,
 				},
 			}},
 		},
-		expectYields: []base.Vals(nil),
+		expectYields: []base.Vals{
+			StringsToVals([]string{`"dev"`, `"paris"`, `"dan"`, `"dev"`}, nil),
+			StringsToVals([]string{`"dev"`, `"paris"`, `"doug"`, `"dev"`}, nil),
+
+			StringsToVals([]string{`"finance"`, `"london"`, `"frank"`, `"finance"`}, nil),
+			StringsToVals([]string{`"finance"`, `"london"`, `"fred"`, `"finance"`}, nil),
+
+			StringsToVals([]string{``, ``, `"mary"`, `"marketing"`}, nil),
+
+			StringsToVals([]string{`"sales"`, `"san diego"`, ``, ``}, nil),
+		},
 	},
 	{
-		about: "test left outer joinHash on never matching condition",
+		about: "test semi joinHash on dept",
 		o: base.Op{
-			Kind:   "order-offset-limit",
-			Labels: base.Labels{"dept", "city", "emp", "empDept"},
+			Kind:   "joinHash-semi",
+			Labels: base.Labels{"dept", "city"},
 			Params: []interface{}{
-				[]interface{}{
-					[]interface{}{"labelPath", "dept"},
-					[]interface{}{"labelPath", "city"},
-					[]interface{}{"labelPath", "emp"},
-					[]interface{}{"labelPath", "empDept"},
-				},
-				[]interface{}{
-					"asc",
-					"asc",
-					"asc",
-					"asc",
-				},
+				[]interface{}{"labelPath", `dept`},
+				[]interface{}{"labelPath", `empDept`},
 			},
 			Children: []*base.Op{&base.Op{
-				Kind:   "joinHash-leftOuter",
-				Labels: base.Labels{"dept", "city", "emp", "empDept"},
+				Kind:   "scan",
+				Labels: base.Labels{"dept", "city"},
 				Params: []interface{}{
-					[]interface{}{"labelPath", `dept`},
-					[]interface{}{"labelPath", `someFakeLabel`},
-				},
-				Children: []*base.Op{&base.Op{
-					Kind:   "scan",
-					Labels: base.Labels{"dept", "city"},
-					Params: []interface{}{
-						"csvData",
-						`
+					"csvData",
+					`
 "dev","paris"
 "finance","london"
+"sales","san diego"
 `,
-					},
-				}, &base.Op{
-					Kind:   "scan",
-					Labels: base.Labels{"emp", "empDept"},
-					Params: []interface{}{
-						"csvData",
-						`
+				},
+			}, &base.Op{
+				Kind:   "scan",
+				Labels: base.Labels{"emp", "empDept"},
+				Params: []interface{}{
+					"csvData",
+					`
 "dan","dev"
 "doug","dev"
 "frank","finance"
 "fred","finance"
+"mary","marketing"
 `,
-					},
-				}},
+				},
 			}},
 		},
 		expectYields: []base.Vals{
-			StringsToVals([]string{`"dev"`, `"paris"`, ``, ``}, nil),
-			StringsToVals([]string{`"finance"`, `"london"`, ``, ``}, nil),
+			StringsToVals([]string{`"dev"`, `"paris"`}, nil),
+			StringsToVals([]string{`"finance"`, `"london"`}, nil),
 		},
 	},
 	{
-		about: "test csv-data scan->project",
+		about: "test anti joinHash on dept",
 		o: base.Op{
-			Kind:   "project",
-			Labels: base.Labels{"x"},
+			Kind:   "joinHash-anti",
+			Labels: base.Labels{"dept", "city"},
 			Params: []interface{}{
-				[]interface{}{"valsEncodeCanonical", "a"},
+				[]interface{}{"labelPath", `dept`},
+				[]interface{}{"labelPath", `empDept`},
 			},
 			Children: []*base.Op{&base.Op{
 				Kind:   "scan",
-				Labels: base.Labels{"a", "b", "c"},
+				Labels: base.Labels{"dept", "city"},
 				Params: []interface{}{
 					"csvData",
 					`
-00,00,0.000
-1.200,-22,-0.0
+"dev","paris"
+"finance","london"
+"sales","san diego"
+`,
+				},
+			}, &base.Op{
+				Kind:   "scan",
+				Labels: base.Labels{"emp", "empDept"},
+				Params: []interface{}{
+					"csvData",
+					`
+"dan","dev"
+"doug","dev"
+"frank","finance"
+"fred","finance"
+"mary","marketing"
 `,
 				},
 			}},
 		},
 		expectYields: []base.Vals{
-			base.Vals{[]byte("\x03\x00\x00\x00\x00\x00\x00\x00\x01\x00\x00\x00\x00\x00\x00\x000\x01\x00\x00\x00\x00\x00\x00\x000\x01\x00\x00\x00\x00\x00\x00\x000")},
-			base.Vals{[]byte("\x03\x00\x00\x00\x00\x00\x00\x00\x03\x00\x00\x00\x00\x00\x00\x001.2\x03\x00\x00\x00\x00\x00\x00\x00-22\x02\x00\x00\x00\x00\x00\x00\x00-0")},
+			StringsToVals([]string{`"sales"`, `"san diego"`}, nil),
 		},
 	},
 	{
-		about: "test csv-data scan->intersect-distinct",
+		about: "test inner joinMerge on dept",
 		o: base.Op{
-			Kind:   "intersect-distinct",
-			Labels: base.Labels{"a", "b"},
+			Kind:   "joinMerge-inner",
+			Labels: base.Labels{"dept", "city", "emp", "empDept"},
+			Params: []interface{}{
+				[]interface{}{"labelPath", `dept`},
+				[]interface{}{"labelPath", `empDept`},
+			},
 			Children: []*base.Op{&base.Op{
 				Kind:   "scan",
-				Labels: base.Labels{"a", "b"},
+				Labels: base.Labels{"dept", "city"},
 				Params: []interface{}{
 					"csvData",
 					`
-10,11
-20,21
+"dev","paris"
+"dev","rome"
+"finance","london"
+"sales","san diego"
 `,
 				},
 			}, &base.Op{
 				Kind:   "scan",
-				Labels: base.Labels{"a", "b"},
+				Labels: base.Labels{"emp", "empDept"},
 				Params: []interface{}{
 					"csvData",
 					`
-20,21
-30,31
+"aaron","admin"
+"dan","dev"
+"doug","dev"
+"frank","finance"
+"mary","marketing"
 `,
 				},
 			}},
 		},
 		expectYields: []base.Vals{
-			StringsToVals([]string{`20`, `21`}, nil),
+			StringsToVals([]string{`"dev"`, `"paris"`, `"dan"`, `"dev"`}, nil),
+			StringsToVals([]string{`"dev"`, `"paris"`, `"doug"`, `"dev"`}, nil),
+			StringsToVals([]string{`"dev"`, `"rome"`, `"dan"`, `"dev"`}, nil),
+			StringsToVals([]string{`"dev"`, `"rome"`, `"doug"`, `"dev"`}, nil),
+
+			StringsToVals([]string{`"finance"`, `"london"`, `"frank"`, `"finance"`}, nil),
 		},
 	},
 	{
-		about: "test csv-data scan->intersect-distinct of empty left",
+		about: "test left outer joinMerge on dept",
 		o: base.Op{
-			Kind:   "intersect-distinct",
-			Labels: base.Labels{"a", "b"},
+			Kind:   "joinMerge-leftOuter",
+			Labels: base.Labels{"dept", "city", "emp", "empDept"},
+			Params: []interface{}{
+				[]interface{}{"labelPath", `dept`},
+				[]interface{}{"labelPath", `empDept`},
+			},
 			Children: []*base.Op{&base.Op{
 				Kind:   "scan",
-				Labels: base.Labels{"a", "b"},
+				Labels: base.Labels{"dept", "city"},
 				Params: []interface{}{
 					"csvData",
 					`
+"dev","paris"
+"dev","rome"
+"finance","london"
+"sales","san diego"
 `,
 				},
 			}, &base.Op{
 				Kind:   "scan",
-				Labels: base.Labels{"a", "b"},
+				Labels: base.Labels{"emp", "empDept"},
 				Params: []interface{}{
 					"csvData",
 					`
-20,21
-30,31
+"aaron","admin"
+"dan","dev"
+"doug","dev"
+"frank","finance"
+"mary","marketing"
 `,
 				},
 			}},
 		},
-		expectYields: []base.Vals(nil),
+		expectYields: []base.Vals{
+			StringsToVals([]string{`"dev"`, `"paris"`, `"dan"`, `"dev"`}, nil),
+			StringsToVals([]string{`"dev"`, `"paris"`, `"doug"`, `"dev"`}, nil),
+			StringsToVals([]string{`"dev"`, `"rome"`, `"dan"`, `"dev"`}, nil),
+			StringsToVals([]string{`"dev"`, `"rome"`, `"doug"`, `"dev"`}, nil),
+
+			StringsToVals([]string{`"finance"`, `"london"`, `"frank"`, `"finance"`}, nil),
+
+			StringsToVals([]string{`"sales"`, `"san diego"`, ``, ``}, nil),
+		},
 	},
 	{
-		about: "test csv-data scan->intersect-distinct of empty right",
+		about: "test full outer joinMerge on dept",
 		o: base.Op{
-			Kind:   "intersect-distinct",
-			Labels: base.Labels{"a", "b"},
+			Kind:   "joinMerge-fullOuter",
+			Labels: base.Labels{"dept", "city", "emp", "empDept"},
+			Params: []interface{}{
+				[]interface{}{"labelPath", `dept`},
+				[]interface{}{"labelPath", `empDept`},
+			},
 			Children: []*base.Op{&base.Op{
 				Kind:   "scan",
-				Labels: base.Labels{"a", "b"},
+				Labels: base.Labels{"dept", "city"},
 				Params: []interface{}{
 					"csvData",
 					`
-10,11
-20,21
+"dev","paris"
+"dev","rome"
+"finance","london"
+"sales","san diego"
 `,
 				},
 			}, &base.Op{
 				Kind:   "scan",
-				Labels: base.Labels{"a", "b"},
+				Labels: base.Labels{"emp", "empDept"},
 				Params: []interface{}{
 					"csvData",
 					`
+"aaron","admin"
+"dan","dev"
+"doug","dev"
+"frank","finance"
+"mary","marketing"
 `,
 				},
 			}},
 		},
-		expectYields: []base.Vals(nil),
+		expectYields: []base.Vals{
+			StringsToVals([]string{``, ``, `"aaron"`, `"admin"`}, nil),
+
+			StringsToVals([]string{`"dev"`, `"paris"`, `"dan"`, `"dev"`}, nil),
+			StringsToVals([]string{`"dev"`, `"paris"`, `"doug"`, `"dev"`}, nil),
+			StringsToVals([]string{`"dev"`, `"rome"`, `"dan"`, `"dev"`}, nil),
+			StringsToVals([]string{`"dev"`, `"rome"`, `"doug"`, `"dev"`}, nil),
+
+			StringsToVals([]string{`"finance"`, `"london"`, `"frank"`, `"finance"`}, nil),
+
+			StringsToVals([]string{``, ``, `"mary"`, `"marketing"`}, nil),
+
+			StringsToVals([]string{`"sales"`, `"san diego"`, ``, ``}, nil),
+		},
 	},
 	{
-		about: "test csv-data scan->intersect-distinct of repeating left",
+		about: "test inner joinHash on multiple keys",
 		o: base.Op{
-			Kind:   "intersect-distinct",
-			Labels: base.Labels{"a", "b"},
+			Kind:   "joinHash-inner",
+			Labels: base.Labels{"dept", "city", "emp", "empDept", "empCity"},
+			Params: []interface{}{
+				[]interface{}{
+					[]interface{}{"labelPath", `dept`},
+					[]interface{}{"labelPath", `city`},
+				},
+				[]interface{}{
+					[]interface{}{"labelPath", `empDept`},
+					[]interface{}{"labelPath", `empCity`},
+				},
+			},
 			Children: []*base.Op{&base.Op{
 				Kind:   "scan",
-				Labels: base.Labels{"a", "b"},
+				Labels: base.Labels{"dept", "city"},
 				Params: []interface{}{
 					"csvData",
 					`
-20,21
-10,11
-20,21
-30,31
+"dev","paris"
+"dev","london"
+"finance","london"
+"sales",null
 `,
 				},
 			}, &base.Op{
 				Kind:   "scan",
-				Labels: base.Labels{"a", "b"},
+				Labels: base.Labels{"emp", "empDept", "empCity"},
 				Params: []interface{}{
 					"csvData",
 					`
+"dan","dev","paris"
+"doug","dev","london"
+"frank","finance","paris"
+"sam","sales",null
 `,
 				},
 			}},
 		},
-		expectYields: []base.Vals(nil),
+		expectYields: []base.Vals{
+			StringsToVals([]string{`"dev"`, `"paris"`, `"dan"`, `"dev"`, `"paris"`}, nil),
+			StringsToVals([]string{`"dev"`, `"london"`, `"doug"`, `"dev"`, `"london"`}, nil),
+		},
 	},
 	{
-		about: "test csv-data scan->intersect-distinct of repeating right",
+		about: "test left outer joinHash on dept with residual",
 		o: base.Op{
-			Kind:   "intersect-distinct",
-			Labels: base.Labels{"a", "b"},
+			Kind:   "joinHash-leftOuter",
+			Labels: base.Labels{"dept", "minLevel", "emp", "empDept", "level"},
+			Params: []interface{}{
+				[]interface{}{"labelPath", `dept`},
+				[]interface{}{"labelPath", `empDept`},
+				[]interface{}{"ge",
+					[]interface{}{"labelPath", `level`},
+					[]interface{}{"labelPath", `minLevel`},
+				},
+			},
 			Children: []*base.Op{&base.Op{
 				Kind:   "scan",
-				Labels: base.Labels{"a", "b"},
+				Labels: base.Labels{"dept", "minLevel"},
 				Params: []interface{}{
 					"csvData",
 					`
+"dev",2
+"finance",5
 `,
 				},
 			}, &base.Op{
 				Kind:   "scan",
-				Labels: base.Labels{"a", "b"},
+				Labels: base.Labels{"emp", "empDept", "level"},
 				Params: []interface{}{
 					"csvData",
 					`
-20,21
-30,11
-20,21
-30,31
+"dan","dev",1
+"doug","dev",3
+"frank","finance",4
+"fred","finance",2
+"mary","marketing",9
 `,
 				},
 			}},
 		},
-		expectYields: []base.Vals(nil),
+		expectYields: []base.Vals{
+			StringsToVals([]string{`"dev"`, `2`, `"doug"`, `"dev"`, `3`}, nil),
+
+			StringsToVals([]string{`"finance"`, `5`, ``, ``, ``}, nil),
+		},
 	},
 	{
-		about: "test csv-data scan->intersect-distinct of repeating",
+		about: "test semi joinHash on dept with residual",
 		o: base.Op{
-			Kind:   "intersect-distinct",
-			Labels: base.Labels{"a", "b"},
+			Kind:   "joinHash-semi",
+			Labels: base.Labels{"dept", "minLevel"},
+			Params: []interface{}{
+				[]interface{}{"labelPath", `dept`},
+				[]interface{}{"labelPath", `empDept`},
+				[]interface{}{"ge",
+					[]interface{}{"labelPath", `level`},
+					[]interface{}{"labelPath", `minLevel`},
+				},
+			},
 			Children: []*base.Op{&base.Op{
 				Kind:   "scan",
-				Labels: base.Labels{"a", "b"},
+				Labels: base.Labels{"dept", "minLevel"},
 				Params: []interface{}{
 					"csvData",
 					`
-20,21
-10,11
-20,21
-10,11
+"dev",2
+"finance",5
+"sales",1
 `,
 				},
 			}, &base.Op{
 				Kind:   "scan",
-				Labels: base.Labels{"a", "b"},
+				Labels: base.Labels{"emp", "empDept", "level"},
 				Params: []interface{}{
 					"csvData",
 					`
-20,21
-30,11
-20,21
-30,31
+"dan","dev",1
+"doug","dev",3
+"frank","finance",4
+"fred","finance",2
+"mary","marketing",9
 `,
 				},
 			}},
 		},
 		expectYields: []base.Vals{
-			StringsToVals([]string{`20`, `21`}, nil),
+			StringsToVals([]string{`"dev"`, `2`}, nil),
 		},
 	},
 	{
-		about: "test csv-data scan->intersect-all",
+		about: "test anti joinHash on dept with residual",
 		o: base.Op{
-			Kind:   "intersect-all",
-			Labels: base.Labels{"a", "b"},
+			Kind:   "joinHash-anti",
+			Labels: base.Labels{"dept", "minLevel"},
+			Params: []interface{}{
+				[]interface{}{"labelPath", `dept`},
+				[]interface{}{"labelPath", `empDept`},
+				[]interface{}{"ge",
+					[]interface{}{"labelPath", `level`},
+					[]interface{}{"labelPath", `minLevel`},
+				},
+			},
 			Children: []*base.Op{&base.Op{
 				Kind:   "scan",
-				Labels: base.Labels{"a", "b"},
+				Labels: base.Labels{"dept", "minLevel"},
 				Params: []interface{}{
 					"csvData",
 					`
-10,11
-20,21
+"dev",2
+"finance",5
+"sales",1
 `,
 				},
 			}, &base.Op{
 				Kind:   "scan",
-				Labels: base.Labels{"a", "b"},
+				Labels: base.Labels{"emp", "empDept", "level"},
 				Params: []interface{}{
 					"csvData",
 					`
-20,21
-30,31
+"dan","dev",1
+"doug","dev",3
+"frank","finance",4
+"fred","finance",2
+"mary","marketing",9
 `,
 				},
 			}},
 		},
 		expectYields: []base.Vals{
-			StringsToVals([]string{`20`, `21`}, nil),
+			StringsToVals([]string{`"finance"`, `5`}, nil),
+			StringsToVals([]string{`"sales"`, `1`}, nil),
 		},
 	},
 	{
-		about: "test csv-data scan->intersect-all of empty left",
+		about: "test partitioned full outer joinHash on dept with tiny budget",
 		o: base.Op{
-			Kind:   "intersect-all",
-			Labels: base.Labels{"a", "b"},
+			Kind:   "joinHash-fullOuter",
+			Labels: base.Labels{"dept", "city", "emp", "empDept"},
+			Params: []interface{}{
+				[]interface{}{"labelPath", `dept`},
+				[]interface{}{"labelPath", `empDept`},
+				nil,
+				[]interface{}{0, 1, 3, 1},
+			},
 			Children: []*base.Op{&base.Op{
 				Kind:   "scan",
-				Labels: base.Labels{"a", "b"},
+				Labels: base.Labels{"dept", "city"},
 				Params: []interface{}{
 					"csvData",
 					`
+"dev","paris"
+"finance","london"
+"sales","san diego"
 `,
 				},
 			}, &base.Op{
 				Kind:   "scan",
-				Labels: base.Labels{"a", "b"},
+				Labels: base.Labels{"emp", "empDept"},
 				Params: []interface{}{
 					"csvData",
 					`
-20,21
-30,31
+"dan","dev"
+"doug","dev"
+"frank","finance"
+"fred","finance"
+"mary","marketing"
 `,
 				},
 			}},
 		},
-		expectYields: []base.Vals(nil),
+		expectYields: []base.Vals{
+			StringsToVals([]string{`"dev"`, `"paris"`, `"dan"`, `"dev"`}, nil),
+			StringsToVals([]string{`"dev"`, `"paris"`, `"doug"`, `"dev"`}, nil),
+
+			StringsToVals([]string{``, ``, `"mary"`, `"marketing"`}, nil),
+
+			StringsToVals([]string{`"finance"`, `"london"`, `"frank"`, `"finance"`}, nil),
+			StringsToVals([]string{`"finance"`, `"london"`, `"fred"`, `"finance"`}, nil),
+
+			StringsToVals([]string{`"sales"`, `"san diego"`, ``, ``}, nil),
+		},
 	},
 	{
-		about: "test csv-data scan->intersect-all of empty right",
+		about: "test left outer joinHash on dept with empty RHS",
 		o: base.Op{
-			Kind:   "intersect-all",
-			Labels: base.Labels{"a", "b"},
+			Kind:   "order-offset-limit",
+			Labels: base.Labels{"dept", "city", "emp", "empDept"},
+			Params: []interface{}{
+				[]interface{}{
+					[]interface{}{"labelPath", "dept"},
+					[]interface{}{"labelPath", "city"},
+					[]interface{}{"labelPath", "emp"},
+					[]interface{}{"labelPath", "empDept"},
+				},
+				[]interface{}{
+					"asc",
+					"asc",
+					"asc",
+					"asc",
+				},
+			},
 			Children: []*base.Op{&base.Op{
-				Kind:   "scan",
-				Labels: base.Labels{"a", "b"},
+				Kind:   "joinHash-leftOuter",
+				Labels: base.Labels{"dept", "city", "emp", "empDept"},
 				Params: []interface{}{
-					"csvData",
-					`
-10,11
-20,21
-`,
+					[]interface{}{"labelPath", `dept`},
+					[]interface{}{"labelPath", `empDept`},
 				},
-			}, &base.Op{
-				Kind:   "scan",
-				Labels: base.Labels{"a", "b"},
-				Params: []interface{}{
-					"csvData",
-					`
+				Children: []*base.Op{&base.Op{
+					Kind:   "scan",
+					Labels: base.Labels{"dept", "city"},
+					Params: []interface{}{
+						"csvData",
+						`
+"dev","paris"
+"finance","london"
 `,
-				},
+					},
+				}, &base.Op{
+					Kind:   "scan",
+					Labels: base.Labels{"emp", "empDept"},
+					Params: []interface{}{
+						"csvData",
+						`
+`,
+					},
+				}},
 			}},
 		},
-		expectYields: []base.Vals(nil),
+		expectYields: []base.Vals{
+			StringsToVals([]string{`"dev"`, `"paris"`, ``, ``}, nil),
+			StringsToVals([]string{`"finance"`, `"london"`, ``, ``}, nil),
+		},
 	},
 	{
-		about: "test csv-data scan->intersect-all of repeating left",
+		about: "test left outer joinHash on dept with empty LHS",
 		o: base.Op{
-			Kind:   "intersect-all",
-			Labels: base.Labels{"a", "b"},
+			Kind:   "joinHash-leftOuter",
+			Labels: base.Labels{"dept", "city", "emp", "empDept"},
+			Params: []interface{}{
+				[]interface{}{"labelPath", `dept`},
+				[]interface{}{"labelPath", `empDept`},
+			},
 			Children: []*base.Op{&base.Op{
 				Kind:   "scan",
-				Labels: base.Labels{"a", "b"},
+				Labels: base.Labels{"dept", "city"},
 				Params: []interface{}{
 					"csvData",
 					`
-20,21
-10,11
-20,21
-30,31
 `,
 				},
 			}, &base.Op{
 				Kind:   "scan",
-				Labels: base.Labels{"a", "b"},
+				Labels: base.Labels{"emp", "empDept"},
 				Params: []interface{}{
 					"csvData",
 					`
+"dan","dev"
+"doug","dev"
+"frank","finance"
+"fred","finance"
 `,
 				},
 			}},
@@ -3424,74 +3917,90 @@ var TestCasesSimple = []TestCaseSimple{
 		expectYields: []base.Vals(nil),
 	},
 	{
-		about: "test csv-data scan->intersect-all of repeating right",
+		about: "test left outer joinHash on never matching condition",
 		o: base.Op{
-			Kind:   "intersect-all",
-			Labels: base.Labels{"a", "b"},
+			Kind:   "order-offset-limit",
+			Labels: base.Labels{"dept", "city", "emp", "empDept"},
+			Params: []interface{}{
+				[]interface{}{
+					[]interface{}{"labelPath", "dept"},
+					[]interface{}{"labelPath", "city"},
+					[]interface{}{"labelPath", "emp"},
+					[]interface{}{"labelPath", "empDept"},
+				},
+				[]interface{}{
+					"asc",
+					"asc",
+					"asc",
+					"asc",
+				},
+			},
 			Children: []*base.Op{&base.Op{
-				Kind:   "scan",
-				Labels: base.Labels{"a", "b"},
+				Kind:   "joinHash-leftOuter",
+				Labels: base.Labels{"dept", "city", "emp", "empDept"},
 				Params: []interface{}{
-					"csvData",
-					`
-`,
+					[]interface{}{"labelPath", `dept`},
+					[]interface{}{"labelPath", `someFakeLabel`},
 				},
-			}, &base.Op{
-				Kind:   "scan",
-				Labels: base.Labels{"a", "b"},
-				Params: []interface{}{
-					"csvData",
-					`
-20,21
-30,11
-20,21
-30,31
+				Children: []*base.Op{&base.Op{
+					Kind:   "scan",
+					Labels: base.Labels{"dept", "city"},
+					Params: []interface{}{
+						"csvData",
+						`
+"dev","paris"
+"finance","london"
 `,
-				},
+					},
+				}, &base.Op{
+					Kind:   "scan",
+					Labels: base.Labels{"emp", "empDept"},
+					Params: []interface{}{
+						"csvData",
+						`
+"dan","dev"
+"doug","dev"
+"frank","finance"
+"fred","finance"
+`,
+					},
+				}},
 			}},
 		},
-		expectYields: []base.Vals(nil),
+		expectYields: []base.Vals{
+			StringsToVals([]string{`"dev"`, `"paris"`, ``, ``}, nil),
+			StringsToVals([]string{`"finance"`, `"london"`, ``, ``}, nil),
+		},
 	},
 	{
-		about: "test csv-data scan->intersect-all of repeating",
+		about: "test csv-data scan->project",
 		o: base.Op{
-			Kind:   "intersect-all",
-			Labels: base.Labels{"a", "b"},
+			Kind:   "project",
+			Labels: base.Labels{"x"},
+			Params: []interface{}{
+				[]interface{}{"valsEncodeCanonical", "a"},
+			},
 			Children: []*base.Op{&base.Op{
 				Kind:   "scan",
-				Labels: base.Labels{"dept", "city"},
-				Params: []interface{}{
-					"csvData",
-					`
-20,21
-10,11
-20,21
-10,11
-`,
-				},
-			}, &base.Op{
-				Kind:   "scan",
-				Labels: base.Labels{"a", "b"},
+				Labels: base.Labels{"a", "b", "c"},
 				Params: []interface{}{
 					"csvData",
 					`
-20,21
-30,11
-20,21
-30,31
+00,00,0.000
+1.200,-22,-0.0
 `,
 				},
 			}},
 		},
 		expectYields: []base.Vals{
-			StringsToVals([]string{`20`, `21`}, nil),
-			StringsToVals([]string{`20`, `21`}, nil),
+			base.Vals{[]byte("\x03\x00\x00\x00\x00\x00\x00\x00\x01\x00\x00\x00\x00\x00\x00\x000\x01\x00\x00\x00\x00\x00\x00\x000\x01\x00\x00\x00\x00\x00\x00\x000")},
+			base.Vals{[]byte("\x03\x00\x00\x00\x00\x00\x00\x00\x03\x00\x00\x00\x00\x00\x00\x001.2\x03\x00\x00\x00\x00\x00\x00\x00-22\x02\x00\x00\x00\x00\x00\x00\x00-0")},
 		},
 	},
 	{
-		about: "test csv-data scan->except-distinct",
+		about: "test csv-data scan->intersect-distinct",
 		o: base.Op{
-			Kind:   "except-distinct",
+			Kind:   "intersect-distinct",
 			Labels: base.Labels{"a", "b"},
 			Children: []*base.Op{&base.Op{
 				Kind:   "scan",
@@ -3516,13 +4025,13 @@ var TestCasesSimple = []TestCaseSimple{
 			}},
 		},
 		expectYields: []base.Vals{
-			StringsToVals([]string{`10`, `11`}, nil),
+			StringsToVals([]string{`20`, `21`}, nil),
 		},
 	},
 	{
-		about: "test csv-data scan->except-distinct of empty left",
+		about: "test csv-data scan->intersect-distinct of empty left",
 		o: base.Op{
-			Kind:   "except-distinct",
+			Kind:   "intersect-distinct",
 			Labels: base.Labels{"a", "b"},
 			Children: []*base.Op{&base.Op{
 				Kind:   "scan",
@@ -3547,9 +4056,9 @@ var TestCasesSimple = []TestCaseSimple{
 		expectYields: []base.Vals(nil),
 	},
 	{
-		about: "test csv-data scan->except-distinct of empty right",
+		about: "test csv-data scan->intersect-distinct of empty right",
 		o: base.Op{
-			Kind:   "except-distinct",
+			Kind:   "intersect-distinct",
 			Labels: base.Labels{"a", "b"},
 			Children: []*base.Op{&base.Op{
 				Kind:   "scan",
@@ -3571,62 +4080,41 @@ var TestCasesSimple = []TestCaseSimple{
 				},
 			}},
 		},
-		expectYields: []base.Vals{
-			StringsToVals([]string{`20`, `21`}, nil),
-			StringsToVals([]string{`10`, `11`}, nil),
-		},
+		expectYields: []base.Vals(nil),
 	},
 	{
-		about: "test csv-data scan->except-distinct of repeating left",
+		about: "test csv-data scan->intersect-distinct of repeating left",
 		o: base.Op{
-			Kind:   "order-offset-limit",
+			Kind:   "intersect-distinct",
 			Labels: base.Labels{"a", "b"},
-			Params: []interface{}{
-				[]interface{}{
-					[]interface{}{"labelPath", "a"},
-					[]interface{}{"labelPath", "b"},
-				},
-				[]interface{}{
-					"asc",
-					"asc",
-				},
-			},
 			Children: []*base.Op{&base.Op{
-				Kind:   "except-distinct",
+				Kind:   "scan",
 				Labels: base.Labels{"a", "b"},
-				Children: []*base.Op{&base.Op{
-					Kind:   "scan",
-					Labels: base.Labels{"a", "b"},
-					Params: []interface{}{
-						"csvData",
-						`
+				Params: []interface{}{
+					"csvData",
+					`
 20,21
 10,11
 20,21
 30,31
 `,
-					},
-				}, &base.Op{
-					Kind:   "scan",
-					Labels: base.Labels{"a", "b"},
-					Params: []interface{}{
-						"csvData",
-						`
+				},
+			}, &base.Op{
+				Kind:   "scan",
+				Labels: base.Labels{"a", "b"},
+				Params: []interface{}{
+					"csvData",
+					`
 `,
-					},
-				}},
+				},
 			}},
 		},
-		expectYields: []base.Vals{
-			StringsToVals([]string{`10`, `11`}, nil),
-			StringsToVals([]string{`20`, `21`}, nil),
-			StringsToVals([]string{`30`, `31`}, nil),
-		},
+		expectYields: []base.Vals(nil),
 	},
 	{
-		about: "test csv-data scan->except-distinct of repeating right",
+		about: "test csv-data scan->intersect-distinct of repeating right",
 		o: base.Op{
-			Kind:   "except-distinct",
+			Kind:   "intersect-distinct",
 			Labels: base.Labels{"a", "b"},
 			Children: []*base.Op{&base.Op{
 				Kind:   "scan",
@@ -3653,13 +4141,13 @@ var TestCasesSimple = []TestCaseSimple{
 		expectYields: []base.Vals(nil),
 	},
 	{
-		about: "test csv-data scan->except-distinct of repeating",
+		about: "test csv-data scan->intersect-distinct of repeating",
 		o: base.Op{
-			Kind:   "except-distinct",
+			Kind:   "intersect-distinct",
 			Labels: base.Labels{"a", "b"},
 			Children: []*base.Op{&base.Op{
 				Kind:   "scan",
-				Labels: base.Labels{"dept", "city"},
+				Labels: base.Labels{"a", "b"},
 				Params: []interface{}{
 					"csvData",
 					`
@@ -3684,13 +4172,13 @@ var TestCasesSimple = []TestCaseSimple{
 			}},
 		},
 		expectYields: []base.Vals{
-			StringsToVals([]string{`10`, `11`}, nil),
+			StringsToVals([]string{`20`, `21`}, nil),
 		},
 	},
 	{
-		about: "test csv-data scan->except-all",
+		about: "test csv-data scan->intersect-all",
 		o: base.Op{
-			Kind:   "except-all",
+			Kind:   "intersect-all",
 			Labels: base.Labels{"a", "b"},
 			Children: []*base.Op{&base.Op{
 				Kind:   "scan",
@@ -3715,13 +4203,13 @@ var TestCasesSimple = []TestCaseSimple{
 			}},
 		},
 		expectYields: []base.Vals{
-			StringsToVals([]string{`10`, `11`}, nil),
+			StringsToVals([]string{`20`, `21`}, nil),
 		},
 	},
 	{
-		about: "test csv-data scan->except-all of empty left",
+		about: "test csv-data scan->intersect-all of empty left",
 		o: base.Op{
-			Kind:   "except-all",
+			Kind:   "intersect-all",
 			Labels: base.Labels{"a", "b"},
 			Children: []*base.Op{&base.Op{
 				Kind:   "scan",
@@ -3746,9 +4234,9 @@ var TestCasesSimple = []TestCaseSimple{
 		expectYields: []base.Vals(nil),
 	},
 	{
-		about: "test csv-data scan->except-all of empty right",
+		about: "test csv-data scan->intersect-all of empty right",
 		o: base.Op{
-			Kind:   "except-all",
+			Kind:   "intersect-all",
 			Labels: base.Labels{"a", "b"},
 			Children: []*base.Op{&base.Op{
 				Kind:   "scan",
@@ -3770,63 +4258,41 @@ var TestCasesSimple = []TestCaseSimple{
 				},
 			}},
 		},
-		expectYields: []base.Vals{
-			StringsToVals([]string{`20`, `21`}, nil),
-			StringsToVals([]string{`10`, `11`}, nil),
-		},
+		expectYields: []base.Vals(nil),
 	},
 	{
-		about: "test csv-data scan->except-all of repeating left",
+		about: "test csv-data scan->intersect-all of repeating left",
 		o: base.Op{
-			Kind:   "order-offset-limit",
+			Kind:   "intersect-all",
 			Labels: base.Labels{"a", "b"},
-			Params: []interface{}{
-				[]interface{}{
-					[]interface{}{"labelPath", "a"},
-					[]interface{}{"labelPath", "b"},
-				},
-				[]interface{}{
-					"asc",
-					"asc",
-				},
-			},
 			Children: []*base.Op{&base.Op{
-				Kind:   "except-all",
+				Kind:   "scan",
 				Labels: base.Labels{"a", "b"},
-				Children: []*base.Op{&base.Op{
-					Kind:   "scan",
-					Labels: base.Labels{"a", "b"},
-					Params: []interface{}{
-						"csvData",
-						`
+				Params: []interface{}{
+					"csvData",
+					`
 20,21
 10,11
 20,21
 30,31
 `,
-					},
-				}, &base.Op{
-					Kind:   "scan",
-					Labels: base.Labels{"a", "b"},
-					Params: []interface{}{
-						"csvData",
-						`
+				},
+			}, &base.Op{
+				Kind:   "scan",
+				Labels: base.Labels{"a", "b"},
+				Params: []interface{}{
+					"csvData",
+					`
 `,
-					},
-				}},
+				},
 			}},
 		},
-		expectYields: []base.Vals{
-			StringsToVals([]string{`10`, `11`}, nil),
-			StringsToVals([]string{`20`, `21`}, nil),
-			StringsToVals([]string{`20`, `21`}, nil),
-			StringsToVals([]string{`30`, `31`}, nil),
-		},
+		expectYields: []base.Vals(nil),
 	},
 	{
-		about: "test csv-data scan->except-all of repeating right",
+		about: "test csv-data scan->intersect-all of repeating right",
 		o: base.Op{
-			Kind:   "except-all",
+			Kind:   "intersect-all",
 			Labels: base.Labels{"a", "b"},
 			Children: []*base.Op{&base.Op{
 				Kind:   "scan",
@@ -3853,9 +4319,9 @@ var TestCasesSimple = []TestCaseSimple{
 		expectYields: []base.Vals(nil),
 	},
 	{
-		about: "test csv-data scan->except-all of repeating",
+		about: "test csv-data scan->intersect-all of repeating",
 		o: base.Op{
-			Kind:   "except-all",
+			Kind:   "intersect-all",
 			Labels: base.Labels{"a", "b"},
 			Children: []*base.Op{&base.Op{
 				Kind:   "scan",
@@ -3884,26 +4350,15 @@ var TestCasesSimple = []TestCaseSimple{
 			}},
 		},
 		expectYields: []base.Vals{
-			StringsToVals([]string{`10`, `11`}, nil),
-			StringsToVals([]string{`10`, `11`}, nil),
+			StringsToVals([]string{`20`, `21`}, nil),
+			StringsToVals([]string{`20`, `21`}, nil),
 		},
 	},
 	{
-		about: "test csv-data scan->group-by a then sum(b)",
+		about: "test csv-data scan->except-distinct",
 		o: base.Op{
-			Kind:   "group",
-			Labels: base.Labels{"a", "sum-b"},
-			Params: []interface{}{
-				[]interface{}{
-					[]interface{}{"labelPath", "a"},
-				},
-				[]interface{}{
-					[]interface{}{"labelPath", "b"},
-				},
-				[]interface{}{
-					[]interface{}{"sum"},
-				},
-			},
+			Kind:   "except-distinct",
+			Labels: base.Labels{"a", "b"},
 			Children: []*base.Op{&base.Op{
 				Kind:   "scan",
 				Labels: base.Labels{"a", "b"},
@@ -3911,142 +4366,91 @@ var TestCasesSimple = []TestCaseSimple{
 					"csvData",
 					`
 10,11
-10,12
-20,20
+20,21
 `,
 				},
-			}},
-		},
-		expectYields: []base.Vals{
-			base.Vals{[]byte("20"), []byte("20")},
-			base.Vals{[]byte("10"), []byte("23")},
-		},
-	},
-	{
-		about: "test csv-data scan->group-by a then sum(a)",
-		o: base.Op{
-			Kind:   "group",
-			Labels: base.Labels{"a", "sum-b"},
-			Params: []interface{}{
-				[]interface{}{
-					[]interface{}{"labelPath", "a"},
-				},
-				[]interface{}{
-					[]interface{}{"labelPath", "a"},
-				},
-				[]interface{}{
-					[]interface{}{"sum"},
-				},
-			},
-			Children: []*base.Op{&base.Op{
+			}, &base.Op{
 				Kind:   "scan",
 				Labels: base.Labels{"a", "b"},
 				Params: []interface{}{
 					"csvData",
 					`
-10,11
-10,12
-20,20
+20,21
+30,31
 `,
 				},
 			}},
 		},
 		expectYields: []base.Vals{
-			base.Vals{[]byte("20"), []byte("20")},
-			base.Vals{[]byte("10"), []byte("20")},
+			StringsToVals([]string{`10`, `11`}, nil),
 		},
 	},
 	{
-		about: "test csv-data scan->group-by a then sum(b), count(b)",
+		about: "test csv-data scan->except-distinct of empty left",
 		o: base.Op{
-			Kind:   "group",
-			Labels: base.Labels{"a", "sum-b", "count-b"},
-			Params: []interface{}{
-				[]interface{}{
-					[]interface{}{"labelPath", "a"},
-				},
-				[]interface{}{
-					[]interface{}{"labelPath", "b"},
-				},
-				[]interface{}{
-					[]interface{}{"sum", "count"},
-				},
-			},
+			Kind:   "except-distinct",
+			Labels: base.Labels{"a", "b"},
 			Children: []*base.Op{&base.Op{
 				Kind:   "scan",
 				Labels: base.Labels{"a", "b"},
 				Params: []interface{}{
 					"csvData",
 					`
-10,11
-10,12
-20,20
+`,
+				},
+			}, &base.Op{
+				Kind:   "scan",
+				Labels: base.Labels{"a", "b"},
+				Params: []interface{}{
+					"csvData",
+					`
+20,21
+30,31
 `,
 				},
 			}},
 		},
-		expectYields: []base.Vals{
-			base.Vals{[]byte("20"), []byte("20"), []byte("1")},
-			base.Vals{[]byte("10"), []byte("23"), []byte("2")},
-		},
+		expectYields: []base.Vals(nil),
 	},
 	{
-		about: "test csv-data scan->group-by min(b)",
+		about: "test csv-data scan->except-distinct of empty right",
 		o: base.Op{
-			Kind:   "order-offset-limit",
-			Labels: base.Labels{"a", "min-b"},
-			Params: []interface{}{
-				[]interface{}{
-					[]interface{}{"labelPath", "a"},
-					[]interface{}{"labelPath", "min-b"},
-				},
-				[]interface{}{
-					"asc",
-					"asc",
-				},
-			},
+			Kind:   "except-distinct",
+			Labels: base.Labels{"a", "b"},
 			Children: []*base.Op{&base.Op{
-				Kind:   "group",
-				Labels: base.Labels{"a", "min-b"},
+				Kind:   "scan",
+				Labels: base.Labels{"a", "b"},
 				Params: []interface{}{
-					[]interface{}{
-						[]interface{}{"labelPath", "a"},
-					},
-					[]interface{}{
-						[]interface{}{"labelPath", "b"},
-					},
-					[]interface{}{
-						[]interface{}{"min"},
-					},
-				},
-				Children: []*base.Op{&base.Op{
-					Kind:   "scan",
-					Labels: base.Labels{"a", "b"},
-					Params: []interface{}{
-						"csvData",
-						`
+					"csvData",
+					`
 10,11
-10,12
-20,20
+20,21
 `,
-					},
-				}},
+				},
+			}, &base.Op{
+				Kind:   "scan",
+				Labels: base.Labels{"a", "b"},
+				Params: []interface{}{
+					"csvData",
+					`
+`,
+				},
 			}},
 		},
 		expectYields: []base.Vals{
-			base.Vals{[]byte("10"), []byte("11")},
-			base.Vals{[]byte("20"), []byte("20")},
+			StringsToVals([]string{`20`, `21`}, nil),
+			StringsToVals([]string{`10`, `11`}, nil),
 		},
 	},
 	{
-		about: "test csv-data scan->group-by max(b)",
+		about: "test csv-data scan->except-distinct of repeating left",
 		o: base.Op{
 			Kind:   "order-offset-limit",
-			Labels: base.Labels{"a", "max-b"},
+			Labels: base.Labels{"a", "b"},
 			Params: []interface{}{
 				[]interface{}{
 					[]interface{}{"labelPath", "a"},
-					[]interface{}{"labelPath", "max-b"},
+					[]interface{}{"labelPath", "b"},
 				},
 				[]interface{}{
 					"asc",
@@ -4054,1934 +4458,4682 @@ var TestCasesSimple = []TestCaseSimple{
 				},
 			},
 			Children: []*base.Op{&base.Op{
-				Kind:   "group",
-				Labels: base.Labels{"a", "max-b"},
-				Params: []interface{}{
-					[]interface{}{
-						[]interface{}{"labelPath", "a"},
-					},
-					[]interface{}{
-						[]interface{}{"labelPath", "b"},
-					},
-					[]interface{}{
-						[]interface{}{"max"},
-					},
-				},
+				Kind:   "except-distinct",
+				Labels: base.Labels{"a", "b"},
 				Children: []*base.Op{&base.Op{
 					Kind:   "scan",
 					Labels: base.Labels{"a", "b"},
 					Params: []interface{}{
 						"csvData",
 						`
+20,21
 10,11
-10,12
-20,20
+20,21
+30,31
 `,
 					},
-				}},
-			}},
-		},
-		expectYields: []base.Vals{
-			base.Vals{[]byte("10"), []byte("12")},
-			base.Vals{[]byte("20"), []byte("20")},
-		},
-	},
-	{
-		about: "test csv-data scan->group-by avg(b)",
-		o: base.Op{
-			Kind:   "order-offset-limit",
-			Labels: base.Labels{"a", "avg-b"},
-			Params: []interface{}{
-				[]interface{}{
-					[]interface{}{"labelPath", "a"},
-					[]interface{}{"labelPath", "avg-b"},
-				},
-				[]interface{}{
-					"asc",
-					"asc",
-				},
-			},
-			Children: []*base.Op{&base.Op{
-				Kind:   "group",
-				Labels: base.Labels{"a", "avg-b"},
-				Params: []interface{}{
-					[]interface{}{
-						[]interface{}{"labelPath", "a"},
-					},
-					[]interface{}{
-						[]interface{}{"labelPath", "b"},
-					},
-					[]interface{}{
-						[]interface{}{"avg"},
-					},
-				},
-				Children: []*base.Op{&base.Op{
+				}, &base.Op{
 					Kind:   "scan",
 					Labels: base.Labels{"a", "b"},
 					Params: []interface{}{
 						"csvData",
 						`
-10,11
-10,12
-20,20
 `,
 					},
 				}},
 			}},
 		},
 		expectYields: []base.Vals{
-			base.Vals{[]byte("10"), []byte("11.5")},
-			base.Vals{[]byte("20"), []byte("20")},
+			StringsToVals([]string{`10`, `11`}, nil),
+			StringsToVals([]string{`20`, `21`}, nil),
+			StringsToVals([]string{`30`, `31`}, nil),
 		},
 	},
 	{
-		about: "test csv-data scan->unnest-inner",
+		about: "test csv-data scan->except-distinct of repeating right",
 		o: base.Op{
-			Kind:   "unnest-inner",
-			Labels: base.Labels{"."},
-			Params: []interface{}{
-				"labelPath", ".", "a",
-			},
+			Kind:   "except-distinct",
+			Labels: base.Labels{"a", "b"},
 			Children: []*base.Op{&base.Op{
 				Kind:   "scan",
-				Labels: base.Labels{"."},
+				Labels: base.Labels{"a", "b"},
 				Params: []interface{}{
-					"jsonsData",
+					"csvData",
 					`
-{"a":[1,2]}
-{"a":[3]}
-{"a":[]}
-{"a":123}
 `,
 				},
 			}, &base.Op{
-				Kind:   "noop",
-				Labels: base.Labels{"child"},
+				Kind:   "scan",
+				Labels: base.Labels{"a", "b"},
+				Params: []interface{}{
+					"csvData",
+					`
+20,21
+30,11
+20,21
+30,31
+`,
+				},
 			}},
 		},
-		expectYields: []base.Vals{
-			base.Vals{[]byte(`{"a":[1,2]}`), []byte("1")},
-			base.Vals{[]byte(`{"a":[1,2]}`), []byte("2")},
-			base.Vals{[]byte(`{"a":[3]}`), []byte("3")},
-		},
+		expectYields: []base.Vals(nil),
 	},
 	{
-		about: "test csv-data scan->unnest-leftOuter",
+		about: "test csv-data scan->except-distinct of repeating",
 		o: base.Op{
-			Kind:   "unnest-leftOuter",
-			Labels: base.Labels{"."},
-			Params: []interface{}{
-				"labelPath", ".", "a",
-			},
+			Kind:   "except-distinct",
+			Labels: base.Labels{"a", "b"},
 			Children: []*base.Op{&base.Op{
 				Kind:   "scan",
-				Labels: base.Labels{"."},
+				Labels: base.Labels{"dept", "city"},
 				Params: []interface{}{
-					"jsonsData",
+					"csvData",
 					`
-{"a":[1,2]}
-{"a":[3]}
-{"a":[]}
-{"a":123}
+20,21
+10,11
+20,21
+10,11
 `,
 				},
 			}, &base.Op{
-				Kind:   "noop",
-				Labels: base.Labels{"child"},
-			}},
-		},
-		expectYields: []base.Vals{
-			base.Vals{[]byte(`{"a":[1,2]}`), []byte("1")},
-			base.Vals{[]byte(`{"a":[1,2]}`), []byte("2")},
-			base.Vals{[]byte(`{"a":[3]}`), []byte("3")},
-			base.Vals{[]byte(`{"a":[]}`), []byte(nil)},
-			base.Vals{[]byte(`{"a":123}`), []byte(nil)},
+				Kind:   "scan",
+				Labels: base.Labels{"a", "b"},
+				Params: []interface{}{
+					"csvData",
+					`
+20,21
+30,11
+20,21
+30,31
+`,
+				},
+			}},
+		},
+		expectYields: []base.Vals{
+			StringsToVals([]string{`10`, `11`}, nil),
 		},
 	},
 	{
-		about: "test csv-data scan->nestNL-inner",
+		about: "test csv-data scan->except-all",
 		o: base.Op{
-			Kind:   "nestNL-inner",
-			Labels: base.Labels{"dept", "city", "emp"},
-			Params: []interface{}{
-				"eq",
-				[]interface{}{"labelPath", "dept"},
-				[]interface{}{"labelPath", "empDept"},
-			},
+			Kind:   "except-all",
+			Labels: base.Labels{"a", "b"},
 			Children: []*base.Op{&base.Op{
 				Kind:   "scan",
-				Labels: base.Labels{"dept", "city"},
+				Labels: base.Labels{"a", "b"},
 				Params: []interface{}{
 					"csvData",
 					`
-"dev","paris"
-"finance","london"
-"sales","san diego"
+10,11
+20,21
 `,
 				},
 			}, &base.Op{
 				Kind:   "scan",
-				Labels: base.Labels{"empDept", "emp"},
+				Labels: base.Labels{"a", "b"},
 				Params: []interface{}{
 					"csvData",
 					`
-"dev","dan"
-"dev","doug"
-"finance","frank"
-"finance","fred"
-"marketing","mary"
+20,21
+30,31
 `,
 				},
 			}},
 		},
 		expectYields: []base.Vals{
-			StringsToVals([]string{`"dev"`, `"paris"`, `["dan","doug"]`}, nil),
-			StringsToVals([]string{`"finance"`, `"london"`, `["frank","fred"]`}, nil),
+			StringsToVals([]string{`10`, `11`}, nil),
 		},
 	},
 	{
-		about: "test csv-data scan->nestNL-leftOuter",
+		about: "test csv-data scan->except-all of empty left",
 		o: base.Op{
-			Kind:   "nestNL-leftOuter",
-			Labels: base.Labels{"dept", "city", "emp"},
-			Params: []interface{}{
-				"eq",
-				[]interface{}{"labelPath", "dept"},
-				[]interface{}{"labelPath", "empDept"},
-			},
+			Kind:   "except-all",
+			Labels: base.Labels{"a", "b"},
 			Children: []*base.Op{&base.Op{
 				Kind:   "scan",
-				Labels: base.Labels{"dept", "city"},
+				Labels: base.Labels{"a", "b"},
 				Params: []interface{}{
 					"csvData",
 					`
-"dev","paris"
-"finance","london"
-"sales","san diego"
 `,
 				},
 			}, &base.Op{
 				Kind:   "scan",
-				Labels: base.Labels{"empDept", "emp"},
+				Labels: base.Labels{"a", "b"},
 				Params: []interface{}{
 					"csvData",
 					`
-"dev","dan"
-"dev","doug"
-"finance","frank"
-"finance","fred"
-"marketing","mary"
+20,21
+30,31
 `,
 				},
 			}},
 		},
-		expectYields: []base.Vals{
-			StringsToVals([]string{`"dev"`, `"paris"`, `["dan","doug"]`}, nil),
-			StringsToVals([]string{`"finance"`, `"london"`, `["frank","fred"]`}, nil),
-			StringsToVals([]string{`"sales"`, `"san diego"`, `[]`}, nil),
-		},
+		expectYields: []base.Vals(nil),
 	},
 	{
-		about: "test csv-data sequence->[scan->filter->project->temp-capture]",
+		about: "test csv-data scan->except-all of empty right",
 		o: base.Op{
-			Kind:   "sequence",
-			Labels: base.Labels{"a", "c"},
+			Kind:   "except-all",
+			Labels: base.Labels{"a", "b"},
 			Children: []*base.Op{&base.Op{
-				Kind:   "temp-capture",
-				Labels: base.Labels{"a", "c"},
-				Params: []interface{}{0},
-				Children: []*base.Op{&base.Op{
-					Kind:   "project",
-					Labels: base.Labels{"a", "c"},
-					Params: []interface{}{
-						[]interface{}{"labelPath", "a"},
-						[]interface{}{"labelPath", "c"},
-					},
-					Children: []*base.Op{&base.Op{
-						Kind:   "filter",
-						Labels: base.Labels{"a", "b", "c"},
-						Params: []interface{}{
-							"eq",
-							[]interface{}{"labelPath", "c"},
-							[]interface{}{"json", `3000`},
-						},
-						Children: []*base.Op{&base.Op{
-							Kind:   "scan",
-							Labels: base.Labels{"a", "b", "c"},
-							Params: []interface{}{
-								"csvData",
-								`
-00,00,0000
-10,20,3000
-11,21,3000
-12,22,1000
+				Kind:   "scan",
+				Labels: base.Labels{"a", "b"},
+				Params: []interface{}{
+					"csvData",
+					`
+10,11
+20,21
 `,
-							},
-						}},
-					}},
-				}},
+				},
+			}, &base.Op{
+				Kind:   "scan",
+				Labels: base.Labels{"a", "b"},
+				Params: []interface{}{
+					"csvData",
+					`
+`,
+				},
 			}},
 		},
-		expectYields: []base.Vals(nil),
+		expectYields: []base.Vals{
+			StringsToVals([]string{`20`, `21`}, nil),
+			StringsToVals([]string{`10`, `11`}, nil),
+		},
 	},
 	{
-		about: "test csv-data sequence->[scan->filter->project->temp-capture, temp-yield]",
+		about: "test csv-data scan->except-all of repeating left",
 		o: base.Op{
-			Kind:   "sequence",
-			Labels: base.Labels{"a", "c"},
+			Kind:   "order-offset-limit",
+			Labels: base.Labels{"a", "b"},
+			Params: []interface{}{
+				[]interface{}{
+					[]interface{}{"labelPath", "a"},
+					[]interface{}{"labelPath", "b"},
+				},
+				[]interface{}{
+					"asc",
+					"asc",
+				},
+			},
 			Children: []*base.Op{&base.Op{
-				Kind:   "temp-capture",
-				Labels: base.Labels{"a", "c"},
-				Params: []interface{}{0},
+				Kind:   "except-all",
+				Labels: base.Labels{"a", "b"},
 				Children: []*base.Op{&base.Op{
-					Kind:   "project",
-					Labels: base.Labels{"a", "c"},
+					Kind:   "scan",
+					Labels: base.Labels{"a", "b"},
 					Params: []interface{}{
-						[]interface{}{"labelPath", "a"},
-						[]interface{}{"labelPath", "c"},
+						"csvData",
+						`
+20,21
+10,11
+20,21
+30,31
+`,
 					},
-					Children: []*base.Op{&base.Op{
-						Kind:   "filter",
-						Labels: base.Labels{"a", "b", "c"},
-						Params: []interface{}{
-							"eq",
-							[]interface{}{"labelPath", "c"},
-							[]interface{}{"json", `3000`},
-						},
-						Children: []*base.Op{&base.Op{
-							Kind:   "scan",
-							Labels: base.Labels{"a", "b", "c"},
-							Params: []interface{}{
-								"csvData",
-								`
-00,00,0000
-10,20,3000
-11,21,3000
-12,22,1000
+				}, &base.Op{
+					Kind:   "scan",
+					Labels: base.Labels{"a", "b"},
+					Params: []interface{}{
+						"csvData",
+						`
 `,
-							},
-						}},
-					}},
+					},
 				}},
-			}, &base.Op{
-				Kind:   "temp-yield",
-				Labels: base.Labels{"a", "c"},
-				Params: []interface{}{0},
 			}},
 		},
 		expectYields: []base.Vals{
-			base.Vals{[]byte("10"), []byte("3000")},
-			base.Vals{[]byte("11"), []byte("3000")},
+			StringsToVals([]string{`10`, `11`}, nil),
+			StringsToVals([]string{`20`, `21`}, nil),
+			StringsToVals([]string{`20`, `21`}, nil),
+			StringsToVals([]string{`30`, `31`}, nil),
 		},
 	},
 	{
-		about: "test csv-data scan->order->window-partition->window-frame->project window-frame-count",
+		about: "test csv-data scan->except-all of repeating right",
 		o: base.Op{
-			Kind:   "project",
-			Labels: base.Labels{"a", "count-a"},
-			Params: []interface{}{
-				[]interface{}{"labelPath", "a"},
-				[]interface{}{
-					"window-frame-count",
-					1, // Slot for window frames.
-					0, // Idx for window frame.
-				},
-			},
+			Kind:   "except-all",
+			Labels: base.Labels{"a", "b"},
 			Children: []*base.Op{&base.Op{
-				Kind:   "window-frames",
+				Kind:   "scan",
 				Labels: base.Labels{"a", "b"},
 				Params: []interface{}{
-					0, // Slot for window partition.
-					1, // Slot for window frames.
-					[]interface{}{ // Window frames cfg.
-						[]interface{}{
-							"rows",
-							"num", -1, // Preceding.
-							"num", 1, // Following.
-							"no-others", // Exclude.
-							0,           // ValIdx, unused.
-						},
-					},
+					"csvData",
+					`
+`,
 				},
-				Children: []*base.Op{&base.Op{
-					Kind:   "window-partition",
-					Labels: base.Labels{"a", "b"},
-					Params: []interface{}{
-						0, // Slot for window partition.
-						[]interface{}{
-							// Partitioning exprs...
-							[]interface{}{"labelPath", "a"},
-						},
-						1,  // # of the partitioning exprs for PARTITION-BY.
-						"", // Additional tracking info.
-					},
-					Children: []*base.Op{&base.Op{
-						Kind:   "order-offset-limit",
-						Labels: base.Labels{"a", "b"},
-						Params: []interface{}{
-							[]interface{}{
-								[]interface{}{"labelPath", "a"},
-								[]interface{}{"labelPath", "b"},
-							},
-							[]interface{}{
-								"asc",
-								"asc",
-							},
-						},
-						Children: []*base.Op{&base.Op{
-							Kind:   "scan",
-							Labels: base.Labels{"a", "b"},
-							Params: []interface{}{
-								"csvData",
-								`
+			}, &base.Op{
+				Kind:   "scan",
+				Labels: base.Labels{"a", "b"},
+				Params: []interface{}{
+					"csvData",
+					`
+20,21
+30,11
+20,21
+30,31
+`,
+				},
+			}},
+		},
+		expectYields: []base.Vals(nil),
+	},
+	{
+		about: "test csv-data scan->except-all of repeating",
+		o: base.Op{
+			Kind:   "except-all",
+			Labels: base.Labels{"a", "b"},
+			Children: []*base.Op{&base.Op{
+				Kind:   "scan",
+				Labels: base.Labels{"dept", "city"},
+				Params: []interface{}{
+					"csvData",
+					`
+20,21
 10,11
-10,12
-10,13
-20,20
 20,21
-30,30
+10,11
 `,
-							},
-						}},
-					}},
-				}},
+				},
+			}, &base.Op{
+				Kind:   "scan",
+				Labels: base.Labels{"a", "b"},
+				Params: []interface{}{
+					"csvData",
+					`
+20,21
+30,11
+20,21
+30,31
+`,
+				},
 			}},
 		},
 		expectYields: []base.Vals{
-			base.Vals{[]byte("10"), []byte("2")},
-			base.Vals{[]byte("10"), []byte("3")},
-			base.Vals{[]byte("10"), []byte("2")},
-			base.Vals{[]byte("20"), []byte("2")},
-			base.Vals{[]byte("20"), []byte("2")},
-			base.Vals{[]byte("30"), []byte("1")},
+			StringsToVals([]string{`10`, `11`}, nil),
+			StringsToVals([]string{`10`, `11`}, nil),
 		},
 	},
 	{
-		about: "test csv-data scan->order->window-partition->window-frame-exclude-current-row->project window-frame-count",
+		about: "test csv-data scan->group-by a then sum(b)",
 		o: base.Op{
-			Kind:   "project",
-			Labels: base.Labels{"a", "count-a"},
+			Kind:   "group",
+			Labels: base.Labels{"a", "sum-b"},
 			Params: []interface{}{
-				[]interface{}{"labelPath", "a"},
 				[]interface{}{
-					"window-frame-count",
-					1, // Slot for window frames.
-					0, // Idx for window frame.
+					[]interface{}{"labelPath", "a"},
+				},
+				[]interface{}{
+					[]interface{}{"labelPath", "b"},
+				},
+				[]interface{}{
+					[]interface{}{"sum"},
 				},
 			},
 			Children: []*base.Op{&base.Op{
-				Kind:   "window-frames",
+				Kind:   "scan",
 				Labels: base.Labels{"a", "b"},
 				Params: []interface{}{
-					0, // Slot for window partition.
-					1, // Slot for window frames.
-					[]interface{}{ // Window frames cfg.
-						[]interface{}{
-							"rows",
-							"num", -1, // Preceding.
-							"num", 1, // Following.
-							"current-row", // Exclude.
-							0,             // ValIdx, unused.
-						},
-					},
-				},
-				Children: []*base.Op{&base.Op{
-					Kind:   "window-partition",
-					Labels: base.Labels{"a", "b"},
-					Params: []interface{}{
-						0, // Slot for window partition.
-						[]interface{}{
-							// Partitioning exprs...
-							[]interface{}{"labelPath", "a"},
-						},
-						1,  // # of the partitioning exprs for PARTITION-BY.
-						"", // Additional tracking info.
-					},
-					Children: []*base.Op{&base.Op{
-						Kind:   "order-offset-limit",
-						Labels: base.Labels{"a", "b"},
-						Params: []interface{}{
-							[]interface{}{
-								[]interface{}{"labelPath", "a"},
-								[]interface{}{"labelPath", "b"},
-							},
-							[]interface{}{
-								"asc",
-								"asc",
-							},
-						},
-						Children: []*base.Op{&base.Op{
-							Kind:   "scan",
-							Labels: base.Labels{"a", "b"},
-							Params: []interface{}{
-								"csvData",
-								`
+					"csvData",
+					`
 10,11
 10,12
-10,13
 20,20
-20,21
-30,30
 `,
-							},
-						}},
-					}},
-				}},
+				},
 			}},
 		},
 		expectYields: []base.Vals{
-			base.Vals{[]byte("10"), []byte("1")},
-			base.Vals{[]byte("10"), []byte("2")},
-			base.Vals{[]byte("10"), []byte("1")},
-			base.Vals{[]byte("20"), []byte("1")},
-			base.Vals{[]byte("20"), []byte("1")},
-			base.Vals{[]byte("30"), []byte("0")},
+			base.Vals{[]byte("20"), []byte("20")},
+			base.Vals{[]byte("10"), []byte("23")},
 		},
 	},
 	{
-		about: "test csv-data scan->order->window-partition->window-frame current-row to unbounded ->project window-frame-count",
+		about: "test csv-data scan->group-by a then sum(a)",
 		o: base.Op{
-			Kind:   "project",
-			Labels: base.Labels{"a", "count-a"},
+			Kind:   "group",
+			Labels: base.Labels{"a", "sum-b"},
 			Params: []interface{}{
-				[]interface{}{"labelPath", "a"},
 				[]interface{}{
-					"window-frame-count",
-					1, // Slot for window frames.
-					0, // Idx for window frame.
+					[]interface{}{"labelPath", "a"},
+				},
+				[]interface{}{
+					[]interface{}{"labelPath", "a"},
+				},
+				[]interface{}{
+					[]interface{}{"sum"},
 				},
 			},
 			Children: []*base.Op{&base.Op{
-				Kind:   "window-frames",
+				Kind:   "scan",
 				Labels: base.Labels{"a", "b"},
 				Params: []interface{}{
-					0, // Slot for window partition.
-					1, // Slot for window frames.
-					[]interface{}{ // Window frames cfg.
-						[]interface{}{
-							"rows",
-							"num", 0, // Preceding.
-							"unbounded", 1, // Following.
-							"no-others", // Exclude.
-							0,           // ValIdx, unused.
-						},
-					},
-				},
-				Children: []*base.Op{&base.Op{
-					Kind:   "window-partition",
-					Labels: base.Labels{"a", "b"},
-					Params: []interface{}{
-						0, // Slot for window partition.
-						[]interface{}{
-							// Partitioning exprs...
-							[]interface{}{"labelPath", "a"},
-						},
-						1,  // # of the partitioning exprs for PARTITION-BY.
-						"", // Additional tracking info.
-					},
-					Children: []*base.Op{&base.Op{
-						Kind:   "order-offset-limit",
-						Labels: base.Labels{"a", "b"},
-						Params: []interface{}{
-							[]interface{}{
-								[]interface{}{"labelPath", "a"},
-								[]interface{}{"labelPath", "b"},
-							},
-							[]interface{}{
-								"asc",
-								"asc",
-							},
-						},
-						Children: []*base.Op{&base.Op{
-							Kind:   "scan",
-							Labels: base.Labels{"a", "b"},
-							Params: []interface{}{
-								"csvData",
-								`
+					"csvData",
+					`
 10,11
 10,12
-10,13
 20,20
-20,21
-30,30
 `,
-							},
-						}},
-					}},
-				}},
+				},
 			}},
 		},
 		expectYields: []base.Vals{
-			base.Vals{[]byte("10"), []byte("3")},
-			base.Vals{[]byte("10"), []byte("2")},
-			base.Vals{[]byte("10"), []byte("1")},
-			base.Vals{[]byte("20"), []byte("2")},
-			base.Vals{[]byte("20"), []byte("1")},
-			base.Vals{[]byte("30"), []byte("1")},
+			base.Vals{[]byte("20"), []byte("20")},
+			base.Vals{[]byte("10"), []byte("20")},
 		},
 	},
 	{
-		about: "test csv-data scan->order->window-partition->window-frame unbounded to current-row-minus-1 ->project window-frame-count",
+		about: "test csv-data scan->group-by a then sum(b), count(b)",
 		o: base.Op{
-			Kind:   "project",
-			Labels: base.Labels{"a", "count-a"},
+			Kind:   "group",
+			Labels: base.Labels{"a", "sum-b", "count-b"},
 			Params: []interface{}{
-				[]interface{}{"labelPath", "a"},
 				[]interface{}{
-					"window-frame-count",
-					1, // Slot for window frames.
-					0, // Idx for window frame.
+					[]interface{}{"labelPath", "a"},
+				},
+				[]interface{}{
+					[]interface{}{"labelPath", "b"},
+				},
+				[]interface{}{
+					[]interface{}{"sum", "count"},
 				},
 			},
 			Children: []*base.Op{&base.Op{
-				Kind:   "window-frames",
+				Kind:   "scan",
 				Labels: base.Labels{"a", "b"},
 				Params: []interface{}{
-					0, // Slot for window partition.
-					1, // Slot for window frames.
-					[]interface{}{ // Window frames cfg.
-						[]interface{}{
-							"rows",
-							"unbounded", 0, // Preceding.
-							"num", -1, // Following.
-							"no-others", // Exclude.
-							0,           // ValIdx, unused.
-						},
-					},
-				},
-				Children: []*base.Op{&base.Op{
-					Kind:   "window-partition",
-					Labels: base.Labels{"a", "b"},
-					Params: []interface{}{
-						0, // Slot for window partition.
-						[]interface{}{
-							// Partitioning exprs...
-							[]interface{}{"labelPath", "a"},
-						},
-						1,  // # of the partitioning exprs for PARTITION-BY.
-						"", // Additional tracking info.
-					},
-					Children: []*base.Op{&base.Op{
-						Kind:   "order-offset-limit",
-						Labels: base.Labels{"a", "b"},
-						Params: []interface{}{
-							[]interface{}{
-								[]interface{}{"labelPath", "a"},
-								[]interface{}{"labelPath", "b"},
-							},
-							[]interface{}{
-								"asc",
-								"asc",
-							},
-						},
-						Children: []*base.Op{&base.Op{
-							Kind:   "scan",
-							Labels: base.Labels{"a", "b"},
-							Params: []interface{}{
-								"csvData",
-								`
+					"csvData",
+					`
 10,11
 10,12
-10,13
 20,20
-20,21
-30,30
 `,
-							},
-						}},
-					}},
-				}},
+				},
 			}},
 		},
 		expectYields: []base.Vals{
-			base.Vals{[]byte("10"), []byte("0")},
-			base.Vals{[]byte("10"), []byte("1")},
-			base.Vals{[]byte("10"), []byte("2")},
-			base.Vals{[]byte("20"), []byte("0")},
-			base.Vals{[]byte("20"), []byte("1")},
-			base.Vals{[]byte("30"), []byte("0")},
+			base.Vals{[]byte("20"), []byte("20"), []byte("1")},
+			base.Vals{[]byte("10"), []byte("23"), []byte("2")},
 		},
 	},
 	{
-		about: "test csv-data window-partition->project window-frame FIRST_VALUE",
+		about: "test csv-data scan->group-by min(b)",
 		o: base.Op{
-			Kind:   "project",
-			Labels: base.Labels{"a", "rowNumber", "firstValue"},
+			Kind:   "order-offset-limit",
+			Labels: base.Labels{"a", "min-b"},
 			Params: []interface{}{
-				[]interface{}{"labelPath", "a"},
 				[]interface{}{
-					"window-partition-row-number",
-					1, // Slot for window frames.
-					0, // Idx for window frame.
+					[]interface{}{"labelPath", "a"},
+					[]interface{}{"labelPath", "min-b"},
 				},
 				[]interface{}{
-					"window-frame-step-value",
-					1,         // Slot for window frames.
-					0,         // Idx for window frame.
-					-1,        // Initial starting position is -1.
-					true,      // Step is ascending.
-					uint64(1), // Number of steps to take.
-					[]interface{}{"labelPath", "b"},
+					"asc",
+					"asc",
 				},
 			},
 			Children: []*base.Op{&base.Op{
-				Kind:   "window-frames",
-				Labels: base.Labels{"a", "b"},
+				Kind:   "group",
+				Labels: base.Labels{"a", "min-b"},
 				Params: []interface{}{
-					0, // Slot for window partition.
-					1, // Slot for window frames.
-					[]interface{}{ // Window frames cfg.
-						[]interface{}{
-							"rows",
-							"num", -1, // Preceding.
-							"num", 0, // Following.
-							"no-others", // Exclude.
-							0,           // ValIdx, unused.
-						},
+					[]interface{}{
+						[]interface{}{"labelPath", "a"},
+					},
+					[]interface{}{
+						[]interface{}{"labelPath", "b"},
+					},
+					[]interface{}{
+						[]interface{}{"min"},
 					},
 				},
 				Children: []*base.Op{&base.Op{
-					Kind:   "window-partition",
+					Kind:   "scan",
 					Labels: base.Labels{"a", "b"},
 					Params: []interface{}{
-						0, // Slot for window partition.
-						[]interface{}{
-							// Partitioning exprs...
-							[]interface{}{"labelPath", "a"},
-						},
-						1,  // # of the partitioning exprs for PARTITION-BY.
-						"", // Additional tracking info.
-					},
-					Children: []*base.Op{&base.Op{
-						Kind:   "order-offset-limit",
-						Labels: base.Labels{"a", "b"},
-						Params: []interface{}{
-							[]interface{}{
-								[]interface{}{"labelPath", "a"},
-								[]interface{}{"labelPath", "b"},
-							},
-							[]interface{}{
-								"asc",
-								"asc",
-							},
-						},
-						Children: []*base.Op{&base.Op{
-							Kind:   "scan",
-							Labels: base.Labels{"a", "b"},
-							Params: []interface{}{
-								"csvData",
-								`
+						"csvData",
+						`
 10,11
 10,12
-10,13
 20,20
-20,21
-30,30
 `,
-							},
-						}},
-					}},
+					},
 				}},
 			}},
 		},
 		expectYields: []base.Vals{
-			base.Vals{[]byte("10"), []byte("1"), []byte("11")},
-			base.Vals{[]byte("10"), []byte("2"), []byte("11")},
-			base.Vals{[]byte("10"), []byte("3"), []byte("12")},
-			base.Vals{[]byte("20"), []byte("1"), []byte("20")},
-			base.Vals{[]byte("20"), []byte("2"), []byte("20")},
-			base.Vals{[]byte("30"), []byte("1"), []byte("30")},
+			base.Vals{[]byte("10"), []byte("11")},
+			base.Vals{[]byte("20"), []byte("20")},
 		},
 	},
 	{
-		about: "test csv-data window-partition->project window-frame LAST_VALUE",
+		about: "test csv-data scan->group-by max(b)",
 		o: base.Op{
-			Kind:   "project",
-			Labels: base.Labels{"a", "rowNumber", "lastValue"},
+			Kind:   "order-offset-limit",
+			Labels: base.Labels{"a", "max-b"},
 			Params: []interface{}{
-				[]interface{}{"labelPath", "a"},
 				[]interface{}{
-					"window-partition-row-number",
-					1, // Slot for window frames.
-					0, // Idx for window frame.
+					[]interface{}{"labelPath", "a"},
+					[]interface{}{"labelPath", "max-b"},
 				},
 				[]interface{}{
-					"window-frame-step-value",
-					1,         // Slot for window frames.
-					0,         // Idx for window frame.
-					1,         // Initial starting position is MaxInt64.
-					false,     // Step is descending.
-					uint64(1), // Number of steps to take.
-					[]interface{}{"labelPath", "b"},
+					"asc",
+					"asc",
 				},
 			},
 			Children: []*base.Op{&base.Op{
-				Kind:   "window-frames",
-				Labels: base.Labels{"a", "b"},
+				Kind:   "group",
+				Labels: base.Labels{"a", "max-b"},
 				Params: []interface{}{
-					0, // Slot for window partition.
-					1, // Slot for window frames.
-					[]interface{}{ // Window frames cfg.
-						[]interface{}{
-							"rows",
-							"num", -1, // Preceding.
-							"num", 1, // Following.
-							"no-others", // Exclude.
-							0,           // ValIdx, unused.
-						},
+					[]interface{}{
+						[]interface{}{"labelPath", "a"},
+					},
+					[]interface{}{
+						[]interface{}{"labelPath", "b"},
+					},
+					[]interface{}{
+						[]interface{}{"max"},
 					},
 				},
 				Children: []*base.Op{&base.Op{
-					Kind:   "window-partition",
+					Kind:   "scan",
 					Labels: base.Labels{"a", "b"},
 					Params: []interface{}{
-						0, // Slot for window partition.
-						[]interface{}{
-							// Partitioning exprs...
-							[]interface{}{"labelPath", "a"},
-						},
-						1,  // # of the partitioning exprs for PARTITION-BY.
-						"", // Additional tracking info.
-					},
-					Children: []*base.Op{&base.Op{
-						Kind:   "order-offset-limit",
-						Labels: base.Labels{"a", "b"},
-						Params: []interface{}{
-							[]interface{}{
-								[]interface{}{"labelPath", "a"},
-								[]interface{}{"labelPath", "b"},
-							},
-							[]interface{}{
-								"asc",
-								"asc",
-							},
-						},
-						Children: []*base.Op{&base.Op{
-							Kind:   "scan",
-							Labels: base.Labels{"a", "b"},
-							Params: []interface{}{
-								"csvData",
-								`
+						"csvData",
+						`
 10,11
 10,12
-10,13
 20,20
-20,21
-30,30
 `,
-							},
-						}},
-					}},
+					},
 				}},
 			}},
 		},
 		expectYields: []base.Vals{
-			base.Vals{[]byte("10"), []byte("1"), []byte("12")},
-			base.Vals{[]byte("10"), []byte("2"), []byte("13")},
-			base.Vals{[]byte("10"), []byte("3"), []byte("13")},
-			base.Vals{[]byte("20"), []byte("1"), []byte("21")},
-			base.Vals{[]byte("20"), []byte("2"), []byte("21")},
-			base.Vals{[]byte("30"), []byte("1"), []byte("30")},
+			base.Vals{[]byte("10"), []byte("12")},
+			base.Vals{[]byte("20"), []byte("20")},
 		},
 	},
 	{
-		about: "test csv-data window-partition->project window-frame NTH_VALUE(b, 2)",
+		about: "test csv-data scan->group-by avg(b)",
 		o: base.Op{
-			Kind:   "project",
-			Labels: base.Labels{"a", "rowNumber", "firstValue"},
+			Kind:   "order-offset-limit",
+			Labels: base.Labels{"a", "avg-b"},
 			Params: []interface{}{
-				[]interface{}{"labelPath", "a"},
 				[]interface{}{
-					"window-partition-row-number",
-					1, // Slot for window frames.
-					0, // Idx for window frame.
+					[]interface{}{"labelPath", "a"},
+					[]interface{}{"labelPath", "avg-b"},
 				},
 				[]interface{}{
-					"window-frame-step-value",
-					1,         // Slot for window frames.
-					0,         // Idx for window frame.
-					-1,        // Initial starting position is -1.
-					true,      // Step is ascending.
-					uint64(2), // Number of steps to take.
-					[]interface{}{"labelPath", "b"},
+					"asc",
+					"asc",
 				},
 			},
 			Children: []*base.Op{&base.Op{
-				Kind:   "window-frames",
-				Labels: base.Labels{"a", "b"},
+				Kind:   "group",
+				Labels: base.Labels{"a", "avg-b"},
 				Params: []interface{}{
-					0, // Slot for window partition.
-					1, // Slot for window frames.
-					[]interface{}{ // Window frames cfg.
-						[]interface{}{
-							"rows",
-							"unbounded", 0, // Preceding.
-							"unbounded", 0, // Following.
-							"no-others", // Exclude.
-							0,           // ValIdx, unused.
-						},
+					[]interface{}{
+						[]interface{}{"labelPath", "a"},
+					},
+					[]interface{}{
+						[]interface{}{"labelPath", "b"},
+					},
+					[]interface{}{
+						[]interface{}{"avg"},
 					},
 				},
 				Children: []*base.Op{&base.Op{
-					Kind:   "window-partition",
+					Kind:   "scan",
 					Labels: base.Labels{"a", "b"},
 					Params: []interface{}{
-						0, // Slot for window partition.
-						[]interface{}{
-							// Partitioning exprs...
-							[]interface{}{"labelPath", "a"},
-						},
-						1,  // # of the partitioning exprs for PARTITION-BY.
-						"", // Additional tracking info.
-					},
-					Children: []*base.Op{&base.Op{
-						Kind:   "order-offset-limit",
-						Labels: base.Labels{"a", "b"},
-						Params: []interface{}{
-							[]interface{}{
-								[]interface{}{"labelPath", "a"},
-								[]interface{}{"labelPath", "b"},
-							},
-							[]interface{}{
-								"asc",
-								"asc",
-							},
-						},
-						Children: []*base.Op{&base.Op{
-							Kind:   "scan",
-							Labels: base.Labels{"a", "b"},
-							Params: []interface{}{
-								"csvData",
-								`
+						"csvData",
+						`
 10,11
 10,12
-10,13
 20,20
-20,21
-30,30
 `,
-							},
-						}},
-					}},
+					},
 				}},
 			}},
 		},
 		expectYields: []base.Vals{
-			base.Vals{[]byte("10"), []byte("1"), []byte("12")},
-			base.Vals{[]byte("10"), []byte("2"), []byte("12")},
-			base.Vals{[]byte("10"), []byte("3"), []byte("12")},
-			base.Vals{[]byte("20"), []byte("1"), []byte("21")},
-			base.Vals{[]byte("20"), []byte("2"), []byte("21")},
-			base.Vals{[]byte("30"), []byte("1"), []byte(nil)},
+			base.Vals{[]byte("10"), []byte("11.5")},
+			base.Vals{[]byte("20"), []byte("20")},
 		},
 	},
 	{
-		about: "test csv-data window-partition->project window-frame LEAD(b, 1)",
+		about: "test csv-data scan->unnest-inner",
 		o: base.Op{
-			Kind:   "project",
-			Labels: base.Labels{"a", "rowNumber", "firstValue"},
+			Kind:   "unnest-inner",
+			Labels: base.Labels{"."},
 			Params: []interface{}{
-				[]interface{}{"labelPath", "a"},
-				[]interface{}{
-					"window-partition-row-number",
-					1, // Slot for window frames.
-					0, // Idx for window frame.
-				},
-				[]interface{}{
-					"window-frame-step-value",
-					1,         // Slot for window frames.
-					0,         // Idx for window frame.
-					0,         // Initial starting position is current-row.
-					true,      // Step is ascending.
-					uint64(1), // Number of steps to take.
-					[]interface{}{"labelPath", "b"},
-				},
+				"labelPath", ".", "a",
 			},
 			Children: []*base.Op{&base.Op{
-				Kind:   "window-frames",
-				Labels: base.Labels{"a", "b"},
+				Kind:   "scan",
+				Labels: base.Labels{"."},
 				Params: []interface{}{
-					0, // Slot for window partition.
-					1, // Slot for window frames.
-					[]interface{}{ // Window frames cfg.
-						[]interface{}{
-							"rows",
-							"unbounded", 0, // Preceding.
-							"unbounded", 0, // Following.
-							"no-others", // Exclude.
-							0,           // ValIdx, unused.
-						},
-					},
-				},
-				Children: []*base.Op{&base.Op{
-					Kind:   "window-partition",
-					Labels: base.Labels{"a", "b"},
-					Params: []interface{}{
-						0, // Slot for window partition.
-						[]interface{}{
-							// Partitioning exprs...
-							[]interface{}{"labelPath", "a"},
-						},
-						1,  // # of the partitioning exprs for PARTITION-BY.
-						"", // Additional tracking info.
-					},
-					Children: []*base.Op{&base.Op{
-						Kind:   "order-offset-limit",
-						Labels: base.Labels{"a", "b"},
-						Params: []interface{}{
-							[]interface{}{
-								[]interface{}{"labelPath", "a"},
-								[]interface{}{"labelPath", "b"},
-							},
-							[]interface{}{
-								"asc",
-								"asc",
-							},
-						},
-						Children: []*base.Op{&base.Op{
-							Kind:   "scan",
-							Labels: base.Labels{"a", "b"},
-							Params: []interface{}{
-								"csvData",
-								`
-10,11
-10,12
-10,13
-20,20
-20,21
-30,30
+					"jsonsData",
+					`
+{"a":[1,2]}
+{"a":[3]}
+{"a":[]}
+{"a":123}
 `,
-							},
-						}},
-					}},
-				}},
+				},
+			}, &base.Op{
+				Kind:   "noop",
+				Labels: base.Labels{"child"},
 			}},
 		},
 		expectYields: []base.Vals{
-			base.Vals{[]byte("10"), []byte("1"), []byte("12")},
-			base.Vals{[]byte("10"), []byte("2"), []byte("13")},
-			base.Vals{[]byte("10"), []byte("3"), []byte(nil)},
-			base.Vals{[]byte("20"), []byte("1"), []byte("21")},
-			base.Vals{[]byte("20"), []byte("2"), []byte(nil)},
-			base.Vals{[]byte("30"), []byte("1"), []byte(nil)},
+			base.Vals{[]byte(`{"a":[1,2]}`), []byte("1")},
+			base.Vals{[]byte(`{"a":[1,2]}`), []byte("2")},
+			base.Vals{[]byte(`{"a":[3]}`), []byte("3")},
 		},
 	},
 	{
-		about: "test csv-data window-partition->project window-frame LEAD(b, 2)",
+		about: "test csv-data scan->unnest-leftOuter",
 		o: base.Op{
-			Kind:   "project",
-			Labels: base.Labels{"a", "rowNumber", "firstValue"},
+			Kind:   "unnest-leftOuter",
+			Labels: base.Labels{"."},
 			Params: []interface{}{
-				[]interface{}{"labelPath", "a"},
-				[]interface{}{
-					"window-partition-row-number",
-					1, // Slot for window frames.
-					0, // Idx for window frame.
-				},
-				[]interface{}{
-					"window-frame-step-value",
-					1,         // Slot for window frames.
-					0,         // Idx for window frame.
-					0,         // Initial starting position is current-row.
-					true,      // Step is ascending.
-					uint64(2), // Number of steps to take.
-					[]interface{}{"labelPath", "b"},
-				},
+				"labelPath", ".", "a",
 			},
 			Children: []*base.Op{&base.Op{
-				Kind:   "window-frames",
-				Labels: base.Labels{"a", "b"},
+				Kind:   "scan",
+				Labels: base.Labels{"."},
 				Params: []interface{}{
-					0, // Slot for window partition.
-					1, // Slot for window frames.
-					[]interface{}{ // Window frames cfg.
-						[]interface{}{
-							"rows",
-							"unbounded", 0, // Preceding.
-							"unbounded", 0, // Following.
-							"no-others", // Exclude.
-							0,           // ValIdx, unused.
-						},
-					},
-				},
-				Children: []*base.Op{&base.Op{
-					Kind:   "window-partition",
-					Labels: base.Labels{"a", "b"},
-					Params: []interface{}{
-						0, // Slot for window partition.
-						[]interface{}{
-							// Partitioning exprs...
-							[]interface{}{"labelPath", "a"},
-						},
-						1,  // # of the partitioning exprs for PARTITION-BY.
-						"", // Additional tracking info.
-					},
-					Children: []*base.Op{&base.Op{
-						Kind:   "order-offset-limit",
-						Labels: base.Labels{"a", "b"},
-						Params: []interface{}{
-							[]interface{}{
-								[]interface{}{"labelPath", "a"},
-								[]interface{}{"labelPath", "b"},
-							},
-							[]interface{}{
-								"asc",
-								"asc",
-							},
-						},
-						Children: []*base.Op{&base.Op{
-							Kind:   "scan",
-							Labels: base.Labels{"a", "b"},
-							Params: []interface{}{
-								"csvData",
-								`
-10,11
-10,12
-10,13
-20,20
-20,21
-30,30
+					"jsonsData",
+					`
+{"a":[1,2]}
+{"a":[3]}
+{"a":[]}
+{"a":123}
 `,
-							},
-						}},
-					}},
-				}},
+				},
+			}, &base.Op{
+				Kind:   "noop",
+				Labels: base.Labels{"child"},
 			}},
 		},
 		expectYields: []base.Vals{
-			base.Vals{[]byte("10"), []byte("1"), []byte("13")},
-			base.Vals{[]byte("10"), []byte("2"), []byte(nil)},
-			base.Vals{[]byte("10"), []byte("3"), []byte(nil)},
+			base.Vals{[]byte(`{"a":[1,2]}`), []byte("1")},
+			base.Vals{[]byte(`{"a":[1,2]}`), []byte("2")},
+			base.Vals{[]byte(`{"a":[3]}`), []byte("3")},
+			base.Vals{[]byte(`{"a":[]}`), []byte(nil)},
+			base.Vals{[]byte(`{"a":123}`), []byte(nil)},
+		},
+	},
+	{
+		about: "test csv-data scan->nestNL-inner",
+		o: base.Op{
+			Kind:   "nestNL-inner",
+			Labels: base.Labels{"dept", "city", "emp"},
+			Params: []interface{}{
+				"eq",
+				[]interface{}{"labelPath", "dept"},
+				[]interface{}{"labelPath", "empDept"},
+			},
+			Children: []*base.Op{&base.Op{
+				Kind:   "scan",
+				Labels: base.Labels{"dept", "city"},
+				Params: []interface{}{
+					"csvData",
+					`
+"dev","paris"
+"finance","london"
+"sales","san diego"
+`,
+				},
+			}, &base.Op{
+				Kind:   "scan",
+				Labels: base.Labels{"empDept", "emp"},
+				Params: []interface{}{
+					"csvData",
+					`
+"dev","dan"
+"dev","doug"
+"finance","frank"
+"finance","fred"
+"marketing","mary"
+`,
+				},
+			}},
+		},
+		expectYields: []base.Vals{
+			StringsToVals([]string{`"dev"`, `"paris"`, `["dan","doug"]`}, nil),
+			StringsToVals([]string{`"finance"`, `"london"`, `["frank","fred"]`}, nil),
+		},
+	},
+	{
+		about: "test csv-data scan->nestNL-leftOuter",
+		o: base.Op{
+			Kind:   "nestNL-leftOuter",
+			Labels: base.Labels{"dept", "city", "emp"},
+			Params: []interface{}{
+				"eq",
+				[]interface{}{"labelPath", "dept"},
+				[]interface{}{"labelPath", "empDept"},
+			},
+			Children: []*base.Op{&base.Op{
+				Kind:   "scan",
+				Labels: base.Labels{"dept", "city"},
+				Params: []interface{}{
+					"csvData",
+					`
+"dev","paris"
+"finance","london"
+"sales","san diego"
+`,
+				},
+			}, &base.Op{
+				Kind:   "scan",
+				Labels: base.Labels{"empDept", "emp"},
+				Params: []interface{}{
+					"csvData",
+					`
+"dev","dan"
+"dev","doug"
+"finance","frank"
+"finance","fred"
+"marketing","mary"
+`,
+				},
+			}},
+		},
+		expectYields: []base.Vals{
+			StringsToVals([]string{`"dev"`, `"paris"`, `["dan","doug"]`}, nil),
+			StringsToVals([]string{`"finance"`, `"london"`, `["frank","fred"]`}, nil),
+			StringsToVals([]string{`"sales"`, `"san diego"`, `[]`}, nil),
+		},
+	},
+	{
+		about: "test csv-data scan->nestHash-inner",
+		o: base.Op{
+			Kind:   "nestHash-inner",
+			Labels: base.Labels{"dept", "city", "emp"},
+			Params: []interface{}{
+				[]interface{}{"labelPath", "dept"},
+				[]interface{}{"labelPath", "empDept"},
+			},
+			Children: []*base.Op{&base.Op{
+				Kind:   "scan",
+				Labels: base.Labels{"dept", "city"},
+				Params: []interface{}{
+					"csvData",
+					`
+"dev","paris"
+"finance","london"
+"sales","san diego"
+`,
+				},
+			}, &base.Op{
+				Kind:   "scan",
+				Labels: base.Labels{"empDept", "emp"},
+				Params: []interface{}{
+					"csvData",
+					`
+"dev","dan"
+"dev","doug"
+"finance","frank"
+"finance","fred"
+"marketing","mary"
+`,
+				},
+			}},
+		},
+		expectYields: []base.Vals{
+			StringsToVals([]string{`"dev"`, `"paris"`, `["dan","doug"]`}, nil),
+			StringsToVals([]string{`"finance"`, `"london"`, `["frank","fred"]`}, nil),
+		},
+	},
+	{
+		about: "test csv-data scan->nestHash-leftOuter",
+		o: base.Op{
+			Kind:   "nestHash-leftOuter",
+			Labels: base.Labels{"dept", "city", "emp"},
+			Params: []interface{}{
+				[]interface{}{"labelPath", "dept"},
+				[]interface{}{"labelPath", "empDept"},
+			},
+			Children: []*base.Op{&base.Op{
+				Kind:   "scan",
+				Labels: base.Labels{"dept", "city"},
+				Params: []interface{}{
+					"csvData",
+					`
+"dev","paris"
+"finance","london"
+"sales","san diego"
+`,
+				},
+			}, &base.Op{
+				Kind:   "scan",
+				Labels: base.Labels{"empDept", "emp"},
+				Params: []interface{}{
+					"csvData",
+					`
+"dev","dan"
+"dev","doug"
+"finance","frank"
+"finance","fred"
+"marketing","mary"
+`,
+				},
+			}},
+		},
+		expectYields: []base.Vals{
+			StringsToVals([]string{`"dev"`, `"paris"`, `["dan","doug"]`}, nil),
+			StringsToVals([]string{`"finance"`, `"london"`, `["frank","fred"]`}, nil),
+			StringsToVals([]string{`"sales"`, `"san diego"`, `[]`}, nil),
+		},
+	},
+	{
+		about: "test csv-data sequence->[scan->filter->project->temp-capture]",
+		o: base.Op{
+			Kind:   "sequence",
+			Labels: base.Labels{"a", "c"},
+			Children: []*base.Op{&base.Op{
+				Kind:   "temp-capture",
+				Labels: base.Labels{"a", "c"},
+				Params: []interface{}{0},
+				Children: []*base.Op{&base.Op{
+					Kind:   "project",
+					Labels: base.Labels{"a", "c"},
+					Params: []interface{}{
+						[]interface{}{"labelPath", "a"},
+						[]interface{}{"labelPath", "c"},
+					},
+					Children: []*base.Op{&base.Op{
+						Kind:   "filter",
+						Labels: base.Labels{"a", "b", "c"},
+						Params: []interface{}{
+							"eq",
+							[]interface{}{"labelPath", "c"},
+							[]interface{}{"json", `3000`},
+						},
+						Children: []*base.Op{&base.Op{
+							Kind:   "scan",
+							Labels: base.Labels{"a", "b", "c"},
+							Params: []interface{}{
+								"csvData",
+								`
+00,00,0000
+10,20,3000
+11,21,3000
+12,22,1000
+`,
+							},
+						}},
+					}},
+				}},
+			}},
+		},
+		expectYields: []base.Vals(nil),
+	},
+	{
+		about: "test csv-data sequence->[scan->filter->project->temp-capture, temp-yield]",
+		o: base.Op{
+			Kind:   "sequence",
+			Labels: base.Labels{"a", "c"},
+			Children: []*base.Op{&base.Op{
+				Kind:   "temp-capture",
+				Labels: base.Labels{"a", "c"},
+				Params: []interface{}{0},
+				Children: []*base.Op{&base.Op{
+					Kind:   "project",
+					Labels: base.Labels{"a", "c"},
+					Params: []interface{}{
+						[]interface{}{"labelPath", "a"},
+						[]interface{}{"labelPath", "c"},
+					},
+					Children: []*base.Op{&base.Op{
+						Kind:   "filter",
+						Labels: base.Labels{"a", "b", "c"},
+						Params: []interface{}{
+							"eq",
+							[]interface{}{"labelPath", "c"},
+							[]interface{}{"json", `3000`},
+						},
+						Children: []*base.Op{&base.Op{
+							Kind:   "scan",
+							Labels: base.Labels{"a", "b", "c"},
+							Params: []interface{}{
+								"csvData",
+								`
+00,00,0000
+10,20,3000
+11,21,3000
+12,22,1000
+`,
+							},
+						}},
+					}},
+				}},
+			}, &base.Op{
+				Kind:   "temp-yield",
+				Labels: base.Labels{"a", "c"},
+				Params: []interface{}{0},
+			}},
+		},
+		expectYields: []base.Vals{
+			base.Vals{[]byte("10"), []byte("3000")},
+			base.Vals{[]byte("11"), []byte("3000")},
+		},
+	},
+	{
+		about: "test csv-data scan->order->window-partition->window-frame->project window-frame-count",
+		o: base.Op{
+			Kind:   "project",
+			Labels: base.Labels{"a", "count-a"},
+			Params: []interface{}{
+				[]interface{}{"labelPath", "a"},
+				[]interface{}{
+					"window-frame-count",
+					1, // Slot for window frames.
+					0, // Idx for window frame.
+				},
+			},
+			Children: []*base.Op{&base.Op{
+				Kind:   "window-frames",
+				Labels: base.Labels{"a", "b"},
+				Params: []interface{}{
+					0, // Slot for window partition.
+					1, // Slot for window frames.
+					[]interface{}{ // Window frames cfg.
+						[]interface{}{
+							"rows",
+							"num", -1, // Preceding.
+							"num", 1, // Following.
+							"no-others", // Exclude.
+							0,           // ValIdx, unused.
+						},
+					},
+				},
+				Children: []*base.Op{&base.Op{
+					Kind:   "window-partition",
+					Labels: base.Labels{"a", "b"},
+					Params: []interface{}{
+						0, // Slot for window partition.
+						[]interface{}{
+							// Partitioning exprs...
+							[]interface{}{"labelPath", "a"},
+						},
+						1,  // # of the partitioning exprs for PARTITION-BY.
+						"", // Additional tracking info.
+					},
+					Children: []*base.Op{&base.Op{
+						Kind:   "order-offset-limit",
+						Labels: base.Labels{"a", "b"},
+						Params: []interface{}{
+							[]interface{}{
+								[]interface{}{"labelPath", "a"},
+								[]interface{}{"labelPath", "b"},
+							},
+							[]interface{}{
+								"asc",
+								"asc",
+							},
+						},
+						Children: []*base.Op{&base.Op{
+							Kind:   "scan",
+							Labels: base.Labels{"a", "b"},
+							Params: []interface{}{
+								"csvData",
+								`
+10,11
+10,12
+10,13
+20,20
+20,21
+30,30
+`,
+							},
+						}},
+					}},
+				}},
+			}},
+		},
+		expectYields: []base.Vals{
+			base.Vals{[]byte("10"), []byte("2")},
+			base.Vals{[]byte("10"), []byte("3")},
+			base.Vals{[]byte("10"), []byte("2")},
+			base.Vals{[]byte("20"), []byte("2")},
+			base.Vals{[]byte("20"), []byte("2")},
+			base.Vals{[]byte("30"), []byte("1")},
+		},
+	},
+	{
+		about: "test csv-data scan->order->window-partition->window-frame-exclude-current-row->project window-frame-count",
+		o: base.Op{
+			Kind:   "project",
+			Labels: base.Labels{"a", "count-a"},
+			Params: []interface{}{
+				[]interface{}{"labelPath", "a"},
+				[]interface{}{
+					"window-frame-count",
+					1, // Slot for window frames.
+					0, // Idx for window frame.
+				},
+			},
+			Children: []*base.Op{&base.Op{
+				Kind:   "window-frames",
+				Labels: base.Labels{"a", "b"},
+				Params: []interface{}{
+					0, // Slot for window partition.
+					1, // Slot for window frames.
+					[]interface{}{ // Window frames cfg.
+						[]interface{}{
+							"rows",
+							"num", -1, // Preceding.
+							"num", 1, // Following.
+							"current-row", // Exclude.
+							0,             // ValIdx, unused.
+						},
+					},
+				},
+				Children: []*base.Op{&base.Op{
+					Kind:   "window-partition",
+					Labels: base.Labels{"a", "b"},
+					Params: []interface{}{
+						0, // Slot for window partition.
+						[]interface{}{
+							// Partitioning exprs...
+							[]interface{}{"labelPath", "a"},
+						},
+						1,  // # of the partitioning exprs for PARTITION-BY.
+						"", // Additional tracking info.
+					},
+					Children: []*base.Op{&base.Op{
+						Kind:   "order-offset-limit",
+						Labels: base.Labels{"a", "b"},
+						Params: []interface{}{
+							[]interface{}{
+								[]interface{}{"labelPath", "a"},
+								[]interface{}{"labelPath", "b"},
+							},
+							[]interface{}{
+								"asc",
+								"asc",
+							},
+						},
+						Children: []*base.Op{&base.Op{
+							Kind:   "scan",
+							Labels: base.Labels{"a", "b"},
+							Params: []interface{}{
+								"csvData",
+								`
+10,11
+10,12
+10,13
+20,20
+20,21
+30,30
+`,
+							},
+						}},
+					}},
+				}},
+			}},
+		},
+		expectYields: []base.Vals{
+			base.Vals{[]byte("10"), []byte("1")},
+			base.Vals{[]byte("10"), []byte("2")},
+			base.Vals{[]byte("10"), []byte("1")},
+			base.Vals{[]byte("20"), []byte("1")},
+			base.Vals{[]byte("20"), []byte("1")},
+			base.Vals{[]byte("30"), []byte("0")},
+		},
+	},
+	{
+		about: "test csv-data scan->order->window-partition->window-frame current-row to unbounded ->project window-frame-count",
+		o: base.Op{
+			Kind:   "project",
+			Labels: base.Labels{"a", "count-a"},
+			Params: []interface{}{
+				[]interface{}{"labelPath", "a"},
+				[]interface{}{
+					"window-frame-count",
+					1, // Slot for window frames.
+					0, // Idx for window frame.
+				},
+			},
+			Children: []*base.Op{&base.Op{
+				Kind:   "window-frames",
+				Labels: base.Labels{"a", "b"},
+				Params: []interface{}{
+					0, // Slot for window partition.
+					1, // Slot for window frames.
+					[]interface{}{ // Window frames cfg.
+						[]interface{}{
+							"rows",
+							"num", 0, // Preceding.
+							"unbounded", 1, // Following.
+							"no-others", // Exclude.
+							0,           // ValIdx, unused.
+						},
+					},
+				},
+				Children: []*base.Op{&base.Op{
+					Kind:   "window-partition",
+					Labels: base.Labels{"a", "b"},
+					Params: []interface{}{
+						0, // Slot for window partition.
+						[]interface{}{
+							// Partitioning exprs...
+							[]interface{}{"labelPath", "a"},
+						},
+						1,  // # of the partitioning exprs for PARTITION-BY.
+						"", // Additional tracking info.
+					},
+					Children: []*base.Op{&base.Op{
+						Kind:   "order-offset-limit",
+						Labels: base.Labels{"a", "b"},
+						Params: []interface{}{
+							[]interface{}{
+								[]interface{}{"labelPath", "a"},
+								[]interface{}{"labelPath", "b"},
+							},
+							[]interface{}{
+								"asc",
+								"asc",
+							},
+						},
+						Children: []*base.Op{&base.Op{
+							Kind:   "scan",
+							Labels: base.Labels{"a", "b"},
+							Params: []interface{}{
+								"csvData",
+								`
+10,11
+10,12
+10,13
+20,20
+20,21
+30,30
+`,
+							},
+						}},
+					}},
+				}},
+			}},
+		},
+		expectYields: []base.Vals{
+			base.Vals{[]byte("10"), []byte("3")},
+			base.Vals{[]byte("10"), []byte("2")},
+			base.Vals{[]byte("10"), []byte("1")},
+			base.Vals{[]byte("20"), []byte("2")},
+			base.Vals{[]byte("20"), []byte("1")},
+			base.Vals{[]byte("30"), []byte("1")},
+		},
+	},
+	{
+		about: "test csv-data scan->order->window-partition->window-frame unbounded to current-row-minus-1 ->project window-frame-count",
+		o: base.Op{
+			Kind:   "project",
+			Labels: base.Labels{"a", "count-a"},
+			Params: []interface{}{
+				[]interface{}{"labelPath", "a"},
+				[]interface{}{
+					"window-frame-count",
+					1, // Slot for window frames.
+					0, // Idx for window frame.
+				},
+			},
+			Children: []*base.Op{&base.Op{
+				Kind:   "window-frames",
+				Labels: base.Labels{"a", "b"},
+				Params: []interface{}{
+					0, // Slot for window partition.
+					1, // Slot for window frames.
+					[]interface{}{ // Window frames cfg.
+						[]interface{}{
+							"rows",
+							"unbounded", 0, // Preceding.
+							"num", -1, // Following.
+							"no-others", // Exclude.
+							0,           // ValIdx, unused.
+						},
+					},
+				},
+				Children: []*base.Op{&base.Op{
+					Kind:   "window-partition",
+					Labels: base.Labels{"a", "b"},
+					Params: []interface{}{
+						0, // Slot for window partition.
+						[]interface{}{
+							// Partitioning exprs...
+							[]interface{}{"labelPath", "a"},
+						},
+						1,  // # of the partitioning exprs for PARTITION-BY.
+						"", // Additional tracking info.
+					},
+					Children: []*base.Op{&base.Op{
+						Kind:   "order-offset-limit",
+						Labels: base.Labels{"a", "b"},
+						Params: []interface{}{
+							[]interface{}{
+								[]interface{}{"labelPath", "a"},
+								[]interface{}{"labelPath", "b"},
+							},
+							[]interface{}{
+								"asc",
+								"asc",
+							},
+						},
+						Children: []*base.Op{&base.Op{
+							Kind:   "scan",
+							Labels: base.Labels{"a", "b"},
+							Params: []interface{}{
+								"csvData",
+								`
+10,11
+10,12
+10,13
+20,20
+20,21
+30,30
+`,
+							},
+						}},
+					}},
+				}},
+			}},
+		},
+		expectYields: []base.Vals{
+			base.Vals{[]byte("10"), []byte("0")},
+			base.Vals{[]byte("10"), []byte("1")},
+			base.Vals{[]byte("10"), []byte("2")},
+			base.Vals{[]byte("20"), []byte("0")},
+			base.Vals{[]byte("20"), []byte("1")},
+			base.Vals{[]byte("30"), []byte("0")},
+		},
+	},
+	{
+		about: "test csv-data window-partition->project window-frame FIRST_VALUE",
+		o: base.Op{
+			Kind:   "project",
+			Labels: base.Labels{"a", "rowNumber", "firstValue"},
+			Params: []interface{}{
+				[]interface{}{"labelPath", "a"},
+				[]interface{}{
+					"window-partition-row-number",
+					1, // Slot for window frames.
+					0, // Idx for window frame.
+				},
+				[]interface{}{
+					"window-frame-step-value",
+					1,         // Slot for window frames.
+					0,         // Idx for window frame.
+					-1,        // Initial starting position is -1.
+					true,      // Step is ascending.
+					uint64(1), // Number of steps to take.
+					[]interface{}{"labelPath", "b"},
+				},
+			},
+			Children: []*base.Op{&base.Op{
+				Kind:   "window-frames",
+				Labels: base.Labels{"a", "b"},
+				Params: []interface{}{
+					0, // Slot for window partition.
+					1, // Slot for window frames.
+					[]interface{}{ // Window frames cfg.
+						[]interface{}{
+							"rows",
+							"num", -1, // Preceding.
+							"num", 0, // Following.
+							"no-others", // Exclude.
+							0,           // ValIdx, unused.
+						},
+					},
+				},
+				Children: []*base.Op{&base.Op{
+					Kind:   "window-partition",
+					Labels: base.Labels{"a", "b"},
+					Params: []interface{}{
+						0, // Slot for window partition.
+						[]interface{}{
+							// Partitioning exprs...
+							[]interface{}{"labelPath", "a"},
+						},
+						1,  // # of the partitioning exprs for PARTITION-BY.
+						"", // Additional tracking info.
+					},
+					Children: []*base.Op{&base.Op{
+						Kind:   "order-offset-limit",
+						Labels: base.Labels{"a", "b"},
+						Params: []interface{}{
+							[]interface{}{
+								[]interface{}{"labelPath", "a"},
+								[]interface{}{"labelPath", "b"},
+							},
+							[]interface{}{
+								"asc",
+								"asc",
+							},
+						},
+						Children: []*base.Op{&base.Op{
+							Kind:   "scan",
+							Labels: base.Labels{"a", "b"},
+							Params: []interface{}{
+								"csvData",
+								`
+10,11
+10,12
+10,13
+20,20
+20,21
+30,30
+`,
+							},
+						}},
+					}},
+				}},
+			}},
+		},
+		expectYields: []base.Vals{
+			base.Vals{[]byte("10"), []byte("1"), []byte("11")},
+			base.Vals{[]byte("10"), []byte("2"), []byte("11")},
+			base.Vals{[]byte("10"), []byte("3"), []byte("12")},
+			base.Vals{[]byte("20"), []byte("1"), []byte("20")},
+			base.Vals{[]byte("20"), []byte("2"), []byte("20")},
+			base.Vals{[]byte("30"), []byte("1"), []byte("30")},
+		},
+	},
+	{
+		about: "test csv-data window-partition->project window-frame LAST_VALUE",
+		o: base.Op{
+			Kind:   "project",
+			Labels: base.Labels{"a", "rowNumber", "lastValue"},
+			Params: []interface{}{
+				[]interface{}{"labelPath", "a"},
+				[]interface{}{
+					"window-partition-row-number",
+					1, // Slot for window frames.
+					0, // Idx for window frame.
+				},
+				[]interface{}{
+					"window-frame-step-value",
+					1,         // Slot for window frames.
+					0,         // Idx for window frame.
+					1,         // Initial starting position is MaxInt64.
+					false,     // Step is descending.
+					uint64(1), // Number of steps to take.
+					[]interface{}{"labelPath", "b"},
+				},
+			},
+			Children: []*base.Op{&base.Op{
+				Kind:   "window-frames",
+				Labels: base.Labels{"a", "b"},
+				Params: []interface{}{
+					0, // Slot for window partition.
+					1, // Slot for window frames.
+					[]interface{}{ // Window frames cfg.
+						[]interface{}{
+							"rows",
+							"num", -1, // Preceding.
+							"num", 1, // Following.
+							"no-others", // Exclude.
+							0,           // ValIdx, unused.
+						},
+					},
+				},
+				Children: []*base.Op{&base.Op{
+					Kind:   "window-partition",
+					Labels: base.Labels{"a", "b"},
+					Params: []interface{}{
+						0, // Slot for window partition.
+						[]interface{}{
+							// Partitioning exprs...
+							[]interface{}{"labelPath", "a"},
+						},
+						1,  // # of the partitioning exprs for PARTITION-BY.
+						"", // Additional tracking info.
+					},
+					Children: []*base.Op{&base.Op{
+						Kind:   "order-offset-limit",
+						Labels: base.Labels{"a", "b"},
+						Params: []interface{}{
+							[]interface{}{
+								[]interface{}{"labelPath", "a"},
+								[]interface{}{"labelPath", "b"},
+							},
+							[]interface{}{
+								"asc",
+								"asc",
+							},
+						},
+						Children: []*base.Op{&base.Op{
+							Kind:   "scan",
+							Labels: base.Labels{"a", "b"},
+							Params: []interface{}{
+								"csvData",
+								`
+10,11
+10,12
+10,13
+20,20
+20,21
+30,30
+`,
+							},
+						}},
+					}},
+				}},
+			}},
+		},
+		expectYields: []base.Vals{
+			base.Vals{[]byte("10"), []byte("1"), []byte("12")},
+			base.Vals{[]byte("10"), []byte("2"), []byte("13")},
+			base.Vals{[]byte("10"), []byte("3"), []byte("13")},
+			base.Vals{[]byte("20"), []byte("1"), []byte("21")},
+			base.Vals{[]byte("20"), []byte("2"), []byte("21")},
+			base.Vals{[]byte("30"), []byte("1"), []byte("30")},
+		},
+	},
+	{
+		about: "test csv-data window-partition->project window-frame NTH_VALUE(b, 2)",
+		o: base.Op{
+			Kind:   "project",
+			Labels: base.Labels{"a", "rowNumber", "firstValue"},
+			Params: []interface{}{
+				[]interface{}{"labelPath", "a"},
+				[]interface{}{
+					"window-partition-row-number",
+					1, // Slot for window frames.
+					0, // Idx for window frame.
+				},
+				[]interface{}{
+					"window-frame-step-value",
+					1,         // Slot for window frames.
+					0,         // Idx for window frame.
+					-1,        // Initial starting position is -1.
+					true,      // Step is ascending.
+					uint64(2), // Number of steps to take.
+					[]interface{}{"labelPath", "b"},
+				},
+			},
+			Children: []*base.Op{&base.Op{
+				Kind:   "window-frames",
+				Labels: base.Labels{"a", "b"},
+				Params: []interface{}{
+					0, // Slot for window partition.
+					1, // Slot for window frames.
+					[]interface{}{ // Window frames cfg.
+						[]interface{}{
+							"rows",
+							"unbounded", 0, // Preceding.
+							"unbounded", 0, // Following.
+							"no-others", // Exclude.
+							0,           // ValIdx, unused.
+						},
+					},
+				},
+				Children: []*base.Op{&base.Op{
+					Kind:   "window-partition",
+					Labels: base.Labels{"a", "b"},
+					Params: []interface{}{
+						0, // Slot for window partition.
+						[]interface{}{
+							// Partitioning exprs...
+							[]interface{}{"labelPath", "a"},
+						},
+						1,  // # of the partitioning exprs for PARTITION-BY.
+						"", // Additional tracking info.
+					},
+					Children: []*base.Op{&base.Op{
+						Kind:   "order-offset-limit",
+						Labels: base.Labels{"a", "b"},
+						Params: []interface{}{
+							[]interface{}{
+								[]interface{}{"labelPath", "a"},
+								[]interface{}{"labelPath", "b"},
+							},
+							[]interface{}{
+								"asc",
+								"asc",
+							},
+						},
+						Children: []*base.Op{&base.Op{
+							Kind:   "scan",
+							Labels: base.Labels{"a", "b"},
+							Params: []interface{}{
+								"csvData",
+								`
+10,11
+10,12
+10,13
+20,20
+20,21
+30,30
+`,
+							},
+						}},
+					}},
+				}},
+			}},
+		},
+		expectYields: []base.Vals{
+			base.Vals{[]byte("10"), []byte("1"), []byte("12")},
+			base.Vals{[]byte("10"), []byte("2"), []byte("12")},
+			base.Vals{[]byte("10"), []byte("3"), []byte("12")},
+			base.Vals{[]byte("20"), []byte("1"), []byte("21")},
+			base.Vals{[]byte("20"), []byte("2"), []byte("21")},
+			base.Vals{[]byte("30"), []byte("1"), []byte(nil)},
+		},
+	},
+	{
+		about: "test csv-data window-partition->project window-frame LEAD(b, 1)",
+		o: base.Op{
+			Kind:   "project",
+			Labels: base.Labels{"a", "rowNumber", "firstValue"},
+			Params: []interface{}{
+				[]interface{}{"labelPath", "a"},
+				[]interface{}{
+					"window-partition-row-number",
+					1, // Slot for window frames.
+					0, // Idx for window frame.
+				},
+				[]interface{}{
+					"window-frame-step-value",
+					1,         // Slot for window frames.
+					0,         // Idx for window frame.
+					0,         // Initial starting position is current-row.
+					true,      // Step is ascending.
+					uint64(1), // Number of steps to take.
+					[]interface{}{"labelPath", "b"},
+				},
+			},
+			Children: []*base.Op{&base.Op{
+				Kind:   "window-frames",
+				Labels: base.Labels{"a", "b"},
+				Params: []interface{}{
+					0, // Slot for window partition.
+					1, // Slot for window frames.
+					[]interface{}{ // Window frames cfg.
+						[]interface{}{
+							"rows",
+							"unbounded", 0, // Preceding.
+							"unbounded", 0, // Following.
+							"no-others", // Exclude.
+							0,           // ValIdx, unused.
+						},
+					},
+				},
+				Children: []*base.Op{&base.Op{
+					Kind:   "window-partition",
+					Labels: base.Labels{"a", "b"},
+					Params: []interface{}{
+						0, // Slot for window partition.
+						[]interface{}{
+							// Partitioning exprs...
+							[]interface{}{"labelPath", "a"},
+						},
+						1,  // # of the partitioning exprs for PARTITION-BY.
+						"", // Additional tracking info.
+					},
+					Children: []*base.Op{&base.Op{
+						Kind:   "order-offset-limit",
+						Labels: base.Labels{"a", "b"},
+						Params: []interface{}{
+							[]interface{}{
+								[]interface{}{"labelPath", "a"},
+								[]interface{}{"labelPath", "b"},
+							},
+							[]interface{}{
+								"asc",
+								"asc",
+							},
+						},
+						Children: []*base.Op{&base.Op{
+							Kind:   "scan",
+							Labels: base.Labels{"a", "b"},
+							Params: []interface{}{
+								"csvData",
+								`
+10,11
+10,12
+10,13
+20,20
+20,21
+30,30
+`,
+							},
+						}},
+					}},
+				}},
+			}},
+		},
+		expectYields: []base.Vals{
+			base.Vals{[]byte("10"), []byte("1"), []byte("12")},
+			base.Vals{[]byte("10"), []byte("2"), []byte("13")},
+			base.Vals{[]byte("10"), []byte("3"), []byte(nil)},
+			base.Vals{[]byte("20"), []byte("1"), []byte("21")},
+			base.Vals{[]byte("20"), []byte("2"), []byte(nil)},
+			base.Vals{[]byte("30"), []byte("1"), []byte(nil)},
+		},
+	},
+	{
+		about: "test csv-data window-partition->project window-frame LEAD(b, 2)",
+		o: base.Op{
+			Kind:   "project",
+			Labels: base.Labels{"a", "rowNumber", "firstValue"},
+			Params: []interface{}{
+				[]interface{}{"labelPath", "a"},
+				[]interface{}{
+					"window-partition-row-number",
+					1, // Slot for window frames.
+					0, // Idx for window frame.
+				},
+				[]interface{}{
+					"window-frame-step-value",
+					1,         // Slot for window frames.
+					0,         // Idx for window frame.
+					0,         // Initial starting position is current-row.
+					true,      // Step is ascending.
+					uint64(2), // Number of steps to take.
+					[]interface{}{"labelPath", "b"},
+				},
+			},
+			Children: []*base.Op{&base.Op{
+				Kind:   "window-frames",
+				Labels: base.Labels{"a", "b"},
+				Params: []interface{}{
+					0, // Slot for window partition.
+					1, // Slot for window frames.
+					[]interface{}{ // Window frames cfg.
+						[]interface{}{
+							"rows",
+							"unbounded", 0, // Preceding.
+							"unbounded", 0, // Following.
+							"no-others", // Exclude.
+							0,           // ValIdx, unused.
+						},
+					},
+				},
+				Children: []*base.Op{&base.Op{
+					Kind:   "window-partition",
+					Labels: base.Labels{"a", "b"},
+					Params: []interface{}{
+						0, // Slot for window partition.
+						[]interface{}{
+							// Partitioning exprs...
+							[]interface{}{"labelPath", "a"},
+						},
+						1,  // # of the partitioning exprs for PARTITION-BY.
+						"", // Additional tracking info.
+					},
+					Children: []*base.Op{&base.Op{
+						Kind:   "order-offset-limit",
+						Labels: base.Labels{"a", "b"},
+						Params: []interface{}{
+							[]interface{}{
+								[]interface{}{"labelPath", "a"},
+								[]interface{}{"labelPath", "b"},
+							},
+							[]interface{}{
+								"asc",
+								"asc",
+							},
+						},
+						Children: []*base.Op{&base.Op{
+							Kind:   "scan",
+							Labels: base.Labels{"a", "b"},
+							Params: []interface{}{
+								"csvData",
+								`
+10,11
+10,12
+10,13
+20,20
+20,21
+30,30
+`,
+							},
+						}},
+					}},
+				}},
+			}},
+		},
+		expectYields: []base.Vals{
+			base.Vals{[]byte("10"), []byte("1"), []byte("13")},
+			base.Vals{[]byte("10"), []byte("2"), []byte(nil)},
+			base.Vals{[]byte("10"), []byte("3"), []byte(nil)},
+			base.Vals{[]byte("20"), []byte("1"), []byte(nil)},
+			base.Vals{[]byte("20"), []byte("2"), []byte(nil)},
+			base.Vals{[]byte("30"), []byte("1"), []byte(nil)},
+		},
+	},
+	{
+		about: "test csv-data window-partition->project window-frame LAG(b, 1)",
+		o: base.Op{
+			Kind:   "project",
+			Labels: base.Labels{"a", "rowNumber", "firstValue"},
+			Params: []interface{}{
+				[]interface{}{"labelPath", "a"},
+				[]interface{}{
+					"window-partition-row-number",
+					1, // Slot for window frames.
+					0, // Idx for window frame.
+				},
+				[]interface{}{
+					"window-frame-step-value",
+					1,         // Slot for window frames.
+					0,         // Idx for window frame.
+					0,         // Initial starting position is current-row.
+					false,     // Step is descending.
+					uint64(1), // Number of steps to take.
+					[]interface{}{"labelPath", "b"},
+				},
+			},
+			Children: []*base.Op{&base.Op{
+				Kind:   "window-frames",
+				Labels: base.Labels{"a", "b"},
+				Params: []interface{}{
+					0, // Slot for window partition.
+					1, // Slot for window frames.
+					[]interface{}{ // Window frames cfg.
+						[]interface{}{
+							"rows",
+							"unbounded", 0, // Preceding.
+							"unbounded", 0, // Following.
+							"no-others", // Exclude.
+							0,           // ValIdx, unused.
+						},
+					},
+				},
+				Children: []*base.Op{&base.Op{
+					Kind:   "window-partition",
+					Labels: base.Labels{"a", "b"},
+					Params: []interface{}{
+						0, // Slot for window partition.
+						[]interface{}{
+							// Partitioning exprs...
+							[]interface{}{"labelPath", "a"},
+						},
+						1,  // # of the partitioning exprs for PARTITION-BY.
+						"", // Additional tracking info.
+					},
+					Children: []*base.Op{&base.Op{
+						Kind:   "order-offset-limit",
+						Labels: base.Labels{"a", "b"},
+						Params: []interface{}{
+							[]interface{}{
+								[]interface{}{"labelPath", "a"},
+								[]interface{}{"labelPath", "b"},
+							},
+							[]interface{}{
+								"asc",
+								"asc",
+							},
+						},
+						Children: []*base.Op{&base.Op{
+							Kind:   "scan",
+							Labels: base.Labels{"a", "b"},
+							Params: []interface{}{
+								"csvData",
+								`
+10,11
+10,12
+10,13
+20,20
+20,21
+30,30
+`,
+							},
+						}},
+					}},
+				}},
+			}},
+		},
+		expectYields: []base.Vals{
+			base.Vals{[]byte("10"), []byte("1"), []byte(nil)},
+			base.Vals{[]byte("10"), []byte("2"), []byte("11")},
+			base.Vals{[]byte("10"), []byte("3"), []byte("12")},
+			base.Vals{[]byte("20"), []byte("1"), []byte(nil)},
+			base.Vals{[]byte("20"), []byte("2"), []byte("20")},
+			base.Vals{[]byte("30"), []byte("1"), []byte(nil)},
+		},
+	},
+	{
+		about: "test csv-data window-partition->project window-frame LAG(b, 2)",
+		o: base.Op{
+			Kind:   "project",
+			Labels: base.Labels{"a", "rowNumber", "firstValue"},
+			Params: []interface{}{
+				[]interface{}{"labelPath", "a"},
+				[]interface{}{
+					"window-partition-row-number",
+					1, // Slot for window frames.
+					0, // Idx for window frame.
+				},
+				[]interface{}{
+					"window-frame-step-value",
+					1,         // Slot for window frames.
+					0,         // Idx for window frame.
+					0,         // Initial starting position is current-row.
+					false,     // Step is descending.
+					uint64(2), // Number of steps to take.
+					[]interface{}{"labelPath", "b"},
+				},
+			},
+			Children: []*base.Op{&base.Op{
+				Kind:   "window-frames",
+				Labels: base.Labels{"a", "b"},
+				Params: []interface{}{
+					0, // Slot for window partition.
+					1, // Slot for window frames.
+					[]interface{}{ // Window frames cfg.
+						[]interface{}{
+							"rows",
+							"unbounded", 0, // Preceding.
+							"unbounded", 0, // Following.
+							"no-others", // Exclude.
+							0,           // ValIdx, unused.
+						},
+					},
+				},
+				Children: []*base.Op{&base.Op{
+					Kind:   "window-partition",
+					Labels: base.Labels{"a", "b"},
+					Params: []interface{}{
+						0, // Slot for window partition.
+						[]interface{}{
+							// Partitioning exprs...
+							[]interface{}{"labelPath", "a"},
+						},
+						1,  // # of the partitioning exprs for PARTITION-BY.
+						"", // Additional tracking info.
+					},
+					Children: []*base.Op{&base.Op{
+						Kind:   "order-offset-limit",
+						Labels: base.Labels{"a", "b"},
+						Params: []interface{}{
+							[]interface{}{
+								[]interface{}{"labelPath", "a"},
+								[]interface{}{"labelPath", "b"},
+							},
+							[]interface{}{
+								"asc",
+								"asc",
+							},
+						},
+						Children: []*base.Op{&base.Op{
+							Kind:   "scan",
+							Labels: base.Labels{"a", "b"},
+							Params: []interface{}{
+								"csvData",
+								`
+10,11
+10,12
+10,13
+20,20
+20,21
+30,30
+`,
+							},
+						}},
+					}},
+				}},
+			}},
+		},
+		expectYields: []base.Vals{
+			base.Vals{[]byte("10"), []byte("1"), []byte(nil)},
+			base.Vals{[]byte("10"), []byte("2"), []byte(nil)},
+			base.Vals{[]byte("10"), []byte("3"), []byte("11")},
 			base.Vals{[]byte("20"), []byte("1"), []byte(nil)},
 			base.Vals{[]byte("20"), []byte("2"), []byte(nil)},
 			base.Vals{[]byte("30"), []byte("1"), []byte(nil)},
 		},
 	},
 	{
-		about: "test csv-data window-partition->project window-frame LAG(b, 1)",
+		about: "test csv-data window-partition->project window-frame NTH_VALUE(b, 2) FROM LAST IGNORE NULLS",
+		o: base.Op{
+			Kind:   "project",
+			Labels: base.Labels{"a", "c", "value"},
+			Params: []interface{}{
+				[]interface{}{"labelPath", "a"},
+				[]interface{}{"labelPath", "c"},
+				[]interface{}{
+					"window-frame-step-value",
+					1,         // Slot for window frames.
+					0,         // Idx for window frame.
+					-1,        // Initial starting position is -1.
+					true,      // Step is ascending.
+					uint64(2), // Number of steps to take.
+					[]interface{}{"labelPath", "b"},
+					"ignoreNulls,fromLast", // Modifiers.
+				},
+			},
+			Children: []*base.Op{&base.Op{
+				Kind:   "window-frames",
+				Labels: base.Labels{"a", "b", "c"},
+				Params: []interface{}{
+					0, // Slot for window partition.
+					1, // Slot for window frames.
+					[]interface{}{ // Window frames cfg.
+						[]interface{}{
+							"rows",
+							"unbounded", 0, // Preceding.
+							"unbounded", 0, // Following.
+							"no-others", // Exclude.
+							0,           // ValIdx, unused.
+						},
+					},
+				},
+				Children: []*base.Op{&base.Op{
+					Kind:   "window-partition",
+					Labels: base.Labels{"a", "b", "c"},
+					Params: []interface{}{
+						0, // Slot for window partition.
+						[]interface{}{
+							// Partitioning exprs...
+							[]interface{}{"labelPath", "a"},
+						},
+						1,  // # of the partitioning exprs for PARTITION-BY.
+						"", // Additional tracking info.
+					},
+					Children: []*base.Op{&base.Op{
+						Kind:   "order-offset-limit",
+						Labels: base.Labels{"a", "b", "c"},
+						Params: []interface{}{
+							[]interface{}{
+								[]interface{}{"labelPath", "a"},
+								[]interface{}{"labelPath", "c"},
+							},
+							[]interface{}{
+								"asc",
+								"asc",
+							},
+						},
+						Children: []*base.Op{&base.Op{
+							Kind:   "scan",
+							Labels: base.Labels{"a", "b", "c"},
+							Params: []interface{}{
+								"csvData",
+								`
+10,11,1
+10,null,2
+10,13,3
+10,null,4
+20,20,1
+20,null,2
+30,null,1
+`,
+							},
+						}},
+					}},
+				}},
+			}},
+		},
+		expectYields: []base.Vals{
+			base.Vals{[]byte("10"), []byte("1"), []byte("11")},
+			base.Vals{[]byte("10"), []byte("2"), []byte("11")},
+			base.Vals{[]byte("10"), []byte("3"), []byte("11")},
+			base.Vals{[]byte("10"), []byte("4"), []byte("11")},
+			base.Vals{[]byte("20"), []byte("1"), []byte("null")},
+			base.Vals{[]byte("20"), []byte("2"), []byte("null")},
+			base.Vals{[]byte("30"), []byte("1"), []byte("null")},
+		},
+	},
+	{
+		about: "test csv-data window-partition->project window-frame FIRST_VALUE(b) IGNORE NULLS of all NULL frame",
+		o: base.Op{
+			Kind:   "project",
+			Labels: base.Labels{"a", "c", "value"},
+			Params: []interface{}{
+				[]interface{}{"labelPath", "a"},
+				[]interface{}{"labelPath", "c"},
+				[]interface{}{
+					"window-frame-step-value",
+					1,         // Slot for window frames.
+					0,         // Idx for window frame.
+					-1,        // Initial starting position is -1.
+					true,      // Step is ascending.
+					uint64(1), // Number of steps to take.
+					[]interface{}{"labelPath", "b"},
+					"ignoreNulls", // Modifiers.
+				},
+			},
+			Children: []*base.Op{&base.Op{
+				Kind:   "window-frames",
+				Labels: base.Labels{"a", "b", "c"},
+				Params: []interface{}{
+					0, // Slot for window partition.
+					1, // Slot for window frames.
+					[]interface{}{ // Window frames cfg.
+						[]interface{}{
+							"rows",
+							"unbounded", 0, // Preceding.
+							"unbounded", 0, // Following.
+							"no-others", // Exclude.
+							0,           // ValIdx, unused.
+						},
+					},
+				},
+				Children: []*base.Op{&base.Op{
+					Kind:   "window-partition",
+					Labels: base.Labels{"a", "b", "c"},
+					Params: []interface{}{
+						0, // Slot for window partition.
+						[]interface{}{
+							// Partitioning exprs...
+							[]interface{}{"labelPath", "a"},
+						},
+						1,  // # of the partitioning exprs for PARTITION-BY.
+						"", // Additional tracking info.
+					},
+					Children: []*base.Op{&base.Op{
+						Kind:   "order-offset-limit",
+						Labels: base.Labels{"a", "b", "c"},
+						Params: []interface{}{
+							[]interface{}{
+								[]interface{}{"labelPath", "a"},
+								[]interface{}{"labelPath", "c"},
+							},
+							[]interface{}{
+								"asc",
+								"asc",
+							},
+						},
+						Children: []*base.Op{&base.Op{
+							Kind:   "scan",
+							Labels: base.Labels{"a", "b", "c"},
+							Params: []interface{}{
+								"csvData",
+								`
+10,null,1
+10,12,2
+20,null,1
+20,null,2
+`,
+							},
+						}},
+					}},
+				}},
+			}},
+		},
+		expectYields: []base.Vals{
+			base.Vals{[]byte("10"), []byte("1"), []byte("12")},
+			base.Vals{[]byte("10"), []byte("2"), []byte("12")},
+			base.Vals{[]byte("20"), []byte("1"), []byte("null")},
+			base.Vals{[]byte("20"), []byte("2"), []byte("null")},
+		},
+	},
+	{
+		about: "test csv-data window-partition->project window-frame LEAD(b, 1) IGNORE NULLS",
+		o: base.Op{
+			Kind:   "project",
+			Labels: base.Labels{"a", "c", "value"},
+			Params: []interface{}{
+				[]interface{}{"labelPath", "a"},
+				[]interface{}{"labelPath", "c"},
+				[]interface{}{
+					"window-frame-step-value",
+					1,         // Slot for window frames.
+					0,         // Idx for window frame.
+					0,         // Initial starting position is current-row.
+					true,      // Step is ascending.
+					uint64(1), // Number of steps to take.
+					[]interface{}{"labelPath", "b"},
+					"ignoreNulls", // Modifiers.
+				},
+			},
+			Children: []*base.Op{&base.Op{
+				Kind:   "window-frames",
+				Labels: base.Labels{"a", "b", "c"},
+				Params: []interface{}{
+					0, // Slot for window partition.
+					1, // Slot for window frames.
+					[]interface{}{ // Window frames cfg.
+						[]interface{}{
+							"rows",
+							"unbounded", 0, // Preceding.
+							"unbounded", 0, // Following.
+							"no-others", // Exclude.
+							0,           // ValIdx, unused.
+						},
+					},
+				},
+				Children: []*base.Op{&base.Op{
+					Kind:   "window-partition",
+					Labels: base.Labels{"a", "b", "c"},
+					Params: []interface{}{
+						0, // Slot for window partition.
+						[]interface{}{
+							// Partitioning exprs...
+							[]interface{}{"labelPath", "a"},
+						},
+						1,  // # of the partitioning exprs for PARTITION-BY.
+						"", // Additional tracking info.
+					},
+					Children: []*base.Op{&base.Op{
+						Kind:   "order-offset-limit",
+						Labels: base.Labels{"a", "b", "c"},
+						Params: []interface{}{
+							[]interface{}{
+								[]interface{}{"labelPath", "a"},
+								[]interface{}{"labelPath", "c"},
+							},
+							[]interface{}{
+								"asc",
+								"asc",
+							},
+						},
+						Children: []*base.Op{&base.Op{
+							Kind:   "scan",
+							Labels: base.Labels{"a", "b", "c"},
+							Params: []interface{}{
+								"csvData",
+								`
+10,11,1
+10,null,2
+10,13,3
+10,null,4
+20,20,1
+20,null,2
+30,null,1
+`,
+							},
+						}},
+					}},
+				}},
+			}},
+		},
+		expectYields: []base.Vals{
+			base.Vals{[]byte("10"), []byte("1"), []byte("13")},
+			base.Vals{[]byte("10"), []byte("2"), []byte("13")},
+			base.Vals{[]byte("10"), []byte("3"), []byte("null")},
+			base.Vals{[]byte("10"), []byte("4"), []byte(nil)},
+			base.Vals{[]byte("20"), []byte("1"), []byte("null")},
+			base.Vals{[]byte("20"), []byte("2"), []byte(nil)},
+			base.Vals{[]byte("30"), []byte("1"), []byte(nil)},
+		},
+	},
+	{
+		about: "test csv-data scan->order->window-partition->window-frame->project RANK, DENSE_RANK",
+		o: base.Op{
+			Kind:   "project",
+			Labels: base.Labels{"a", "b", "rowNumber", "result-rank", "result-denseRank"},
+			Params: []interface{}{
+				[]interface{}{"labelPath", "a"},
+				[]interface{}{"labelPath", "b"},
+				[]interface{}{
+					"window-partition-row-number",
+					1, // Slot for window frames.
+					0, // Idx for window frame.
+				},
+				[]interface{}{"labelUint64", "myRank"},
+				[]interface{}{"labelUint64", "myDenseRank"},
+			},
+			Children: []*base.Op{&base.Op{
+				Kind:   "window-frames",
+				Labels: base.Labels{"a", "b", "myRank", "myDenseRank"},
+				Params: []interface{}{
+					0, // Slot for window partition.
+					1, // Slot for window frames.
+					[]interface{}{ // Window frames cfg.
+						[]interface{}{
+							"rows",
+							"unbounded", 0, // Preceding.
+							"unbounded", 0, // Following.
+							"no-others", // Exclude.
+							0,           // ValIdx, unused.
+						},
+					},
+				},
+				Children: []*base.Op{&base.Op{
+					Kind:   "window-partition",
+					Labels: base.Labels{"a", "b", "myRank", "myDenseRank"},
+					Params: []interface{}{
+						0, // Slot for window partition.
+						[]interface{}{
+							// Partitioning exprs...
+							[]interface{}{"labelPath", "a"},
+							[]interface{}{"labelPath", "b"},
+						},
+						1,                // # of the partitioning exprs for PARTITION-BY.
+						"rank,denseRank", // Additional tracking info.
+					},
+					Children: []*base.Op{&base.Op{
+						Kind:   "order-offset-limit",
+						Labels: base.Labels{"a", "b"},
+						Params: []interface{}{
+							[]interface{}{
+								[]interface{}{"labelPath", "a"},
+								[]interface{}{"labelPath", "b"},
+							},
+							[]interface{}{
+								"asc",
+								"asc",
+							},
+						},
+						Children: []*base.Op{&base.Op{
+							Kind:   "scan",
+							Labels: base.Labels{"a", "b"},
+							Params: []interface{}{
+								"csvData",
+								`
+10,11
+10,12
+10,12
+10,13
+10,13
+10,14
+20,20
+20,21
+20,21
+30,30
+30,30
+30,31
+`,
+							},
+						}},
+					}},
+				}},
+			}},
+		},
+		expectYields: []base.Vals{
+			base.Vals{[]byte("10"), []byte("11"), []byte("1"), []byte("1"), []byte("1")},
+			base.Vals{[]byte("10"), []byte("12"), []byte("2"), []byte("2"), []byte("2")},
+			base.Vals{[]byte("10"), []byte("12"), []byte("3"), []byte("2"), []byte("2")},
+			base.Vals{[]byte("10"), []byte("13"), []byte("4"), []byte("4"), []byte("3")},
+			base.Vals{[]byte("10"), []byte("13"), []byte("5"), []byte("4"), []byte("3")},
+			base.Vals{[]byte("10"), []byte("14"), []byte("6"), []byte("6"), []byte("4")},
+			base.Vals{[]byte("20"), []byte("20"), []byte("1"), []byte("1"), []byte("1")},
+			base.Vals{[]byte("20"), []byte("21"), []byte("2"), []byte("2"), []byte("2")},
+			base.Vals{[]byte("20"), []byte("21"), []byte("3"), []byte("2"), []byte("2")},
+			base.Vals{[]byte("30"), []byte("30"), []byte("1"), []byte("1"), []byte("1")},
+			base.Vals{[]byte("30"), []byte("30"), []byte("2"), []byte("1"), []byte("1")},
+			base.Vals{[]byte("30"), []byte("31"), []byte("3"), []byte("3"), []byte("2")},
+		},
+	},
+	{
+		about: "test csv-data scan->order->window-partition->window-frame->project PERCENT_RANK, CUME_DIST, NTILE",
+		o: base.Op{
+			Kind:   "project",
+			Labels: base.Labels{"a", "b", "percentRank", "cumeDist", "ntile"},
+			Params: []interface{}{
+				[]interface{}{"labelPath", "a"},
+				[]interface{}{"labelPath", "b"},
+				[]interface{}{
+					"window-partition-percent-rank",
+					"myRank",
+					"myPartitionSize",
+				},
+				[]interface{}{
+					"window-partition-cume-dist",
+					"myPeerEnd",
+					"myPartitionSize",
+				},
+				[]interface{}{
+					"window-partition-ntile",
+					1, // Slot for window frames.
+					0, // Idx for window frame.
+					[]interface{}{"json", "3"},
+					"myPartitionSize",
+				},
+			},
+			Children: []*base.Op{&base.Op{
+				Kind:   "window-frames",
+				Labels: base.Labels{"a", "b", "myRank", "myPartitionSize", "myPeerEnd"},
+				Params: []interface{}{
+					0, // Slot for window partition.
+					1, // Slot for window frames.
+					[]interface{}{ // Window frames cfg.
+						[]interface{}{
+							"rows",
+							"unbounded", 0, // Preceding.
+							"unbounded", 0, // Following.
+							"no-others", // Exclude.
+							0,           // ValIdx, unused.
+						},
+					},
+				},
+				Children: []*base.Op{&base.Op{
+					Kind:   "window-partition",
+					Labels: base.Labels{"a", "b", "myRank", "myPartitionSize", "myPeerEnd"},
+					Params: []interface{}{
+						0, // Slot for window partition.
+						[]interface{}{
+							// Partitioning exprs...
+							[]interface{}{"labelPath", "a"},
+							[]interface{}{"labelPath", "b"},
+						},
+						1,                            // # of the partitioning exprs for PARTITION-BY.
+						"rank,partitionSize,peerEnd", // Additional tracking info.
+					},
+					Children: []*base.Op{&base.Op{
+						Kind:   "order-offset-limit",
+						Labels: base.Labels{"a", "b"},
+						Params: []interface{}{
+							[]interface{}{
+								[]interface{}{"labelPath", "a"},
+								[]interface{}{"labelPath", "b"},
+							},
+							[]interface{}{
+								"asc",
+								"asc",
+							},
+						},
+						Children: []*base.Op{&base.Op{
+							Kind:   "scan",
+							Labels: base.Labels{"a", "b"},
+							Params: []interface{}{
+								"csvData",
+								`
+10,11
+10,12
+10,12
+10,13
+10,13
+10,14
+20,20
+20,21
+20,21
+30,30
+30,30
+30,31
+40,40
+`,
+							},
+						}},
+					}},
+				}},
+			}},
+		},
+		expectYields: []base.Vals{
+			base.Vals{[]byte("10"), []byte("11"), []byte("0"), []byte("0.16666666666666666"), []byte("1")},
+			base.Vals{[]byte("10"), []byte("12"), []byte("0.2"), []byte("0.5"), []byte("1")},
+			base.Vals{[]byte("10"), []byte("12"), []byte("0.2"), []byte("0.5"), []byte("2")},
+			base.Vals{[]byte("10"), []byte("13"), []byte("0.6"), []byte("0.8333333333333334"), []byte("2")},
+			base.Vals{[]byte("10"), []byte("13"), []byte("0.6"), []byte("0.8333333333333334"), []byte("3")},
+			base.Vals{[]byte("10"), []byte("14"), []byte("1"), []byte("1"), []byte("3")},
+			base.Vals{[]byte("20"), []byte("20"), []byte("0"), []byte("0.3333333333333333"), []byte("1")},
+			base.Vals{[]byte("20"), []byte("21"), []byte("0.5"), []byte("1"), []byte("2")},
+			base.Vals{[]byte("20"), []byte("21"), []byte("0.5"), []byte("1"), []byte("3")},
+			base.Vals{[]byte("30"), []byte("30"), []byte("0"), []byte("0.6666666666666666"), []byte("1")},
+			base.Vals{[]byte("30"), []byte("30"), []byte("0"), []byte("0.6666666666666666"), []byte("2")},
+			base.Vals{[]byte("30"), []byte("31"), []byte("1"), []byte("1"), []byte("3")},
+			base.Vals{[]byte("40"), []byte("40"), []byte("0"), []byte("1"), []byte("1")},
+		},
+	},
+	{
+		about: "test csv-data scan->order->window-partition->window-frame->project CUME_DIST, NTILE with hidden rank",
+		o: base.Op{
+			Kind:   "project",
+			Labels: base.Labels{"a", "b", "denseRank", "cumeDist", "ntile"},
+			Params: []interface{}{
+				[]interface{}{"labelPath", "a"},
+				[]interface{}{"labelPath", "b"},
+				[]interface{}{"labelUint64", "myDenseRank"},
+				[]interface{}{
+					"window-partition-cume-dist",
+					"myPeerEnd",
+					"myPartitionSize",
+				},
+				[]interface{}{
+					"window-partition-ntile",
+					1, // Slot for window frames.
+					0, // Idx for window frame.
+					[]interface{}{"json", "4"},
+					"myPartitionSize",
+				},
+			},
+			Children: []*base.Op{&base.Op{
+				Kind:   "window-frames",
+				Labels: base.Labels{"a", "b", "myDenseRank", "myPartitionSize", "myPeerEnd"},
+				Params: []interface{}{
+					0, // Slot for window partition.
+					1, // Slot for window frames.
+					[]interface{}{ // Window frames cfg.
+						[]interface{}{
+							"rows",
+							"unbounded", 0, // Preceding.
+							"unbounded", 0, // Following.
+							"no-others", // Exclude.
+							0,           // ValIdx, unused.
+						},
+					},
+				},
+				Children: []*base.Op{&base.Op{
+					Kind:   "window-partition",
+					Labels: base.Labels{"a", "b", "myDenseRank", "myPartitionSize", "myPeerEnd"},
+					Params: []interface{}{
+						0, // Slot for window partition.
+						[]interface{}{
+							// Partitioning exprs...
+							[]interface{}{"labelPath", "a"},
+							[]interface{}{"labelPath", "b"},
+						},
+						1,                                 // # of the partitioning exprs for PARTITION-BY.
+						"denseRank,partitionSize,peerEnd", // Additional tracking info.
+					},
+					Children: []*base.Op{&base.Op{
+						Kind:   "order-offset-limit",
+						Labels: base.Labels{"a", "b"},
+						Params: []interface{}{
+							[]interface{}{
+								[]interface{}{"labelPath", "a"},
+								[]interface{}{"labelPath", "b"},
+							},
+							[]interface{}{
+								"asc",
+								"asc",
+							},
+						},
+						Children: []*base.Op{&base.Op{
+							Kind:   "scan",
+							Labels: base.Labels{"a", "b"},
+							Params: []interface{}{
+								"csvData",
+								`
+10,11
+10,12
+10,12
+10,13
+10,13
+10,14
+20,20
+20,21
+20,21
+`,
+							},
+						}},
+					}},
+				}},
+			}},
+		},
+		expectYields: []base.Vals{
+			base.Vals{[]byte("10"), []byte("11"), []byte("1"), []byte("0.16666666666666666"), []byte("1")},
+			base.Vals{[]byte("10"), []byte("12"), []byte("2"), []byte("0.5"), []byte("1")},
+			base.Vals{[]byte("10"), []byte("12"), []byte("2"), []byte("0.5"), []byte("2")},
+			base.Vals{[]byte("10"), []byte("13"), []byte("3"), []byte("0.8333333333333334"), []byte("2")},
+			base.Vals{[]byte("10"), []byte("13"), []byte("3"), []byte("0.8333333333333334"), []byte("3")},
+			base.Vals{[]byte("10"), []byte("14"), []byte("4"), []byte("1"), []byte("4")},
+			base.Vals{[]byte("20"), []byte("20"), []byte("1"), []byte("0.3333333333333333"), []byte("1")},
+			base.Vals{[]byte("20"), []byte("21"), []byte("2"), []byte("1"), []byte("2")},
+			base.Vals{[]byte("20"), []byte("21"), []byte("2"), []byte("1"), []byte("3")},
+		},
+	},
+	{
+		about: "test csv-data scan->order->window-partition->window-frame->project CUME_DIST, NTILE with hidden rank and streaming fallback",
+		o: base.Op{
+			Kind:   "project",
+			Labels: base.Labels{"a", "b", "denseRank", "cumeDist", "ntile"},
+			Params: []interface{}{
+				[]interface{}{"labelPath", "a"},
+				[]interface{}{"labelPath", "b"},
+				[]interface{}{"labelUint64", "myDenseRank"},
+				[]interface{}{
+					"window-partition-cume-dist",
+					"myPeerEnd",
+					"myPartitionSize",
+				},
+				[]interface{}{
+					"window-partition-ntile",
+					1, // Slot for window frames.
+					0, // Idx for window frame.
+					[]interface{}{"json", "4"},
+					"myPartitionSize",
+				},
+			},
+			Children: []*base.Op{&base.Op{
+				Kind:   "window-frames",
+				Labels: base.Labels{"a", "b", "myDenseRank", "myPartitionSize", "myPeerEnd"},
+				Params: []interface{}{
+					0, // Slot for window partition.
+					1, // Slot for window frames.
+					[]interface{}{ // Window frames cfg.
+						[]interface{}{
+							"rows",
+							"num", 0, // Current row.
+							"num", 0, // Current row.
+							"no-others", // Exclude.
+							0,           // ValIdx, unused.
+						},
+					},
+				},
+				Children: []*base.Op{&base.Op{
+					Kind:   "window-partition",
+					Labels: base.Labels{"a", "b", "myDenseRank", "myPartitionSize", "myPeerEnd"},
+					Params: []interface{}{
+						0, // Slot for window partition.
+						[]interface{}{
+							// Partitioning exprs...
+							[]interface{}{"labelPath", "a"},
+							[]interface{}{"labelPath", "b"},
+						},
+						1,                                 // # of the partitioning exprs for PARTITION-BY.
+						"denseRank,partitionSize,peerEnd", // Additional tracking info.
+						[]interface{}{0, 0},               // Stream config of [numPreceding, numFollowing].
+					},
+					Children: []*base.Op{&base.Op{
+						Kind:   "order-offset-limit",
+						Labels: base.Labels{"a", "b"},
+						Params: []interface{}{
+							[]interface{}{
+								[]interface{}{"labelPath", "a"},
+								[]interface{}{"labelPath", "b"},
+							},
+							[]interface{}{
+								"asc",
+								"asc",
+							},
+						},
+						Children: []*base.Op{&base.Op{
+							Kind:   "scan",
+							Labels: base.Labels{"a", "b"},
+							Params: []interface{}{
+								"csvData",
+								`
+10,11
+10,12
+10,12
+10,13
+10,13
+10,14
+20,20
+20,21
+20,21
+`,
+							},
+						}},
+					}},
+				}},
+			}},
+		},
+		expectYields: []base.Vals{
+			base.Vals{[]byte("10"), []byte("11"), []byte("1"), []byte("0.16666666666666666"), []byte("1")},
+			base.Vals{[]byte("10"), []byte("12"), []byte("2"), []byte("0.5"), []byte("1")},
+			base.Vals{[]byte("10"), []byte("12"), []byte("2"), []byte("0.5"), []byte("2")},
+			base.Vals{[]byte("10"), []byte("13"), []byte("3"), []byte("0.8333333333333334"), []byte("2")},
+			base.Vals{[]byte("10"), []byte("13"), []byte("3"), []byte("0.8333333333333334"), []byte("3")},
+			base.Vals{[]byte("10"), []byte("14"), []byte("4"), []byte("1"), []byte("4")},
+			base.Vals{[]byte("20"), []byte("20"), []byte("1"), []byte("0.3333333333333333"), []byte("1")},
+			base.Vals{[]byte("20"), []byte("21"), []byte("2"), []byte("1"), []byte("2")},
+			base.Vals{[]byte("20"), []byte("21"), []byte("2"), []byte("1"), []byte("3")},
+		},
+	},
+	{
+		about: "test csv-data window-partition->ROWS window-frame [-1...1], project FIRST_VALUE, LAST_VALUE",
+		o: base.Op{
+			Kind:   "project",
+			Labels: base.Labels{"a", "denseRank", "firstValue", "lastValue"},
+			Params: []interface{}{
+				[]interface{}{"labelPath", "a"},
+				[]interface{}{"labelUint64", "myDenseRank"},
+				[]interface{}{
+					"window-frame-step-value",
+					1,         // Slot for window frames.
+					0,         // Idx for window frame.
+					-1,        // Initial starting position is -1.
+					true,      // Step is ascending.
+					uint64(1), // Number of steps to take.
+					[]interface{}{"labelPath", "b"},
+				},
+				[]interface{}{
+					"window-frame-step-value",
+					1,         // Slot for window frames.
+					0,         // Idx for window frame.
+					1,         // Initial starting position is end.
+					false,     // Step is descending.
+					uint64(1), // Number of steps to take.
+					[]interface{}{"labelPath", "b"},
+				},
+			},
+			Children: []*base.Op{&base.Op{
+				Kind:   "window-frames",
+				Labels: base.Labels{"a", "b", "myDenseRank"},
+				Params: []interface{}{
+					0, // Slot for window partition.
+					1, // Slot for window frames.
+					[]interface{}{ // Window frames cfg.
+						[]interface{}{
+							"rows",
+							"num", -1, // Preceding.
+							"num", 1, // Following.
+							"no-others", // Exclude.
+							0,           // ValIdx, unused with ROWS.
+						},
+					},
+				},
+				Children: []*base.Op{&base.Op{
+					Kind:   "window-partition",
+					Labels: base.Labels{"a", "b", "myDenseRank"},
+					Params: []interface{}{
+						0, // Slot for window partition.
+						[]interface{}{
+							// Partitioning exprs...
+							[]interface{}{"labelPath", "a"},
+							[]interface{}{"labelPath", "b"},
+						},
+						1,           // # of the partitioning exprs for PARTITION-BY.
+						"denseRank", // Additional tracking info.
+					},
+					Children: []*base.Op{&base.Op{
+						Kind:   "order-offset-limit",
+						Labels: base.Labels{"a", "b"},
+						Params: []interface{}{
+							[]interface{}{
+								[]interface{}{"labelPath", "a"},
+								[]interface{}{"labelPath", "b"},
+							},
+							[]interface{}{
+								"asc",
+								"asc",
+							},
+						},
+						Children: []*base.Op{&base.Op{
+							Kind:   "scan",
+							Labels: base.Labels{"a", "b"},
+							Params: []interface{}{
+								"csvData",
+								`
+10,11
+10,12
+10,12
+10,12
+10,13
+20,20
+20,20
+20,21
+30,30
+30,31
+30,31
+`,
+							},
+						}},
+					}},
+				}},
+			}},
+		},
+		expectYields: []base.Vals{
+			base.Vals{[]byte("10"), []byte("1"), []byte("11"), []byte("12")},
+			base.Vals{[]byte("10"), []byte("2"), []byte("11"), []byte("12")},
+			base.Vals{[]byte("10"), []byte("2"), []byte("12"), []byte("12")},
+			base.Vals{[]byte("10"), []byte("2"), []byte("12"), []byte("13")},
+			base.Vals{[]byte("10"), []byte("3"), []byte("12"), []byte("13")},
+			base.Vals{[]byte("20"), []byte("1"), []byte("20"), []byte("20")},
+			base.Vals{[]byte("20"), []byte("1"), []byte("20"), []byte("21")},
+			base.Vals{[]byte("20"), []byte("2"), []byte("20"), []byte("21")},
+			base.Vals{[]byte("30"), []byte("1"), []byte("30"), []byte("31")},
+			base.Vals{[]byte("30"), []byte("2"), []byte("30"), []byte("31")},
+			base.Vals{[]byte("30"), []byte("2"), []byte("31"), []byte("31")},
+		},
+	},
+	{
+		about: "test csv-data window-partition streaming->ROWS window-frame [-1...1], project FIRST_VALUE, LAST_VALUE",
+		o: base.Op{
+			Kind:   "project",
+			Labels: base.Labels{"a", "denseRank", "firstValue", "lastValue"},
+			Params: []interface{}{
+				[]interface{}{"labelPath", "a"},
+				[]interface{}{"labelUint64", "myDenseRank"},
+				[]interface{}{
+					"window-frame-step-value",
+					1,         // Slot for window frames.
+					0,         // Idx for window frame.
+					-1,        // Initial starting position is -1.
+					true,      // Step is ascending.
+					uint64(1), // Number of steps to take.
+					[]interface{}{"labelPath", "b"},
+				},
+				[]interface{}{
+					"window-frame-step-value",
+					1,         // Slot for window frames.
+					0,         // Idx for window frame.
+					1,         // Initial starting position is end.
+					false,     // Step is descending.
+					uint64(1), // Number of steps to take.
+					[]interface{}{"labelPath", "b"},
+				},
+			},
+			Children: []*base.Op{&base.Op{
+				Kind:   "window-frames",
+				Labels: base.Labels{"a", "b", "myDenseRank"},
+				Params: []interface{}{
+					0, // Slot for window partition.
+					1, // Slot for window frames.
+					[]interface{}{ // Window frames cfg.
+						[]interface{}{
+							"rows",
+							"num", -1, // Preceding.
+							"num", 1, // Following.
+							"no-others", // Exclude.
+							0,           // ValIdx, unused with ROWS.
+						},
+					},
+				},
+				Children: []*base.Op{&base.Op{
+					Kind:   "window-partition",
+					Labels: base.Labels{"a", "b", "myDenseRank"},
+					Params: []interface{}{
+						0, // Slot for window partition.
+						[]interface{}{
+							// Partitioning exprs...
+							[]interface{}{"labelPath", "a"},
+							[]interface{}{"labelPath", "b"},
+						},
+						1,                   // # of the partitioning exprs for PARTITION-BY.
+						"denseRank",         // Additional tracking info.
+						[]interface{}{1, 1}, // Stream config of [numPreceding, numFollowing].
+					},
+					Children: []*base.Op{&base.Op{
+						Kind:   "order-offset-limit",
+						Labels: base.Labels{"a", "b"},
+						Params: []interface{}{
+							[]interface{}{
+								[]interface{}{"labelPath", "a"},
+								[]interface{}{"labelPath", "b"},
+							},
+							[]interface{}{
+								"asc",
+								"asc",
+							},
+						},
+						Children: []*base.Op{&base.Op{
+							Kind:   "scan",
+							Labels: base.Labels{"a", "b"},
+							Params: []interface{}{
+								"csvData",
+								`
+10,11
+10,12
+10,12
+10,12
+10,13
+20,20
+20,20
+20,21
+30,30
+30,31
+30,31
+`,
+							},
+						}},
+					}},
+				}},
+			}},
+		},
+		expectYields: []base.Vals{
+			base.Vals{[]byte("10"), []byte("1"), []byte("11"), []byte("12")},
+			base.Vals{[]byte("10"), []byte("2"), []byte("11"), []byte("12")},
+			base.Vals{[]byte("10"), []byte("2"), []byte("12"), []byte("12")},
+			base.Vals{[]byte("10"), []byte("2"), []byte("12"), []byte("13")},
+			base.Vals{[]byte("10"), []byte("3"), []byte("12"), []byte("13")},
+			base.Vals{[]byte("20"), []byte("1"), []byte("20"), []byte("20")},
+			base.Vals{[]byte("20"), []byte("1"), []byte("20"), []byte("21")},
+			base.Vals{[]byte("20"), []byte("2"), []byte("20"), []byte("21")},
+			base.Vals{[]byte("30"), []byte("1"), []byte("30"), []byte("31")},
+			base.Vals{[]byte("30"), []byte("2"), []byte("30"), []byte("31")},
+			base.Vals{[]byte("30"), []byte("2"), []byte("31"), []byte("31")},
+		},
+	},
+	{
+		about: "test csv-data window-partition streaming->ROWS window-frame [unbounded...0], project ROW_NUMBER, RANK, COUNT, running SUM",
+		o: base.Op{
+			Kind:   "project",
+			Labels: base.Labels{"a", "b", "rowNumber", "rank", "count", "sum"},
+			Params: []interface{}{
+				[]interface{}{"labelPath", "a"},
+				[]interface{}{"labelPath", "b"},
+				[]interface{}{
+					"window-partition-row-number",
+					1, // Slot for window frames.
+					0, // Idx for window frame.
+				},
+				[]interface{}{"labelUint64", "myRank"},
+				[]interface{}{
+					"window-frame-count",
+					1, // Slot for window frames.
+					0, // Idx for window frame.
+				},
+				[]interface{}{
+					"window-frame-agg",
+					1,     // Slot for window frames.
+					0,     // Idx for window frame.
+					"sum", // Aggregation name.
+					[]interface{}{"labelPath", "b"},
+				},
+			},
+			Children: []*base.Op{&base.Op{
+				Kind:   "window-frames",
+				Labels: base.Labels{"a", "b", "myRank"},
+				Params: []interface{}{
+					0, // Slot for window partition.
+					1, // Slot for window frames.
+					[]interface{}{ // Window frames cfg.
+						[]interface{}{
+							"rows",
+							"unbounded", 0, // Preceding.
+							"num", 0, // Current row.
+							"no-others", // Exclude.
+							0,           // ValIdx, unused with ROWS.
+						},
+					},
+				},
+				Children: []*base.Op{&base.Op{
+					Kind:   "window-partition",
+					Labels: base.Labels{"a", "b", "myRank"},
+					Params: []interface{}{
+						0, // Slot for window partition.
+						[]interface{}{
+							// Partitioning exprs...
+							[]interface{}{"labelPath", "a"},
+							[]interface{}{"labelPath", "b"},
+						},
+						1,                   // # of the partitioning exprs for PARTITION-BY.
+						"rank",              // Additional tracking info.
+						[]interface{}{0, 0}, // Stream config of [numPreceding, numFollowing].
+					},
+					Children: []*base.Op{&base.Op{
+						Kind:   "order-offset-limit",
+						Labels: base.Labels{"a", "b"},
+						Params: []interface{}{
+							[]interface{}{
+								[]interface{}{"labelPath", "a"},
+								[]interface{}{"labelPath", "b"},
+							},
+							[]interface{}{
+								"asc",
+								"asc",
+							},
+						},
+						Children: []*base.Op{&base.Op{
+							Kind:   "scan",
+							Labels: base.Labels{"a", "b"},
+							Params: []interface{}{
+								"csvData",
+								`
+10,11
+10,12
+10,12
+10,13
+10,14
+20,20
+30,30
+30,30
+`,
+							},
+						}},
+					}},
+				}},
+			}},
+		},
+		expectYields: []base.Vals{
+			base.Vals{[]byte("10"), []byte("11"), []byte("1"), []byte("1"), []byte("1"), []byte("11")},
+			base.Vals{[]byte("10"), []byte("12"), []byte("2"), []byte("2"), []byte("2"), []byte("23")},
+			base.Vals{[]byte("10"), []byte("12"), []byte("3"), []byte("2"), []byte("3"), []byte("35")},
+			base.Vals{[]byte("10"), []byte("13"), []byte("4"), []byte("4"), []byte("4"), []byte("48")},
+			base.Vals{[]byte("10"), []byte("14"), []byte("5"), []byte("5"), []byte("5"), []byte("62")},
+			base.Vals{[]byte("20"), []byte("20"), []byte("1"), []byte("1"), []byte("1"), []byte("20")},
+			base.Vals{[]byte("30"), []byte("30"), []byte("1"), []byte("1"), []byte("1"), []byte("30")},
+			base.Vals{[]byte("30"), []byte("30"), []byte("2"), []byte("1"), []byte("2"), []byte("60")},
+		},
+	},
+	{
+		about: "test csv-data window-partition streaming->ROWS window-frame [-1...1], project window-frame-agg COUNT, AVG, MIN, SUM with NULLs",
+		o: base.Op{
+			Kind:   "project",
+			Labels: base.Labels{"a", "b", "count", "avg", "min", "sum"},
+			Params: []interface{}{
+				[]interface{}{"labelPath", "a"},
+				[]interface{}{"labelPath", "b"},
+				[]interface{}{"window-frame-agg", 1, 0, "count", []interface{}{"labelPath", "b"}},
+				[]interface{}{"window-frame-agg", 1, 0, "avg", []interface{}{"labelPath", "b"}},
+				[]interface{}{"window-frame-agg", 1, 0, "min", []interface{}{"labelPath", "b"}},
+				[]interface{}{"window-frame-agg", 1, 0, "sum", []interface{}{"labelPath", "b"}},
+			},
+			Children: []*base.Op{&base.Op{
+				Kind:   "window-frames",
+				Labels: base.Labels{"a", "b"},
+				Params: []interface{}{
+					0, // Slot for window partition.
+					1, // Slot for window frames.
+					[]interface{}{ // Window frames cfg.
+						[]interface{}{
+							"rows",
+							"num", -1, // Preceding.
+							"num", 1, // Following.
+							"no-others", // Exclude.
+							0,           // ValIdx, unused with ROWS.
+						},
+					},
+				},
+				Children: []*base.Op{&base.Op{
+					Kind:   "window-partition",
+					Labels: base.Labels{"a", "b"},
+					Params: []interface{}{
+						0, // Slot for window partition.
+						[]interface{}{
+							// Partitioning exprs...
+							[]interface{}{"labelPath", "a"},
+						},
+						1,                   // # of the partitioning exprs for PARTITION-BY.
+						"",                  // Additional tracking info.
+						[]interface{}{1, 1}, // Stream config of [numPreceding, numFollowing].
+					},
+					Children: []*base.Op{&base.Op{
+						Kind:   "scan",
+						Labels: base.Labels{"a", "b"},
+						Params: []interface{}{
+							"csvData",
+							`
+1,10
+1,null
+1,30
+2,null
+`,
+						},
+					}},
+				}},
+			}},
+		},
+		expectYields: []base.Vals{
+			base.Vals{[]byte("1"), []byte("10"), []byte("1"), []byte("10"), []byte("10"), []byte("10")},
+			base.Vals{[]byte("1"), []byte("null"), []byte("2"), []byte("20"), []byte("10"), []byte("40")},
+			base.Vals{[]byte("1"), []byte("30"), []byte("1"), []byte("30"), []byte("30"), []byte("30")},
+			base.Vals{[]byte("2"), []byte("null"), []byte("0"), []byte("null"), []byte("null"), []byte("null")},
+		},
+	},
+	{
+		about: "test csv-data window-partition streaming fallback->ROWS window-frame [0...unbounded], project LAST_VALUE, COUNT",
+		o: base.Op{
+			Kind:   "project",
+			Labels: base.Labels{"a", "b", "lastValue", "count"},
+			Params: []interface{}{
+				[]interface{}{"labelPath", "a"},
+				[]interface{}{"labelPath", "b"},
+				[]interface{}{
+					"window-frame-step-value",
+					1,         // Slot for window frames.
+					0,         // Idx for window frame.
+					1,         // Initial starting position is end.
+					false,     // Step is descending.
+					uint64(1), // Number of steps to take.
+					[]interface{}{"labelPath", "b"},
+				},
+				[]interface{}{
+					"window-frame-count",
+					1, // Slot for window frames.
+					0, // Idx for window frame.
+				},
+			},
+			Children: []*base.Op{&base.Op{
+				Kind:   "window-frames",
+				Labels: base.Labels{"a", "b"},
+				Params: []interface{}{
+					0, // Slot for window partition.
+					1, // Slot for window frames.
+					[]interface{}{ // Window frames cfg.
+						[]interface{}{
+							"rows",
+							"num", 0, // Current row.
+							"unbounded", 0, // Following.
+							"no-others", // Exclude.
+							0,           // ValIdx, unused with ROWS.
+						},
+					},
+				},
+				Children: []*base.Op{&base.Op{
+					Kind:   "window-partition",
+					Labels: base.Labels{"a", "b"},
+					Params: []interface{}{
+						0, // Slot for window partition.
+						[]interface{}{
+							// Partitioning exprs...
+							[]interface{}{"labelPath", "a"},
+							[]interface{}{"labelPath", "b"},
+						},
+						1,                   // # of the partitioning exprs for PARTITION-BY.
+						"",                  // Additional tracking info.
+						[]interface{}{0, 0}, // Stream config of [numPreceding, numFollowing].
+					},
+					Children: []*base.Op{&base.Op{
+						Kind:   "order-offset-limit",
+						Labels: base.Labels{"a", "b"},
+						Params: []interface{}{
+							[]interface{}{
+								[]interface{}{"labelPath", "a"},
+								[]interface{}{"labelPath", "b"},
+							},
+							[]interface{}{
+								"asc",
+								"asc",
+							},
+						},
+						Children: []*base.Op{&base.Op{
+							Kind:   "scan",
+							Labels: base.Labels{"a", "b"},
+							Params: []interface{}{
+								"csvData",
+								`
+10,11
+10,12
+10,13
+20,20
+`,
+							},
+						}},
+					}},
+				}},
+			}},
+		},
+		expectYields: []base.Vals{
+			base.Vals{[]byte("10"), []byte("11"), []byte("13"), []byte("3")},
+			base.Vals{[]byte("10"), []byte("12"), []byte("13"), []byte("2")},
+			base.Vals{[]byte("10"), []byte("13"), []byte("13"), []byte("1")},
+			base.Vals{[]byte("20"), []byte("20"), []byte("20"), []byte("1")},
+		},
+	},
+	{
+		about: "test csv-data window-partition->GROUPS window-frame [-1...1], project FIRST_VALUE, LAST_VALUE",
+		o: base.Op{
+			Kind:   "project",
+			Labels: base.Labels{"a", "c", "denseRank", "firstValue", "lastValue"},
+			Params: []interface{}{
+				[]interface{}{"labelPath", "a"},
+				[]interface{}{"labelPath", "c"},
+				[]interface{}{"labelUint64", "myDenseRank"},
+				[]interface{}{
+					"window-frame-step-value",
+					1,         // Slot for window frames.
+					0,         // Idx for window frame.
+					-1,        // Initial starting position is -1.
+					true,      // Step is ascending.
+					uint64(1), // Number of steps to take.
+					[]interface{}{"labelPath", "c"},
+				},
+				[]interface{}{
+					"window-frame-step-value",
+					1,         // Slot for window frames.
+					0,         // Idx for window frame.
+					1,         // Initial starting position is end.
+					false,     // Step is descending.
+					uint64(1), // Number of steps to take.
+					[]interface{}{"labelPath", "c"},
+				},
+			},
+			Children: []*base.Op{&base.Op{
+				Kind:   "window-frames",
+				Labels: base.Labels{"a", "b", "c", "myDenseRank"},
+				Params: []interface{}{
+					0, // Slot for window partition.
+					1, // Slot for window frames.
+					[]interface{}{ // Window frames cfg.
+						[]interface{}{
+							"groups",
+							"num", -1, // Preceding.
+							"num", 1, // Following.
+							"no-others", // Exclude.
+							3,           // ValIdx to the denseRank val.
+						},
+					},
+				},
+				Children: []*base.Op{&base.Op{
+					Kind:   "window-partition",
+					Labels: base.Labels{"a", "b", "c", "myDenseRank"},
+					Params: []interface{}{
+						0, // Slot for window partition.
+						[]interface{}{
+							// Partitioning exprs...
+							[]interface{}{"labelPath", "a"},
+							[]interface{}{"labelPath", "b"},
+						},
+						1,           // # of the partitioning exprs for PARTITION-BY.
+						"denseRank", // Additional tracking info.
+					},
+					Children: []*base.Op{&base.Op{
+						Kind:   "order-offset-limit",
+						Labels: base.Labels{"a", "b", "c"},
+						Params: []interface{}{
+							[]interface{}{
+								[]interface{}{"labelPath", "a"},
+								[]interface{}{"labelPath", "b"},
+								[]interface{}{"labelPath", "c"},
+							},
+							[]interface{}{
+								"asc",
+								"asc",
+								"asc",
+							},
+						},
+						Children: []*base.Op{&base.Op{
+							Kind:   "scan",
+							Labels: base.Labels{"a", "b", "c"},
+							Params: []interface{}{
+								"csvData",
+								`
+10,11,100
+10,12,101
+10,12,102
+10,12,103
+10,13,104
+20,20,200
+20,20,201
+20,21,202
+30,30,300
+30,31,301
+30,31,302
+`,
+							},
+						}},
+					}},
+				}},
+			}},
+		},
+		expectYields: []base.Vals{
+			base.Vals{[]byte("10"), []byte("100"), []byte("1"), []byte("100"), []byte("103")},
+			base.Vals{[]byte("10"), []byte("101"), []byte("2"), []byte("100"), []byte("104")},
+			base.Vals{[]byte("10"), []byte("102"), []byte("2"), []byte("100"), []byte("104")},
+			base.Vals{[]byte("10"), []byte("103"), []byte("2"), []byte("100"), []byte("104")},
+			base.Vals{[]byte("10"), []byte("104"), []byte("3"), []byte("101"), []byte("104")},
+			base.Vals{[]byte("20"), []byte("200"), []byte("1"), []byte("200"), []byte("202")},
+			base.Vals{[]byte("20"), []byte("201"), []byte("1"), []byte("200"), []byte("202")},
+			base.Vals{[]byte("20"), []byte("202"), []byte("2"), []byte("200"), []byte("202")},
+			base.Vals{[]byte("30"), []byte("300"), []byte("1"), []byte("300"), []byte("302")},
+			base.Vals{[]byte("30"), []byte("301"), []byte("2"), []byte("300"), []byte("302")},
+			base.Vals{[]byte("30"), []byte("302"), []byte("2"), []byte("300"), []byte("302")},
+		},
+	},
+	{
+		about: "test csv-data window-partition->RANGE window-frame [-1...1], project FIRST_VALUE, LAST_VALUE",
+		o: base.Op{
+			Kind:   "project",
+			Labels: base.Labels{"a", "c", "denseRank", "firstValue", "lastValue"},
+			Params: []interface{}{
+				[]interface{}{"labelPath", "a"},
+				[]interface{}{"labelPath", "c"},
+				[]interface{}{"labelUint64", "myDenseRank"},
+				[]interface{}{
+					"window-frame-step-value",
+					1,         // Slot for window frames.
+					0,         // Idx for window frame.
+					-1,        // Initial starting position is -1.
+					true,      // Step is ascending.
+					uint64(1), // Number of steps to take.
+					[]interface{}{"labelPath", "c"},
+				},
+				[]interface{}{
+					"window-frame-step-value",
+					1,         // Slot for window frames.
+					0,         // Idx for window frame.
+					1,         // Initial starting position is end.
+					false,     // Step is descending.
+					uint64(1), // Number of steps to take.
+					[]interface{}{"labelPath", "c"},
+				},
+			},
+			Children: []*base.Op{&base.Op{
+				Kind:   "window-frames",
+				Labels: base.Labels{"a", "b", "c", "myDenseRank"},
+				Params: []interface{}{
+					0, // Slot for window partition.
+					1, // Slot for window frames.
+					[]interface{}{ // Window frames cfg.
+						[]interface{}{
+							"range",
+							"num", float64(-1.0), // Preceding.
+							"num", float64(1.0), // Following.
+							"no-others", // Exclude.
+							1,           // ValIdx, for RANGE type.
+						},
+					},
+				},
+				Children: []*base.Op{&base.Op{
+					Kind:   "window-partition",
+					Labels: base.Labels{"a", "b", "c", "myDenseRank"},
+					Params: []interface{}{
+						0, // Slot for window partition.
+						[]interface{}{
+							// Partitioning exprs...
+							[]interface{}{"labelPath", "a"},
+							[]interface{}{"labelPath", "b"},
+						},
+						1,           // # of the partitioning exprs for PARTITION-BY.
+						"denseRank", // Additional tracking info.
+					},
+					Children: []*base.Op{&base.Op{
+						Kind:   "order-offset-limit",
+						Labels: base.Labels{"a", "b", "c"},
+						Params: []interface{}{
+							[]interface{}{
+								[]interface{}{"labelPath", "a"},
+								[]interface{}{"labelPath", "b"},
+								[]interface{}{"labelPath", "c"},
+							},
+							[]interface{}{
+								"asc",
+								"asc",
+								"asc",
+							},
+						},
+						Children: []*base.Op{&base.Op{
+							Kind:   "scan",
+							Labels: base.Labels{"a", "b", "c"},
+							Params: []interface{}{
+								"csvData",
+								`
+10,11,100
+10,12,101
+10,12,102
+10,12,103
+10,13,104
+20,20,200
+20,20,201
+20,21,202
+30,30,300
+30,31,301
+30,31,302
+`,
+							},
+						}},
+					}},
+				}},
+			}},
+		},
+		expectYields: []base.Vals{
+			base.Vals{[]byte("10"), []byte("100"), []byte("1"), []byte("100"), []byte("103")},
+			base.Vals{[]byte("10"), []byte("101"), []byte("2"), []byte("100"), []byte("104")},
+			base.Vals{[]byte("10"), []byte("102"), []byte("2"), []byte("100"), []byte("104")},
+			base.Vals{[]byte("10"), []byte("103"), []byte("2"), []byte("100"), []byte("104")},
+			base.Vals{[]byte("10"), []byte("104"), []byte("3"), []byte("101"), []byte("104")},
+			base.Vals{[]byte("20"), []byte("200"), []byte("1"), []byte("200"), []byte("202")},
+			base.Vals{[]byte("20"), []byte("201"), []byte("1"), []byte("200"), []byte("202")},
+			base.Vals{[]byte("20"), []byte("202"), []byte("2"), []byte("200"), []byte("202")},
+			base.Vals{[]byte("30"), []byte("300"), []byte("1"), []byte("300"), []byte("302")},
+			base.Vals{[]byte("30"), []byte("301"), []byte("2"), []byte("300"), []byte("302")},
+			base.Vals{[]byte("30"), []byte("302"), []byte("2"), []byte("300"), []byte("302")},
+		},
+	},
+	{
+		about: "test csv-data window-partition->RANGE DESC NULLS FIRST window-frame [-1...0], project FIRST_VALUE, LAST_VALUE",
+		o: base.Op{
+			Kind:   "project",
+			Labels: base.Labels{"a", "c", "firstValue", "lastValue"},
+			Params: []interface{}{
+				[]interface{}{"labelPath", "a"},
+				[]interface{}{"labelPath", "c"},
+				[]interface{}{
+					"window-frame-step-value",
+					1,         // Slot for window frames.
+					0,         // Idx for window frame.
+					-1,        // Initial starting position is -1.
+					true,      // Step is ascending.
+					uint64(1), // Number of steps to take.
+					[]interface{}{"labelPath", "c"},
+				},
+				[]interface{}{
+					"window-frame-step-value",
+					1,         // Slot for window frames.
+					0,         // Idx for window frame.
+					1,         // Initial starting position is end.
+					false,     // Step is descending.
+					uint64(1), // Number of steps to take.
+					[]interface{}{"labelPath", "c"},
+				},
+			},
+			Children: []*base.Op{&base.Op{
+				Kind:   "window-frames",
+				Labels: base.Labels{"a", "b", "c"},
+				Params: []interface{}{
+					0, // Slot for window partition.
+					1, // Slot for window frames.
+					[]interface{}{ // Window frames cfg.
+						[]interface{}{
+							"range",
+							"num", float64(-1.0), // Preceding.
+							"num", float64(0.0), // Current row.
+							"no-others", // Exclude.
+							1,           // ValIdx, for RANGE type.
+							"desc",      // Direction, for RANGE type.
+						},
+					},
+				},
+				Children: []*base.Op{&base.Op{
+					Kind:   "window-partition",
+					Labels: base.Labels{"a", "b", "c"},
+					Params: []interface{}{
+						0, // Slot for window partition.
+						[]interface{}{
+							// Partitioning exprs...
+							[]interface{}{"labelPath", "a"},
+						},
+						1,  // # of the partitioning exprs for PARTITION-BY.
+						"", // Additional tracking info.
+					},
+					Children: []*base.Op{&base.Op{
+						Kind:   "order-offset-limit",
+						Labels: base.Labels{"a", "b", "c"},
+						Params: []interface{}{
+							[]interface{}{
+								[]interface{}{"labelPath", "a"},
+								[]interface{}{"labelPath", "b"},
+								[]interface{}{"labelPath", "c"},
+							},
+							[]interface{}{
+								"asc",
+								"desc-nulls-first",
+								"asc",
+							},
+						},
+						Children: []*base.Op{&base.Op{
+							Kind:   "scan",
+							Labels: base.Labels{"a", "b", "c"},
+							Params: []interface{}{
+								"csvData",
+								`
+10,5,2
+10,4,4
+20,3,6
+10,2,5
+10,null,1
+10,4,3
+20,null,7
+`,
+							},
+						}},
+					}},
+				}},
+			}},
+		},
+		expectYields: []base.Vals{
+			base.Vals{[]byte("10"), []byte("1"), []byte("1"), []byte("1")},
+			base.Vals{[]byte("10"), []byte("2"), []byte("2"), []byte("2")},
+			base.Vals{[]byte("10"), []byte("3"), []byte("2"), []byte("4")},
+			base.Vals{[]byte("10"), []byte("4"), []byte("2"), []byte("4")},
+			base.Vals{[]byte("10"), []byte("5"), []byte("5"), []byte("5")},
+			base.Vals{[]byte("20"), []byte("7"), []byte("7"), []byte("7")},
+			base.Vals{[]byte("20"), []byte("6"), []byte("6"), []byte("6")},
+		},
+	},
+	{
+		about: "test csv-data window-partition->RANGE date window-frame [-2 days...0], project FIRST_VALUE, LAST_VALUE",
+		o: base.Op{
+			Kind:   "project",
+			Labels: base.Labels{"a", "c", "firstValue", "lastValue"},
+			Params: []interface{}{
+				[]interface{}{"labelPath", "a"},
+				[]interface{}{"labelPath", "c"},
+				[]interface{}{
+					"window-frame-step-value",
+					1,         // Slot for window frames.
+					0,         // Idx for window frame.
+					-1,        // Initial starting position is -1.
+					true,      // Step is ascending.
+					uint64(1), // Number of steps to take.
+					[]interface{}{"labelPath", "c"},
+				},
+				[]interface{}{
+					"window-frame-step-value",
+					1,         // Slot for window frames.
+					0,         // Idx for window frame.
+					1,         // Initial starting position is end.
+					false,     // Step is descending.
+					uint64(1), // Number of steps to take.
+					[]interface{}{"labelPath", "c"},
+				},
+			},
+			Children: []*base.Op{&base.Op{
+				Kind:   "window-frames",
+				Labels: base.Labels{"a", "b", "c"},
+				Params: []interface{}{
+					0, // Slot for window partition.
+					1, // Slot for window frames.
+					[]interface{}{ // Window frames cfg.
+						[]interface{}{
+							"range",
+							"num", float64(-2.0), // Preceding.
+							"num", float64(0.0), // Current row.
+							"no-others", // Exclude.
+							1,           // ValIdx, for RANGE type.
+							"asc",       // Direction, for RANGE type.
+							"day",       // Unit, for RANGE type of dates.
+						},
+					},
+				},
+				Children: []*base.Op{&base.Op{
+					Kind:   "window-partition",
+					Labels: base.Labels{"a", "b", "c"},
+					Params: []interface{}{
+						0, // Slot for window partition.
+						[]interface{}{
+							// Partitioning exprs...
+							[]interface{}{"labelPath", "a"},
+						},
+						1,  // # of the partitioning exprs for PARTITION-BY.
+						"", // Additional tracking info.
+					},
+					Children: []*base.Op{&base.Op{
+						Kind:   "order-offset-limit",
+						Labels: base.Labels{"a", "b", "c"},
+						Params: []interface{}{
+							[]interface{}{
+								[]interface{}{"labelPath", "a"},
+								[]interface{}{"labelPath", "b"},
+								[]interface{}{"labelPath", "c"},
+							},
+							[]interface{}{
+								"asc",
+								"asc",
+								"asc",
+							},
+						},
+						Children: []*base.Op{&base.Op{
+							Kind:   "scan",
+							Labels: base.Labels{"a", "b", "c"},
+							Params: []interface{}{
+								"csvData",
+								`
+10,"2019-02-03",4
+10,"2019-01-31",2
+10,null,0
+10,"2019-02-01T12:00:00Z",3
+10,"2019-01-30",1
+`,
+							},
+						}},
+					}},
+				}},
+			}},
+		},
+		expectYields: []base.Vals{
+			base.Vals{[]byte("10"), []byte("0"), []byte("0"), []byte("0")},
+			base.Vals{[]byte("10"), []byte("1"), []byte("1"), []byte("1")},
+			base.Vals{[]byte("10"), []byte("2"), []byte("1"), []byte("2")},
+			base.Vals{[]byte("10"), []byte("3"), []byte("2"), []byte("3")},
+			base.Vals{[]byte("10"), []byte("4"), []byte("3"), []byte("4")},
+		},
+	},
+	{
+		about: "test csv-data window-partition->RANGE window-frame [unbounded...unbounded] EXCLUDE GROUP, project FIRST_VALUE, LAST_VALUE",
+		o: base.Op{
+			Kind:   "project",
+			Labels: base.Labels{"a", "c", "denseRank", "firstValue", "lastValue"},
+			Params: []interface{}{
+				[]interface{}{"labelPath", "a"},
+				[]interface{}{"labelPath", "c"},
+				[]interface{}{"labelUint64", "myDenseRank"},
+				[]interface{}{
+					"window-frame-step-value",
+					1,         // Slot for window frames.
+					0,         // Idx for window frame.
+					-1,        // Initial starting position is -1.
+					true,      // Step is ascending.
+					uint64(1), // Number of steps to take.
+					[]interface{}{"labelPath", "c"},
+				},
+				[]interface{}{
+					"window-frame-step-value",
+					1,         // Slot for window frames.
+					0,         // Idx for window frame.
+					1,         // Initial starting position is end.
+					false,     // Step is descending.
+					uint64(1), // Number of steps to take.
+					[]interface{}{"labelPath", "c"},
+				},
+			},
+			Children: []*base.Op{&base.Op{
+				Kind:   "window-frames",
+				Labels: base.Labels{"a", "b", "c", "myDenseRank"},
+				Params: []interface{}{
+					0, // Slot for window partition.
+					1, // Slot for window frames.
+					[]interface{}{ // Window frames cfg.
+						[]interface{}{
+							"range",
+							"unbounded", 0, // Preceding.
+							"unbounded", 0, // Following.
+							"group", // Exclude.
+							1,       // ValIdx, for RANGE type.
+						},
+					},
+				},
+				Children: []*base.Op{&base.Op{
+					Kind:   "window-partition",
+					Labels: base.Labels{"a", "b", "c", "myDenseRank"},
+					Params: []interface{}{
+						0, // Slot for window partition.
+						[]interface{}{
+							// Partitioning exprs...
+							[]interface{}{"labelPath", "a"},
+							[]interface{}{"labelPath", "b"},
+						},
+						1,           // # of the partitioning exprs for PARTITION-BY.
+						"denseRank", // Additional tracking info.
+					},
+					Children: []*base.Op{&base.Op{
+						Kind:   "order-offset-limit",
+						Labels: base.Labels{"a", "b", "c"},
+						Params: []interface{}{
+							[]interface{}{
+								[]interface{}{"labelPath", "a"},
+								[]interface{}{"labelPath", "b"},
+								[]interface{}{"labelPath", "c"},
+							},
+							[]interface{}{
+								"asc",
+								"asc",
+								"asc",
+							},
+						},
+						Children: []*base.Op{&base.Op{
+							Kind:   "scan",
+							Labels: base.Labels{"a", "b", "c"},
+							Params: []interface{}{
+								"csvData",
+								`
+10,11,100
+10,12,101
+10,12,102
+10,12,103
+10,13,104
+20,20,200
+20,20,201
+20,21,202
+30,30,300
+30,31,301
+30,31,302
+`,
+							},
+						}},
+					}},
+				}},
+			}},
+		},
+		expectYields: []base.Vals{
+			base.Vals{[]byte("10"), []byte("100"), []byte("1"), []byte("101"), []byte("104")},
+			base.Vals{[]byte("10"), []byte("101"), []byte("2"), []byte("100"), []byte("104")},
+			base.Vals{[]byte("10"), []byte("102"), []byte("2"), []byte("100"), []byte("104")},
+			base.Vals{[]byte("10"), []byte("103"), []byte("2"), []byte("100"), []byte("104")},
+			base.Vals{[]byte("10"), []byte("104"), []byte("3"), []byte("100"), []byte("103")},
+			base.Vals{[]byte("20"), []byte("200"), []byte("1"), []byte("202"), []byte("202")},
+			base.Vals{[]byte("20"), []byte("201"), []byte("1"), []byte("202"), []byte("202")},
+			base.Vals{[]byte("20"), []byte("202"), []byte("2"), []byte("200"), []byte("201")},
+			base.Vals{[]byte("30"), []byte("300"), []byte("1"), []byte("301"), []byte("302")},
+			base.Vals{[]byte("30"), []byte("301"), []byte("2"), []byte("300"), []byte("300")},
+			base.Vals{[]byte("30"), []byte("302"), []byte("2"), []byte("300"), []byte("300")},
+		},
+	},
+	{
+		about: "test csv-data window-partition->RANGE window-frame [unbounded...unbounded] EXCLUDE TIES, project FIRST_VALUE, LAST_VALUE",
+		o: base.Op{
+			Kind:   "project",
+			Labels: base.Labels{"a", "c", "denseRank", "firstValue", "lastValue"},
+			Params: []interface{}{
+				[]interface{}{"labelPath", "a"},
+				[]interface{}{"labelPath", "c"},
+				[]interface{}{"labelUint64", "myDenseRank"},
+				[]interface{}{
+					"window-frame-step-value",
+					1,         // Slot for window frames.
+					0,         // Idx for window frame.
+					-1,        // Initial starting position is -1.
+					true,      // Step is ascending.
+					uint64(1), // Number of steps to take.
+					[]interface{}{"labelPath", "c"},
+				},
+				[]interface{}{
+					"window-frame-step-value",
+					1,         // Slot for window frames.
+					0,         // Idx for window frame.
+					1,         // Initial starting position is end.
+					false,     // Step is descending.
+					uint64(1), // Number of steps to take.
+					[]interface{}{"labelPath", "c"},
+				},
+			},
+			Children: []*base.Op{&base.Op{
+				Kind:   "window-frames",
+				Labels: base.Labels{"a", "b", "c", "myDenseRank"},
+				Params: []interface{}{
+					0, // Slot for window partition.
+					1, // Slot for window frames.
+					[]interface{}{ // Window frames cfg.
+						[]interface{}{
+							"range",
+							"unbounded", 0, // Preceding.
+							"unbounded", 0, // Following.
+							"ties", // Exclude.
+							1,      // ValIdx, for RANGE type.
+						},
+					},
+				},
+				Children: []*base.Op{&base.Op{
+					Kind:   "window-partition",
+					Labels: base.Labels{"a", "b", "c", "myDenseRank"},
+					Params: []interface{}{
+						0, // Slot for window partition.
+						[]interface{}{
+							// Partitioning exprs...
+							[]interface{}{"labelPath", "a"},
+							[]interface{}{"labelPath", "b"},
+						},
+						1,           // # of the partitioning exprs for PARTITION-BY.
+						"denseRank", // Additional tracking info.
+					},
+					Children: []*base.Op{&base.Op{
+						Kind:   "order-offset-limit",
+						Labels: base.Labels{"a", "b", "c"},
+						Params: []interface{}{
+							[]interface{}{
+								[]interface{}{"labelPath", "a"},
+								[]interface{}{"labelPath", "b"},
+								[]interface{}{"labelPath", "c"},
+							},
+							[]interface{}{
+								"asc",
+								"asc",
+								"asc",
+							},
+						},
+						Children: []*base.Op{&base.Op{
+							Kind:   "scan",
+							Labels: base.Labels{"a", "b", "c"},
+							Params: []interface{}{
+								"csvData",
+								`
+10,11,100
+10,12,101
+10,12,102
+10,12,103
+10,13,104
+20,20,200
+20,20,201
+20,21,202
+30,30,300
+30,31,301
+30,31,302
+`,
+							},
+						}},
+					}},
+				}},
+			}},
+		},
+		expectYields: []base.Vals{
+			base.Vals{[]byte("10"), []byte("100"), []byte("1"), []byte("100"), []byte("104")},
+			base.Vals{[]byte("10"), []byte("101"), []byte("2"), []byte("100"), []byte("104")},
+			base.Vals{[]byte("10"), []byte("102"), []byte("2"), []byte("100"), []byte("104")},
+			base.Vals{[]byte("10"), []byte("103"), []byte("2"), []byte("100"), []byte("104")},
+			base.Vals{[]byte("10"), []byte("104"), []byte("3"), []byte("100"), []byte("104")},
+			base.Vals{[]byte("20"), []byte("200"), []byte("1"), []byte("200"), []byte("202")},
+			base.Vals{[]byte("20"), []byte("201"), []byte("1"), []byte("201"), []byte("202")},
+			base.Vals{[]byte("20"), []byte("202"), []byte("2"), []byte("200"), []byte("202")},
+			base.Vals{[]byte("30"), []byte("300"), []byte("1"), []byte("300"), []byte("302")},
+			base.Vals{[]byte("30"), []byte("301"), []byte("2"), []byte("300"), []byte("301")},
+			base.Vals{[]byte("30"), []byte("302"), []byte("2"), []byte("300"), []byte("302")},
+		},
+	},
+	{
+		about: "test correlated subquery with correlation key",
+		o: base.Op{
+			Kind:   "project",
+			Labels: base.Labels{"emp", "colleagues"},
+			Params: []interface{}{
+				[]interface{}{"labelPath", "emp"},
+				[]interface{}{"subquery",
+					&base.Op{
+						Kind:   "project",
+						Labels: base.Labels{"emp2"},
+						Params: []interface{}{
+							[]interface{}{"labelPath", "emp2"},
+						},
+						Children: []*base.Op{&base.Op{
+							Kind:   "filter",
+							Labels: base.Labels{"emp2", "empDept2"},
+							Params: []interface{}{
+								"eq",
+								[]interface{}{"labelPath", "empDept2"},
+								[]interface{}{"outerLabelPath", "empDept"},
+							},
+							Children: []*base.Op{&base.Op{
+								Kind:   "scan",
+								Labels: base.Labels{"emp2", "empDept2"},
+								Params: []interface{}{
+									"csvData",
+									`
+"dan","dev"
+"doug","dev"
+"frank","finance"
+"mary","marketing"
+`,
+								},
+							}},
+						}},
+					},
+					[]interface{}{
+						[]interface{}{"labelPath", "empDept"},
+					},
+				},
+			},
+			Children: []*base.Op{&base.Op{
+				Kind:   "scan",
+				Labels: base.Labels{"emp", "empDept"},
+				Params: []interface{}{
+					"csvData",
+					`
+"dan","dev"
+"doug","dev"
+"frank","finance"
+"mary","marketing"
+`,
+				},
+			}},
+		},
+		expectYields: []base.Vals{
+			StringsToVals([]string{`"dan"`, `["dan","doug"]`}, nil),
+			StringsToVals([]string{`"doug"`, `["dan","doug"]`}, nil),
+			StringsToVals([]string{`"frank"`, `["frank"]`}, nil),
+			StringsToVals([]string{`"mary"`, `["mary"]`}, nil),
+		},
+	},
+	{
+		about: "test correlated subquery with correlation key and a cache max of 1",
+		o: base.Op{
+			Kind:   "project",
+			Labels: base.Labels{"emp", "colleagues"},
+			Params: []interface{}{
+				[]interface{}{"labelPath", "emp"},
+				[]interface{}{"subquery",
+					&base.Op{
+						Kind:   "project",
+						Labels: base.Labels{"emp2"},
+						Params: []interface{}{
+							[]interface{}{"labelPath", "emp2"},
+						},
+						Children: []*base.Op{&base.Op{
+							Kind:   "filter",
+							Labels: base.Labels{"emp2", "empDept2"},
+							Params: []interface{}{
+								"eq",
+								[]interface{}{"labelPath", "empDept2"},
+								[]interface{}{"outerLabelPath", "empDept"},
+							},
+							Children: []*base.Op{&base.Op{
+								Kind:   "scan",
+								Labels: base.Labels{"emp2", "empDept2"},
+								Params: []interface{}{
+									"csvData",
+									`
+"dan","dev"
+"doug","dev"
+"frank","finance"
+"mary","marketing"
+`,
+								},
+							}},
+						}},
+					},
+					[]interface{}{
+						[]interface{}{"labelPath", "empDept"},
+					},
+					1,
+				},
+			},
+			Children: []*base.Op{&base.Op{
+				Kind:   "scan",
+				Labels: base.Labels{"emp", "empDept"},
+				Params: []interface{}{
+					"csvData",
+					`
+"dan","dev"
+"doug","dev"
+"frank","finance"
+"mary","marketing"
+`,
+				},
+			}},
+		},
+		expectYields: []base.Vals{
+			StringsToVals([]string{`"dan"`, `["dan","doug"]`}, nil),
+			StringsToVals([]string{`"doug"`, `["dan","doug"]`}, nil),
+			StringsToVals([]string{`"frank"`, `["frank"]`}, nil),
+			StringsToVals([]string{`"mary"`, `["mary"]`}, nil),
+		},
+	},
+	{
+		about: "test correlated subquery with NULL correlation keys",
+		o: base.Op{
+			Kind:   "project",
+			Labels: base.Labels{"a", "sub"},
+			Params: []interface{}{
+				[]interface{}{"labelPath", "a"},
+				[]interface{}{"subquery",
+					&base.Op{
+						Kind:   "project",
+						Labels: base.Labels{"outerA"},
+						Params: []interface{}{
+							[]interface{}{"outerLabelPath", "a"},
+						},
+						Children: []*base.Op{&base.Op{
+							Kind:   "scan",
+							Labels: base.Labels{"x"},
+							Params: []interface{}{
+								"csvData",
+								`
+0
+`,
+							},
+						}},
+					},
+					[]interface{}{
+						[]interface{}{"labelPath", "a"},
+						[]interface{}{"labelPath", "b"},
+					},
+				},
+			},
+			Children: []*base.Op{&base.Op{
+				Kind:   "scan",
+				Labels: base.Labels{"a", "b"},
+				Params: []interface{}{
+					"csvData",
+					`
+1,null
+2,null
+1,null
+`,
+				},
+			}},
+		},
+		expectYields: []base.Vals{
+			StringsToVals([]string{`1`, `[1]`}, nil),
+			StringsToVals([]string{`2`, `[2]`}, nil),
+			StringsToVals([]string{`1`, `[1]`}, nil),
+		},
+	},
+	{
+		about: "test correlated subquery without correlation key",
+		o: base.Op{
+			Kind:   "project",
+			Labels: base.Labels{"dept", "emps"},
+			Params: []interface{}{
+				[]interface{}{"labelPath", "dept"},
+				[]interface{}{"subquery",
+					&base.Op{
+						Kind:   "project",
+						Labels: base.Labels{"emp"},
+						Params: []interface{}{
+							[]interface{}{"labelPath", "emp"},
+						},
+						Children: []*base.Op{&base.Op{
+							Kind:   "filter",
+							Labels: base.Labels{"emp", "empDept"},
+							Params: []interface{}{
+								"eq",
+								[]interface{}{"labelPath", "empDept"},
+								[]interface{}{"outerLabelPath", "dept"},
+							},
+							Children: []*base.Op{&base.Op{
+								Kind:   "scan",
+								Labels: base.Labels{"emp", "empDept"},
+								Params: []interface{}{
+									"csvData",
+									`
+"dan","dev"
+"doug","dev"
+"frank","finance"
+"mary","marketing"
+`,
+								},
+							}},
+						}},
+					},
+				},
+			},
+			Children: []*base.Op{&base.Op{
+				Kind:   "scan",
+				Labels: base.Labels{"dept", "city"},
+				Params: []interface{}{
+					"csvData",
+					`
+"dev","paris"
+"finance","london"
+"sales","san diego"
+"dev","paris"
+`,
+				},
+			}},
+		},
+		expectYields: []base.Vals{
+			StringsToVals([]string{`"dev"`, `["dan","doug"]`}, nil),
+			StringsToVals([]string{`"finance"`, `["frank"]`}, nil),
+			StringsToVals([]string{`"sales"`, `[]`}, nil),
+			StringsToVals([]string{`"dev"`, `["dan","doug"]`}, nil),
+		},
+	},
+	{
+		about: "test recursive-union of graph reachability",
 		o: base.Op{
-			Kind:   "project",
-			Labels: base.Labels{"a", "rowNumber", "firstValue"},
-			Params: []interface{}{
-				[]interface{}{"labelPath", "a"},
-				[]interface{}{
-					"window-partition-row-number",
-					1, // Slot for window frames.
-					0, // Idx for window frame.
+			Kind:   "recursive-union",
+			Labels: base.Labels{"node"},
+			Params: []interface{}{0, 10, false},
+			Children: []*base.Op{&base.Op{
+				Kind:   "scan",
+				Labels: base.Labels{"node"},
+				Params: []interface{}{
+					"csvData",
+					`
+"a"
+`,
 				},
-				[]interface{}{
-					"window-frame-step-value",
-					1,         // Slot for window frames.
-					0,         // Idx for window frame.
-					0,         // Initial starting position is current-row.
-					false,     // Step is descending.
-					uint64(1), // Number of steps to take.
-					[]interface{}{"labelPath", "b"},
+			}, &base.Op{
+				Kind:   "project",
+				Labels: base.Labels{"node"},
+				Params: []interface{}{
+					[]interface{}{"labelPath", "to"},
 				},
-			},
+				Children: []*base.Op{&base.Op{
+					Kind:   "joinHash-inner",
+					Labels: base.Labels{"node", "from", "to"},
+					Params: []interface{}{
+						[]interface{}{"labelPath", "node"},
+						[]interface{}{"labelPath", "from"},
+					},
+					Children: []*base.Op{&base.Op{
+						Kind:   "temp-yield",
+						Labels: base.Labels{"node"},
+						Params: []interface{}{0},
+					}, &base.Op{
+						Kind:   "scan",
+						Labels: base.Labels{"from", "to"},
+						Params: []interface{}{
+							"csvData",
+							`
+"a","b"
+"b","c"
+"c","d"
+"x","y"
+`,
+						},
+					}},
+				}},
+			}},
+		},
+		expectYields: []base.Vals{
+			StringsToVals([]string{`"a"`}, nil),
+			StringsToVals([]string{`"b"`}, nil),
+			StringsToVals([]string{`"c"`}, nil),
+			StringsToVals([]string{`"d"`}, nil),
+		},
+	},
+	{
+		about: "test recursive-union as a distinct UNION of a cycle",
+		o: base.Op{
+			Kind:   "recursive-union",
+			Labels: base.Labels{"node"},
+			Params: []interface{}{0, 10, true},
 			Children: []*base.Op{&base.Op{
-				Kind:   "window-frames",
-				Labels: base.Labels{"a", "b"},
+				Kind:   "scan",
+				Labels: base.Labels{"node"},
 				Params: []interface{}{
-					0, // Slot for window partition.
-					1, // Slot for window frames.
-					[]interface{}{ // Window frames cfg.
-						[]interface{}{
-							"rows",
-							"unbounded", 0, // Preceding.
-							"unbounded", 0, // Following.
-							"no-others", // Exclude.
-							0,           // ValIdx, unused.
-						},
-					},
+					"csvData",
+					`
+"a"
+`,
+				},
+			}, &base.Op{
+				Kind:   "project",
+				Labels: base.Labels{"node"},
+				Params: []interface{}{
+					[]interface{}{"labelPath", "to"},
 				},
 				Children: []*base.Op{&base.Op{
-					Kind:   "window-partition",
-					Labels: base.Labels{"a", "b"},
+					Kind:   "joinHash-inner",
+					Labels: base.Labels{"node", "from", "to"},
 					Params: []interface{}{
-						0, // Slot for window partition.
-						[]interface{}{
-							// Partitioning exprs...
-							[]interface{}{"labelPath", "a"},
-						},
-						1,  // # of the partitioning exprs for PARTITION-BY.
-						"", // Additional tracking info.
+						[]interface{}{"labelPath", "node"},
+						[]interface{}{"labelPath", "from"},
 					},
 					Children: []*base.Op{&base.Op{
-						Kind:   "order-offset-limit",
-						Labels: base.Labels{"a", "b"},
+						Kind:   "temp-yield",
+						Labels: base.Labels{"node"},
+						Params: []interface{}{0},
+					}, &base.Op{
+						Kind:   "scan",
+						Labels: base.Labels{"from", "to"},
 						Params: []interface{}{
-							[]interface{}{
-								[]interface{}{"labelPath", "a"},
-								[]interface{}{"labelPath", "b"},
-							},
-							[]interface{}{
-								"asc",
-								"asc",
-							},
+							"csvData",
+							`
+"a","b"
+"b","a"
+"b","c"
+`,
 						},
-						Children: []*base.Op{&base.Op{
-							Kind:   "scan",
-							Labels: base.Labels{"a", "b"},
-							Params: []interface{}{
-								"csvData",
-								`
-10,11
-10,12
-10,13
-20,20
-20,21
-30,30
+					}},
+				}},
+			}},
+		},
+		expectYields: []base.Vals{
+			StringsToVals([]string{`"a"`}, nil),
+			StringsToVals([]string{`"b"`}, nil),
+			StringsToVals([]string{`"c"`}, nil),
+		},
+	},
+	{
+		about: "test recursive-union as a distinct UNION of a diamond",
+		o: base.Op{
+			Kind:   "recursive-union",
+			Labels: base.Labels{"node"},
+			Params: []interface{}{0, 10, true},
+			Children: []*base.Op{&base.Op{
+				Kind:   "scan",
+				Labels: base.Labels{"node"},
+				Params: []interface{}{
+					"csvData",
+					`
+"a"
 `,
-							},
-						}},
+				},
+			}, &base.Op{
+				Kind:   "project",
+				Labels: base.Labels{"node"},
+				Params: []interface{}{
+					[]interface{}{"labelPath", "to"},
+				},
+				Children: []*base.Op{&base.Op{
+					Kind:   "joinHash-inner",
+					Labels: base.Labels{"node", "from", "to"},
+					Params: []interface{}{
+						[]interface{}{"labelPath", "node"},
+						[]interface{}{"labelPath", "from"},
+					},
+					Children: []*base.Op{&base.Op{
+						Kind:   "temp-yield",
+						Labels: base.Labels{"node"},
+						Params: []interface{}{0},
+					}, &base.Op{
+						Kind:   "scan",
+						Labels: base.Labels{"from", "to"},
+						Params: []interface{}{
+							"csvData",
+							`
+"a","b"
+"a","c"
+"b","d"
+"c","d"
+`,
+						},
 					}},
 				}},
 			}},
 		},
 		expectYields: []base.Vals{
-			base.Vals{[]byte("10"), []byte("1"), []byte(nil)},
-			base.Vals{[]byte("10"), []byte("2"), []byte("11")},
-			base.Vals{[]byte("10"), []byte("3"), []byte("12")},
-			base.Vals{[]byte("20"), []byte("1"), []byte(nil)},
-			base.Vals{[]byte("20"), []byte("2"), []byte("20")},
-			base.Vals{[]byte("30"), []byte("1"), []byte(nil)},
+			StringsToVals([]string{`"a"`}, nil),
+			StringsToVals([]string{`"b"`}, nil),
+			StringsToVals([]string{`"c"`}, nil),
+			StringsToVals([]string{`"d"`}, nil),
 		},
 	},
 	{
-		about: "test csv-data window-partition->project window-frame LAG(b, 2)",
+		about: "test recursive-union as a UNION ALL of a diamond",
 		o: base.Op{
-			Kind:   "project",
-			Labels: base.Labels{"a", "rowNumber", "firstValue"},
-			Params: []interface{}{
-				[]interface{}{"labelPath", "a"},
-				[]interface{}{
-					"window-partition-row-number",
-					1, // Slot for window frames.
-					0, // Idx for window frame.
+			Kind:   "recursive-union",
+			Labels: base.Labels{"node"},
+			Params: []interface{}{0, 10, false},
+			Children: []*base.Op{&base.Op{
+				Kind:   "scan",
+				Labels: base.Labels{"node"},
+				Params: []interface{}{
+					"csvData",
+					`
+"a"
+`,
+				},
+			}, &base.Op{
+				Kind:   "project",
+				Labels: base.Labels{"node"},
+				Params: []interface{}{
+					[]interface{}{"labelPath", "to"},
 				},
+				Children: []*base.Op{&base.Op{
+					Kind:   "joinHash-inner",
+					Labels: base.Labels{"node", "from", "to"},
+					Params: []interface{}{
+						[]interface{}{"labelPath", "node"},
+						[]interface{}{"labelPath", "from"},
+					},
+					Children: []*base.Op{&base.Op{
+						Kind:   "temp-yield",
+						Labels: base.Labels{"node"},
+						Params: []interface{}{0},
+					}, &base.Op{
+						Kind:   "scan",
+						Labels: base.Labels{"from", "to"},
+						Params: []interface{}{
+							"csvData",
+							`
+"a","b"
+"a","c"
+"b","d"
+"c","d"
+`,
+						},
+					}},
+				}},
+			}},
+		},
+		expectYields: []base.Vals{
+			StringsToVals([]string{`"a"`}, nil),
+			StringsToVals([]string{`"b"`}, nil),
+			StringsToVals([]string{`"c"`}, nil),
+			StringsToVals([]string{`"d"`}, nil),
+			StringsToVals([]string{`"d"`}, nil),
+		},
+	},
+	{
+		about: "test recursive-union with cycle detection on a cycle key",
+		o: base.Op{
+			Kind:   "recursive-union",
+			Labels: base.Labels{"node", "via"},
+			Params: []interface{}{0, 10, false,
 				[]interface{}{
-					"window-frame-step-value",
-					1,         // Slot for window frames.
-					0,         // Idx for window frame.
-					0,         // Initial starting position is current-row.
-					false,     // Step is descending.
-					uint64(2), // Number of steps to take.
-					[]interface{}{"labelPath", "b"},
+					[]interface{}{"labelPath", "node"},
 				},
 			},
 			Children: []*base.Op{&base.Op{
-				Kind:   "window-frames",
-				Labels: base.Labels{"a", "b"},
+				Kind:   "scan",
+				Labels: base.Labels{"node", "via"},
 				Params: []interface{}{
-					0, // Slot for window partition.
-					1, // Slot for window frames.
-					[]interface{}{ // Window frames cfg.
-						[]interface{}{
-							"rows",
-							"unbounded", 0, // Preceding.
-							"unbounded", 0, // Following.
-							"no-others", // Exclude.
-							0,           // ValIdx, unused.
-						},
+					"csvData",
+					`
+"a","-"
+`,
+				},
+			}, &base.Op{
+				Kind:   "project",
+				Labels: base.Labels{"node", "via"},
+				Params: []interface{}{
+					[]interface{}{"labelPath", "to"},
+					[]interface{}{"labelPath", "node"},
+				},
+				Children: []*base.Op{&base.Op{
+					Kind:   "joinHash-inner",
+					Labels: base.Labels{"node", "via", "from", "to"},
+					Params: []interface{}{
+						[]interface{}{"labelPath", "node"},
+						[]interface{}{"labelPath", "from"},
 					},
+					Children: []*base.Op{&base.Op{
+						Kind:   "temp-yield",
+						Labels: base.Labels{"node", "via"},
+						Params: []interface{}{0},
+					}, &base.Op{
+						Kind:   "scan",
+						Labels: base.Labels{"from", "to"},
+						Params: []interface{}{
+							"csvData",
+							`
+"a","b"
+"b","a"
+"b","c"
+`,
+						},
+					}},
+				}},
+			}},
+		},
+		expectYields: []base.Vals{
+			StringsToVals([]string{`"a"`, `"-"`}, nil),
+			StringsToVals([]string{`"b"`, `"a"`}, nil),
+			StringsToVals([]string{`"c"`, `"b"`}, nil),
+		},
+	},
+	{
+		about: "test recursive-union of cycle exceeding max iterations",
+		o: base.Op{
+			Kind:   "recursive-union",
+			Labels: base.Labels{"node"},
+			Params: []interface{}{0, 3, false},
+			Children: []*base.Op{&base.Op{
+				Kind:   "scan",
+				Labels: base.Labels{"node"},
+				Params: []interface{}{
+					"csvData",
+					`
+"a"
+`,
+				},
+			}, &base.Op{
+				Kind:   "project",
+				Labels: base.Labels{"node"},
+				Params: []interface{}{
+					[]interface{}{"labelPath", "to"},
 				},
 				Children: []*base.Op{&base.Op{
-					Kind:   "window-partition",
-					Labels: base.Labels{"a", "b"},
+					Kind:   "joinHash-inner",
+					Labels: base.Labels{"node", "from", "to"},
 					Params: []interface{}{
-						0, // Slot for window partition.
-						[]interface{}{
-							// Partitioning exprs...
-							[]interface{}{"labelPath", "a"},
-						},
-						1,  // # of the partitioning exprs for PARTITION-BY.
-						"", // Additional tracking info.
+						[]interface{}{"labelPath", "node"},
+						[]interface{}{"labelPath", "from"},
 					},
 					Children: []*base.Op{&base.Op{
-						Kind:   "order-offset-limit",
-						Labels: base.Labels{"a", "b"},
+						Kind:   "temp-yield",
+						Labels: base.Labels{"node"},
+						Params: []interface{}{0},
+					}, &base.Op{
+						Kind:   "scan",
+						Labels: base.Labels{"from", "to"},
 						Params: []interface{}{
-							[]interface{}{
-								[]interface{}{"labelPath", "a"},
-								[]interface{}{"labelPath", "b"},
-							},
-							[]interface{}{
-								"asc",
-								"asc",
-							},
-						},
-						Children: []*base.Op{&base.Op{
-							Kind:   "scan",
-							Labels: base.Labels{"a", "b"},
-							Params: []interface{}{
-								"csvData",
-								`
-10,11
-10,12
-10,13
-20,20
-20,21
-30,30
+							"csvData",
+							`
+"a","b"
+"b","a"
 `,
-							},
-						}},
+						},
 					}},
 				}},
 			}},
 		},
 		expectYields: []base.Vals{
-			base.Vals{[]byte("10"), []byte("1"), []byte(nil)},
-			base.Vals{[]byte("10"), []byte("2"), []byte(nil)},
-			base.Vals{[]byte("10"), []byte("3"), []byte("11")},
-			base.Vals{[]byte("20"), []byte("1"), []byte(nil)},
-			base.Vals{[]byte("20"), []byte("2"), []byte(nil)},
-			base.Vals{[]byte("30"), []byte("1"), []byte(nil)},
+			StringsToVals([]string{`"a"`}, nil),
+			StringsToVals([]string{`"b"`}, nil),
+			StringsToVals([]string{`"a"`}, nil),
+			StringsToVals([]string{`"b"`}, nil),
 		},
+		expectErr: "max iterations exceeded",
 	},
 	{
-		about: "test csv-data scan->order->window-partition->window-frame->project RANK, DENSE_RANK",
+		about: "test csv-data scan->let with a let referencing an earlier let",
 		o: base.Op{
-			Kind:   "project",
-			Labels: base.Labels{"a", "b", "rowNumber", "result-rank", "result-denseRank"},
+			Kind:   "filter",
+			Labels: base.Labels{"emp", "empDept", "dept", "isDev"},
 			Params: []interface{}{
-				[]interface{}{"labelPath", "a"},
-				[]interface{}{"labelPath", "b"},
-				[]interface{}{
-					"window-partition-row-number",
-					1, // Slot for window frames.
-					0, // Idx for window frame.
+				"eq",
+				[]interface{}{"labelPath", "isDev"},
+				[]interface{}{"json", `true`},
+			},
+			Children: []*base.Op{&base.Op{
+				Kind:   "let",
+				Labels: base.Labels{"emp", "empDept", "dept", "isDev"},
+				Params: []interface{}{
+					[]interface{}{"labelPath", "empDept"},
+					[]interface{}{"eq",
+						[]interface{}{"labelPath", "dept"},
+						[]interface{}{"json", `"dev"`},
+					},
 				},
-				[]interface{}{"labelUint64", "myRank"},
-				[]interface{}{"labelUint64", "myDenseRank"},
+				Children: []*base.Op{&base.Op{
+					Kind:   "scan",
+					Labels: base.Labels{"emp", "empDept"},
+					Params: []interface{}{
+						"csvData",
+						`
+"dan","dev"
+"doug","dev"
+"frank","finance"
+`,
+					},
+				}},
+			}},
+		},
+		expectYields: []base.Vals{
+			StringsToVals([]string{`"dan"`, `"dev"`, `"dev"`, `true`}, nil),
+			StringsToVals([]string{`"doug"`, `"dev"`, `"dev"`, `true`}, nil),
+		},
+	},
+	{
+		about: "test csv-data scan->group-by->letting",
+		o: base.Op{
+			Kind:   "filter",
+			Labels: base.Labels{"a", "count-a", "many"},
+			Params: []interface{}{
+				"eq",
+				[]interface{}{"labelPath", "many"},
+				[]interface{}{"json", `true`},
 			},
 			Children: []*base.Op{&base.Op{
-				Kind:   "window-frames",
-				Labels: base.Labels{"a", "b", "myRank", "myDenseRank"},
+				Kind:   "let",
+				Labels: base.Labels{"a", "count-a", "many"},
 				Params: []interface{}{
-					0, // Slot for window partition.
-					1, // Slot for window frames.
-					[]interface{}{ // Window frames cfg.
-						[]interface{}{
-							"rows",
-							"unbounded", 0, // Preceding.
-							"unbounded", 0, // Following.
-							"no-others", // Exclude.
-							0,           // ValIdx, unused.
-						},
+					[]interface{}{"gt",
+						[]interface{}{"labelPath", "count-a"},
+						[]interface{}{"json", `1`},
 					},
 				},
 				Children: []*base.Op{&base.Op{
-					Kind:   "window-partition",
-					Labels: base.Labels{"a", "b", "myRank", "myDenseRank"},
+					Kind:   "group",
+					Labels: base.Labels{"a", "count-a"},
 					Params: []interface{}{
-						0, // Slot for window partition.
 						[]interface{}{
-							// Partitioning exprs...
 							[]interface{}{"labelPath", "a"},
-							[]interface{}{"labelPath", "b"},
 						},
-						1,                // # of the partitioning exprs for PARTITION-BY.
-						"rank,denseRank", // Additional tracking info.
+						[]interface{}{
+							[]interface{}{"labelPath", "a"},
+						},
+						[]interface{}{
+							[]interface{}{"count"},
+						},
 					},
 					Children: []*base.Op{&base.Op{
-						Kind:   "order-offset-limit",
+						Kind:   "scan",
 						Labels: base.Labels{"a", "b"},
 						Params: []interface{}{
-							[]interface{}{
-								[]interface{}{"labelPath", "a"},
-								[]interface{}{"labelPath", "b"},
-							},
-							[]interface{}{
-								"asc",
-								"asc",
-							},
-						},
-						Children: []*base.Op{&base.Op{
-							Kind:   "scan",
-							Labels: base.Labels{"a", "b"},
-							Params: []interface{}{
-								"csvData",
-								`
+							"csvData",
+							`
 10,11
 10,12
-10,12
-10,13
-10,13
-10,14
 20,20
-20,21
-20,21
-30,30
-30,30
-30,31
 `,
-							},
-						}},
+						},
 					}},
 				}},
 			}},
 		},
 		expectYields: []base.Vals{
-			base.Vals{[]byte("10"), []byte("11"), []byte("1"), []byte("1"), []byte("1")},
-			base.Vals{[]byte("10"), []byte("12"), []byte("2"), []byte("2"), []byte("2")},
-			base.Vals{[]byte("10"), []byte("12"), []byte("3"), []byte("2"), []byte("2")},
-			base.Vals{[]byte("10"), []byte("13"), []byte("4"), []byte("4"), []byte("3")},
-			base.Vals{[]byte("10"), []byte("13"), []byte("5"), []byte("4"), []byte("3")},
-			base.Vals{[]byte("10"), []byte("14"), []byte("6"), []byte("6"), []byte("4")},
-			base.Vals{[]byte("20"), []byte("20"), []byte("1"), []byte("1"), []byte("1")},
-			base.Vals{[]byte("20"), []byte("21"), []byte("2"), []byte("2"), []byte("2")},
-			base.Vals{[]byte("20"), []byte("21"), []byte("3"), []byte("2"), []byte("2")},
-			base.Vals{[]byte("30"), []byte("30"), []byte("1"), []byte("1"), []byte("1")},
-			base.Vals{[]byte("30"), []byte("30"), []byte("2"), []byte("1"), []byte("1")},
-			base.Vals{[]byte("30"), []byte("31"), []byte("3"), []byte("3"), []byte("2")},
+			base.Vals{[]byte("10"), []byte("2"), []byte("true")},
 		},
 	},
 	{
-		about: "test csv-data window-partition->ROWS window-frame [-1...1], project FIRST_VALUE, LAST_VALUE",
+		about: "test csv-data scan->project with arithmetic",
 		o: base.Op{
 			Kind:   "project",
-			Labels: base.Labels{"a", "denseRank", "firstValue", "lastValue"},
+			Labels: base.Labels{"sum", "diff", "prod", "quot", "rem"},
 			Params: []interface{}{
-				[]interface{}{"labelPath", "a"},
-				[]interface{}{"labelUint64", "myDenseRank"},
-				[]interface{}{
-					"window-frame-step-value",
-					1,         // Slot for window frames.
-					0,         // Idx for window frame.
-					-1,        // Initial starting position is -1.
-					true,      // Step is ascending.
-					uint64(1), // Number of steps to take.
+				[]interface{}{"add",
+					[]interface{}{"labelPath", "a"},
 					[]interface{}{"labelPath", "b"},
 				},
-				[]interface{}{
-					"window-frame-step-value",
-					1,         // Slot for window frames.
-					0,         // Idx for window frame.
-					1,         // Initial starting position is end.
-					false,     // Step is descending.
-					uint64(1), // Number of steps to take.
+				[]interface{}{"sub",
+					[]interface{}{"labelPath", "a"},
+					[]interface{}{"json", `1.5`},
+				},
+				[]interface{}{"mult",
+					[]interface{}{"labelPath", "a"},
+					[]interface{}{"labelPath", "b"},
+				},
+				[]interface{}{"div",
+					[]interface{}{"labelPath", "a"},
+					[]interface{}{"labelPath", "b"},
+				},
+				[]interface{}{"mod",
+					[]interface{}{"labelPath", "a"},
 					[]interface{}{"labelPath", "b"},
 				},
 			},
 			Children: []*base.Op{&base.Op{
-				Kind:   "window-frames",
-				Labels: base.Labels{"a", "b", "myDenseRank"},
+				Kind:   "scan",
+				Labels: base.Labels{"a", "b"},
 				Params: []interface{}{
-					0, // Slot for window partition.
-					1, // Slot for window frames.
-					[]interface{}{ // Window frames cfg.
-						[]interface{}{
-							"rows",
-							"num", -1, // Preceding.
-							"num", 1, // Following.
-							"no-others", // Exclude.
-							0,           // ValIdx, unused with ROWS.
-						},
-					},
+					"csvData",
+					`
+10,4
+3,0
+"x",2
+`,
 				},
-				Children: []*base.Op{&base.Op{
-					Kind:   "window-partition",
-					Labels: base.Labels{"a", "b", "myDenseRank"},
-					Params: []interface{}{
-						0, // Slot for window partition.
-						[]interface{}{
-							// Partitioning exprs...
-							[]interface{}{"labelPath", "a"},
-							[]interface{}{"labelPath", "b"},
-						},
-						1,           // # of the partitioning exprs for PARTITION-BY.
-						"denseRank", // Additional tracking info.
+			}},
+		},
+		expectYields: []base.Vals{
+			StringsToVals([]string{`14`, `8.5`, `40`, `2.5`, `2`}, nil),
+			StringsToVals([]string{`3`, `1.5`, `0`, `null`, `null`}, nil),
+			StringsToVals([]string{`null`, `null`, `null`, `null`, `null`}, nil),
+		},
+	},
+	{
+		about: "test csv-data scan->project with nested arithmetic & string case",
+		o: base.Op{
+			Kind:   "project",
+			Labels: base.Labels{"x", "s"},
+			Params: []interface{}{
+				[]interface{}{"add",
+					[]interface{}{"add",
+						[]interface{}{"labelPath", "a"},
+						[]interface{}{"labelPath", "b"},
 					},
-					Children: []*base.Op{&base.Op{
-						Kind:   "order-offset-limit",
-						Labels: base.Labels{"a", "b"},
-						Params: []interface{}{
-							[]interface{}{
-								[]interface{}{"labelPath", "a"},
-								[]interface{}{"labelPath", "b"},
-							},
-							[]interface{}{
-								"asc",
-								"asc",
-							},
-						},
-						Children: []*base.Op{&base.Op{
-							Kind:   "scan",
-							Labels: base.Labels{"a", "b"},
-							Params: []interface{}{
-								"csvData",
-								`
-10,11
-10,12
-10,12
-10,12
-10,13
-20,20
-20,20
-20,21
-30,30
-30,31
-30,31
+					[]interface{}{"mult",
+						[]interface{}{"labelPath", "a"},
+						[]interface{}{"json", `2`},
+					},
+				},
+				[]interface{}{"upper",
+					[]interface{}{"lower",
+						[]interface{}{"labelPath", "c"},
+					},
+				},
+			},
+			Children: []*base.Op{&base.Op{
+				Kind:   "scan",
+				Labels: base.Labels{"a", "b", "c"},
+				Params: []interface{}{
+					"csvData",
+					`
+10,4,"Ab"
 `,
-							},
-						}},
-					}},
-				}},
+				},
 			}},
 		},
 		expectYields: []base.Vals{
-			base.Vals{[]byte("10"), []byte("1"), []byte("11"), []byte("12")},
-			base.Vals{[]byte("10"), []byte("2"), []byte("11"), []byte("12")},
-			base.Vals{[]byte("10"), []byte("2"), []byte("12"), []byte("12")},
-			base.Vals{[]byte("10"), []byte("2"), []byte("12"), []byte("13")},
-			base.Vals{[]byte("10"), []byte("3"), []byte("12"), []byte("13")},
-			base.Vals{[]byte("20"), []byte("1"), []byte("20"), []byte("20")},
-			base.Vals{[]byte("20"), []byte("1"), []byte("20"), []byte("21")},
-			base.Vals{[]byte("20"), []byte("2"), []byte("20"), []byte("21")},
-			base.Vals{[]byte("30"), []byte("1"), []byte("30"), []byte("31")},
-			base.Vals{[]byte("30"), []byte("2"), []byte("30"), []byte("31")},
-			base.Vals{[]byte("30"), []byte("2"), []byte("31"), []byte("31")},
+			StringsToVals([]string{`34`, `"AB"`}, nil),
 		},
 	},
 	{
-		about: "test csv-data window-partition->GROUPS window-frame [-1...1], project FIRST_VALUE, LAST_VALUE",
+		about: "test csv-data scan->filter not->project upper & lower",
 		o: base.Op{
 			Kind:   "project",
-			Labels: base.Labels{"a", "c", "denseRank", "firstValue", "lastValue"},
+			Labels: base.Labels{"upper", "lower"},
 			Params: []interface{}{
-				[]interface{}{"labelPath", "a"},
-				[]interface{}{"labelPath", "c"},
-				[]interface{}{"labelUint64", "myDenseRank"},
-				[]interface{}{
-					"window-frame-step-value",
-					1,         // Slot for window frames.
-					0,         // Idx for window frame.
-					-1,        // Initial starting position is -1.
-					true,      // Step is ascending.
-					uint64(1), // Number of steps to take.
-					[]interface{}{"labelPath", "c"},
+				[]interface{}{"upper",
+					[]interface{}{"labelPath", "a"},
 				},
-				[]interface{}{
-					"window-frame-step-value",
-					1,         // Slot for window frames.
-					0,         // Idx for window frame.
-					1,         // Initial starting position is end.
-					false,     // Step is descending.
-					uint64(1), // Number of steps to take.
-					[]interface{}{"labelPath", "c"},
+				[]interface{}{"lower",
+					[]interface{}{"labelPath", "a"},
 				},
 			},
 			Children: []*base.Op{&base.Op{
-				Kind:   "window-frames",
-				Labels: base.Labels{"a", "b", "c", "myDenseRank"},
+				Kind:   "filter",
+				Labels: base.Labels{"a", "b"},
 				Params: []interface{}{
-					0, // Slot for window partition.
-					1, // Slot for window frames.
-					[]interface{}{ // Window frames cfg.
-						[]interface{}{
-							"groups",
-							"num", -1, // Preceding.
-							"num", 1, // Following.
-							"no-others", // Exclude.
-							3,           // ValIdx to the denseRank val.
-						},
-					},
+					"not",
+					[]interface{}{"labelPath", "b"},
 				},
 				Children: []*base.Op{&base.Op{
-					Kind:   "window-partition",
-					Labels: base.Labels{"a", "b", "c", "myDenseRank"},
+					Kind:   "scan",
+					Labels: base.Labels{"a", "b"},
 					Params: []interface{}{
-						0, // Slot for window partition.
-						[]interface{}{
-							// Partitioning exprs...
-							[]interface{}{"labelPath", "a"},
-							[]interface{}{"labelPath", "b"},
-						},
-						1,           // # of the partitioning exprs for PARTITION-BY.
-						"denseRank", // Additional tracking info.
-					},
-					Children: []*base.Op{&base.Op{
-						Kind:   "order-offset-limit",
-						Labels: base.Labels{"a", "b", "c"},
-						Params: []interface{}{
-							[]interface{}{
-								[]interface{}{"labelPath", "a"},
-								[]interface{}{"labelPath", "b"},
-								[]interface{}{"labelPath", "c"},
-							},
-							[]interface{}{
-								"asc",
-								"asc",
-								"asc",
-							},
-						},
-						Children: []*base.Op{&base.Op{
-							Kind:   "scan",
-							Labels: base.Labels{"a", "b", "c"},
-							Params: []interface{}{
-								"csvData",
-								`
-10,11,100
-10,12,101
-10,12,102
-10,12,103
-10,13,104
-20,20,200
-20,20,201
-20,21,202
-30,30,300
-30,31,301
-30,31,302
+						"csvData",
+						`
+"Hello\nWorld",false
+"skipped",true
+10,0
+"Dev",""
+"skipped",null
 `,
-							},
-						}},
-					}},
+					},
 				}},
 			}},
 		},
 		expectYields: []base.Vals{
-			base.Vals{[]byte("10"), []byte("100"), []byte("1"), []byte("100"), []byte("103")},
-			base.Vals{[]byte("10"), []byte("101"), []byte("2"), []byte("100"), []byte("104")},
-			base.Vals{[]byte("10"), []byte("102"), []byte("2"), []byte("100"), []byte("104")},
-			base.Vals{[]byte("10"), []byte("103"), []byte("2"), []byte("100"), []byte("104")},
-			base.Vals{[]byte("10"), []byte("104"), []byte("3"), []byte("101"), []byte("104")},
-			base.Vals{[]byte("20"), []byte("200"), []byte("1"), []byte("200"), []byte("202")},
-			base.Vals{[]byte("20"), []byte("201"), []byte("1"), []byte("200"), []byte("202")},
-			base.Vals{[]byte("20"), []byte("202"), []byte("2"), []byte("200"), []byte("202")},
-			base.Vals{[]byte("30"), []byte("300"), []byte("1"), []byte("300"), []byte("302")},
-			base.Vals{[]byte("30"), []byte("301"), []byte("2"), []byte("300"), []byte("302")},
-			base.Vals{[]byte("30"), []byte("302"), []byte("2"), []byte("300"), []byte("302")},
+			StringsToVals([]string{`"HELLO\nWORLD"`, `"hello\nworld"`}, nil),
+			StringsToVals([]string{`null`, `null`}, nil),
+			StringsToVals([]string{`"DEV"`, `"dev"`}, nil),
 		},
 	},
 	{
-		about: "test csv-data window-partition->RANGE window-frame [-1...1], project FIRST_VALUE, LAST_VALUE",
+		about: "test csv-data scan->project three-valued and, or & not",
 		o: base.Op{
 			Kind:   "project",
-			Labels: base.Labels{"a", "c", "denseRank", "firstValue", "lastValue"},
+			Labels: base.Labels{"missingAndA", "bOrA", "notA"},
 			Params: []interface{}{
-				[]interface{}{"labelPath", "a"},
-				[]interface{}{"labelPath", "c"},
-				[]interface{}{"labelUint64", "myDenseRank"},
-				[]interface{}{
-					"window-frame-step-value",
-					1,         // Slot for window frames.
-					0,         // Idx for window frame.
-					-1,        // Initial starting position is -1.
-					true,      // Step is ascending.
-					uint64(1), // Number of steps to take.
-					[]interface{}{"labelPath", "c"},
+				[]interface{}{"and",
+					[]interface{}{"labelPath", "nofield"},
+					[]interface{}{"labelPath", "a"},
 				},
-				[]interface{}{
-					"window-frame-step-value",
-					1,         // Slot for window frames.
-					0,         // Idx for window frame.
-					1,         // Initial starting position is end.
-					false,     // Step is descending.
-					uint64(1), // Number of steps to take.
-					[]interface{}{"labelPath", "c"},
+				[]interface{}{"or",
+					[]interface{}{"labelPath", "b"},
+					[]interface{}{"labelPath", "a"},
+				},
+				[]interface{}{"not",
+					[]interface{}{"labelPath", "a"},
 				},
 			},
 			Children: []*base.Op{&base.Op{
-				Kind:   "window-frames",
-				Labels: base.Labels{"a", "b", "c", "myDenseRank"},
+				Kind:   "scan",
+				Labels: base.Labels{"a", "b"},
 				Params: []interface{}{
-					0, // Slot for window partition.
-					1, // Slot for window frames.
-					[]interface{}{ // Window frames cfg.
-						[]interface{}{
-							"range",
-							"num", float64(-1.0), // Preceding.
-							"num", float64(1.0), // Following.
-							"no-others", // Exclude.
-							1,           // ValIdx, for RANGE type.
-						},
+					"csvData",
+					`
+false,null
+true,null
+"[]",[ ]
+{ },[0]
+"",null
+`,
+				},
+			}},
+		},
+		expectYields: []base.Vals{
+			StringsToVals([]string{`false`, `null`, `true`}, nil),
+			StringsToVals([]string{``, `true`, `false`}, nil),
+			StringsToVals([]string{``, `true`, `false`}, nil),
+			StringsToVals([]string{`false`, `true`, `true`}, nil),
+			StringsToVals([]string{`false`, `null`, `true`}, nil),
+		},
+	},
+	{
+		about: "test csv-data scan->filter not (nofield=1 and a=zzz)",
+		o: base.Op{
+			Kind:   "filter",
+			Labels: base.Labels{"a"},
+			Params: []interface{}{
+				"not",
+				[]interface{}{"and",
+					[]interface{}{"eq",
+						[]interface{}{"labelPath", "nofield"},
+						[]interface{}{"json", `1`},
+					},
+					[]interface{}{"eq",
+						[]interface{}{"labelPath", "a"},
+						[]interface{}{"json", `"zzz"`},
 					},
 				},
-				Children: []*base.Op{&base.Op{
-					Kind:   "window-partition",
-					Labels: base.Labels{"a", "b", "c", "myDenseRank"},
-					Params: []interface{}{
-						0, // Slot for window partition.
-						[]interface{}{
-							// Partitioning exprs...
-							[]interface{}{"labelPath", "a"},
-							[]interface{}{"labelPath", "b"},
-						},
-						1,           // # of the partitioning exprs for PARTITION-BY.
-						"denseRank", // Additional tracking info.
+			},
+			Children: []*base.Op{&base.Op{
+				Kind:   "scan",
+				Labels: base.Labels{"a"},
+				Params: []interface{}{
+					"csvData",
+					`
+"abc"
+"zzz"
+`,
+				},
+			}},
+		},
+		expectYields: []base.Vals{
+			StringsToVals([]string{`"abc"`}, nil),
+		},
+	},
+	{
+		about: "test jsons-data scan->project is-missing, is-null, is-valued, concat & element",
+		o: base.Op{
+			Kind:   "project",
+			Labels: base.Labels{"isMissing", "isNotNull", "isValued", "concat", "element", "field"},
+			Params: []interface{}{
+				[]interface{}{"isMissing",
+					[]interface{}{"labelPath", "a", "x"},
+				},
+				[]interface{}{"isNotNull",
+					[]interface{}{"labelPath", "a", "x"},
+				},
+				[]interface{}{"isValued",
+					[]interface{}{"labelPath", "a", "x"},
+				},
+				[]interface{}{"concat",
+					[]interface{}{"concat",
+						[]interface{}{"labelPath", "a", "s"},
+						[]interface{}{"json", `"-"`},
 					},
-					Children: []*base.Op{&base.Op{
-						Kind:   "order-offset-limit",
-						Labels: base.Labels{"a", "b", "c"},
-						Params: []interface{}{
-							[]interface{}{
-								[]interface{}{"labelPath", "a"},
-								[]interface{}{"labelPath", "b"},
-								[]interface{}{"labelPath", "c"},
-							},
-							[]interface{}{
-								"asc",
-								"asc",
-								"asc",
-							},
-						},
-						Children: []*base.Op{&base.Op{
-							Kind:   "scan",
-							Labels: base.Labels{"a", "b", "c"},
-							Params: []interface{}{
-								"csvData",
-								`
-10,11,100
-10,12,101
-10,12,102
-10,12,103
-10,13,104
-20,20,200
-20,20,201
-20,21,202
-30,30,300
-30,31,301
-30,31,302
+					[]interface{}{"labelPath", "a", "x"},
+				},
+				[]interface{}{"element",
+					[]interface{}{"labelPath", "a", "arr"},
+					[]interface{}{"json", `-1`},
+				},
+				[]interface{}{"element",
+					[]interface{}{"labelPath", "a"},
+					[]interface{}{"json", `"s"`},
+				},
+			},
+			Children: []*base.Op{&base.Op{
+				Kind:   "scan",
+				Labels: base.Labels{"a"},
+				Params: []interface{}{
+					"jsonsData",
+					`
+{"s":"a\"b","x":"c","arr":[1,"two"]}
+{"s":"d","x":null,"arr":[]}
+{"s":"e","arr":{"z":1}}
 `,
-							},
-						}},
-					}},
-				}},
+				},
 			}},
 		},
 		expectYields: []base.Vals{
-			base.Vals{[]byte("10"), []byte("100"), []byte("1"), []byte("100"), []byte("103")},
-			base.Vals{[]byte("10"), []byte("101"), []byte("2"), []byte("100"), []byte("104")},
-			base.Vals{[]byte("10"), []byte("102"), []byte("2"), []byte("100"), []byte("104")},
-			base.Vals{[]byte("10"), []byte("103"), []byte("2"), []byte("100"), []byte("104")},
-			base.Vals{[]byte("10"), []byte("104"), []byte("3"), []byte("101"), []byte("104")},
-			base.Vals{[]byte("20"), []byte("200"), []byte("1"), []byte("200"), []byte("202")},
-			base.Vals{[]byte("20"), []byte("201"), []byte("1"), []byte("200"), []byte("202")},
-			base.Vals{[]byte("20"), []byte("202"), []byte("2"), []byte("200"), []byte("202")},
-			base.Vals{[]byte("30"), []byte("300"), []byte("1"), []byte("300"), []byte("302")},
-			base.Vals{[]byte("30"), []byte("301"), []byte("2"), []byte("300"), []byte("302")},
-			base.Vals{[]byte("30"), []byte("302"), []byte("2"), []byte("300"), []byte("302")},
+			StringsToVals([]string{`false`, `true`, `true`, `"a\"b-c"`, `"two"`, `"a\"b"`}, nil),
+			StringsToVals([]string{`false`, `false`, `false`, `null`, ``, `"d"`}, nil),
+			StringsToVals([]string{`true`, ``, `false`, ``, ``, `"e"`}, nil),
 		},
 	},
 	{
-		about: "test csv-data window-partition->RANGE window-frame [unbounded...unbounded] EXCLUDE GROUP, project FIRST_VALUE, LAST_VALUE",
+		about: "test csv-data scan->union-all->distinct->order-by of ^id's, like a glue UnionScan",
 		o: base.Op{
-			Kind:   "project",
-			Labels: base.Labels{"a", "c", "denseRank", "firstValue", "lastValue"},
+			Kind:   "order-offset-limit",
+			Labels: base.Labels{"^id"},
 			Params: []interface{}{
-				[]interface{}{"labelPath", "a"},
-				[]interface{}{"labelPath", "c"},
-				[]interface{}{"labelUint64", "myDenseRank"},
 				[]interface{}{
-					"window-frame-step-value",
-					1,         // Slot for window frames.
-					0,         // Idx for window frame.
-					-1,        // Initial starting position is -1.
-					true,      // Step is ascending.
-					uint64(1), // Number of steps to take.
-					[]interface{}{"labelPath", "c"},
+					[]interface{}{"labelPath", "^id"},
 				},
 				[]interface{}{
-					"window-frame-step-value",
-					1,         // Slot for window frames.
-					0,         // Idx for window frame.
-					1,         // Initial starting position is end.
-					false,     // Step is descending.
-					uint64(1), // Number of steps to take.
-					[]interface{}{"labelPath", "c"},
+					"asc",
 				},
 			},
 			Children: []*base.Op{&base.Op{
-				Kind:   "window-frames",
-				Labels: base.Labels{"a", "b", "c", "myDenseRank"},
+				Kind:   "distinct",
+				Labels: base.Labels{"^id"},
 				Params: []interface{}{
-					0, // Slot for window partition.
-					1, // Slot for window frames.
-					[]interface{}{ // Window frames cfg.
-						[]interface{}{
-							"range",
-							"unbounded", 0, // Preceding.
-							"unbounded", 0, // Following.
-							"group", // Exclude.
-							1,       // ValIdx, for RANGE type.
-						},
+					[]interface{}{
+						[]interface{}{"labelPath", "^id"},
 					},
 				},
 				Children: []*base.Op{&base.Op{
-					Kind:   "window-partition",
-					Labels: base.Labels{"a", "b", "c", "myDenseRank"},
-					Params: []interface{}{
-						0, // Slot for window partition.
-						[]interface{}{
-							// Partitioning exprs...
-							[]interface{}{"labelPath", "a"},
-							[]interface{}{"labelPath", "b"},
-						},
-						1,           // # of the partitioning exprs for PARTITION-BY.
-						"denseRank", // Additional tracking info.
-					},
+					Kind:   "union-all",
+					Labels: base.Labels{"^id"},
 					Children: []*base.Op{&base.Op{
-						Kind:   "order-offset-limit",
-						Labels: base.Labels{"a", "b", "c"},
+						Kind:   "scan",
+						Labels: base.Labels{"^id"},
 						Params: []interface{}{
-							[]interface{}{
-								[]interface{}{"labelPath", "a"},
-								[]interface{}{"labelPath", "b"},
-								[]interface{}{"labelPath", "c"},
-							},
-							[]interface{}{
-								"asc",
-								"asc",
-								"asc",
-							},
+							"csvData",
+							`
+"k1"
+"k2"
+`,
 						},
-						Children: []*base.Op{&base.Op{
-							Kind:   "scan",
-							Labels: base.Labels{"a", "b", "c"},
-							Params: []interface{}{
-								"csvData",
-								`
-10,11,100
-10,12,101
-10,12,102
-10,12,103
-10,13,104
-20,20,200
-20,20,201
-20,21,202
-30,30,300
-30,31,301
-30,31,302
+					}, &base.Op{
+						Kind:   "scan",
+						Labels: base.Labels{"^id"},
+						Params: []interface{}{
+							"csvData",
+							`
+"k2"
+"k3"
 `,
-							},
-						}},
+						},
 					}},
 				}},
 			}},
 		},
 		expectYields: []base.Vals{
-			base.Vals{[]byte("10"), []byte("100"), []byte("1"), []byte("101"), []byte("104")},
-			base.Vals{[]byte("10"), []byte("101"), []byte("2"), []byte("100"), []byte("104")},
-			base.Vals{[]byte("10"), []byte("102"), []byte("2"), []byte("100"), []byte("104")},
-			base.Vals{[]byte("10"), []byte("103"), []byte("2"), []byte("100"), []byte("104")},
-			base.Vals{[]byte("10"), []byte("104"), []byte("3"), []byte("100"), []byte("103")},
-			base.Vals{[]byte("20"), []byte("200"), []byte("1"), []byte("202"), []byte("202")},
-			base.Vals{[]byte("20"), []byte("201"), []byte("1"), []byte("202"), []byte("202")},
-			base.Vals{[]byte("20"), []byte("202"), []byte("2"), []byte("200"), []byte("201")},
-			base.Vals{[]byte("30"), []byte("300"), []byte("1"), []byte("301"), []byte("302")},
-			base.Vals{[]byte("30"), []byte("301"), []byte("2"), []byte("300"), []byte("300")},
-			base.Vals{[]byte("30"), []byte("302"), []byte("2"), []byte("300"), []byte("300")},
+			StringsToVals([]string{`"k1"`}, nil),
+			StringsToVals([]string{`"k2"`}, nil),
+			StringsToVals([]string{`"k3"`}, nil),
 		},
 	},
 	{
-		about: "test csv-data window-partition->RANGE window-frame [unbounded...unbounded] EXCLUDE TIES, project FIRST_VALUE, LAST_VALUE",
+		// The intersection is of the keys only, as the scans do not
+		// track any setBit()'s, unlike N1QL's IntersectScan.
+		about: "test csv-data scan->intersect-distinct chain of ^id's, like a glue IntersectScan",
+		o: base.Op{
+			Kind:   "intersect-distinct",
+			Labels: base.Labels{"^id"},
+			Children: []*base.Op{&base.Op{
+				Kind:   "intersect-distinct",
+				Labels: base.Labels{"^id"},
+				Children: []*base.Op{&base.Op{
+					Kind:   "scan",
+					Labels: base.Labels{"^id"},
+					Params: []interface{}{
+						"csvData",
+						`
+"k1"
+"k2"
+"k3"
+"k3"
+`,
+					},
+				}, &base.Op{
+					Kind:   "scan",
+					Labels: base.Labels{"^id"},
+					Params: []interface{}{
+						"csvData",
+						`
+"k3"
+"k2"
+"k4"
+`,
+					},
+				}},
+			}, &base.Op{
+				Kind:   "scan",
+				Labels: base.Labels{"^id"},
+				Params: []interface{}{
+					"csvData",
+					`
+"k3"
+"k5"
+`,
+				},
+			}},
+		},
+		expectYields: []base.Vals{
+			StringsToVals([]string{`"k3"`}, nil),
+		},
+	},
+	{
+		about: "test jsons-data scan->project setPath & unsetPath",
 		o: base.Op{
 			Kind:   "project",
-			Labels: base.Labels{"a", "c", "denseRank", "firstValue", "lastValue"},
+			Labels: base.Labels{"s", "u", "n", "m"},
 			Params: []interface{}{
-				[]interface{}{"labelPath", "a"},
-				[]interface{}{"labelPath", "c"},
-				[]interface{}{"labelUint64", "myDenseRank"},
-				[]interface{}{
-					"window-frame-step-value",
-					1,         // Slot for window frames.
-					0,         // Idx for window frame.
-					-1,        // Initial starting position is -1.
-					true,      // Step is ascending.
-					uint64(1), // Number of steps to take.
-					[]interface{}{"labelPath", "c"},
+				[]interface{}{"setPath",
+					[]interface{}{"setPath",
+						[]interface{}{"labelPath", "."},
+						[]interface{}{"json", `"z"`},
+						"d", "y",
+					},
+					[]interface{}{"json", "3"},
+					"e",
 				},
-				[]interface{}{
-					"window-frame-step-value",
-					1,         // Slot for window frames.
-					0,         // Idx for window frame.
-					1,         // Initial starting position is end.
-					false,     // Step is descending.
-					uint64(1), // Number of steps to take.
-					[]interface{}{"labelPath", "c"},
+				[]interface{}{"unsetPath",
+					[]interface{}{"labelPath", "."},
+					"a",
+				},
+				[]interface{}{"setPath",
+					[]interface{}{"labelPath", "."},
+					[]interface{}{"json", "0"},
+					"c", "x",
+				},
+				[]interface{}{"setPath",
+					[]interface{}{"labelPath", "."},
+					[]interface{}{"labelPath", ".", "zzz"},
+					"b",
 				},
 			},
 			Children: []*base.Op{&base.Op{
-				Kind:   "window-frames",
-				Labels: base.Labels{"a", "b", "c", "myDenseRank"},
+				Kind:   "scan",
+				Labels: base.Labels{"."},
 				Params: []interface{}{
-					0, // Slot for window partition.
-					1, // Slot for window frames.
-					[]interface{}{ // Window frames cfg.
-						[]interface{}{
-							"range",
-							"unbounded", 0, // Preceding.
-							"unbounded", 0, // Following.
-							"ties", // Exclude.
-							1,      // ValIdx, for RANGE type.
-						},
-					},
-				},
-				Children: []*base.Op{&base.Op{
-					Kind:   "window-partition",
-					Labels: base.Labels{"a", "b", "c", "myDenseRank"},
-					Params: []interface{}{
-						0, // Slot for window partition.
-						[]interface{}{
-							// Partitioning exprs...
-							[]interface{}{"labelPath", "a"},
-							[]interface{}{"labelPath", "b"},
-						},
-						1,           // # of the partitioning exprs for PARTITION-BY.
-						"denseRank", // Additional tracking info.
-					},
-					Children: []*base.Op{&base.Op{
-						Kind:   "order-offset-limit",
-						Labels: base.Labels{"a", "b", "c"},
-						Params: []interface{}{
-							[]interface{}{
-								[]interface{}{"labelPath", "a"},
-								[]interface{}{"labelPath", "b"},
-								[]interface{}{"labelPath", "c"},
-							},
-							[]interface{}{
-								"asc",
-								"asc",
-								"asc",
-							},
-						},
-						Children: []*base.Op{&base.Op{
-							Kind:   "scan",
-							Labels: base.Labels{"a", "b", "c"},
-							Params: []interface{}{
-								"csvData",
-								`
-10,11,100
-10,12,101
-10,12,102
-10,12,103
-10,13,104
-20,20,200
-20,20,201
-20,21,202
-30,30,300
-30,31,301
-30,31,302
+					"jsonsData",
+					`
+{"a":1,"b":10,"c":[1,2],"d":{"x":"a","y":"b"}}
+{"a":2,"b":20,"c":[2,3],"d":{"x":"a","y":"B"}}
 `,
-							},
-						}},
-					}},
-				}},
+				},
 			}},
 		},
 		expectYields: []base.Vals{
-			base.Vals{[]byte("10"), []byte("100"), []byte("1"), []byte("100"), []byte("104")},
-			base.Vals{[]byte("10"), []byte("101"), []byte("2"), []byte("100"), []byte("104")},
-			base.Vals{[]byte("10"), []byte("102"), []byte("2"), []byte("100"), []byte("104")},
-			base.Vals{[]byte("10"), []byte("103"), []byte("2"), []byte("100"), []byte("104")},
-			base.Vals{[]byte("10"), []byte("104"), []byte("3"), []byte("100"), []byte("104")},
-			base.Vals{[]byte("20"), []byte("200"), []byte("1"), []byte("200"), []byte("202")},
-			base.Vals{[]byte("20"), []byte("201"), []byte("1"), []byte("201"), []byte("202")},
-			base.Vals{[]byte("20"), []byte("202"), []byte("2"), []byte("200"), []byte("202")},
-			base.Vals{[]byte("30"), []byte("300"), []byte("1"), []byte("300"), []byte("302")},
-			base.Vals{[]byte("30"), []byte("301"), []byte("2"), []byte("300"), []byte("301")},
-			base.Vals{[]byte("30"), []byte("302"), []byte("2"), []byte("300"), []byte("302")},
+			StringsToVals([]string{
+				`{"a":1,"b":10,"c":[1,2],"d":{"x":"a","y":"z"},"e":3}`,
+				`{"b":10,"c":[1,2],"d":{"x":"a","y":"b"}}`,
+				`{"a":1,"b":10,"c":[1,2],"d":{"x":"a","y":"b"}}`,
+				`{"a":1,"c":[1,2],"d":{"x":"a","y":"b"}}`,
+			}, nil),
+			StringsToVals([]string{
+				`{"a":2,"b":20,"c":[2,3],"d":{"x":"a","y":"z"},"e":3}`,
+				`{"b":20,"c":[2,3],"d":{"x":"a","y":"B"}}`,
+				`{"a":2,"b":20,"c":[2,3],"d":{"x":"a","y":"B"}}`,
+				`{"a":2,"c":[2,3],"d":{"x":"a","y":"B"}}`,
+			}, nil),
 		},
 	},
 }
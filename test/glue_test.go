@@ -14,18 +14,24 @@ package test
 import (
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"os"
+	"sort"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/couchbase/query/algebra"
 	"github.com/couchbase/query/auth"
 	"github.com/couchbase/query/datastore"
 	"github.com/couchbase/query/errors"
 	"github.com/couchbase/query/execution"
+	"github.com/couchbase/query/expression"
+	"github.com/couchbase/query/expression/parser"
 	"github.com/couchbase/query/plan"
 	server_http "github.com/couchbase/query/server/http"
+	"github.com/couchbase/query/timestamp"
 	"github.com/couchbase/query/value"
 
 	"github.com/couchbase/n1k1"
@@ -622,44 +628,1581 @@ func TestFileStoreGroupByCountSum(t *testing.T) {
 	}
 }
 
-// TODO: Need to rewrite the projection expression of...
-//   "count((`o`.`custId`)) OVER (PARTITION BY (`o`.`custId`) ORDER BY (`o`.`id`) ROWS UNBOUNDED PRECEDING)"
-// into a n1k1 expr_window function like "window-frame-count".
-//
-func DISABLED_TestFileStoreWindowOver(t *testing.T) {
-	store, p, conv, err :=
+func TestFileStoreWindowOver(t *testing.T) {
+	conv := testFileStoreExpect(t, `
+SELECT
+ o.id,
+ COUNT(o.custId) OVER (PARTITION BY o.custId ORDER BY o.id ROWS UNBOUNDED PRECEDING),
+ SUM(TONUMBER(o.id)) OVER (ORDER BY o.id ROWS UNBOUNDED PRECEDING),
+ MAX(o.id) OVER (ORDER BY o.id ROWS BETWEEN 1 PRECEDING AND 1 FOLLOWING),
+ AVG(TONUMBER(o.id)) OVER (ORDER BY o.id ROWS BETWEEN 1 PRECEDING AND CURRENT ROW),
+ COUNT(*) OVER (PARTITION BY o.custId)
+FROM data:orders AS o`,
+		`"1200",1,1200,"1234",1200,1`,
+		`"1234",1,2434,"1235",1217,1`,
+		`"1235",1,3669,"1236",1234.5,2`,
+		`"1236",2,4905,"1236",1235.5,2`)
+
+	// The running totals and the bounded frames are streamed, while
+	// the COUNT(*) over the whole partition is materialized.
+	var streamCfgs []string
+
+	var visit func(op *base.Op)
+	visit = func(op *base.Op) {
+		if op.Kind == "window-partition" {
+			var streamCfg interface{}
+			if len(op.Params) > 4 {
+				streamCfg = op.Params[4]
+			}
+
+			streamCfgs = append(streamCfgs, fmt.Sprintf("%v", streamCfg))
+		}
+
+		for _, child := range op.Children {
+			visit(child)
+		}
+	}
+
+	visit(conv.TopOp)
+
+	sort.Strings(streamCfgs)
+
+	if strings.Join(streamCfgs, " ") != "<nil> [0 0] [1 1]" {
+		t.Fatalf("expected stream cfgs, got: %+v", streamCfgs)
+	}
+}
+
+func TestFileStoreWindowSpecs(t *testing.T) {
+	_, p, conv, err :=
 		testFileStoreSelect(t, `
 SELECT
- COUNT(o.custId) OVER (PARTITION BY o.custId ORDER BY o.id ROWS UNBOUNDED PRECEDING)
+ FIRST_VALUE(o.id) OVER w1,
+ LAST_VALUE(o.id) OVER (PARTITION BY o.custId),
+ NTH_VALUE(o.id, 2) OVER w2
+FROM data:orders AS o
+WINDOW w1 AS (PARTITION BY o.custId ORDER BY o.id),
+       w2 AS (ORDER BY o.id DESC)`,
+			false)
+	if err != nil {
+		t.Fatalf("expected no nil err, got: %v", err)
+	}
+	if p == nil || conv == nil || conv.TopOp == nil {
+		t.Fatalf("expected p and conv an op, got nil")
+	}
+
+	counts := map[string]int{}
+
+	var visit func(op *base.Op)
+	visit = func(op *base.Op) {
+		counts[op.Kind]++
+
+		if op.Kind == "window-partition" && len(op.Children) != 1 {
+			t.Fatalf("expected window-partition with 1 child, got: %+v", op)
+		}
+
+		for _, child := range op.Children {
+			visit(child)
+		}
+	}
+
+	visit(conv.TopOp)
+
+	// The w1 spec's sort is shared by the PARTITION BY o.custId spec,
+	// while the w2 spec needs its own sort.
+	if counts["window-partition"] != 3 ||
+		counts["window-frames"] != 3 ||
+		counts["order-offset-limit"] != 2 {
+		t.Fatalf("expected 3 window-partition's and 2 sorts, got: %+v", counts)
+	}
+}
+
+func TestFileStoreWindowSpecsSharedSort(t *testing.T) {
+	conv := testFileStoreExpect(t, `
+SELECT
+ o.id,
+ FIRST_VALUE(o.id) OVER w1 AS f,
+ LAST_VALUE(o.id) OVER (PARTITION BY o.custId
+                        ROWS BETWEEN UNBOUNDED PRECEDING AND UNBOUNDED FOLLOWING) AS l
+FROM data:orders AS o
+WINDOW w1 AS (PARTITION BY o.custId ORDER BY o.id
+              ROWS BETWEEN UNBOUNDED PRECEDING AND UNBOUNDED FOLLOWING)`,
+		`"1200","1200","1200"`,
+		`"1234","1234","1234"`,
+		`"1235","1235","1236"`,
+		`"1236","1235","1236"`)
+
+	counts := map[string]int{}
+	for _, kind := range testOpKinds(conv.TopOp) {
+		counts[kind]++
+	}
+
+	// The PARTITION BY o.custId spec's sort is a prefix of the w1
+	// spec's sort, so the two specs share a single sort.
+	if counts["window-partition"] != 2 ||
+		counts["window-frames"] != 2 ||
+		counts["order-offset-limit"] != 1 {
+		t.Fatalf("expected 2 window-partition's and 1 sort, got: %+v", counts)
+	}
+}
+
+func TestFileStoreWindowNumbering(t *testing.T) {
+	conv := testFileStoreExpect(t, `
+SELECT
+ o.id,
+ ROW_NUMBER() OVER w2,
+ RANK() OVER w1,
+ DENSE_RANK() OVER w1,
+ PERCENT_RANK() OVER w1,
+ CUME_DIST() OVER w1,
+ NTILE(3) OVER w2
+FROM data:orders AS o
+WINDOW w1 AS (ORDER BY o.custId),
+       w2 AS (ORDER BY o.id)`,
+		`"1200",1,1,1,0,0.25,1`,
+		`"1234",2,2,2,0.3333333333333333,0.5,1`,
+		`"1235",3,3,3,0.6666666666666666,1,2`,
+		`"1236",4,3,3,0.6666666666666666,1,3`)
+
+	var tracks []string
+
+	var visit func(op *base.Op)
+	visit = func(op *base.Op) {
+		if op.Kind == "window-partition" {
+			tracks = append(tracks, op.Params[3].(string))
+		}
+
+		for _, child := range op.Children {
+			visit(child)
+		}
+	}
+
+	visit(conv.TopOp)
+
+	sort.Strings(tracks)
+
+	if strings.Join(tracks, " ") != "partitionSize rank,denseRank,partitionSize,peerEnd" {
+		t.Fatalf("expected tracked infos, got: %+v", tracks)
+	}
+}
+
+func TestFileStoreWindowRangeGroups(t *testing.T) {
+	conv := testFileStoreExpect(t, `
+SELECT
+ o.id,
+ FIRST_VALUE(o.id) OVER (ORDER BY TONUMBER(o.id) / 10
+   RANGE BETWEEN 0.15 PRECEDING AND CURRENT ROW),
+ FIRST_VALUE(o.custId) OVER (ORDER BY o.custId
+   GROUPS BETWEEN 1 PRECEDING AND CURRENT ROW)
 FROM data:orders AS o`,
-			true)
+		`"1200","1200","abc"`,
+		`"1234","1234","abc"`,
+		`"1235","1234","bbb"`,
+		`"1236","1235","bbb"`)
+
+	var frameCfgs []string
+
+	var visit func(op *base.Op)
+	visit = func(op *base.Op) {
+		if op.Kind == "window-frames" {
+			for _, cfg := range op.Params[2].([]interface{}) {
+				parts := cfg.([]interface{})
+
+				// The val compared by the frame is the ORDER BY term
+				// for RANGE and the tracked denseRank for GROUPS.
+				valLabel := op.Labels[parts[6].(int)]
+				valLabel = valLabel[strings.Index(valLabel, "|")+1:]
+
+				frameCfgs = append(frameCfgs,
+					fmt.Sprintf("%v %v %s", parts[0], parts[2], valLabel))
+			}
+		}
+
+		for _, child := range op.Children {
+			visit(child)
+		}
+	}
+
+	visit(conv.TopOp)
+
+	sort.Strings(frameCfgs)
+
+	if strings.Join(frameCfgs, "; ") != "groups -1 denseRank; range -0.15 rangeKey" {
+		t.Fatalf("expected frame cfgs, got: %+v", frameCfgs)
+	}
+}
+
+// ---------------------------------------------------------------
+
+func TestFileStoreWhere(t *testing.T) {
+	results, conv := testFileStoreWhere(t,
+		`SELECT * FROM data:orders WHERE custId = "ccc"`, "1235,1236")
+
+	// The WHERE clause is converted into a native expression.
+	var visit func(op *base.Op)
+	visit = func(op *base.Op) {
+		if op.Kind == "filter" && op.Params[0] != "eq" {
+			t.Fatalf("expected native filter, got: %+v", op)
+		}
+
+		for _, child := range op.Children {
+			visit(child)
+		}
+	}
+
+	visit(conv.TopOp)
+
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got: %+v", results)
+	}
+}
+
+func TestFileStoreWhereAnd(t *testing.T) {
+	results, _ := testFileStoreWhere(t,
+		`SELECT * FROM data:orders WHERE custId = "ccc" AND id > "1235"`, "1236")
+	if len(results) != 1 {
+		t.Fatalf("expected 1 results, got: %+v", results)
+	}
+}
+
+func TestFileStoreWhereOr(t *testing.T) {
+	results, _ := testFileStoreWhere(t,
+		`SELECT * FROM data:orders WHERE custId = "abc" OR id <= "1234"`, "1200,1234")
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got: %+v", results)
+	}
+}
+
+func TestFileStoreWhereFallback(t *testing.T) {
+	// The CONTAINS() function falls back to exprTree, while the
+	// rest of the WHERE clause is still converted natively.
+	results, conv := testFileStoreWhere(t,
+		`SELECT * FROM data:orders WHERE CONTAINS(custId, "bb") AND id > "1200"`, "1234")
+	if len(results) != 1 {
+		t.Fatalf("expected 1 results, got: %+v", results)
+	}
+
+	var visit func(op *base.Op)
+	visit = func(op *base.Op) {
+		if op.Kind == "filter" {
+			if op.Params[0] != "and" ||
+				op.Params[1].([]interface{})[0] != "exprTree" ||
+				op.Params[2].([]interface{})[0] != "lt" {
+				t.Fatalf("expected partly native filter, got: %+v", op)
+			}
+		}
+
+		for _, child := range op.Children {
+			visit(child)
+		}
+	}
+
+	visit(conv.TopOp)
+}
+
+func TestFileStoreWhereFunction(t *testing.T) {
+	results, _ := testFileStoreWhere(t,
+		`SELECT * FROM data:orders WHERE UPPER(custId) = "BBB"`, "1234")
+	if len(results) != 1 {
+		t.Fatalf("expected 1 results, got: %+v", results)
+	}
+}
+
+func TestFileStoreWhereArith(t *testing.T) {
+	results, _ := testFileStoreWhere(t,
+		`SELECT * FROM data:orders WHERE orderlines[0].qty * 10 - 5 = 15 OR NOT (custId <= "ccc")`, "1234")
+	if len(results) != 1 {
+		t.Fatalf("expected 1 results, got: %+v", results)
+	}
+}
+
+func TestFileStoreWhereNotAnd(t *testing.T) {
+	// The MISSING of the nofield comparison AND'ed with FALSE is
+	// FALSE, so the NOT keeps every order.
+	results, conv := testFileStoreWhere(t,
+		`SELECT * FROM data:orders WHERE NOT (nofield = 1 AND custId = "zzz")`,
+		"1200,1234,1235,1236")
+	if len(results) != 4 {
+		t.Fatalf("expected 4 results, got: %+v", results)
+	}
+
+	testFileStoreWhereNative(t, conv.TopOp)
+}
+
+func TestFileStoreWhereIsConcatElement(t *testing.T) {
+	results, conv := testFileStoreWhere(t,
+		"SELECT * FROM data:orders WHERE `shipped-on` IS NOT MISSING"+
+			` AND custId || "-" || id = "ccc-1236"`, "1236")
+	if len(results) != 1 {
+		t.Fatalf("expected 1 results, got: %+v", results)
+	}
+
+	testFileStoreWhereNative(t, conv.TopOp)
+
+	results, conv = testFileStoreWhere(t,
+		`SELECT * FROM data:orders WHERE orderlines[-1].productId = "sugar22"`+
+			` AND `+"`shipped-on`"+` IS NULL`, "1236")
+	if len(results) != 1 {
+		t.Fatalf("expected 1 results, got: %+v", results)
+	}
+
+	testFileStoreWhereNative(t, conv.TopOp)
+}
+
+// testFileStoreWhereNative checks that filters have no exprTree or
+// exprStr fallbacks.
+func testFileStoreWhereNative(t *testing.T, op *base.Op) {
+	if op.Kind == "filter" {
+		j, _ := json.Marshal(op.Params)
+		if strings.Contains(string(j), "exprTree") ||
+			strings.Contains(string(j), "exprStr") {
+			t.Fatalf("expected native filter, got: %s", j)
+		}
+	}
+
+	for _, child := range op.Children {
+		testFileStoreWhereNative(t, child)
+	}
+}
+
+func TestFileStoreLet(t *testing.T) {
+	conv := testFileStoreExpect(t, `
+SELECT o.id, x, y
+FROM data:orders AS o
+LET x = LOWER(o.custId || "X"), y = UPPER(x)
+WHERE o.id = "1234"`,
+		`"1234","bbbx","BBBX"`)
+
+	// The LET bindings are converted natively, where a binding may
+	// refer to the earlier bindings.
+	var visit func(op *base.Op)
+	visit = func(op *base.Op) {
+		if op.Kind == "let" {
+			j, _ := json.Marshal(op.Params)
+			if strings.Contains(string(j), "exprTree") ||
+				strings.Contains(string(j), "exprStr") {
+				t.Fatalf("expected native let, got: %s", j)
+			}
+		}
+
+		for _, child := range op.Children {
+			visit(child)
+		}
+	}
+
+	visit(conv.TopOp)
+}
+
+func TestFileStoreWhereNone(t *testing.T) {
+	results, _ := testFileStoreWhere(t,
+		`SELECT * FROM data:orders WHERE custId = "zzz"`, "")
+	if len(results) != 0 {
+		t.Fatalf("expected 0 results, got: %+v", results)
+	}
+}
+
+func testFileStoreWhere(t *testing.T, stmt string, expectIds string) (
+	[]base.Vals, *glue.Conv) {
+	store, p, conv, err := testFileStoreSelect(t, stmt, false)
+	if err != nil {
+		t.Fatalf("expected no nil err, got: %v", err)
+	}
+	if p == nil || conv == nil || conv.TopOp == nil {
+		t.Fatalf("expected p and conv an op, got nil")
+	}
+
+	results := testGlueExec(t, false, store, conv)
+
+	for _, result := range results {
+		if len(result) != 1 {
+			t.Fatalf("expected result has 1 labels, got: %+v", result)
+		}
+
+		var m map[string]interface{}
+		err := json.Unmarshal(result[0], &m)
+		if err != nil {
+			t.Fatalf("expected no err, got: %v", err)
+		}
+
+		if strings.Index(expectIds, m["id"].(string)) < 0 {
+			t.Fatalf("unexpected id: %+v, m: %+v", result, m)
+		}
+	}
+
+	return results, conv
+}
+
+// ---------------------------------------------------------------
+
+func TestFileStoreUnionAll(t *testing.T) {
+	testFileStoreExpect(t, `
+SELECT custId FROM data:orders WHERE id < "1235"
+UNION ALL
+SELECT custId FROM data:orders WHERE id >= "1235"`,
+		`"abc"`, `"bbb"`, `"ccc"`, `"ccc"`)
+}
+
+func TestFileStoreUnion(t *testing.T) {
+	testFileStoreExpect(t, `
+SELECT custId FROM data:orders
+UNION
+SELECT custId FROM data:orders WHERE id > "1234"`,
+		`"abc"`, `"bbb"`, `"ccc"`)
+}
+
+func TestFileStoreUnionAllLabels(t *testing.T) {
+	// The children's labels differ, so the vals are aligned by
+	// label, where a label that a child doesn't have is MISSING.
+	testFileStoreExpect(t, `
+SELECT custId FROM data:orders WHERE id = "1200"
+UNION ALL
+SELECT id FROM data:orders WHERE id = "1234"`,
+		`"abc",`, `,"1234"`)
+}
+
+func TestFileStoreIntersectAll(t *testing.T) {
+	testFileStoreExpect(t, `
+SELECT custId FROM data:orders
+INTERSECT ALL
+SELECT custId FROM data:orders WHERE id > "1234"`,
+		`"ccc"`, `"ccc"`)
+}
+
+func TestFileStoreIntersect(t *testing.T) {
+	testFileStoreExpect(t, `
+SELECT custId FROM data:orders
+INTERSECT
+SELECT custId FROM data:orders WHERE id > "1234"`,
+		`"ccc"`)
+}
+
+func TestFileStoreExceptAll(t *testing.T) {
+	testFileStoreExpect(t, `
+SELECT custId FROM data:orders
+EXCEPT ALL
+SELECT custId FROM data:orders WHERE id = "1235"`,
+		`"abc"`, `"bbb"`, `"ccc"`)
+}
+
+func TestFileStoreExcept(t *testing.T) {
+	testFileStoreExpect(t, `
+SELECT custId FROM data:orders
+EXCEPT
+SELECT custId FROM data:orders WHERE id = "1235"`,
+		`"abc"`, `"bbb"`)
+}
+
+func TestFileStoreExceptLabels(t *testing.T) {
+	// As the children's labels differ, no vals are in common.
+	testFileStoreExpect(t, `
+SELECT custId FROM data:orders WHERE id = "1200"
+EXCEPT
+SELECT id FROM data:orders WHERE id = "1234"`,
+		`"abc",`)
+}
+
+func TestFileStoreWithConstant(t *testing.T) {
+	testFileStoreExpect(t,
+		`WITH nums AS ([1, 2, 3]) SELECT n FROM nums AS n`,
+		`1`, `2`, `3`)
+
+	testFileStoreExpect(t,
+		`WITH one AS (40 + 2) SELECT n FROM one AS n`,
+		`42`)
+}
+
+func TestFileStoreWithParam(t *testing.T) {
+	store, _, conv, err := testFileStoreSelect(t,
+		`WITH nums AS ($1) SELECT n FROM nums AS n`, false)
+	if err != nil {
+		t.Fatalf("expected no err, got: %v", err)
+	}
+
+	for _, test := range []struct {
+		arg     interface{}
+		expects string
+	}{
+		{[]interface{}{10, 20}, `10,20`},
+		{30, `30`},
+	} {
+		results := testGlueExecOp(t, false, store, conv.TopOp, conv.Temps,
+			nil, value.Values{value.NewValue(test.arg)})
+
+		var actuals []string
+		for _, result := range results {
+			actuals = append(actuals, string(result[0]))
+		}
+
+		sort.Strings(actuals)
+
+		if strings.Join(actuals, ",") != test.expects {
+			t.Fatalf("expected: %s, got: %+v", test.expects, actuals)
+		}
+	}
+}
+
+func TestFileStoreWithSubquery(t *testing.T) {
+	testFileStoreExpect(t,
+		`WITH ccc AS (SELECT o.id, o.custId FROM data:orders AS o WHERE o.custId = "ccc")
+SELECT x.id, x.custId FROM ccc AS x`,
+		`"1235","ccc"`,
+		`"1236","ccc"`)
+
+	testFileStoreExpect(t,
+		`WITH ccc AS (SELECT o.id FROM data:orders AS o WHERE o.custId = "ccc"),
+     abc AS (SELECT o.id FROM data:orders AS o WHERE o.custId = "abc")
+SELECT x.id FROM ccc AS x UNION ALL SELECT y.id FROM abc AS y`,
+		`"1200"`,
+		`"1235"`,
+		`"1236"`)
+}
+
+// testFileStoreExpect checks the results of a statement, where the
+// results are compared in sorted order and each result is formatted as
+// its comma separated vals.
+func testFileStoreExpect(t *testing.T, stmt string, expects ...string) *glue.Conv {
+	return testFileStoreDirExpect(t, "./", stmt, expects...)
+}
+
+// testFileStoreDirExpect is like testFileStoreExpect, but with a file
+// datastore from the given dir.
+func testFileStoreDirExpect(t *testing.T, dir, stmt string,
+	expects ...string) *glue.Conv {
+	store, p, conv, err := testFileStorePlan(t, dir, stmt, false)
+	if err != nil {
+		t.Fatalf("expected no nil err, got: %v", err)
+	}
+	if p == nil || conv == nil || conv.TopOp == nil {
+		t.Fatalf("expected p and conv an op, got nil")
+	}
+
+	results := testGlueExec(t, false, store, conv)
+
+	var actuals []string
+	for _, result := range results {
+		var vals []string
+		for _, val := range result {
+			vals = append(vals, string(val))
+		}
+
+		actuals = append(actuals, strings.Join(vals, ","))
+	}
+
+	sort.Strings(actuals)
+
+	if strings.Join(actuals, "\n") != strings.Join(expects, "\n") {
+		t.Fatalf("expected results: %+v, got: %+v", expects, actuals)
+	}
+
+	return conv
+}
+
+// testOpKinds returns the kinds of the ops in the tree.
+func testOpKinds(op *base.Op) (rv []string) {
+	rv = append(rv, op.Kind)
+
+	for _, child := range op.Children {
+		rv = append(rv, testOpKinds(child)...)
+	}
+
+	return rv
+}
+
+// ---------------------------------------------------------------
+
+func TestFileStoreAnsiJoin(t *testing.T) {
+	conv := testFileStoreExpect(t, `
+SELECT o1.id AS id1, o2.id AS id2
+  FROM data:orders o1 JOIN data:orders o2
+    ON o1.custId = o2.custId AND o1.id < o2.id`,
+		`"1235","1236"`)
+
+	kinds := strings.Join(testOpKinds(conv.TopOp), " ")
+	if !strings.Contains(kinds, "join") {
+		t.Fatalf("expected a join op, got: %s", kinds)
+	}
+}
+
+func TestFileStoreAnsiLeftJoin(t *testing.T) {
+	testFileStoreExpect(t, `
+SELECT o1.id AS id1, o2.id AS id2
+  FROM data:orders o1 LEFT JOIN data:orders o2
+    ON o1.custId = o2.custId AND o1.id < o2.id`,
+		`"1200",`, `"1234",`, `"1235","1236"`, `"1236",`)
+}
+
+func TestFileStoreHashJoin(t *testing.T) {
+	testFileStoreExpect(t, `
+SELECT o1.id AS id1, o2.id AS id2
+  FROM data:orders o1 JOIN data:orders o2 USE HASH(build)
+    ON o1.custId = o2.custId AND o1.id < o2.id`,
+		`"1235","1236"`)
+}
+
+func TestFileStoreHashLeftJoin(t *testing.T) {
+	testFileStoreExpect(t, `
+SELECT o1.id AS id1, o2.id AS id2
+  FROM data:orders o1 LEFT JOIN data:orders o2 USE HASH(build)
+    ON o1.custId = o2.custId AND o1.id < o2.id`,
+		`"1200",`, `"1234",`, `"1235","1236"`, `"1236",`)
+}
+
+func TestFileStoreAnsiNest(t *testing.T) {
+	testFileStoreExpect(t, `
+SELECT o1.id, ARRAY_LENGTH(o2) AS n
+  FROM data:orders o1 NEST data:orders o2
+    ON o1.custId = o2.custId`,
+		`"1200",1`, `"1234",1`, `"1235",2`, `"1236",2`)
+}
+
+func TestFileStoreAnsiLeftNest(t *testing.T) {
+	testFileStoreExpect(t, `
+SELECT o1.id, ARRAY_LENGTH(o2) AS n
+  FROM data:orders o1 LEFT NEST data:orders o2
+    ON o1.custId = o2.custId AND o1.id < o2.id`,
+		`"1200",`, `"1234",`, `"1235",1`, `"1236",`)
+}
+
+func TestFileStoreHashNest(t *testing.T) {
+	testFileStoreExpect(t, `
+SELECT o1.id, ARRAY_LENGTH(o2) AS n
+  FROM data:orders o1 NEST data:orders o2 USE HASH(build)
+    ON o1.custId = o2.custId`,
+		`"1200",1`, `"1234",1`, `"1235",2`, `"1236",2`)
+}
+
+// ---------------------------------------------------------------
+
+func TestFileStoreCountScan(t *testing.T) {
+	conv := testFileStoreExpect(t, `SELECT COUNT(*) AS n FROM data:orders`, `4`)
+
+	kinds := testOpKinds(conv.TopOp)
+	if kinds[len(kinds)-1] != "datastore-count" {
+		t.Fatalf("expected a datastore-count, got: %+v", kinds)
+	}
+
+	conv = testFileStoreExpect(t,
+		`SELECT COUNT(*) + 1 AS n1, COUNT(*) * 2 AS n2, COUNT(*) AS n
+           FROM data:orders`, `5,8,4`)
+
+	kinds = testOpKinds(conv.TopOp)
+	if kinds[len(kinds)-1] != "datastore-count" {
+		t.Fatalf("expected a datastore-count, got: %+v", kinds)
+	}
+}
+
+// ---------------------------------------------------------------
+
+func TestFileStoreMutations(t *testing.T) {
+	dir := testFileStoreCopy(t, "orders")
+
+	defer os.RemoveAll(dir)
+
+	testFileStoreDirExpect(t, dir, `
+INSERT INTO data:orders (KEY, VALUE)
+  VALUES ("9000", {"id": "9000", "custId": "xyz"}),
+         ("9001", {"id": "9001", "custId": "xyz"})
+  RETURNING orders.id`,
+		`"9000"`, `"9001"`)
+
+	testFileStoreDirExpect(t, dir, `
+SELECT id FROM data:orders WHERE custId = "xyz"`,
+		`"9000"`, `"9001"`)
+
+	testFileStoreDirExpect(t, dir, `
+UPSERT INTO data:orders (KEY, VALUE)
+  VALUES ("9001", {"id": "9001", "custId": "xyz", "n": 1})`)
+
+	testFileStoreDirExpect(t, dir, `
+SELECT id, n FROM data:orders WHERE custId = "xyz"`,
+		`"9000",`, `"9001",1`)
+
+	testFileStoreDirExpect(t, dir, `
+UPDATE data:orders SET n = 2, shipping.carrier = "x" UNSET orderlines
+  WHERE id = "1200"`)
+
+	testFileStoreDirExpect(t, dir, `
+SELECT id, n, ARRAY_LENGTH(orderlines) AS c, shipping
+  FROM data:orders WHERE id = "1200" OR id = "1234"`,
+		`"1200",2,,`, `"1234",,2,`)
+
+	testFileStoreDirExpect(t, dir, `
+DELETE FROM data:orders WHERE custId = "xyz"`)
+
+	testFileStoreDirExpect(t, dir, `
+SELECT id FROM data:orders`,
+		`"1200"`, `"1234"`, `"1235"`, `"1236"`)
+
+	testFileStoreDirExpect(t, dir, `
+MERGE INTO data:orders o USING [{"k": "1200"}, {"k": "9002"}] AS s ON KEY s.k
+  WHEN MATCHED THEN UPDATE SET o.m = 1
+  WHEN NOT MATCHED THEN INSERT {"id": s.k, "m": 2}`)
+
+	testFileStoreDirExpect(t, dir, `
+SELECT id, m FROM data:orders WHERE m IS NOT MISSING`,
+		`"1200",1`, `"9002",2`)
+}
+
+func TestFileStoreMutationErrs(t *testing.T) {
+	dir := testFileStoreCopy(t, "orders")
+
+	defer os.RemoveAll(dir)
+
+	for _, test := range []struct {
+		stmt      string
+		expectErr string
+	}{
+		{`INSERT INTO data:orders (KEY, VALUE)
+            VALUES ("9000", {"id": "9000", "custId": "xyz"}),
+                   (123, {"id": "123", "custId": "xyz"}),
+                   ("9001", {"id": "9001", "custId": "xyz"})
+            RETURNING orders.id`,
+			"non-string key"},
+		{`UPSERT INTO data:orders (KEY, VALUE)
+            VALUES ("9000", {"id": "9000", "custId": "xyz"}),
+                   (NULL, {"id": "null", "custId": "xyz"})`,
+			"missing key"},
+	} {
+		store, _, conv, err := testFileStorePlan(t, dir, test.stmt, false)
+		if err != nil {
+			t.Fatalf("expected no err, got: %v", err)
+		}
+
+		results, errs := testGlueExecOpErrs(t, false, store,
+			conv.TopOp, conv.Temps, nil, nil)
+		if len(results) != 0 {
+			t.Fatalf("stmt: %s, expected no results, got: %+v", test.stmt, results)
+		}
+
+		// The error is yielded once.
+		if len(errs) != 1 || !strings.Contains(errs[0].Error(), test.expectErr) {
+			t.Fatalf("stmt: %s, expected 1 err: %s, got: %+v",
+				test.stmt, test.expectErr, errs)
+		}
+	}
+
+	// No writes happened after the errors.
+	testFileStoreDirExpect(t, dir, `
+SELECT id FROM data:orders WHERE custId = "xyz"`)
+}
+
+// testFileStoreCopy returns a temp dir that has a copy of the data of
+// a keyspace, so that mutations don't modify the test data.
+func testFileStoreCopy(t *testing.T, keyspace string) string {
+	dir, err := ioutil.TempDir("", "n1k1GlueTest")
+	if err != nil {
+		t.Fatalf("expected no err, got: %v", err)
+	}
+
+	src := "./data/" + keyspace
+	dst := dir + "/data/" + keyspace
+
+	err = os.MkdirAll(dst, 0700)
+	if err != nil {
+		t.Fatalf("expected no err, got: %v", err)
+	}
+
+	files, err := ioutil.ReadDir(src)
+	if err != nil {
+		t.Fatalf("expected no err, got: %v", err)
+	}
+
+	for _, f := range files {
+		b, err := ioutil.ReadFile(src + "/" + f.Name())
+		if err != nil {
+			t.Fatalf("expected no err, got: %v", err)
+		}
+
+		err = ioutil.WriteFile(dst+"/"+f.Name(), b, 0600)
+		if err != nil {
+			t.Fatalf("expected no err, got: %v", err)
+		}
+	}
+
+	return dir
+}
+
+// ---------------------------------------------------------------
+
+func TestFileStoreExplain(t *testing.T) {
+	_, _, conv, err := testFileStoreSelect(t, `
+SELECT id FROM data:orders WHERE custId = "abc"`, false)
+	if err != nil {
+		t.Fatalf("expected no err, got: %v", err)
+	}
+
+	j, err := conv.Explain()
+	if err != nil {
+		t.Fatalf("expected no err, got: %v", err)
+	}
+
+	var e glue.Explain
+
+	err = json.Unmarshal(j, &e)
+	if err != nil {
+		t.Fatalf("expected no err, got: %v", err)
+	}
+
+	if e.Op == nil || e.Op.Kind != "project" ||
+		len(e.Op.Exprs) != 1 || e.Op.Exprs[0].Native {
+		t.Fatalf("expected a project with an exprStr, got: %s", j)
+	}
+
+	filter := e.Op.Children[0]
+	if filter.Kind != "filter" ||
+		len(filter.Exprs) != 1 || !filter.Exprs[0].Native {
+		t.Fatalf("expected a native filter, got: %s", j)
+	}
+
+	if e.NumNative != 1 || e.NumFallback != 1 {
+		t.Fatalf("expected 1 native & 1 fallback, got: %s", j)
+	}
+
+	if len(e.Temps) <= 0 || e.Temps[0].Type != "context" {
+		t.Fatalf("expected temps with a context, got: %s", j)
+	}
+}
+
+func TestFileStoreExplainStatement(t *testing.T) {
+	store, _, conv, err := testFileStoreSelect(t, `
+EXPLAIN SELECT id FROM data:orders WHERE custId = "abc"`, false)
+	if err != nil {
+		t.Fatalf("expected no err, got: %v", err)
+	}
+
+	results := testGlueExec(t, false, store, conv)
+	if len(results) != 1 || len(results[0]) != 1 {
+		t.Fatalf("expected 1 result, got: %+v", results)
+	}
+
+	var e glue.Explain
+
+	err = json.Unmarshal(results[0][0], &e)
+	if err != nil {
+		t.Fatalf("expected no err, got: %v", err)
+	}
+
+	kinds := strings.Join(testExplainKinds(e.Op), " ")
+	if !strings.HasPrefix(kinds, "project filter") ||
+		!strings.HasSuffix(kinds, "datastore-scan-primary") {
+		t.Fatalf("expected explained op kinds, got: %s", kinds)
+	}
+}
+
+// testExplainKinds returns the kinds of the explained ops in the tree.
+func testExplainKinds(op *glue.ExplainOp) (rv []string) {
+	rv = append(rv, op.Kind)
+
+	for _, child := range op.Children {
+		rv = append(rv, testExplainKinds(child)...)
+	}
+
+	return rv
+}
+
+// ---------------------------------------------------------------
+
+func TestFileStorePrepared(t *testing.T) {
+	store, err := glue.FileStore("./")
+	if err != nil {
+		t.Fatalf("did not expect err: %v", err)
+	}
+
+	store.InitParser()
+
+	pc := glue.NewPreparedCache()
+
+	ctx := &base.Ctx{}
+
+	_, err = store.Execute(pc, "p0", "", "", ctx, nil, nil)
+	if err == nil {
+		t.Fatalf("expected err for an unknown prepared name")
+	}
+
+	text := `SELECT id FROM data:orders WHERE custId = $c`
+
+	args := map[string]value.Value{"c": value.NewValue("abc")}
+
+	p1, err := store.Execute(pc, "p1", text, "", ctx, args, nil)
+	if err != nil || p1 == nil || p1.Op == nil {
+		t.Fatalf("expected a prepared, got: %+v, err: %v", p1, err)
+	}
+
+	if string(ctx.NamedParams["c"]) != `"abc"` {
+		t.Fatalf("expected the args to be bound, got: %+v", ctx.NamedParams)
+	}
+
+	p1Again, err := store.Execute(pc, "p1", text, "", ctx, args, nil)
+	if err != nil || p1Again != p1 {
+		t.Fatalf("expected a cached prepared, got: %+v, err: %v", p1Again, err)
+	}
+
+	p1ByName, err := store.Execute(pc, "p1", "", "", ctx, args, nil)
+	if err != nil || p1ByName != p1 {
+		t.Fatalf("expected a cached prepared by name, got: %+v, err: %v", p1ByName, err)
+	}
+
+	for _, test := range []struct {
+		custId  string
+		expects string
+	}{
+		{"abc", `"1200"`},
+		{"ccc", `"1235","1236"`},
+		{"nope", ``},
+	} {
+		p, results := testGlueExecute(t, store, pc, "p1", "",
+			map[string]value.Value{"c": value.NewValue(test.custId)}, nil)
+		if p != p1 {
+			t.Fatalf("expected the cached prepared, got: %+v", p)
+		}
+
+		var actuals []string
+		for _, result := range results {
+			actuals = append(actuals, string(result[0]))
+		}
+
+		sort.Strings(actuals)
+
+		if strings.Join(actuals, ",") != test.expects {
+			t.Fatalf("expected: %s, got: %+v", test.expects, actuals)
+		}
+	}
+
+	p2, err := store.Prepare(pc, "p2",
+		`SELECT id FROM data:orders WHERE custId = $1`, "")
+	if err != nil || p2 == nil {
+		t.Fatalf("expected a prepared, got: %+v, err: %v", p2, err)
+	}
+
+	_, results := testGlueExecute(t, store, pc, "p2", "",
+		nil, value.Values{value.NewValue("bbb")})
+	if len(results) != 1 || string(results[0][0]) != `"1234"` {
+		t.Fatalf("expected 1234, got: %+v", results)
+	}
+}
+
+// The native param exprs, such as of a LIMIT, are bound via the
+// Ctx.ParamsBinder from the request args of the execution.Context
+// when the caller doesn't invoke glue.SetCtxParams(), and a binding
+// error is yielded.
+func TestFileStoreParamsBinder(t *testing.T) {
+	store, _, conv, err := testFileStoreSelect(t,
+		`SELECT id FROM data:orders WHERE custId = $c ORDER BY id LIMIT $n`, false)
+	if err != nil {
+		t.Fatalf("expected no err, got: %v", err)
+	}
+
+	if p := conv.TopOp.Params[3].([]interface{}); p[0] != "param" {
+		t.Fatalf("expected a native limit param, got: %+v", p)
+	}
+
+	context, _ := testGlueContext(store, map[string]value.Value{
+		"c": value.NewValue("ccc"),
+		"n": value.NewValue(1),
+	}, nil)
+
+	origExecOpEx := n1k1.ExecOpEx
+
+	defer func() { n1k1.ExecOpEx = origExecOpEx }()
+
+	n1k1.ExecOpEx = glue.DatastoreOp
+
+	run := func(binder func(ctx *base.Ctx) error) (
+		*base.Vars, []base.Vals, []error) {
+		tmpDir, vars, yieldVals, _, returnYields :=
+			MakeYieldCaptureFuncs(t, 0, "")
+
+		defer os.RemoveAll(tmpDir)
+
+		var errs []error
+
+		yieldErr := func(err error) {
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}
+
+		vars.Ctx.NamedParams, vars.Ctx.PositionalParams = nil, nil
+
+		vars.Ctx.ParamsBinder = binder
+
+		vars.Temps = append([]interface{}{context}, conv.Temps[1:]...)
+
+		for i := 0; i < 16; i++ {
+			vars.Temps = append(vars.Temps, nil)
+		}
+
+		n1k1.ExecOp(conv.TopOp, vars, yieldVals, yieldErr, "", "")
+
+		return vars, returnYields(), errs
+	}
+
+	vars, results, errs := run(glue.CtxParamsBinder(context))
+	if len(errs) > 0 {
+		t.Fatalf("expected no errs, got: %+v", errs)
+	}
+
+	if len(results) != 1 || string(results[0][0]) != `"1235"` {
+		t.Fatalf("expected 1235, got: %+v", results)
+	}
+
+	if string(vars.Ctx.NamedParams["n"]) != `1` {
+		t.Fatalf("expected bound params, got: %+v", vars.Ctx.NamedParams)
+	}
+
+	errBind := fmt.Errorf("bind failed")
+
+	_, _, errs = run(func(ctx *base.Ctx) error { return errBind })
+	if len(errs) <= 0 || errs[0] != errBind {
+		t.Fatalf("expected the bind err, got: %+v", errs)
+	}
+}
+
+func TestFileStorePreparedLimit(t *testing.T) {
+	store, err := glue.FileStore("./")
+	if err != nil {
+		t.Fatalf("did not expect err: %v", err)
+	}
+
+	store.InitParser()
+
+	pc := glue.NewPreparedCache()
+
+	p, err := store.Prepare(pc, "pLimit",
+		`SELECT id FROM data:orders ORDER BY id OFFSET $o LIMIT $n`, "")
+	if err != nil || p == nil {
+		t.Fatalf("expected a prepared, got: %+v, err: %v", p, err)
+	}
+
+	for _, test := range []struct {
+		offset, limit int
+		expects       string
+	}{
+		{0, 1, `"1200"`},
+		{0, 3, `"1200","1234","1235"`},
+		{2, 1, `"1235"`},
+	} {
+		results := testGlueExecOp(t, false, store, p.Op, p.Temps,
+			map[string]value.Value{
+				"o": value.NewValue(test.offset),
+				"n": value.NewValue(test.limit),
+			}, nil)
+
+		var actuals []string
+		for _, result := range results {
+			actuals = append(actuals, string(result[0]))
+		}
+
+		if strings.Join(actuals, ",") != test.expects {
+			t.Fatalf("expected: %s, got: %+v", test.expects, actuals)
+		}
+	}
+
+	// Without an ORDER BY, only the number of results is checked.
+	p, err = store.Prepare(pc, "pLimitNoOrder",
+		`SELECT id FROM data:orders OFFSET $o LIMIT $n`, "")
+	if err != nil || p == nil {
+		t.Fatalf("expected a prepared, got: %+v, err: %v", p, err)
+	}
+
+	for _, test := range []struct {
+		offset, limit, expects int
+	}{
+		{0, 1, 1},
+		{0, 3, 3},
+		{1, 10, 3},
+	} {
+		results := testGlueExecOp(t, false, store, p.Op, p.Temps,
+			map[string]value.Value{
+				"o": value.NewValue(test.offset),
+				"n": value.NewValue(test.limit),
+			}, nil)
+		if len(results) != test.expects {
+			t.Fatalf("expected %d results, got: %+v", test.expects, results)
+		}
+	}
+}
+
+func TestFileStorePrepareStatement(t *testing.T) {
+	store, p, conv, err := testFileStoreSelect(t,
+		`PREPARE pStmt FROM SELECT id FROM data:orders WHERE custId = $c ORDER BY id LIMIT $n`,
+		false)
+	if err != nil || p == nil {
+		t.Fatalf("expected no err, got: %v", err)
+	}
+
+	pc := glue.NewPreparedCache()
+
+	conv = glue.NewConv()
+	conv.PreparedCache = pc
+
+	err = conv.Convert(p)
 	if err != nil {
-		t.Fatalf("expected no nil err, got: %v", err)
+		t.Fatalf("expected no convert err, got: %v", err)
 	}
-	if p == nil || conv == nil || conv.TopOp == nil {
-		t.Fatalf("expected p and conv an op, got nil")
+
+	// The PREPARE yields the prepared statement's JSON.
+	results := testGlueExec(t, false, store, conv)
+	if len(results) != 1 || !strings.Contains(string(results[0][0]), "pStmt") {
+		t.Fatalf("expected the prepared JSON, got: %+v", results)
+	}
+
+	for _, test := range []struct {
+		custId  string
+		limit   int
+		expects string
+	}{
+		{"ccc", 1, `"1235"`},
+		{"ccc", 2, `"1235","1236"`},
+		{"abc", 2, `"1200"`},
+	} {
+		prepared, results := testGlueExecute(t, store, pc, "pStmt", "",
+			map[string]value.Value{
+				"c": value.NewValue(test.custId),
+				"n": value.NewValue(test.limit),
+			}, nil)
+		if prepared == nil || prepared.Op == nil {
+			t.Fatalf("expected a cached prepared, got: %+v", prepared)
+		}
+
+		var actuals []string
+		for _, result := range results {
+			actuals = append(actuals, string(result[0]))
+		}
+
+		if strings.Join(actuals, ",") != test.expects {
+			t.Fatalf("expected: %s, got: %+v", test.expects, actuals)
+		}
 	}
+}
 
-	results := testGlueExec(t, true, store, conv)
-	if len(results) != 3 {
-		t.Fatalf("expected 3 results, got: %+v", results)
+// ---------------------------------------------------------------
+
+func TestGlueCoverLabels(t *testing.T) {
+	tmpDir, vars := MakeVars()
+
+	defer os.RemoveAll(tmpDir)
+
+	if n1k1.ExprCatalog["exprTree"] == nil {
+		n1k1.ExprCatalog["exprTree"] = glue.ExprTree
 	}
 
-	for _, result := range results {
-		if len(result) != 3 {
-			t.Fatalf("expected result has 3 labels, got: %+v", result)
+	e, err := parser.Parse("cover ((`o`.`custId`))")
+	if err != nil {
+		t.Fatalf("expected no parse err, got: %v", err)
+	}
+
+	cover, ok := e.(*expression.Cover)
+	if !ok {
+		t.Fatalf("expected a cover, got: %#v", e)
+	}
+
+	// The vals of a covering index scan, as labeled by glue.CoveringLabels().
+	labels := base.Labels{"~" + cover.Text(), "^id"}
+	vals := base.Vals{base.Val(`"bbb"`), base.Val(`"1234"`)}
+
+	params := glue.ExprConv(labels, e)
+	if params[0] != "labelPath" || params[1] != labels[0] {
+		t.Fatalf("expected native labelPath of cover, got: %+v", params)
+	}
+
+	// The exprTree evaluates the cover via the covers of the
+	// converted, annotated value.
+	for _, params := range [][]interface{}{params, glue.ExprConvFallback(e)} {
+		exprFunc := n1k1.MakeExprFunc(vars, labels, params, "", "")
+
+		val := exprFunc(vals, func(err error) {
+			if err != nil {
+				t.Fatalf("expected no err, got: %v", err)
+			}
+		})
+
+		if string(val) != `"bbb"` {
+			t.Fatalf("expected covered val, params: %+v, got: %s", params, val)
 		}
+	}
+}
 
-		if strings.Index(`"abc","bbb","ccc"`, string(result[0])) < 0 {
-			t.Fatalf("unexpected id: %+v", result)
+// testCoveringIndex is a stub index that yields its entries from
+// Scan2() and Scan3(), recording the evaluated spans.
+type testCoveringIndex struct {
+	datastore.Index3 // Other methods are unused, so panic if called.
+
+	entries []*datastore.IndexEntry
+	spans   datastore.Spans2
+}
+
+func (i *testCoveringIndex) Scan2(requestId string, spans datastore.Spans2,
+	reverse, distinctAfterProjection, ordered bool,
+	projection *datastore.IndexProjection, offset, limit int64,
+	cons datastore.ScanConsistency, vector timestamp.Vector,
+	conn *datastore.IndexConnection) {
+	i.send(spans, conn)
+}
+
+func (i *testCoveringIndex) Scan3(requestId string, spans datastore.Spans2,
+	reverse, distinctAfterProjection bool,
+	projection *datastore.IndexProjection, offset, limit int64,
+	groupAggs *datastore.IndexGroupAggregates, indexOrders datastore.IndexKeyOrders,
+	cons datastore.ScanConsistency, vector timestamp.Vector,
+	conn *datastore.IndexConnection) {
+	i.send(spans, conn)
+}
+
+func (i *testCoveringIndex) send(spans datastore.Spans2,
+	conn *datastore.IndexConnection) {
+	i.spans = spans
+
+	for _, entry := range i.entries {
+		conn.Sender().SendEntry(entry)
+	}
+
+	conn.Sender().Close()
+}
+
+func TestGlueCoveringIndexScan(t *testing.T) {
+	store, err := glue.FileStore("./")
+	if err != nil {
+		t.Fatalf("did not expect err: %v", err)
+	}
+
+	store.InitParser()
+
+	s, err := glue.ParseStatement(`SELECT o.id FROM data:orders AS o`, "", true)
+	if err != nil {
+		t.Fatalf("parse did not expect err: %v", err)
+	}
+
+	term := s.(*algebra.Select).Subresult().(*algebra.Subselect).From().(*algebra.KeyspaceTerm)
+
+	parse := func(s string) expression.Expression {
+		e, err := parser.Parse(s)
+		if err != nil {
+			t.Fatalf("expected no parse err, got: %v", err)
 		}
+		return e
+	}
 
-		if strings.Index(`2,4`, string(result[1])) < 0 {
-			t.Fatalf("unexpected count: %+v", result)
+	// The last cover is of the primary key, as from the planner.
+	covers := expression.Covers{
+		parse("cover ((`o`.`custId`))").(*expression.Cover),
+		parse("cover ((`o`.`id`))").(*expression.Cover),
+		parse("cover ((meta(`o`).`id`))").(*expression.Cover),
+	}
+
+	filterCover := parse("cover ((`o`.`type`))").(*expression.Cover)
+
+	filterCovers := map[*expression.Cover]value.Value{
+		filterCover: value.NewValue("order"),
+	}
+
+	// Only the o.id cover is projected, so the custId cover is MISSING.
+	projection := &plan.IndexProjection{EntryKeys: []int{1}, PrimaryKey: true}
+
+	// The 2nd span has a MISSING low, so is empty and is not scanned.
+	spans := plan.Spans2{
+		&plan.Span2{Ranges: []*plan.Range2{&plan.Range2{
+			Low:       expression.NewConstant("1234"),
+			High:      parse("$1"),
+			Inclusion: datastore.BOTH,
+		}}},
+		&plan.Span2{Ranges: []*plan.Range2{&plan.Range2{
+			Low:       parse("$2"),
+			Inclusion: datastore.LOW,
+		}}},
+	}
+
+	index := &testCoveringIndex{
+		entries: []*datastore.IndexEntry{
+			&datastore.IndexEntry{
+				EntryKey:   value.Values{value.NewValue("1234")},
+				PrimaryKey: "k1",
+			},
+			&datastore.IndexEntry{
+				EntryKey:   value.Values{value.NewValue("1235")},
+				PrimaryKey: "k2",
+			},
+		},
+	}
+
+	expectLabels := base.Labels{
+		"~" + covers[0].Text(),
+		"~" + covers[1].Text(),
+		"~" + covers[2].Text(),
+		"~" + filterCover.Text(),
+		"^id",
+	}
+
+	expectResults := []string{
+		`,"1234","k1","order","k1"`,
+		`,"1235","k2","order","k2"`,
+	}
+
+	for _, test := range []struct {
+		kind string
+		scan plan.Operator
+	}{
+		{"datastore-scan-index2",
+			plan.NewIndexScan2(index, term, spans, false, false, false,
+				nil, nil, projection, covers, filterCovers, 0, 0)},
+		{"datastore-scan-index3",
+			plan.NewIndexScan3(index, term, spans, false, false, false,
+				nil, nil, projection, nil, nil, covers, filterCovers, 0, 0)},
+	} {
+		index.spans = nil
+
+		conv := glue.NewConv()
+
+		err = conv.Convert(test.scan)
+		if err != nil {
+			t.Fatalf("expected no convert err, got: %v", err)
 		}
 
-		if strings.Index(`2,3,4`, string(result[2])) < 0 {
-			t.Fatalf("unexpected sum: %+v", result)
+		if conv.TopOp.Kind != test.kind {
+			t.Fatalf("expected kind: %s, got: %s", test.kind, conv.TopOp.Kind)
+		}
+
+		if strings.Join(conv.TopOp.Labels, "\n") != strings.Join(expectLabels, "\n") {
+			t.Fatalf("expected labels: %+v, got: %+v", expectLabels, conv.TopOp.Labels)
+		}
+
+		results := testGlueExecOp(t, false, store, conv.TopOp, conv.Temps,
+			nil, value.Values{value.NewValue("2000")})
+
+		var actuals []string
+		for _, result := range results {
+			var vals []string
+			for _, val := range result {
+				vals = append(vals, string(val))
+			}
+
+			actuals = append(actuals, strings.Join(vals, ","))
+		}
+
+		if strings.Join(actuals, "\n") != strings.Join(expectResults, "\n") {
+			t.Fatalf("kind: %s, expected results: %+v, got: %+v",
+				test.kind, expectResults, actuals)
+		}
+
+		// The spans as evaluated by EvalSpans2().
+		if len(index.spans) != 1 || len(index.spans[0].Ranges) != 1 ||
+			index.spans[0].Ranges[0].Low.String() != `"1234"` ||
+			index.spans[0].Ranges[0].High.String() != `"2000"` ||
+			index.spans[0].Ranges[0].Inclusion != datastore.BOTH {
+			t.Fatalf("kind: %s, expected evaluated spans, got: %+v",
+				test.kind, index.spans)
+		}
+	}
+}
+
+func TestGlueCompositeScans(t *testing.T) {
+	store, err := glue.FileStore("./")
+	if err != nil {
+		t.Fatalf("did not expect err: %v", err)
+	}
+
+	store.InitParser()
+
+	s, err := glue.ParseStatement(`SELECT o.id FROM data:orders AS o`, "", true)
+	if err != nil {
+		t.Fatalf("parse did not expect err: %v", err)
+	}
+
+	term := s.(*algebra.Select).Subresult().(*algebra.Subselect).From().(*algebra.KeyspaceTerm)
+
+	limit, err := parser.Parse("$n")
+	if err != nil {
+		t.Fatalf("expected no parse err, got: %v", err)
+	}
+
+	spans := plan.Spans2{&plan.Span2{}}
+
+	indexScan := func(keys ...string) *plan.IndexScan3 {
+		index := &testCoveringIndex{}
+		for _, key := range keys {
+			index.entries = append(index.entries,
+				&datastore.IndexEntry{PrimaryKey: key})
+		}
+
+		return plan.NewIndexScan3(index, term, spans, false, false, false,
+			nil, nil, nil, nil, nil, nil, nil, 0, 0)
+	}
+
+	scanA := indexScan("k1", "k2", "k3")
+	scanB := indexScan("k4", "k3", "k2")
+
+	for _, test := range []struct {
+		about   string
+		scan    plan.Operator
+		limit   int
+		kinds   string
+		expects string // Sorted unless ordered.
+		ordered bool
+	}{
+		{"union",
+			plan.NewUnionScan(limit, nil, 0, 0, scanA, scanB), 3,
+			"order-offset-limit distinct union-all" +
+				" datastore-scan-index3 datastore-scan-index3",
+			`"k1","k2","k3"`, false},
+		{"union, larger limit",
+			plan.NewUnionScan(limit, nil, 0, 0, scanA, scanB), 10,
+			"order-offset-limit distinct union-all" +
+				" datastore-scan-index3 datastore-scan-index3",
+			`"k1","k2","k3","k4"`, false},
+		{"intersect",
+			plan.NewIntersectScan(limit, 0, 0, scanA, scanB), 10,
+			"order-offset-limit intersect-distinct" +
+				" datastore-scan-index3 datastore-scan-index3",
+			`"k2","k3"`, false},
+		{"intersect, limit 1",
+			plan.NewIntersectScan(limit, 0, 0, scanA, scanB), 1,
+			"order-offset-limit intersect-distinct" +
+				" datastore-scan-index3 datastore-scan-index3",
+			`"k2"`, false},
+		// The order of the first scan, scanB, is kept.
+		{"ordered intersect",
+			plan.NewOrderedIntersectScan(limit, 0, 0, scanB, scanA), 10,
+			"order-offset-limit intersect-distinct" +
+				" datastore-scan-index3 datastore-scan-index3",
+			`"k3","k2"`, true},
+	} {
+		conv := glue.NewConv()
+
+		err = conv.Convert(test.scan)
+		if err != nil {
+			t.Fatalf("%s, expected no convert err, got: %v", test.about, err)
+		}
+
+		kinds := strings.Join(testOpKinds(conv.TopOp), " ")
+		if kinds != test.kinds {
+			t.Fatalf("%s, expected kinds: %s, got: %s", test.about, test.kinds, kinds)
+		}
+
+		// The param of the limit is resolved for each execution.
+		if p := conv.TopOp.Params[3].([]interface{}); p[0] != "param" {
+			t.Fatalf("%s, expected a limit param, got: %+v", test.about, p)
+		}
+
+		// The ops all yield only the "^id".
+		var checkLabels func(op *base.Op)
+		checkLabels = func(op *base.Op) {
+			if strings.Join(op.Labels, ",") != "^id" {
+				t.Fatalf("%s, expected ^id labels, got: %+v", test.about, op)
+			}
+
+			for _, child := range op.Children {
+				checkLabels(child)
+			}
+		}
+
+		checkLabels(conv.TopOp)
+
+		results := testGlueExecOp(t, false, store, conv.TopOp, conv.Temps,
+			map[string]value.Value{"n": value.NewValue(test.limit)}, nil)
+
+		var actuals []string
+		for _, result := range results {
+			actuals = append(actuals, string(result[0]))
+		}
+
+		if !test.ordered {
+			sort.Strings(actuals)
+		}
+
+		if strings.Join(actuals, ",") != test.expects {
+			t.Fatalf("%s, expected: %s, got: %+v", test.about, test.expects, actuals)
+		}
+	}
+
+	// The first scan of an ordered intersect becomes the last,
+	// probing side of the intersect-distinct.
+	conv := glue.NewConv()
+
+	err = conv.Convert(plan.NewOrderedIntersectScan(nil, 0, 0, scanB, scanA))
+	if err != nil {
+		t.Fatalf("expected no convert err, got: %v", err)
+	}
+
+	if conv.TopOp.Kind != "intersect-distinct" ||
+		conv.Temps[conv.TopOp.Children[0].Params[0].(int)] != scanA ||
+		conv.Temps[conv.TopOp.Children[1].Params[0].(int)] != scanB {
+		t.Fatalf("expected scanB as the probing side, got: %+v", conv.TopOp)
+	}
+}
+
+// ---------------------------------------------------------------
+
+func BenchmarkGlueExprConvNative(b *testing.B) {
+	benchmarkGlueExprConv(b, true)
+}
+
+func BenchmarkGlueExprConvTree(b *testing.B) {
+	benchmarkGlueExprConv(b, false)
+}
+
+// benchmarkGlueExprConv compares the evaluation of a WHERE-like
+// expression over the docs of test/data/orders, where the expression
+// is either converted into a native expression or is evaluated as an
+// exprTree.
+func benchmarkGlueExprConv(b *testing.B, native bool) {
+	tmpDir, vars := MakeVars()
+
+	defer os.RemoveAll(tmpDir)
+
+	if n1k1.ExprCatalog["exprTree"] == nil {
+		n1k1.ExprCatalog["exprTree"] = glue.ExprTree
+	}
+
+	labels := base.Labels{`.["o"]`}
+
+	e, err := parser.Parse(`o.custId = "ccc" AND o.id > "1235"`)
+	if err != nil {
+		b.Fatalf("expected no parse err, got: %v", err)
+	}
+
+	params := glue.ExprConvFallback(e)
+	if native {
+		params = glue.ExprConv(labels, e)
+		if params[0] != "and" {
+			b.Fatalf("expected native params, got: %+v", params)
+		}
+	}
+
+	files, err := ioutil.ReadDir("./data/orders")
+	if err != nil {
+		b.Fatalf("expected no read dir err, got: %v", err)
+	}
+
+	var docs []base.Vals
+	for _, file := range files {
+		doc, err := ioutil.ReadFile("./data/orders/" + file.Name())
+		if err != nil {
+			b.Fatalf("expected no read file err, got: %v", err)
+		}
+
+		docs = append(docs, base.Vals{base.Val(doc)})
+	}
+
+	yieldErr := func(err error) {
+		if err != nil {
+			b.Fatalf("expected no yieldErr, got: %v", err)
+		}
+	}
+
+	exprFunc := n1k1.MakeExprFunc(vars, labels, params, "", "")
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		matches := 0
+
+		for _, doc := range docs {
+			if base.ValEqualTrue(exprFunc(doc, yieldErr)) {
+				matches++
+			}
+		}
+
+		if matches != 1 {
+			b.Fatalf("expected 1 match, got: %d", matches)
 		}
 	}
 }
@@ -699,7 +2242,12 @@ func TestFileStoreSelectComplex(t *testing.T) {
 
 func testFileStoreSelect(t *testing.T, stmt string, emit bool) (
 	*glue.Store, plan.Operator, *glue.Conv, error) {
-	store, err := glue.FileStore("./")
+	return testFileStorePlan(t, "./", stmt, emit)
+}
+
+func testFileStorePlan(t *testing.T, dir, stmt string, emit bool) (
+	*glue.Store, plan.Operator, *glue.Conv, error) {
+	store, err := glue.FileStore(dir)
 	if err != nil {
 		t.Fatalf("did not expect err: %v", err)
 	}
@@ -728,6 +2276,7 @@ func testFileStoreSelect(t *testing.T, stmt string, emit bool) (
 	}
 
 	conv := glue.NewConv()
+	conv.Store = store
 
 	return store, p, conv, conv.Convert(p)
 }
@@ -736,53 +2285,101 @@ func testFileStoreSelect(t *testing.T, stmt string, emit bool) (
 
 func testGlueExec(t *testing.T, emit bool,
 	store *glue.Store, conv *glue.Conv) []base.Vals {
-	if emit {
-		jop, _ := json.MarshalIndent(conv.TopOp, " ", " ")
-		fmt.Printf("jop: %s\n", jop)
+	return testGlueExecOp(t, emit, store, conv.TopOp, conv.Temps, nil, nil)
+}
+
+// testGlueExecOp executes an op with its Temps template and with the
+// given request args.
+func testGlueExecOp(t *testing.T, emit bool,
+	store *glue.Store, op *base.Op, temps []interface{},
+	namedArgs map[string]value.Value, positionalArgs value.Values) []base.Vals {
+	results, errs := testGlueExecOpErrs(t, emit, store, op, temps,
+		namedArgs, positionalArgs)
+	if len(errs) > 0 {
+		t.Fatalf("expected no errs, got: %+v", errs)
+	}
+
+	return results
+}
+
+// testGlueExecOpErrs is like testGlueExecOp, but also returns the
+// non-nil errors that were yielded.
+func testGlueExecOpErrs(t *testing.T, emit bool,
+	store *glue.Store, op *base.Op, temps []interface{},
+	namedArgs map[string]value.Value, positionalArgs value.Values) (
+	[]base.Vals, []error) {
+	return testGlueExecBind(t, emit, store, namedArgs, positionalArgs,
+		func(ctx *base.Ctx) (*base.Op, []interface{}, error) {
+			return op, temps, glue.SetCtxParams(ctx, namedArgs, positionalArgs)
+		})
+}
+
+// testGlueExecute executes a prepared statement via Store.Execute(),
+// which binds the given request args.
+func testGlueExecute(t *testing.T, store *glue.Store,
+	pc *glue.PreparedCache, name, text string,
+	namedArgs map[string]value.Value, positionalArgs value.Values) (
+	*glue.Prepared, []base.Vals) {
+	var p *glue.Prepared
+
+	results, errs := testGlueExecBind(t, false, store, namedArgs, positionalArgs,
+		func(ctx *base.Ctx) (*base.Op, []interface{}, error) {
+			var err error
+
+			p, err = store.Execute(pc, name, text, "", ctx, namedArgs, positionalArgs)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			return p.Op, p.Temps, nil
+		})
+	if len(errs) > 0 {
+		t.Fatalf("expected no errs, got: %+v", errs)
 	}
 
+	return p, results
+}
+
+// testGlueExecBind executes the op that's returned by the bind func,
+// which also binds the request args into the Ctx.
+func testGlueExecBind(t *testing.T, emit bool, store *glue.Store,
+	namedArgs map[string]value.Value, positionalArgs value.Values,
+	bind func(ctx *base.Ctx) (*base.Op, []interface{}, error)) (
+	[]base.Vals, []error) {
 	testi := 0
 
 	testExpectErr := ""
 
-	tmpDir, vars, yieldVals, yieldErr, returnYields :=
+	tmpDir, vars, yieldVals, _, returnYields :=
 		MakeYieldCaptureFuncs(t, testi, testExpectErr)
 
 	defer os.RemoveAll(tmpDir)
 
-	namespace := ""
-	readonly := true
-	maxParallelism := 1
+	var errs []error
 
-	requestId := "test-request"
-	requestScanCap := int64(1000)
-	requestPipelineCap := int64(1000)
-	requestPipelineBatch := 100
-	requestNamedArgs := map[string]value.Value(nil)
-	requestPositionalArgs := value.Values(nil)
-	requestCredentials := auth.Credentials(nil)
-	requestScanConsistency := datastore.UNBOUNDED
-	requestScanVectorSource := &server_http.ZeroScanVectorSource{}
-	requestOutput := &Output{}
+	yieldErr := func(err error) {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
 
-	var requestOriginalHttpRequest *http.Request
+	context, requestOutput := testGlueContext(store, namedArgs, positionalArgs)
 
-	var prepared *plan.Prepared
+	op, temps, err := bind(vars.Ctx)
+	if err != nil {
+		t.Fatalf("bind, err: %v", err)
+	}
 
-	context := execution.NewContext(requestId,
-		store.Datastore, store.Systemstore, namespace,
-		readonly, maxParallelism,
-		requestScanCap, requestPipelineCap, requestPipelineBatch,
-		requestNamedArgs, requestPositionalArgs,
-		requestCredentials, requestScanConsistency, requestScanVectorSource,
-		requestOutput, requestOriginalHttpRequest,
-		prepared, store.IndexApiVersion, store.FeatureControls)
+	if emit {
+		jop, _ := json.MarshalIndent(op, " ", " ")
+		fmt.Printf("jop: %s\n", jop)
+	}
 
 	vars.Temps = vars.Temps[:0]
 
 	vars.Temps = append(vars.Temps, context)
 
-	vars.Temps = append(vars.Temps, conv.Temps[1:]...)
+	vars.Temps = append(vars.Temps, temps[1:]...)
 
 	for i := 0; i < 16; i++ {
 		vars.Temps = append(vars.Temps, nil)
@@ -794,7 +2391,7 @@ func testGlueExec(t *testing.T, emit bool,
 
 	n1k1.ExecOpEx = glue.DatastoreOp
 
-	n1k1.ExecOp(conv.TopOp, vars, yieldVals, yieldErr, "", "")
+	n1k1.ExecOp(op, vars, yieldVals, yieldErr, "", "")
 
 	results := returnYields()
 
@@ -804,7 +2401,43 @@ func testGlueExec(t *testing.T, emit bool,
 		fmt.Printf("results: %+v\n  output: %v\n", results, requestOutput)
 	}
 
-	return results
+	return results, errs
+}
+
+// testGlueContext returns an execution.Context for a request with
+// the given args.
+func testGlueContext(store *glue.Store,
+	namedArgs map[string]value.Value, positionalArgs value.Values) (
+	*execution.Context, *Output) {
+	namespace := ""
+	readonly := true
+	maxParallelism := 1
+
+	requestId := "test-request"
+	requestScanCap := int64(1000)
+	requestPipelineCap := int64(1000)
+	requestPipelineBatch := 100
+	requestNamedArgs := namedArgs
+	requestPositionalArgs := positionalArgs
+	requestCredentials := auth.Credentials(nil)
+	requestScanConsistency := datastore.UNBOUNDED
+	requestScanVectorSource := &server_http.ZeroScanVectorSource{}
+	requestOutput := &Output{}
+
+	var requestOriginalHttpRequest *http.Request
+
+	var prepared *plan.Prepared
+
+	context := execution.NewContext(requestId,
+		store.Datastore, store.Systemstore, namespace,
+		readonly, maxParallelism,
+		requestScanCap, requestPipelineCap, requestPipelineBatch,
+		requestNamedArgs, requestPositionalArgs,
+		requestCredentials, requestScanConsistency, requestScanVectorSource,
+		requestOutput, requestOriginalHttpRequest,
+		prepared, store.IndexApiVersion, store.FeatureControls)
+
+	return context, requestOutput
 }
 
 // -------------------------------------------------------------
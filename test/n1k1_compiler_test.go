@@ -108,20 +108,45 @@ func TestCasesSimpleWithCompiler(t *testing.T) {
 
 			capturedOut := captured.Out
 
-			// Scan backwards until a func/closure.
-			start := len(capturedOut) - 1
-			for start >= 0 {
-				if strings.Index(capturedOut[start], " func(") > 0 {
-					break
+			// Find the last func/closure that's not nested inside
+			// another func/closure, such as from a subquery.
+			start := -1
+			var blocks []bool // Stack of open blocks, true for funcs.
+			numFuncs := 0     // Count of funcs in the blocks.
+			for i, line := range capturedOut {
+				trimmed := strings.TrimSpace(line)
+
+				opens := strings.HasSuffix(trimmed, "{")
+				closes := strings.HasPrefix(trimmed, "}")
+
+				if closes && !opens && len(blocks) > 0 {
+					if blocks[len(blocks)-1] {
+						numFuncs -= 1
+					}
+
+					blocks = blocks[:len(blocks)-1]
 				}
 
-				start = start - 1
+				if opens && !closes {
+					isFunc := strings.Index(line, " func(") > 0
+					if isFunc {
+						if numFuncs <= 0 {
+							start = i
+						}
+
+						numFuncs += 1
+					}
+
+					blocks = append(blocks, isFunc)
+				}
 			}
 
 			// Emit the body of the last func/closure.
 			var out []string
 
-			scopes := 0 // Count scope / braces of IF statements.
+			scopes := 0 // Count scope / braces of IF statements, etc.
+
+			var funcs []int // The scopes of any nested funcs/closures.
 
 			for i := start + 1; i < len(capturedOut); i++ {
 				trimmed := strings.TrimSpace(capturedOut[i])
@@ -129,17 +154,26 @@ func TestCasesSimpleWithCompiler(t *testing.T) {
 					continue
 				}
 
-				if strings.HasPrefix(trimmed, "return ") { // Filter away return lines.
+				if strings.HasPrefix(trimmed, "return ") &&
+					len(funcs) <= 0 { // Filter away return lines.
 					continue
 				}
 
-				if strings.HasPrefix(trimmed, "if ") ||
-					strings.HasPrefix(trimmed, "for ") {
+				if strings.HasSuffix(trimmed, "{") &&
+					!strings.HasPrefix(trimmed, "}") {
 					scopes += 1
+
+					if strings.Index(trimmed, " func(") > 0 {
+						funcs = append(funcs, scopes)
+					}
 				}
 
 				if strings.HasSuffix(trimmed, "}") { // Ignore IF close braces.
 					if scopes > 0 {
+						if len(funcs) > 0 && funcs[len(funcs)-1] == scopes {
+							funcs = funcs[:len(funcs)-1]
+						}
+
 						scopes -= 1
 					} else {
 						continue
@@ -190,7 +224,11 @@ func TestCasesSimpleWithCompiler(t *testing.T) {
 		}
 
 		intermed.ExecOp(&test.o,
-			&base.Vars{Ctx: &base.Ctx{ExprCatalog: intermed.ExprCatalog}},
+			&base.Vars{Ctx: &base.Ctx{
+				ExprCatalog:      intermed.ExprCatalog,
+				NamedParams:      TestNamedParams,
+				PositionalParams: TestPositionalParams,
+			}},
 			nil, nil, "Top", "EO")
 
 		if len(outStack) != 1 {
@@ -236,7 +274,7 @@ func TestCasesSimpleWithCompiler(t *testing.T) {
 		c = append(c, `  lzTmpDir, lzVars, lzYieldVals, lzYieldErr, returnYields :=`)
 		c = append(c, fmt.Sprintf(`    test.MakeYieldCaptureFuncs(nil, %d, %q)`,
 			testi, test.expectErr))
-		c = append(c, "  os.RemoveAll(lzTmpDir)")
+		c = append(c, "  defer os.RemoveAll(lzTmpDir)")
 		c = append(c, "  _ = lzVars")
 		c = append(c, "  _ = lzYieldVals")
 		c = append(c, "  _ = lzYieldErr")